@@ -0,0 +1,74 @@
+package ddns
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// acmeChallengePrefix is prepended to a domain to form the name of its DNS-01 challenge record.
+const acmeChallengePrefix = "_acme-challenge."
+
+// ACMEChallengeName returns the name of the TXT record which proves control of domain for an ACME DNS-01 challenge.
+// A wildcard domain such as *.example.com uses the same record as example.com,
+// and a name which already has the _acme-challenge label is returned as it is.
+func ACMEChallengeName(domain string) string {
+	domain = canonicalName(strings.TrimPrefix(domain, "*."))
+	if strings.HasPrefix(domain, acmeChallengePrefix) {
+		return domain
+	}
+	return acmeChallengePrefix + domain
+}
+
+// ACMEChallengeValue returns the value of the DNS-01 challenge TXT record for a key authorization:
+// the unpadded base64url encoding of its SHA-256 digest.
+func ACMEChallengeValue(keyAuthorization string) string {
+	sum := sha256.Sum256([]byte(keyAuthorization))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// SetACMEChallenge adds value to the DNS-01 challenge TXT records of domain.
+// Existing values are kept, as a certificate for both example.com and *.example.com needs two values at once.
+func SetACMEChallenge(ctx context.Context, p TXTProvider, domain, value string) error {
+	name := ACMEChallengeName(domain)
+	values, err := p.GetTXTRecords(ctx, name)
+	if err != nil {
+		return fmt.Errorf("unable to get challenge records: %w", err)
+	}
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+	if err := p.SetTXTRecords(ctx, name, append(values, value)); err != nil {
+		return fmt.Errorf("unable to set challenge record: %w", err)
+	}
+	return nil
+}
+
+// ClearACMEChallenge removes value from the DNS-01 challenge TXT records of domain,
+// or every challenge record if value is empty.
+func ClearACMEChallenge(ctx context.Context, p TXTProvider, domain, value string) error {
+	name := ACMEChallengeName(domain)
+	var keep []string
+	if value != "" {
+		values, err := p.GetTXTRecords(ctx, name)
+		if err != nil {
+			return fmt.Errorf("unable to get challenge records: %w", err)
+		}
+		for _, v := range values {
+			if v != value {
+				keep = append(keep, v)
+			}
+		}
+		if len(keep) == len(values) {
+			return nil
+		}
+	}
+	if err := p.SetTXTRecords(ctx, name, keep); err != nil {
+		return fmt.Errorf("unable to clear challenge records: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package ddns_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestACMEChallenge(t *testing.T) {
+	for domain, expected := range map[string]string{
+		"example.com":                  "_acme-challenge.example.com",
+		"*.Example.com":                "_acme-challenge.example.com",
+		"_acme-challenge.example.com.": "_acme-challenge.example.com",
+	} {
+		if got := ddns.ACMEChallengeName(domain); got != expected {
+			t.Errorf("Expected %q; got %q", expected, got)
+		}
+	}
+	if expected, got := "67MSe_XHxLTkK1FxD0lGwcHQWzMdI3ndFeOlQx7ZNBY", ddns.ACMEChallengeValue("abc.def"); got != expected {
+		t.Errorf("Expected %q; got %q", expected, got)
+	}
+
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	// a certificate for example.com and *.example.com has two challenges for the same record
+	for _, d := range []struct{ domain, value string }{{"example.com", "one"}, {"*.example.com", "two"}, {"example.com", "one"}} {
+		if err := ddns.SetACMEChallenge(ctx, p, d.domain, d.value); err != nil {
+			t.Fatalf("SetACMEChallenge returned an error: %s", err)
+		}
+	}
+	values, _ := p.GetTXTRecords(ctx, "_acme-challenge.example.com")
+	if expected := []string{"one", "two"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %q; got %q", expected, values)
+	}
+	if err := ddns.ClearACMEChallenge(ctx, p, "example.com", "one"); err != nil {
+		t.Fatalf("ClearACMEChallenge returned an error: %s", err)
+	}
+	values, _ = p.GetTXTRecords(ctx, "_acme-challenge.example.com")
+	if expected := []string{"two"}; !reflect.DeepEqual(values, expected) {
+		t.Errorf("Expected %q; got %q", expected, values)
+	}
+	if err := ddns.ClearACMEChallenge(ctx, p, "example.com", ""); err != nil {
+		t.Fatalf("ClearACMEChallenge returned an error: %s", err)
+	}
+	if values, _ = p.GetTXTRecords(ctx, "_acme-challenge.example.com"); len(values) != 0 {
+		t.Errorf("Expected no challenge records; got %q", values)
+	}
+}
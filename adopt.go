@@ -0,0 +1,57 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// AdoptExisting configures the client to adopt the records it finds on its first run which already match its addresses,
+// as if it had published them itself, instead of treating them as foreign.
+// This avoids churn when migrating from another ddns tool.
+//
+// If the existing records are already the records the client would publish then nothing is written,
+// so they keep their TTL, proxied status, and other settings even if the provider would replace them on an update.
+// The adopted records are recorded as the last published records,
+// and in [AppendMode] they are owned by the client and removed once its address changes.
+//
+// The Provider must implement [RecordGetter].
+func AdoptExisting() clientOption {
+	return func(c *client) error {
+		c.adopt = true
+		return nil
+	}
+}
+
+// adoptExisting adopts the existing records which match addrs,
+// reporting whether they are already the desired records so that the update can be skipped.
+func (c *client) adoptExisting(ctx context.Context, records, addrs []netip.Addr) (bool, error) {
+	rg, ok := c.Provider.(RecordGetter)
+	if !ok {
+		return false, errGetRecordsUnsupported
+	}
+	existing, err := rg.GetDNSRecords(ctx, c.domain)
+	if err != nil {
+		return false, fmt.Errorf("error getting existing records: %w", err)
+	}
+	c.adopted = true
+	adopted := Diff(existing, addrs).Keep
+	if len(adopted) == 0 {
+		return false, nil
+	}
+	c.logger.Printf("adopting existing records %v for %s\n", adopted, c.domain)
+	if c.appendMode {
+		c.owned = adopted
+	}
+	if !Diff(existing, records).Empty() {
+		return false, nil
+	}
+	c.published = records
+	c.setPublished(ctx, records, addrs)
+	if c.store != nil {
+		if err := c.store.SetLastPublished(ctx, c.domain, records); err != nil {
+			return false, fmt.Errorf("error storing published records: %w", err)
+		}
+	}
+	return true, nil
+}
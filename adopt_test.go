@@ -0,0 +1,57 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// writeCountingProvider counts the updates made to a ddnstest.Provider.
+type writeCountingProvider struct {
+	*ddnstest.Provider
+	writes int
+}
+
+func (p *writeCountingProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	p.writes++
+	return p.Provider.SetDNSRecords(ctx, domain, records)
+}
+
+func TestAdoptExisting(t *testing.T) {
+	ctx := context.Background()
+	ours, other := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.10")
+	p := &writeCountingProvider{Provider: &ddnstest.Provider{}}
+	p.Provider.SetDNSRecords(ctx, "rr.example.com", []netip.Addr{ours, other})
+
+	ip := ours
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) { return []netip.Addr{ip}, nil })
+	c, err := ddns.New("rr.example.com", func() (ddns.Provider, error) { return p, nil },
+		ddns.UsingResolver(r), ddns.AppendMode(), ddns.AdoptExisting())
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if p.writes != 0 {
+		t.Errorf("Expected matching records to be adopted without an update; got %d updates", p.writes)
+	}
+	if got := c.(ddns.StateReporter).LastPublished().Records; len(got) != 2 {
+		t.Errorf("Expected the adopted records to be the last published; got %q", got)
+	}
+
+	ip = netip.MustParseAddr("192.0.2.2")
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("rr.example.com"); len(got) != 2 || got[0] != ip || got[1] != other {
+		t.Errorf("Expected the adopted address to be replaced and the other host's kept; got %q", got)
+	}
+
+	if _, err := ddns.New("rr.example.com", (&recordingProvider{}).fn(), ddns.AdoptExisting()); err == nil {
+		t.Errorf("Expected an error for a provider which can't list records")
+	}
+}
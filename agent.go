@@ -0,0 +1,397 @@
+package ddns
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Agent runs a daemon for each of a set of domains which may be changed while it is running,
+// and serves a small HTTP control API with [Agent.Handler].
+//
+// newClient is called to create the client for each domain when it is added.
+// The interval, logger, and daemon options are the same as for [RunDaemon].
+type Agent struct {
+	newClient func(domain string) (DDNSClient, error)
+	interval  time.Duration
+	logger    logf
+	options   []daemonOption
+
+	mu      sync.Mutex
+	ctx     context.Context // set while Run is running
+	wg      sync.WaitGroup
+	domains map[string]*agentDomain
+}
+
+// DomainStatus reports the state of a domain managed by an [Agent].
+type DomainStatus struct {
+	Domain      string    `json:"domain"`
+	Running     bool      `json:"running"` // false before the agent runs and after the daemon stops for bad credentials
+	LastRun     time.Time `json:"last_run"`
+	LastSuccess time.Time `json:"last_success"`
+	LastError   string    `json:"last_error,omitempty"` // the error of the last run, if it failed
+	Paused      bool      `json:"paused,omitempty"`
+	PausedUntil time.Time `json:"paused_until,omitempty"` // the zero Time if paused until resumed
+}
+
+// paused reports whether updates are paused at now, clearing a pause which has expired. a.mu must be held.
+func (s *DomainStatus) paused(now time.Time) bool {
+	if s.Paused && !s.PausedUntil.IsZero() && !now.Before(s.PausedUntil) {
+		s.Paused, s.PausedUntil = false, time.Time{}
+	}
+	return s.Paused
+}
+
+type agentDomain struct {
+	client  DDNSClient
+	trigger chan string
+	cancel  context.CancelFunc
+	status  DomainStatus
+}
+
+// NewAgent creates an Agent with no domains.
+func NewAgent(newClient func(domain string) (DDNSClient, error), interval time.Duration, logger logf, options ...daemonOption) *Agent {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Agent{
+		newClient: newClient,
+		interval:  interval,
+		logger:    logger,
+		options:   options,
+		domains:   map[string]*agentDomain{},
+	}
+}
+
+// Add creates a client for domain and starts updating it if the agent is running.
+func (a *Agent) Add(domain string) error {
+	domain = canonicalName(domain)
+	if domain == "" {
+		return errors.New("domain cannot be empty")
+	}
+	a.mu.Lock()
+	_, found := a.domains[domain]
+	a.mu.Unlock()
+	if found {
+		return fmt.Errorf("domain %s is already managed", domain)
+	}
+	client, err := a.newClient(domain)
+	if err != nil {
+		return fmt.Errorf("error creating client for %s: %w", domain, err)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, found := a.domains[domain]; found {
+		return fmt.Errorf("domain %s is already managed", domain)
+	}
+	ad := &agentDomain{client: client, trigger: make(chan string, 1), status: DomainStatus{Domain: domain}}
+	a.domains[domain] = ad
+	if a.ctx != nil {
+		a.start(ad)
+	}
+	return nil
+}
+
+// Remove stops updating domain.
+// Its DNS records are left in place.
+func (a *Agent) Remove(domain string) error {
+	domain = canonicalName(domain)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ad, found := a.domains[domain]
+	if !found {
+		return fmt.Errorf("domain %s is not managed", domain)
+	}
+	if ad.cancel != nil {
+		ad.cancel()
+	}
+	delete(a.domains, domain)
+	return nil
+}
+
+// Domains returns the managed domains in sorted order.
+func (a *Agent) Domains() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	domains := make([]string, 0, len(a.domains))
+	for d := range a.domains {
+		domains = append(domains, d)
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// Status returns the status of every managed domain, sorted by domain.
+func (a *Agent) Status() []DomainStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	statuses := make([]DomainStatus, 0, len(a.domains))
+	now := time.Now()
+	for _, ad := range a.domains {
+		ad.status.paused(now)
+		statuses = append(statuses, ad.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Domain < statuses[j].Domain })
+	return statuses
+}
+
+var errPaused = errors.New("updates are paused")
+
+// Trigger starts an update for domain without waiting for the interval.
+// An update which is already pending is not repeated.
+// Updates can't be triggered while the domain is paused.
+func (a *Agent) Trigger(domain string) error {
+	domain = canonicalName(domain)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	ad, found := a.domains[domain]
+	if !found {
+		return fmt.Errorf("domain %s is not managed", domain)
+	}
+	if ad.status.paused(time.Now()) {
+		return fmt.Errorf("%w: %s", errPaused, domain)
+	}
+	select {
+	case ad.trigger <- TriggerManual:
+	default:
+	}
+	return nil
+}
+
+// Pause stops updating domain for d, or until [Agent.Resume] is called if d is 0,
+// e.g. during network maintenance while the address bounces around.
+// The daemon keeps running, and updates again on the first scheduled run after the pause ends.
+// An empty domain pauses every managed domain.
+func (a *Agent) Pause(domain string, d time.Duration) error {
+	if d < 0 {
+		return errors.New("pause duration cannot be negative")
+	}
+	var until time.Time
+	if d > 0 {
+		until = time.Now().Add(d)
+	}
+	return a.each(domain, func(ad *agentDomain) {
+		ad.status.Paused, ad.status.PausedUntil = true, until
+	})
+}
+
+// Resume ends a pause of domain and starts an update without waiting for the interval.
+// An empty domain resumes every managed domain.
+func (a *Agent) Resume(domain string) error {
+	return a.each(domain, func(ad *agentDomain) {
+		if !ad.status.Paused {
+			return
+		}
+		ad.status.Paused, ad.status.PausedUntil = false, time.Time{}
+		select {
+		case ad.trigger <- TriggerManual:
+		default:
+		}
+	})
+}
+
+// each calls fn with a.mu held for domain, or for every managed domain if domain is empty.
+func (a *Agent) each(domain string, fn func(*agentDomain)) error {
+	domain = canonicalName(domain)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if domain == "" {
+		for _, ad := range a.domains {
+			fn(ad)
+		}
+		return nil
+	}
+	ad, found := a.domains[domain]
+	if !found {
+		return fmt.Errorf("domain %s is not managed", domain)
+	}
+	fn(ad)
+	return nil
+}
+
+// Run updates every managed domain until ctx is canceled, and waits for the daemons to stop.
+// An agent can only be run once.
+func (a *Agent) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.ctx != nil {
+		a.mu.Unlock()
+		return errors.New("agent has already been run")
+	}
+	a.ctx = ctx
+	for _, ad := range a.domains {
+		a.start(ad)
+	}
+	a.mu.Unlock()
+	<-ctx.Done()
+	a.wg.Wait()
+	return nil
+}
+
+// start runs the daemon for ad. a.mu must be held.
+func (a *Agent) start(ad *agentDomain) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	ad.cancel = cancel
+	ad.status.Running = true
+	d := &daemon{
+		client:   &agentClient{DDNSClient: ad.client, agent: a, domain: ad},
+		interval: a.interval,
+		logger:   a.logger,
+		clock:    realClock{},
+		trigger:  ad.trigger,
+	}
+	for _, opt := range a.options {
+		opt(d)
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		defer cancel()
+		d.run(ctx)
+		a.mu.Lock()
+		ad.status.Running = false
+		a.mu.Unlock()
+	}()
+}
+
+// agentClient records the result of each run in the domain's status.
+type agentClient struct {
+	DDNSClient
+	agent  *Agent
+	domain *agentDomain
+}
+
+func (c *agentClient) Unwrap() DDNSClient { return c.DDNSClient }
+
+func (c *agentClient) RunDDNS(ctx context.Context) error {
+	c.agent.mu.Lock()
+	paused := c.domain.status.paused(time.Now())
+	c.agent.mu.Unlock()
+	if paused {
+		c.agent.logger.Printf("ddns.Agent: updates of %s are paused; skipping run", c.domain.status.Domain)
+		return nil
+	}
+	err := c.DDNSClient.RunDDNS(ctx)
+	c.agent.mu.Lock()
+	defer c.agent.mu.Unlock()
+	c.domain.status.LastRun = time.Now()
+	c.domain.status.LastError = ""
+	if err != nil {
+		c.domain.status.LastError = err.Error()
+	} else {
+		c.domain.status.LastSuccess = c.domain.status.LastRun
+	}
+	return err
+}
+
+// Handler returns an http.Handler for the agent's control API,
+// which requires requests to send token in an "Authorization: Bearer" header.
+// Every request is rejected if token is empty.
+//
+//	GET    /status                  the status of every domain
+//	GET    /domains                 the managed domains
+//	PUT    /domains/{domain}        add a domain
+//	DELETE /domains/{domain}        remove a domain
+//	POST   /domains/{domain}/update update a domain now
+//	POST   /domains/{domain}/pause  pause updates of a domain, until resumed or for the duration given as ?for=2h
+//	POST   /domains/{domain}/resume resume updates of a domain and update it now
+//	POST   /pause                   pause updates of every domain, with the same ?for parameter
+//	POST   /resume                  resume updates of every domain
+//
+// Responses are JSON.
+func (a *Agent) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		a.serveAPI(w, r)
+	})
+}
+
+func (a *Agent) serveAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch {
+	case path == "status" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, a.Status())
+	case path == "domains" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, a.Domains())
+	case path == "pause" || path == "resume":
+		a.serveAction(w, r, "", path)
+	case strings.HasPrefix(path, "domains/") && strings.Count(path, "/") == 2:
+		domain, action, _ := strings.Cut(strings.TrimPrefix(path, "domains/"), "/")
+		a.serveAction(w, r, domain, action)
+	case strings.HasPrefix(path, "domains/") && !strings.Contains(strings.TrimPrefix(path, "domains/"), "/"):
+		domain := strings.TrimPrefix(path, "domains/")
+		switch r.Method {
+		case http.MethodPut:
+			if err := a.Add(domain); err != nil {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"domain": canonicalName(domain)})
+		case http.MethodDelete:
+			if err := a.Remove(domain); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+// serveAction handles a POST request to update, pause, or resume domain, or every domain if it is empty.
+func (a *Agent) serveAction(w http.ResponseWriter, r *http.Request, domain, action string) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var err error
+	switch action {
+	case "update":
+		err = a.Trigger(domain)
+	case "pause":
+		var d time.Duration
+		if s := r.URL.Query().Get("for"); s != "" {
+			if d, err = time.ParseDuration(s); err != nil || d <= 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("invalid pause duration \"%s\"", s)})
+				return
+			}
+		}
+		err = a.Pause(domain, d)
+	case "resume":
+		err = a.Resume(domain)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	switch {
+	case errors.Is(err, errPaused):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	case err != nil:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case domain == "":
+		writeJSON(w, http.StatusAccepted, a.Status())
+	default:
+		writeJSON(w, http.StatusAccepted, map[string]string{"domain": canonicalName(domain)})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
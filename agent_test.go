@@ -0,0 +1,193 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestAgentAPI(t *testing.T) {
+	var mu sync.Mutex
+	clients := map[string]*countingClient{}
+	agent := ddns.NewAgent(func(domain string) (ddns.DDNSClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		c := &countingClient{}
+		if domain == "bad.example.com" {
+			c.err = errors.New("update failed")
+		}
+		clients[domain] = c
+		return c, nil
+	}, time.Hour, &bufferLogger{})
+	if err := agent.Add("a.example.com"); err != nil {
+		t.Fatalf("Add returned an error: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		agent.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	srv := httptest.NewServer(agent.Handler("secret"))
+	defer srv.Close()
+	do := func(method, path, token string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %s", method, path, err)
+		}
+		return resp
+	}
+	waitFor := func(what string, cond func() bool) {
+		for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+	client := func(domain string) *countingClient {
+		mu.Lock()
+		defer mu.Unlock()
+		return clients[domain]
+	}
+
+	if resp := do(http.MethodGet, "/status", ""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without a token; got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+	if resp := do(http.MethodGet, "/status", "wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d with the wrong token; got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/status", nil)
+	req.Header.Set("Authorization", "secret")
+	noScheme, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /status failed: %s", err)
+	}
+	if noScheme.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a token without the Bearer scheme; got %d", http.StatusUnauthorized, noScheme.StatusCode)
+	}
+
+	waitFor("the first run", func() bool { return client("a.example.com").count() == 1 })
+	if resp := do(http.MethodPost, "/domains/a.example.com/update", "secret"); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status %d for a triggered update; got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	waitFor("the triggered run", func() bool { return client("a.example.com").count() == 2 })
+
+	if resp := do(http.MethodPut, "/domains/bad.example.com", "secret"); resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected status %d for an added domain; got %d", http.StatusCreated, resp.StatusCode)
+	}
+	waitFor("the added domain to run", func() bool { c := client("bad.example.com"); return c != nil && c.count() == 1 })
+	waitFor("the status to be recorded", func() bool {
+		for _, s := range agent.Status() {
+			if s.Domain == "bad.example.com" && s.LastError != "" {
+				return true
+			}
+		}
+		return false
+	})
+
+	resp := do(http.MethodGet, "/status", "secret")
+	var statuses []ddns.DomainStatus
+	json.NewDecoder(resp.Body).Decode(&statuses)
+	resp.Body.Close()
+	if len(statuses) != 2 || statuses[0].Domain != "a.example.com" || statuses[0].LastSuccess.IsZero() || statuses[1].LastError != "update failed" {
+		t.Errorf("Unexpected status: %+v", statuses)
+	}
+
+	if resp := do(http.MethodDelete, "/domains/a.example.com", "secret"); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status %d for a removed domain; got %d", http.StatusNoContent, resp.StatusCode)
+	}
+	resp = do(http.MethodGet, "/domains", "secret")
+	var domains []string
+	json.NewDecoder(resp.Body).Decode(&domains)
+	resp.Body.Close()
+	if strings.Join(domains, ",") != "bad.example.com" {
+		t.Errorf("Expected domains [bad.example.com]; got %q", domains)
+	}
+	if resp := do(http.MethodPost, "/domains/a.example.com/update", "secret"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for a removed domain; got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestAgentPause(t *testing.T) {
+	c := &countingClient{}
+	agent := ddns.NewAgent(func(string) (ddns.DDNSClient, error) { return c, nil }, time.Hour, &bufferLogger{})
+	agent.Add("a.example.com")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		agent.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	srv := httptest.NewServer(agent.Handler("secret"))
+	defer srv.Close()
+	post := func(path string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s failed: %s", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	waitFor := func(what string, cond func() bool) {
+		for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+		}
+	}
+	waitFor("the first run", func() bool { return c.count() == 1 })
+
+	if status := post("/domains/a.example.com/pause"); status != http.StatusAccepted {
+		t.Errorf("Expected status %d for a paused domain; got %d", http.StatusAccepted, status)
+	}
+	if s := agent.Status()[0]; !s.Paused || !s.PausedUntil.IsZero() {
+		t.Errorf("Expected the domain to be paused until resumed; got %+v", s)
+	}
+	if status := post("/domains/a.example.com/update"); status != http.StatusConflict {
+		t.Errorf("Expected status %d for an update of a paused domain; got %d", http.StatusConflict, status)
+	}
+	if status := post("/domains/a.example.com/resume"); status != http.StatusAccepted {
+		t.Errorf("Expected status %d for a resumed domain; got %d", http.StatusAccepted, status)
+	}
+	waitFor("the run after resuming", func() bool { return c.count() == 2 })
+
+	if status := post("/pause?for=soon"); status != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid duration; got %d", http.StatusBadRequest, status)
+	}
+	if status := post("/pause?for=10ms"); status != http.StatusAccepted {
+		t.Errorf("Expected status %d for pausing every domain; got %d", http.StatusAccepted, status)
+	}
+	if s := agent.Status()[0]; !s.Paused || s.PausedUntil.IsZero() {
+		t.Errorf("Expected the domain to be paused until a time; got %+v", s)
+	}
+	waitFor("the pause to end", func() bool { return !agent.Status()[0].Paused })
+	if err := agent.Trigger("a.example.com"); err != nil {
+		t.Errorf("Trigger returned an error after the pause ended: %s", err)
+	}
+	if err := agent.Pause("b.example.com", 0); err == nil {
+		t.Errorf("Expected an error pausing an unmanaged domain")
+	}
+}
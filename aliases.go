@@ -0,0 +1,106 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// Aliases configures the client to publish the same records to each of names in addition to the domain.
+//
+// The addresses are resolved once per run for all names,
+// which saves requests compared to running a client per name.
+// Heartbeats, [AppendMode], and [DryRun] only consider the domain.
+//
+// Aliases cannot be combined with [MapFamilyDomains] or a domain template which uses the address family.
+func Aliases(names ...string) clientOption {
+	return func(c *client) error {
+		for _, name := range names {
+			if name == "" {
+				return errors.New("alias cannot be empty")
+			}
+		}
+		c.aliases = append(c.aliases, names...)
+		return nil
+	}
+}
+
+// aliasProvider publishes records to the aliases of every domain it is given.
+type aliasProvider struct {
+	Provider
+	aliases []string
+}
+
+func (ap *aliasProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	errs := []error{ap.Provider.SetDNSRecords(ctx, domain, records)}
+	for _, alias := range ap.aliases {
+		if err := ap.Provider.SetDNSRecords(ctx, alias, records); err != nil {
+			errs = append(errs, fmt.Errorf("alias %s: %w", alias, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (ap *aliasProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	if rg, ok := ap.Provider.(RecordGetter); ok {
+		return rg.GetDNSRecords(ctx, domain)
+	}
+	return nil, errGetRecordsUnsupported
+}
+
+func (ap *aliasProvider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	if tp, ok := ap.Provider.(TXTProvider); ok {
+		return tp.GetTXTRecords(ctx, name)
+	}
+	return nil, errTXTUnsupported
+}
+
+func (ap *aliasProvider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	if tp, ok := ap.Provider.(TXTProvider); ok {
+		return tp.SetTXTRecords(ctx, name, values)
+	}
+	return errTXTUnsupported
+}
+
+func (ap *aliasProvider) GetSRVRecords(ctx context.Context, name string) ([]SRV, error) {
+	if sp, ok := ap.Provider.(SRVProvider); ok {
+		return sp.GetSRVRecords(ctx, name)
+	}
+	return nil, errSRVUnsupported
+}
+
+func (ap *aliasProvider) SetSRVRecords(ctx context.Context, name string, records []SRV) error {
+	if sp, ok := ap.Provider.(SRVProvider); ok {
+		return sp.SetSRVRecords(ctx, name, records)
+	}
+	return errSRVUnsupported
+}
+
+func (ap *aliasProvider) RotateCredentials(ctx context.Context, token string) error {
+	if r, ok := ap.Provider.(CredentialRotator); ok {
+		return r.RotateCredentials(ctx, token)
+	}
+	return errors.New("provider does not support credential rotation")
+}
+
+func (ap *aliasProvider) Capabilities() Capabilities {
+	caps := ProviderCapabilities(ap.Provider)
+	caps.Batch = false
+	return caps
+}
+
+func (ap *aliasProvider) SetLogger(logger *log.Logger) {
+	setLogger(ap.Provider, logger)
+}
+
+func (ap *aliasProvider) SetHTTPClient(httpclient *http.Client) {
+	setHTTPClient(ap.Provider, httpclient)
+}
+
+func (ap *aliasProvider) SetTTL(ttl time.Duration) {
+	setTTL(ap.Provider, ttl)
+}
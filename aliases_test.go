@@ -0,0 +1,49 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestAliases(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	resolves := 0
+	ip := netip.MustParseAddr("192.0.2.1")
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		resolves++
+		return []netip.Addr{ip}, nil
+	})
+	c, err := ddns.New("a.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(r),
+		ddns.Aliases("b.example.com", "c.example.com"),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if resolves != 1 {
+		t.Errorf("Expected 1 resolve; got %d", resolves)
+	}
+	for _, name := range []string{"a.example.com", "b.example.com", "c.example.com"} {
+		if got := p.Records(name); len(got) != 1 || got[0] != ip {
+			t.Errorf("Expected %s for %s; got %q", ip, name, got)
+		}
+	}
+}
+
+func TestAliasesWithFamilyDomains(t *testing.T) {
+	_, err := ddns.New("example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.Aliases("www.example.com"),
+		ddns.MapFamilyDomains("v4.example.com", "v6.example.com"),
+	)
+	if err == nil {
+		t.Fatalf("Expected an error combining Aliases and MapFamilyDomains")
+	}
+}
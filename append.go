@@ -0,0 +1,72 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+)
+
+var errGetRecordsUnsupported = errors.New("provider does not support listing records")
+
+// RecordGetter is the interface for providers which can report the A and AAAA records currently published for domain.
+type RecordGetter interface {
+	GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error)
+}
+
+// AppendMode configures the client to add its addresses to the domain without deleting records published by other hosts.
+// This allows several dynamic hosts to share one name for round-robin DNS.
+//
+// The client only removes addresses which it published itself and no longer resolves.
+// Ownership is remembered for the lifetime of the client,
+// so an address which changes while the client is not running will not be cleaned up.
+//
+// The Provider must implement [RecordGetter].
+func AppendMode() clientOption {
+	return func(c *client) error {
+		c.appendMode = true
+		return nil
+	}
+}
+
+// appendRecords returns the records to publish in append mode:
+// the existing records, minus the ones we previously published and no longer own, plus our own.
+func (c *client) appendRecords(ctx context.Context, own []netip.Addr) ([]netip.Addr, error) {
+	rg, ok := c.Provider.(RecordGetter)
+	if !ok {
+		return nil, errGetRecordsUnsupported
+	}
+	existing, err := rg.GetDNSRecords(ctx, c.domain)
+	if err != nil {
+		return nil, fmt.Errorf("error getting existing records: %w", err)
+	}
+	// records we published earlier and no longer own are removed, and records from other hosts are kept
+	applier := &listApplier{logger: c.logger, addrs: append([]netip.Addr(nil), existing...)}
+	if err := Reconcile(ctx, addrRecords(existing), own, applier, OwnedOnly(c.owned)); err != nil {
+		return nil, err
+	}
+	return applier.addrs, nil
+}
+
+// listApplier applies the changes found by Reconcile to a list of addresses.
+type listApplier struct {
+	logger *log.Logger
+	addrs  []netip.Addr
+}
+
+func (a *listApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.addrs = append(a.addrs, addr)
+	return nil
+}
+
+func (a *listApplier) Delete(ctx context.Context, r Record) error {
+	a.logger.Printf("removing our stale address %s\n", r.Addr)
+	for i, addr := range a.addrs {
+		if addr == r.Addr {
+			a.addrs = append(a.addrs[:i], a.addrs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestAppendMode(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	other := netip.MustParseAddr("192.0.2.10")
+	p.SetDNSRecords(ctx, "rr.example.com", []netip.Addr{other})
+
+	ip := netip.MustParseAddr("192.0.2.1")
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) { return []netip.Addr{ip}, nil })
+	c, err := ddns.New("rr.example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r), ddns.AppendMode())
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("rr.example.com"); len(got) != 2 || got[0] != ip || got[1] != other {
+		t.Fatalf("Expected our address to be appended to the existing record; got %q", got)
+	}
+
+	old := ip
+	ip = netip.MustParseAddr("192.0.2.2")
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	got := p.Records("rr.example.com")
+	for _, a := range got {
+		if a == old {
+			t.Fatalf("Expected our stale address %s to be removed; got %q", old, got)
+		}
+	}
+	if len(got) != 2 || got[0] != ip || got[1] != other {
+		t.Fatalf("Expected other host's record to be kept alongside our new address; got %q", got)
+	}
+}
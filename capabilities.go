@@ -0,0 +1,98 @@
+package ddns
+
+import (
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// Capabilities describes the features supported by a Provider.
+type Capabilities struct {
+	IPv4        bool // A records
+	IPv6        bool // AAAA records
+	TXT         bool // implements TXTProvider, required for heartbeats
+	SRV         bool // implements SRVProvider, required for PublishSRV
+	ListRecords bool // implements RecordGetter, required for AppendMode and DryRun
+	Proxy       bool // records can be proxied by the provider, e.g. Cloudflare's orange cloud
+	Batch       bool // all changes for a domain are applied in a single request
+	TTL         bool // the TTL of new records can be set with WithTTL
+
+	// MinTTL is the lowest TTL accepted by the provider, or 0 if there is no minimum.
+	MinTTL time.Duration
+}
+
+// CapabilityReporter is the interface for providers which report the features they support.
+//
+// Providers which only implement an optional interface in some configurations,
+// such as wrappers around other providers, should implement CapabilityReporter.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// ProviderCapabilities returns the features supported by p.
+//
+// If p does not implement [CapabilityReporter],
+// then it is assumed to support IPv4 and IPv6,
+// and the TXT, SRV, and ListRecords capabilities are inferred from the interfaces it implements.
+func ProviderCapabilities(p Provider) Capabilities {
+	if cr, ok := p.(CapabilityReporter); ok {
+		return cr.Capabilities()
+	}
+	_, txt := p.(TXTProvider)
+	_, srv := p.(SRVProvider)
+	_, list := p.(RecordGetter)
+	return Capabilities{IPv4: true, IPv6: true, TXT: txt, SRV: srv, ListRecords: list}
+}
+
+// checkCapabilities returns an error if the provider does not support the configured features.
+func (c *client) checkCapabilities() error {
+	caps := ProviderCapabilities(c.Provider)
+	if c.heartbeatID != "" && !caps.TXT {
+		return errors.New("heartbeats require a provider which supports TXT records")
+	}
+	if len(c.srv) > 0 && !caps.SRV {
+		return errors.New("SRV records require a provider which supports them")
+	}
+	if c.appendMode && !caps.ListRecords {
+		return errors.New("append mode requires a provider which can list records")
+	}
+	if c.adopt && !caps.ListRecords {
+		return errors.New("adopting existing records requires a provider which can list records")
+	}
+	if c.dryRun && !caps.ListRecords {
+		return errors.New("dry run requires a provider which can list records")
+	}
+	if len(c.fallback) > 0 {
+		if err := checkProviderFamilies(c.Provider, c.fallback); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// familyChecker is implemented by wrappers which send only some of the addresses to the providers they wrap,
+// so that each address is checked against the capabilities of the providers which will receive it.
+type familyChecker interface {
+	checkFamilies(addrs []netip.Addr) error
+}
+
+// checkProviderFamilies returns an error if p, or a provider it sends part of addrs to, does not support an address family in addrs.
+func checkProviderFamilies(p Provider, addrs []netip.Addr) error {
+	if fc, ok := p.(familyChecker); ok {
+		return fc.checkFamilies(addrs)
+	}
+	return checkFamilies(ProviderCapabilities(p), addrs)
+}
+
+// checkFamilies returns an error if the provider does not support an address family in addrs.
+func checkFamilies(caps Capabilities, addrs []netip.Addr) error {
+	for _, a := range addrs {
+		if a.Unmap().Is4() && !caps.IPv4 {
+			return errors.New("provider does not support IPv4 (A) records")
+		}
+		if !a.Unmap().Is4() && !caps.IPv6 {
+			return errors.New("provider does not support IPv6 (AAAA) records")
+		}
+	}
+	return nil
+}
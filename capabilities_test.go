@@ -0,0 +1,145 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// ipv4OnlyProvider reports that it cannot publish AAAA records.
+type ipv4OnlyProvider struct {
+	calls int
+}
+
+func (p *ipv4OnlyProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	p.calls++
+	return nil
+}
+
+func (p *ipv4OnlyProvider) Capabilities() ddns.Capabilities {
+	return ddns.Capabilities{IPv4: true}
+}
+
+func TestCapabilitiesFailFast(t *testing.T) {
+	p := &ipv4OnlyProvider{}
+	c, err := ddns.New("www.example.com", func() (ddns.Provider, error) { return p, nil },
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Fatalf("Expected an error publishing IPv6 addresses")
+	}
+	if p.calls != 0 {
+		t.Fatalf("Expected no update to be attempted; got %d", p.calls)
+	}
+
+	if _, err := ddns.New("www.example.com", func() (ddns.Provider, error) { return p, nil },
+		ddns.FallbackAddrs(netip.MustParseAddr("2001:db8::2")),
+	); err == nil {
+		t.Fatalf("Expected an error for IPv6 fallback addresses")
+	}
+}
+
+func TestMultiProviderCapabilities(t *testing.T) {
+	v4 := &ipv4OnlyProvider{}
+	c, err := ddns.New("www.example.com", ddns.MultiProvider(ddnstest.ProviderFunc(&ddnstest.Provider{}), ddnstest.ProviderFunc(v4)),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Fatalf("Expected an error publishing IPv6 addresses to an IPv4-only provider")
+	}
+	if v4.calls != 0 {
+		t.Fatalf("Expected no update to be attempted; got %d", v4.calls)
+	}
+}
+
+func TestCapabilitiesThroughWrappers(t *testing.T) {
+	// Filter implements the TXT methods, but the wrapped provider does not support them
+	_, err := ddns.New("www.example.com", ddns.Filter(func() (ddns.Provider, error) { return &ipv4OnlyProvider{}, nil }, ddns.PublicAddr),
+		ddns.Heartbeat("host1"),
+	)
+	if err == nil {
+		t.Fatalf("Expected an error for heartbeats without TXT support")
+	}
+}
+
+// ttlProvider accepts TTLs of at least five minutes.
+type ttlProvider struct {
+	ipv4OnlyProvider
+	ttl time.Duration
+}
+
+func (p *ttlProvider) Capabilities() ddns.Capabilities {
+	return ddns.Capabilities{IPv4: true, TTL: true, MinTTL: 5 * time.Minute}
+}
+
+func (p *ttlProvider) SetTTL(ttl time.Duration) { p.ttl = ttl }
+
+func TestMinTTL(t *testing.T) {
+	p := &ttlProvider{}
+	fn := func() (ddns.Provider, error) { return p, nil }
+	if _, err := ddns.New("www.example.com", fn, ddns.WithTTL(30*time.Second)); err == nil {
+		t.Errorf("Expected an error for a TTL below the provider's minimum")
+	}
+	if _, err := ddns.New("www.example.com", fn, ddns.WithTTL(30*time.Second), ddns.ClampTTL()); err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if p.ttl != 5*time.Minute {
+		t.Errorf("Expected TTL to be clamped to %s; got %s", 5*time.Minute, p.ttl)
+	}
+	if _, err := ddns.New("www.example.com", fn, ddns.WithTTL(time.Hour)); err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if p.ttl != time.Hour {
+		t.Errorf("Expected TTL %s; got %s", time.Hour, p.ttl)
+	}
+	if _, err := ddns.New("www.example.com", func() (ddns.Provider, error) { return &ipv4OnlyProvider{}, nil }, ddns.WithTTL(time.Hour)); err == nil {
+		t.Errorf("Expected an error setting the TTL of a provider without TTL support")
+	}
+}
+
+func TestMultiProviderFilterCapabilities(t *testing.T) {
+	all := &ddnstest.Provider{}
+	v4 := &ipv4OnlyProvider{}
+	c, err := ddns.New("www.example.com",
+		ddns.MultiProvider(ddnstest.ProviderFunc(all), ddns.Filter(ddnstest.ProviderFunc(v4), ddns.IPv4Addr)),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if v4.calls != 1 {
+		t.Errorf("Expected the IPv4-only provider to be updated once; got %d", v4.calls)
+	}
+	if got := all.Records("www.example.com"); len(got) != 2 {
+		t.Errorf("Expected both records on the unfiltered provider; got %v", got)
+	}
+
+	// a filter which keeps IPv6 addresses still can't send them to an IPv4-only provider
+	v4 = &ipv4OnlyProvider{}
+	c, err = ddns.New("www.example.com",
+		ddns.MultiProvider(ddnstest.ProviderFunc(all), ddns.Filter(ddnstest.ProviderFunc(v4), ddns.PublicAddr)),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Errorf("Expected an error publishing IPv6 addresses through a filter which keeps them")
+	}
+	if v4.calls != 0 {
+		t.Errorf("Expected no update to be attempted; got %d", v4.calls)
+	}
+}
@@ -0,0 +1,139 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// sharedAddressSpace is the range reserved for carrier-grade NAT by RFC 6598.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// natMismatchRuns is the number of consecutive updates the compared addresses must differ
+// before the difference is reported, so that a change of address seen by one side first is not mistaken for NAT.
+const natMismatchRuns = 3
+
+// DetectCGNAT configures the client to warn when publishing its IPv4 address won't make the host reachable
+// because the connection is behind carrier-grade NAT or a second router.
+//
+// An address in the shared address space (100.64.0.0/10) is reported as soon as it is resolved.
+// If compare is not nil its IPv4 addresses are also compared with the resolved ones each update,
+// and a difference which lasts for several updates is reported as NAT upstream of the router.
+// compare should see the address from the other side of the router:
+// a web resolver such as [WebResolver] if the client resolves the address of the router's WAN interface,
+// or the WAN interface, e.g. [PPPResolver] or [MikroTikResolver], if the client uses a web resolver.
+//
+// Each problem is logged as a warning and sent as a [NATDetected] event when it is first found.
+// If fail is true, updates also return the [*CGNATError] instead of publishing the records.
+func DetectCGNAT(compare Resolver, fail bool) clientOption {
+	return func(c *client) error {
+		c.detectCGNAT = true
+		c.natCompare = compare
+		c.failOnCGNAT = fail
+		return nil
+	}
+}
+
+// CGNATError describes NAT upstream of the host which stops its published address from reaching it.
+type CGNATError struct {
+	Addr     netip.Addr // the resolved address
+	Compared netip.Addr // the address it was compared with, or the zero Addr if Addr is itself in the shared address space
+	Updates  int        // consecutive updates for which the addresses differed
+}
+
+func (e *CGNATError) Error() string {
+	const advice = "DDNS will not make the host reachable; ask the ISP for a public IPv4 address, forward ports on the upstream router, or use IPv6 or a tunnel such as NewCloudflareTunnel"
+	switch {
+	case !e.Compared.IsValid():
+		return fmt.Sprintf("%s is in the shared address space used by carrier-grade NAT (%s): %s", e.Addr, sharedAddressSpace, advice)
+	case sharedAddressSpace.Contains(e.Compared):
+		return fmt.Sprintf("%s is in the shared address space used by carrier-grade NAT (%s), so %s is shared with other customers of the ISP: %s", e.Compared, sharedAddressSpace, e.Addr, advice)
+	default:
+		return fmt.Sprintf("resolved address %s has differed from %s for %d updates, which means there is NAT upstream of the router (double NAT or carrier-grade NAT): %s", e.Addr, e.Compared, e.Updates, advice)
+	}
+}
+
+// NATDetected is sent when [DetectCGNAT] finds a problem which was not found by the previous update.
+type NATDetected struct {
+	Time   time.Time
+	Domain string
+	Err    *CGNATError
+	Run    RunInfo
+}
+
+func (NATDetected) event() {}
+
+// checkCGNAT reports NAT found for addrs, returning the error if updates should fail because of it.
+func (c *client) checkCGNAT(ctx context.Context, addrs []netip.Addr) error {
+	if !c.detectCGNAT {
+		return nil
+	}
+	found := findCGNAT(addrs)
+	if found == nil && c.natCompare != nil {
+		found = c.compareNAT(ctx, addrs)
+	}
+	if found == nil {
+		c.natReported = ""
+		return nil
+	}
+	if key := found.Addr.String() + " " + found.Compared.String(); key != c.natReported {
+		c.natReported = key
+		c.warnf("warning: %s\n", found)
+		c.emit(ctx, NATDetected{Time: time.Now(), Domain: c.domain, Err: found, Run: RunInfoFrom(ctx)})
+	}
+	if c.failOnCGNAT {
+		return found
+	}
+	return nil
+}
+
+// findCGNAT returns an error for the first address in the shared address space, or nil if there is none.
+func findCGNAT(addrs []netip.Addr) *CGNATError {
+	for _, a := range addrs {
+		if sharedAddressSpace.Contains(a.Unmap()) {
+			return &CGNATError{Addr: a.Unmap()}
+		}
+	}
+	return nil
+}
+
+// compareNAT compares the IPv4 addresses in addrs with those of c.natCompare.
+func (c *client) compareNAT(ctx context.Context, addrs []netip.Addr) *CGNATError {
+	resolved, ok := firstIPv4(addrs)
+	if !ok {
+		return nil
+	}
+	other, err := c.natCompare.Resolve(ctx)
+	if err != nil {
+		c.warnf("warning: unable to resolve addresses to check for NAT: %s\n", err)
+		return nil
+	}
+	compared, ok := firstIPv4(other)
+	if !ok {
+		return nil
+	}
+	for _, a := range other {
+		if a.Unmap() == resolved {
+			c.natMismatches = 0
+			return nil
+		}
+	}
+	if sharedAddressSpace.Contains(compared) {
+		return &CGNATError{Addr: resolved, Compared: compared}
+	}
+	c.natMismatches++
+	if c.natMismatches < natMismatchRuns {
+		return nil
+	}
+	return &CGNATError{Addr: resolved, Compared: compared, Updates: c.natMismatches}
+}
+
+func firstIPv4(addrs []netip.Addr) (netip.Addr, bool) {
+	for _, a := range addrs {
+		if a.Unmap().Is4() {
+			return a.Unmap(), true
+		}
+	}
+	return netip.Addr{}, false
+}
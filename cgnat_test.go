@@ -0,0 +1,103 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestDetectCGNATSharedAddress(t *testing.T) {
+	p := &ddnstest.Provider{}
+	events := make(chan ddns.Event, 16)
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("100.72.1.2"))),
+		ddns.DetectCGNAT(nil, true),
+		ddns.WithEvents(events),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	err = c.RunDDNS(context.Background())
+	var cgnat *ddns.CGNATError
+	if !errors.As(err, &cgnat) {
+		t.Fatalf("Expected a CGNATError; got %v", err)
+	}
+	if cgnat.Addr != netip.MustParseAddr("100.72.1.2") || cgnat.Compared.IsValid() {
+		t.Errorf("Expected the shared address to be reported; got %+v", cgnat)
+	}
+	if got := p.Records("home.example.com"); len(got) != 0 {
+		t.Errorf("Expected nothing to be published; got %q", got)
+	}
+	detected := 0
+	for len(events) > 0 {
+		if _, ok := (<-events).(ddns.NATDetected); ok {
+			detected++
+		}
+	}
+	if detected != 1 {
+		t.Errorf("Expected 1 NATDetected event; got %d", detected)
+	}
+}
+
+func TestDetectCGNATDoubleNAT(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	wan := &ddnstest.Resolver{}
+	wan.Set(netip.MustParseAddr("192.168.1.10"))
+	events := make(chan ddns.Event, 64)
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+		ddns.DetectCGNAT(wan, false),
+		ddns.WithEvents(events),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	countDetected := func() (n int, last *ddns.CGNATError) {
+		for len(events) > 0 {
+			if e, ok := (<-events).(ddns.NATDetected); ok {
+				n, last = n+1, e.Err
+			}
+		}
+		return n, last
+	}
+	for i := 0; i < 2; i++ {
+		if err := c.RunDDNS(ctx); err != nil {
+			t.Fatalf("RunDDNS failed: %s", err)
+		}
+	}
+	if n, _ := countDetected(); n != 0 {
+		t.Errorf("Expected a brief difference not to be reported; got %d events", n)
+	}
+	for i := 0; i < 3; i++ {
+		if err := c.RunDDNS(ctx); err != nil {
+			t.Fatalf("Expected updates to continue without fail; got %s", err)
+		}
+	}
+	n, last := countDetected()
+	if n != 1 {
+		t.Fatalf("Expected 1 NATDetected event for a lasting difference; got %d", n)
+	}
+	if last.Compared != netip.MustParseAddr("192.168.1.10") || last.Updates != 3 {
+		t.Errorf("Expected double NAT with 192.168.1.10 after 3 updates; got %+v", last)
+	}
+	if got := p.Records("home.example.com"); len(got) != 1 {
+		t.Errorf("Expected the record to be published; got %q", got)
+	}
+
+	wan.Set(netip.MustParseAddr("203.0.113.5"))
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	wan.Set(netip.MustParseAddr("100.64.0.9"))
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if n, last := countDetected(); n != 1 || last.Compared != netip.MustParseAddr("100.64.0.9") {
+		t.Errorf("Expected a CGNAT WAN address to be reported at once; got %d events, last %+v", n, last)
+	}
+}
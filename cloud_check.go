@@ -0,0 +1,57 @@
+package ddns
+
+import (
+	"context"
+	"net/netip"
+)
+
+// CheckCloudMetadata configures the client to compare the resolved addresses with the public address
+// assigned to the instance in the cloud's metadata (see [CloudMetadataResolver]),
+// such as an EC2 Elastic IP or a floating IP,
+// and to log a warning when they differ.
+// A difference usually means the resolver saw an address reflected by NAT or a proxy,
+// which would be published instead of the instance's own public address.
+//
+// If prefer is true, the metadata address replaces the resolved addresses of the same family when they differ.
+// If the metadata can't be read, the resolved addresses are published as they are.
+func CheckCloudMetadata(cloud string, prefer bool) clientOption {
+	return func(c *client) error {
+		c.cloudCheck = CloudMetadataResolver(cloud).(*cloudResolver)
+		c.preferCloud = prefer
+		return nil
+	}
+}
+
+// checkCloudAddrs compares addrs with the cloud metadata, returning the addresses to publish.
+func (c *client) checkCloudAddrs(ctx context.Context, addrs []netip.Addr) []netip.Addr {
+	if c.cloudCheck == nil {
+		return addrs
+	}
+	assigned, err := c.cloudCheck.Resolve(ctx)
+	if err != nil {
+		c.warnf("warning: unable to check resolved addresses against cloud metadata: %s\n", err)
+		return addrs
+	}
+	assigned = canonicalAddrs(assigned)
+	for _, a := range assigned {
+		// the addresses of a's family, and the others
+		var family, others []netip.Addr
+		found := false
+		for _, b := range addrs {
+			if b.Is4() != a.Is4() {
+				others = append(others, b)
+				continue
+			}
+			family = append(family, b)
+			found = found || b == a
+		}
+		if found {
+			continue
+		}
+		c.warnf("warning: resolved addresses %+v do not include %s, the public address assigned in cloud metadata\n", family, a)
+		if c.preferCloud {
+			addrs = append(others, a)
+		}
+	}
+	return canonicalAddrs(addrs)
+}
@@ -0,0 +1,200 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clouds supported by [CloudMetadataResolver].
+const (
+	CloudAWS   = "aws"   // EC2, using IMDSv2
+	CloudGCP   = "gcp"   // Google Compute Engine
+	CloudAzure = "azure" // Azure virtual machines
+)
+
+// metadataClient is used by CloudMetadataResolver unless an http.Client is configured.
+// Metadata services are link-local, so requests must not be sent through a proxy from the environment.
+var metadataClient = &http.Client{Transport: directTransport()}
+
+func directTransport() http.RoundTripper {
+	t := cloneDefaultTransport()
+	t.Proxy = nil
+	return t
+}
+
+// CloudMetadataResolver constructs a resolver which returns the public IP address of a cloud VM from its instance metadata service,
+// so that ephemeral instances can register their address without an external lookup.
+//
+// cloud is one of [CloudAWS], [CloudGCP], or [CloudAzure], or "" to detect the cloud on the first lookup.
+//
+// Only EC2 reports a public IPv6 address, if the instance has one.
+// Azure only reports public addresses of the Basic SKU; Standard SKU public IPs must be looked up another way.
+// OCI's metadata service does not report public addresses.
+//
+// The http.Client given with [UsingHTTPClient] must reach the metadata service directly, not through a proxy.
+func CloudMetadataResolver(cloud string) Resolver {
+	r := &cloudResolver{cloud: strings.ToLower(cloud)}
+	switch r.cloud {
+	case "", CloudAWS, CloudGCP, CloudAzure:
+	default:
+		r.err = fmt.Errorf("unsupported cloud \"%s\"", cloud)
+	}
+	return r
+}
+
+type cloudResolver struct {
+	httpClient *http.Client
+	err        error // configuration error, returned by Resolve
+
+	mu    sync.Mutex
+	cloud string // empty until detected
+}
+
+func (r *cloudResolver) validate() error { return r.err }
+
+func (r *cloudResolver) SetHTTPClient(httpclient *http.Client) {
+	r.httpClient = httpclient
+}
+
+func (r *cloudResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.mu.Lock()
+	cloud := r.cloud
+	r.mu.Unlock()
+	if cloud != "" {
+		return r.lookup(ctx, cloud)
+	}
+	return r.detect(ctx)
+}
+
+// detect looks up the address from every supported cloud at once,
+// remembering the first to answer for future lookups.
+func (r *cloudResolver) detect(ctx context.Context) ([]netip.Addr, error) {
+	// a metadata service answers immediately, so there's no need to wait long on other machines
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	clouds := []string{CloudAWS, CloudGCP, CloudAzure}
+	type result struct {
+		cloud string
+		addrs []netip.Addr
+		err   error
+	}
+	results := make(chan result, len(clouds))
+	for _, c := range clouds {
+		go func(cloud string) {
+			addrs, err := r.lookup(ctx, cloud)
+			results <- result{cloud, addrs, err}
+		}(c)
+	}
+	var errs []error
+	for range clouds {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.cloud, res.err))
+			continue
+		}
+		r.mu.Lock()
+		r.cloud = res.cloud
+		r.mu.Unlock()
+		return res.addrs, nil
+	}
+	return nil, fmt.Errorf("no cloud metadata service found: %w", errors.Join(errs...))
+}
+
+func (r *cloudResolver) lookup(ctx context.Context, cloud string) (_ []netip.Addr, err error) {
+	// lookups run in their own goroutine
+	defer recoverError(&err)
+	var addrs []netip.Addr
+	switch cloud {
+	case CloudAWS:
+		token, err := r.fetch(ctx, http.MethodPut, "http://169.254.169.254/latest/api/token", "X-aws-ec2-metadata-token-ttl-seconds", "60")
+		if err != nil {
+			return nil, fmt.Errorf("unable to get IMDSv2 token: %w", err)
+		}
+		for _, path := range []string{"public-ipv4", "ipv6"} {
+			a, err := r.fetchAddr(ctx, "http://169.254.169.254/latest/meta-data/"+path, "X-aws-ec2-metadata-token", token)
+			if err != nil {
+				return nil, err
+			}
+			if a.IsValid() {
+				addrs = append(addrs, a)
+			}
+		}
+	case CloudGCP:
+		a, err := r.fetchAddr(ctx, "http://metadata.google.internal/computeMetadata/v1/instance/network-interfaces/0/access-configs/0/external-ip", "Metadata-Flavor", "Google")
+		if err != nil {
+			return nil, err
+		}
+		if a.IsValid() {
+			addrs = append(addrs, a)
+		}
+	case CloudAzure:
+		a, err := r.fetchAddr(ctx, "http://169.254.169.254/metadata/instance/network/interface/0/ipv4/ipAddress/0/publicIpAddress?api-version=2021-02-01&format=text", "Metadata", "true")
+		if err != nil {
+			return nil, err
+		}
+		if a.IsValid() {
+			addrs = append(addrs, a)
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("instance has no public IP address")
+	}
+	return addrs, nil
+}
+
+// fetchAddr returns the address at url, or the zero Addr if the metadata service has none.
+func (r *cloudResolver) fetchAddr(ctx context.Context, url, header, value string) (netip.Addr, error) {
+	body, err := r.fetch(ctx, http.MethodGet, url, header, value)
+	if errors.Is(err, errNoMetadata) || (err == nil && body == "") {
+		return netip.Addr{}, nil
+	}
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := netip.ParseAddr(body)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("error parsing IP from metadata: %w", err)
+	}
+	return a, nil
+}
+
+var errNoMetadata = errors.New("metadata not found")
+
+// fetch requests url from the metadata service with the given header, returning the trimmed response body.
+func (r *cloudResolver) fetch(ctx context.Context, method, url, header, value string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set(header, value)
+	httpClient := r.httpClient
+	if httpClient == nil {
+		httpClient = metadataClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("metadata request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errNoMetadata
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata request returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,93 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// fakeEC2 returns an http.Client which sends requests for 169.254.169.254 to an EC2 metadata service
+// with IMDSv2 required and the public address 198.51.100.7.
+func fakeEC2(t *testing.T) *http.Client {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "169.254.169.254" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			fmt.Fprint(w, "token")
+		case r.Header.Get("X-aws-ec2-metadata-token") != "token":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path == "/latest/meta-data/public-ipv4":
+			fmt.Fprint(w, "198.51.100.7")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.Host = r.URL.Host
+		r.URL.Host = srv.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+func TestCloudMetadataResolver(t *testing.T) {
+	httpClient := fakeEC2(t)
+
+	want := []netip.Addr{netip.MustParseAddr("198.51.100.7")}
+	for _, cloud := range []string{ddns.CloudAWS, ""} {
+		r := ddns.CloudMetadataResolver(cloud)
+		r.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(httpClient)
+		got, err := r.Resolve(context.Background())
+		if err != nil {
+			t.Fatalf("cloud %q: Resolve returned an error: %s", cloud, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("cloud %q: Expected %q; got %q", cloud, want, got)
+		}
+	}
+
+	r := ddns.CloudMetadataResolver(ddns.CloudGCP)
+	r.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(httpClient)
+	if _, err := r.Resolve(context.Background()); err == nil {
+		t.Errorf("Expected an error from a cloud without a metadata service")
+	}
+	if _, err := ddns.New("example.com", (&recordingProvider{}).fn(), ddns.UsingResolver(ddns.CloudMetadataResolver("ibm"))); err == nil {
+		t.Errorf("Expected an error for an unsupported cloud")
+	}
+}
+
+func TestCheckCloudMetadata(t *testing.T) {
+	resolved := netip.MustParseAddr("192.0.2.1") // e.g. reflected by NAT
+	v6 := netip.MustParseAddr("2001:db8::1")
+	for _, prefer := range []bool{false, true} {
+		p := &recordingProvider{}
+		c, err := ddns.New("example.com", p.fn(),
+			ddns.UsingResolver(ddns.StaticIP(resolved, v6)),
+			ddns.UsingHTTPClient(fakeEC2(t)),
+			ddns.CheckCloudMetadata(ddns.CloudAWS, prefer),
+		)
+		if err != nil {
+			t.Fatalf("New returned an error: %s", err)
+		}
+		if err := c.RunDDNS(context.Background()); err != nil {
+			t.Fatalf("RunDDNS returned an error: %s", err)
+		}
+		want := []netip.Addr{resolved, v6}
+		if prefer {
+			want = []netip.Addr{netip.MustParseAddr("198.51.100.7"), v6}
+		}
+		if got := p.records; !reflect.DeepEqual(got, want) {
+			t.Errorf("prefer %t: Expected %q; got %q", prefer, want, got)
+		}
+	}
+}
@@ -2,34 +2,162 @@ package ddns
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"net/netip"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 )
 
-func newCloudflareProvider(token string) (cf *cloudflareProvider, err error) {
+func newCloudflareProvider(token string, options ...cloudflareOption) (cf *cloudflareProvider, err error) {
+	return newCloudflareProviderAuth(token, "", options...)
+}
+
+// newCloudflareProviderAuth creates a provider which authenticates with an API token,
+// or with a legacy global API key if email is not empty.
+func newCloudflareProviderAuth(key, email string, options ...cloudflareOption) (cf *cloudflareProvider, err error) {
 	cf = new(cloudflareProvider)
-	cf.api, err = cloudflare.NewWithAPIToken(token)
+	cf.email = email
+	cf.api, err = cf.newAPI(key)
 	if err != nil {
 		return nil, fmt.Errorf("error creating cloudflare api client: %w", err)
 	}
 	cf.logger = discard
 	cf.comment = "managed by ddns"
+	for i, opt := range options {
+		if err := opt(cf); err != nil {
+			return nil, fmt.Errorf("cloudflare option %d returned an error: %w", i, err)
+		}
+	}
 	return cf, err
 }
 
+type cloudflareOption func(*cloudflareProvider) error
+
+// CloudflareZoneID pins a Cloudflare provider to the zone with the given ID,
+// so that zones are never listed.
+// This is required for API tokens which are scoped to a single zone.
+//
+// Every domain given to the provider must be in the zone.
+func CloudflareZoneID(id string) cloudflareOption {
+	return func(cf *cloudflareProvider) error {
+		if id == "" {
+			return errors.New("zone ID cannot be empty")
+		}
+		cf.zoneID = id
+		return nil
+	}
+}
+
+// CloudflareAccountID limits the zones searched by a Cloudflare provider to those owned by the account with the given ID.
+func CloudflareAccountID(id string) cloudflareOption {
+	return func(cf *cloudflareProvider) error {
+		if id == "" {
+			return errors.New("account ID cannot be empty")
+		}
+		cf.accountID = id
+		return nil
+	}
+}
+
 // cloudflareProvider implements ddns.Provider.
 //
 // It should be constructed using NewCloudflareProvider.
 type cloudflareProvider struct {
-	api    *cloudflare.API
-	logger *log.Logger
+	mu         sync.RWMutex // guards api and httpClient during credential rotation
+	api        *cloudflare.API
+	httpClient *http.Client
+	logger     *log.Logger
 	// cache *cache
 	comment string // optional comment to attach to each new DNS entry
+	ttl     int    // TTL in seconds set by WithTTL; 0 to use 60 or the TTL of the existing records
+
+	zones        []cloudflare.Zone // zones listed by the most recent ListZones call
+	zonesFetched time.Time
+	email        string   // account email for legacy API key authentication; empty for API tokens
+	zoneID       string   // optional zone to use for every domain
+	accountID    string   // optional account to limit the zone search to
+	httpsRecords bool     // update the address hints of HTTPS records
+	httpsALPN    []string // protocols of the HTTPS record created for domains without one
+}
+
+// zoneCacheTTL is how long the zone list is reused before it is fetched again.
+// Names in an unknown zone always cause the list to be fetched again.
+const zoneCacheTTL = time.Hour
+
+func (cf *cloudflareProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, TXT: true, SRV: true, ListRecords: true, Proxy: true, TTL: true, MinTTL: time.Minute}
+}
+
+// SetTTL sets the TTL of new and updated records.
+// Cloudflare only allows TTLs below one minute for Enterprise zones.
+func (cf *cloudflareProvider) SetTTL(ttl time.Duration) {
+	cf.ttl = ttlSeconds(ttl)
+}
+
+func (cf *cloudflareProvider) SetLogger(logger *log.Logger) {
+	cf.logger = logger
+}
+
+func (cf *cloudflareProvider) SetHTTPClient(httpclient *http.Client) {
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.httpClient = httpclient
+	cloudflare.HTTPClient(httpclient)(cf.api)
+}
+
+func (cf *cloudflareProvider) client() *cloudflare.API {
+	cf.mu.RLock()
+	defer cf.mu.RUnlock()
+	return cf.api
+}
+
+func (cf *cloudflareProvider) newAPI(key string) (*cloudflare.API, error) {
+	if cf.email != "" {
+		return cloudflare.New(key, cf.email, cloudflare.UserAgent(userAgent()))
+	}
+	return cloudflare.NewWithAPIToken(key, cloudflare.UserAgent(userAgent()))
+}
+
+// RotateCredentials verifies token and then uses it for all future requests.
+// The previous token remains in use if verification fails.
+//
+// For providers using a legacy API key, token is the new API key for the same email.
+func (cf *cloudflareProvider) RotateCredentials(ctx context.Context, token string) error {
+	api, err := cf.newAPI(token)
+	if err != nil {
+		return fmt.Errorf("error creating cloudflare api client: %w", err)
+	}
+	cf.mu.RLock()
+	if cf.httpClient != nil {
+		cloudflare.HTTPClient(cf.httpClient)(api)
+	}
+	cf.mu.RUnlock()
+	if cf.email != "" {
+		// API keys can't use the token verification endpoint
+		if _, err := api.UserDetails(ctx); err != nil {
+			return &cfError{err: fmt.Errorf("unable to verify api key: %w", err)}
+		}
+	} else {
+		result, err := api.VerifyAPIToken(ctx)
+		if err != nil {
+			return &cfError{err: fmt.Errorf("unable to verify api token: %w", err)}
+		}
+		if result.Status != "active" {
+			return fmt.Errorf("expected api token status to be \"active\"; got \"%s\"", result.Status)
+		}
+	}
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	cf.api = api
+	cf.zones = nil
+	cf.logger.Printf("rotated cloudflare api token\n")
+	return nil
 }
 
 func (cf *cloudflareProvider) SetDNSRecords(ctx context.Context, domain string, addrs []netip.Addr) error {
@@ -41,10 +169,11 @@ func (cf *cloudflareProvider) SetDNSRecords(ctx context.Context, domain string,
 }
 
 func (cf *cloudflareProvider) setDNSRecords(ctx context.Context, domain string, addrs []netip.Addr) error {
+	domain = canonicalName(domain)
 
 	// this nil check feels odd and redundant, but it's technically possible for someone to use the type directly and cause a program crash.
 	// should I just unexport CloudflareProvider and make the constructor return an interface or unexported type?
-	if cf.api == nil {
+	if cf.client() == nil {
 		return errors.New("ddns.CloudflareProvider.SetDNSRecords: ddns.CloudflareProvider should be constructed with ddns.NewCloudflareProvider")
 	}
 
@@ -55,88 +184,198 @@ func (cf *cloudflareProvider) setDNSRecords(ctx context.Context, domain string,
 	cf.logger.Printf("got zone ID: %s\n", zid)
 	cf.logger.Printf("looking up A,AAAA records for zone %s...\n", zid)
 
-	records, _, err := cf.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
 		Type:    "A,AAAA",
 		Name:    domain,
 		Content: "",
 		Comment: "",
 	})
-	cf.logger.Printf("found %d existing records: %+v\n", len(records), records)
-	existing := map[netip.Addr]bool{}
-	newAddrs := map[netip.Addr]bool{}
-
-	for _, a := range addrs {
-		newAddrs[a] = true
+	if err != nil {
+		return fmt.Errorf("error listing records for %s: %w", domain, err)
 	}
-	for _, r := range records {
+	cf.logger.Printf("found %d existing records: %+v\n", len(records), records)
+	existing := make([]Record, len(records))
+	for i, r := range records {
 		a, err := netip.ParseAddr(r.Content)
 		if err != nil {
 			return fmt.Errorf("error parsing IP from content: %w", err)
 		}
-		existing[a] = true
+		existing[i] = Record{ID: r.ID, Addr: a}
+	}
+	if err := Reconcile(ctx, existing, addrs, &cfApplier{cf: cf, zid: zid, domain: domain, records: records}); err != nil {
+		return err
+	}
+	if cf.httpsRecords {
+		return cf.setHTTPSHints(ctx, zid, domain, addrs)
+	}
+	return nil
+}
 
-		if _, found := newAddrs[a]; found {
-			cf.logger.Printf("existing record %s is in the set of new addrs\n", a)
-			continue
-		}
+// cfApplier applies record changes for one domain through the Cloudflare API.
+type cfApplier struct {
+	cf      *cloudflareProvider
+	zid     string
+	domain  string
+	records []cloudflare.DNSRecord // the existing records for the domain
+}
 
-		cf.logger.Printf("deleting DNS record for %s...\n", a)
-		err = cf.api.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), r.ID)
-		if err != nil {
-			return fmt.Errorf("unable to delete DNS record %s: %w", r.ID, err)
+func (a *cfApplier) record(id string) cloudflare.DNSRecord {
+	for _, r := range a.records {
+		if r.ID == id {
+			return r
 		}
-		cf.logger.Printf("successfully deleted record for %s\n", a)
 	}
+	return cloudflare.DNSRecord{}
+}
 
-	for _, a := range addrs {
-		if _, found := existing[a]; found {
-			cf.logger.Printf("record already exists for %s\n", a)
-			continue
-		}
-		cf.logger.Printf("creating record for %s...", a)
-		record, err := cf.api.CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.CreateDNSRecordParams{
-			Type:    recordType(a),
-			Name:    domain,
-			Content: a.String(),
-			ZoneID:  zid,
-			TTL:     60,
-			Comment: cf.comment,
-		})
-		if err != nil {
-			return fmt.Errorf("error creating DNS record: %w", err)
-		}
-		cf.logger.Printf("successfully added record: %+v\n", record)
+// Update changes the content of a stale record in place instead of deleting and re-creating it.
+// This keeps the record ID, proxied status, comment, and tags, and avoids a gap with no record.
+func (a *cfApplier) Update(ctx context.Context, rec Record, addr netip.Addr) error {
+	r := a.record(rec.ID)
+	a.cf.logger.Printf("updating record %s from %s to %s...\n", r.ID, r.Content, addr)
+	_, err := a.cf.client().UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(a.zid), cloudflare.UpdateDNSRecordParams{
+		ID:      r.ID,
+		Type:    r.Type,
+		Name:    r.Name,
+		Content: addr.Unmap().String(),
+		TTL:     ttlOr(a.cf.ttl, r.TTL),
+		Proxied: r.Proxied,
+		Comment: r.Comment,
+		Tags:    r.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("record ID %s: %w", r.ID, err)
 	}
+	a.cf.logger.Printf("successfully updated record for %s\n", addr)
+	return nil
+}
 
+func (a *cfApplier) Delete(ctx context.Context, rec Record) error {
+	a.cf.logger.Printf("deleting DNS record for %s...\n", rec.Addr)
+	if err := a.cf.client().DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(a.zid), rec.ID); err != nil {
+		return fmt.Errorf("record ID %s: %w", rec.ID, err)
+	}
+	a.cf.logger.Printf("successfully deleted record for %s\n", rec.Addr)
 	return nil
 }
 
-func (cf *cloudflareProvider) getZoneIDFromDomain(ctx context.Context, domain string) (zid string, err error) {
-	zones, err := cf.api.ListZones(ctx)
+func (a *cfApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.cf.logger.Printf("creating record for %s...", addr)
+	typ, err := recordType(addr)
 	if err != nil {
-		return "", fmt.Errorf("error listing zones: %w", err)
+		return err
+	}
+	params := cloudflare.CreateDNSRecordParams{
+		Type:    typ,
+		Name:    a.domain,
+		Content: addr.Unmap().String(),
+		ZoneID:  a.zid,
+		TTL:     60,
+		Comment: a.cf.comment,
+	}
+	// carry over the metadata set by the user on the records for the name instead of stamping the managed defaults
+	if r, ok := metadataSource(a.records, params.Type); ok {
+		params.TTL, params.Proxied, params.Comment, params.Tags = r.TTL, r.Proxied, r.Comment, r.Tags
 	}
+	params.TTL = ttlOr(a.cf.ttl, params.TTL)
+	record, err := a.cf.client().CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(a.zid), params)
+	if err != nil {
+		return err
+	}
+	a.cf.logger.Printf("successfully added record: %+v\n", record)
+	return nil
+}
 
-	max := 0
-	for _, z := range zones {
-		if strings.HasSuffix(domain, z.Name) && len(z.Name) > max {
-			max, zid = len(z.Name), z.ID
+// ttlOr returns ttl if it was set, or else def.
+func ttlOr(ttl, def int) int {
+	if ttl > 0 {
+		return ttl
+	}
+	return def
+}
+
+// metadataSource returns the existing record to copy metadata from when creating a record of type typ,
+// preferring a record of the same type.
+func metadataSource(records []cloudflare.DNSRecord, typ string) (cloudflare.DNSRecord, bool) {
+	for _, r := range records {
+		if r.Type == typ {
+			return r, true
 		}
 	}
-	if max == 0 {
+	if len(records) > 0 {
+		return records[0], true
+	}
+	return cloudflare.DNSRecord{}, false
+}
+
+func (cf *cloudflareProvider) getZoneIDFromDomain(ctx context.Context, domain string) (zid string, err error) {
+	if cf.zoneID != "" {
+		return cf.zoneID, nil
+	}
+	cf.mu.RLock()
+	zones, fetched := cf.zones, cf.zonesFetched
+	cf.mu.RUnlock()
+	if zid, ok := matchZone(zones, domain); ok && time.Since(fetched) < zoneCacheTTL {
+		return zid, nil
+	}
+
+	zones, err = cf.listZones(ctx)
+	if err != nil {
+		return "", fmt.Errorf("error listing zones: %w", err)
+	}
+	cf.mu.Lock()
+	cf.zones, cf.zonesFetched = zones, time.Now()
+	cf.mu.Unlock()
+
+	zid, ok := matchZone(zones, domain)
+	if !ok {
 		return "", fmt.Errorf("unable to find a zone matching \"%s\"", domain)
 	}
 	return zid, nil
 }
 
-func recordType(a netip.Addr) string {
+func (cf *cloudflareProvider) listZones(ctx context.Context) ([]cloudflare.Zone, error) {
+	if cf.accountID == "" {
+		return cf.client().ListZones(ctx)
+	}
+	r, err := cf.client().ListZonesContext(ctx, cloudflare.WithZoneFilters("", cf.accountID, ""))
+	if err != nil {
+		return nil, err
+	}
+	return r.Result, nil
+}
+
+// matchZone returns the ID of the zone which contains domain.
+func matchZone(zones []cloudflare.Zone, domain string) (zid string, ok bool) {
+	if len(zones) == 0 {
+		return "", false
+	}
+	names := make([]string, len(zones))
+	for i, z := range zones {
+		names[i] = z.Name
+	}
+	name, err := ZoneFromDomain(domain, names)
+	if err != nil {
+		return "", false
+	}
+	for _, z := range zones {
+		if canonicalName(z.Name) == name {
+			return z.ID, true
+		}
+	}
+	return "", false
+}
+
+// recordType returns the DNS record type for a, treating IPv4-mapped IPv6 addresses as IPv4.
+func recordType(a netip.Addr) (string, error) {
+	a = a.Unmap()
 	if a.Is4() {
-		return "A"
+		return "A", nil
 	}
 	if a.Is6() {
-		return "AAAA"
+		return "AAAA", nil
 	}
-	panic("unknown ip configuration")
+	return "", fmt.Errorf("invalid address %q", a)
 }
 
 type cfError struct {
@@ -159,3 +398,195 @@ func (e *cfError) IsAuthorizationError() bool {
 	}
 	return false
 }
+func (e *cfError) IsRateLimited() bool {
+	var et *cloudflare.RatelimitError
+	return errors.As(e.err, &et)
+}
+func (e *cfError) IsTemporary() bool {
+	var et *cloudflare.ServiceError
+	return errors.As(e.err, &et)
+}
+
+func (cf *cloudflareProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	domain = canonicalName(domain)
+	zid, err := cf.getZoneIDFromDomain(ctx, domain)
+	if err != nil {
+		return nil, &cfError{err: fmt.Errorf("unable to get zone ID for %s: %w", domain, err)}
+	}
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+		Type: "A,AAAA",
+		Name: domain,
+	})
+	if err != nil {
+		return nil, &cfError{err: fmt.Errorf("error listing records for %s: %w", domain, err)}
+	}
+	var addrs []netip.Addr
+	for _, r := range records {
+		a, err := netip.ParseAddr(r.Content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing IP from content: %w", err)
+		}
+		addrs = append(addrs, a)
+	}
+	return addrs, nil
+}
+
+func (cf *cloudflareProvider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	name = canonicalName(name)
+	zid, err := cf.getZoneIDFromDomain(ctx, name)
+	if err != nil {
+		return nil, &cfError{err: fmt.Errorf("unable to get zone ID for %s: %w", name, err)}
+	}
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+		Type: "TXT",
+		Name: name,
+	})
+	if err != nil {
+		return nil, &cfError{err: fmt.Errorf("error listing TXT records for %s: %w", name, err)}
+	}
+	var values []string
+	for _, r := range records {
+		values = append(values, r.Content)
+	}
+	return values, nil
+}
+
+func (cf *cloudflareProvider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	name = canonicalName(name)
+	zid, err := cf.getZoneIDFromDomain(ctx, name)
+	if err != nil {
+		return &cfError{err: fmt.Errorf("unable to get zone ID for %s: %w", name, err)}
+	}
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+		Type: "TXT",
+		Name: name,
+	})
+	if err != nil {
+		return &cfError{err: fmt.Errorf("error listing TXT records for %s: %w", name, err)}
+	}
+	existing := map[string]bool{}
+	wanted := map[string]bool{}
+	for _, v := range values {
+		wanted[v] = true
+	}
+	for _, r := range records {
+		existing[r.Content] = true
+		if wanted[r.Content] {
+			continue
+		}
+		cf.logger.Printf("deleting TXT record %s for %s...\n", r.ID, name)
+		if err := cf.client().DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), r.ID); err != nil {
+			return &cfError{err: fmt.Errorf("unable to delete DNS record %s: %w", r.ID, err)}
+		}
+	}
+	for _, v := range values {
+		if existing[v] {
+			continue
+		}
+		cf.logger.Printf("creating TXT record for %s...\n", name)
+		_, err := cf.client().CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.CreateDNSRecordParams{
+			Type:    "TXT",
+			Name:    name,
+			Content: v,
+			ZoneID:  zid,
+			TTL:     ttlOr(cf.ttl, 60),
+			Comment: cf.comment,
+		})
+		if err != nil {
+			return &cfError{err: fmt.Errorf("error creating TXT record: %w", err)}
+		}
+	}
+	return nil
+}
+
+// cfSRVData is the data of a Cloudflare SRV record.
+type cfSRVData struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+func (cf *cloudflareProvider) listSRVRecords(ctx context.Context, zid, name string) ([]cloudflare.DNSRecord, []SRV, error) {
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+		Type: "SRV",
+		Name: name,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing SRV records for %s: %w", name, err)
+	}
+	srv := make([]SRV, len(records))
+	for i, r := range records {
+		b, err := json.Marshal(r.Data)
+		if err != nil {
+			return nil, nil, err
+		}
+		var data cfSRVData
+		if err := json.Unmarshal(b, &data); err != nil {
+			return nil, nil, fmt.Errorf("error parsing data of SRV record %s: %w", r.ID, err)
+		}
+		srv[i] = SRV{Priority: data.Priority, Weight: data.Weight, Port: data.Port, Target: canonicalName(data.Target)}
+	}
+	return records, srv, nil
+}
+
+func (cf *cloudflareProvider) GetSRVRecords(ctx context.Context, name string) ([]SRV, error) {
+	name = canonicalName(name)
+	zid, err := cf.getZoneIDFromDomain(ctx, name)
+	if err != nil {
+		return nil, &cfError{err: fmt.Errorf("unable to get zone ID for %s: %w", name, err)}
+	}
+	_, srv, err := cf.listSRVRecords(ctx, zid, name)
+	if err != nil {
+		return nil, &cfError{err: err}
+	}
+	return srv, nil
+}
+
+func (cf *cloudflareProvider) SetSRVRecords(ctx context.Context, name string, values []SRV) error {
+	name = canonicalName(name)
+	zid, err := cf.getZoneIDFromDomain(ctx, name)
+	if err != nil {
+		return &cfError{err: fmt.Errorf("unable to get zone ID for %s: %w", name, err)}
+	}
+	records, existing, err := cf.listSRVRecords(ctx, zid, name)
+	if err != nil {
+		return &cfError{err: err}
+	}
+	wanted := map[SRV]bool{}
+	for _, v := range values {
+		v.Target = canonicalName(v.Target)
+		wanted[v] = true
+	}
+	have := map[SRV]bool{}
+	for i, r := range records {
+		have[existing[i]] = true
+		if wanted[existing[i]] {
+			continue
+		}
+		cf.logger.Printf("deleting SRV record %s for %s...\n", r.ID, name)
+		if err := cf.client().DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), r.ID); err != nil {
+			return &cfError{err: fmt.Errorf("unable to delete DNS record %s: %w", r.ID, err)}
+		}
+	}
+	for _, v := range values {
+		v.Target = canonicalName(v.Target)
+		if have[v] {
+			continue
+		}
+		have[v] = true
+		cf.logger.Printf("creating SRV record %s for %s...\n", v, name)
+		_, err := cf.client().CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.CreateDNSRecordParams{
+			Type:    "SRV",
+			Name:    name,
+			Data:    cfSRVData{Priority: v.Priority, Weight: v.Weight, Port: v.Port, Target: v.Target},
+			ZoneID:  zid,
+			TTL:     ttlOr(cf.ttl, 60),
+			Comment: cf.comment,
+		})
+		if err != nil {
+			return &cfError{err: fmt.Errorf("error creating SRV record: %w", err)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// CloudflareHTTPSRecords configures a Cloudflare provider to keep the HTTPS (type 65) records of each domain in step with its addresses,
+// since browsers which look up the HTTPS record may connect to the addresses in its hints.
+//
+// After each update the ipv4hint and ipv6hint parameters of the domain's HTTPS records are set to the new addresses.
+// Records without a hint for a family are left without it, a hint is removed when there are no addresses of its family,
+// and the other parameters such as alpn are preserved.
+//
+// If alpn is given, e.g. "h3", "h2", then a record "1 . alpn=... ipv4hint=... ipv6hint=..." is created for domains without one.
+func CloudflareHTTPSRecords(alpn ...string) cloudflareOption {
+	return func(cf *cloudflareProvider) error {
+		for _, p := range alpn {
+			if p == "" || strings.ContainsAny(p, ",\" ") {
+				return fmt.Errorf("invalid ALPN protocol ID \"%s\"", p)
+			}
+		}
+		cf.httpsRecords = true
+		cf.httpsALPN = alpn
+		return nil
+	}
+}
+
+// cfHTTPSData is the data of a Cloudflare HTTPS record. Value holds the SvcParams in presentation format.
+type cfHTTPSData struct {
+	Priority uint16 `json:"priority"`
+	Target   string `json:"target"`
+	Value    string `json:"value"`
+}
+
+// setHTTPSHints updates the address hints of the HTTPS records for domain, creating one if configured to.
+func (cf *cloudflareProvider) setHTTPSHints(ctx context.Context, zid, domain string, addrs []netip.Addr) error {
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{
+		Type: "HTTPS",
+		Name: domain,
+	})
+	if err != nil {
+		return fmt.Errorf("error listing HTTPS records for %s: %w", domain, err)
+	}
+	var v4, v6 []string
+	for _, a := range addrs {
+		if a.Unmap().Is4() {
+			v4 = append(v4, a.Unmap().String())
+		} else {
+			v6 = append(v6, a.String())
+		}
+	}
+	if len(records) == 0 && len(cf.httpsALPN) > 0 {
+		params := []string{fmt.Sprintf("alpn=\"%s\"", strings.Join(cf.httpsALPN, ","))}
+		params = setSvcParam(setSvcParam(params, "ipv4hint", v4, true), "ipv6hint", v6, true)
+		cf.logger.Printf("creating HTTPS record for %s...\n", domain)
+		_, err := cf.client().CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.CreateDNSRecordParams{
+			Type:    "HTTPS",
+			Name:    domain,
+			Data:    cfHTTPSData{Priority: 1, Target: ".", Value: strings.Join(params, " ")},
+			ZoneID:  zid,
+			TTL:     ttlOr(cf.ttl, 60),
+			Comment: cf.comment,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating HTTPS record for %s: %w", domain, err)
+		}
+		return nil
+	}
+	for _, r := range records {
+		b, err := json.Marshal(r.Data)
+		if err != nil {
+			return err
+		}
+		var data cfHTTPSData
+		if err := json.Unmarshal(b, &data); err != nil {
+			return fmt.Errorf("error parsing data of HTTPS record %s: %w", r.ID, err)
+		}
+		if data.Priority == 0 {
+			// AliasMode records have no parameters
+			continue
+		}
+		params := splitSvcParams(data.Value)
+		params = setSvcParam(setSvcParam(params, "ipv4hint", v4, false), "ipv6hint", v6, false)
+		value := strings.Join(params, " ")
+		if value == data.Value {
+			continue
+		}
+		cf.logger.Printf("updating HTTPS record %s for %s from %q to %q...\n", r.ID, domain, data.Value, value)
+		data.Value = value
+		_, err = cf.client().UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.UpdateDNSRecordParams{
+			ID:      r.ID,
+			Type:    r.Type,
+			Name:    r.Name,
+			Data:    data,
+			TTL:     r.TTL,
+			Comment: r.Comment,
+			Tags:    r.Tags,
+		})
+		if err != nil {
+			return fmt.Errorf("record ID %s: %w", r.ID, err)
+		}
+	}
+	return nil
+}
+
+// splitSvcParams splits SvcParams in presentation format into key=value parameters,
+// keeping quoted values which contain spaces together.
+func splitSvcParams(s string) []string {
+	var params []string
+	var b strings.Builder
+	quoted, escaped := false, false
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if b.Len() > 0 {
+				params = append(params, b.String())
+				b.Reset()
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		params = append(params, b.String())
+	}
+	return params
+}
+
+// setSvcParam sets the value of key in params to the comma-separated values, or removes it if there are none.
+// A missing key is only added if add is true.
+func setSvcParam(params []string, key string, values []string, add bool) []string {
+	var out []string
+	found := false
+	for _, p := range params {
+		k, _, _ := strings.Cut(p, "=")
+		if k != key {
+			out = append(out, p)
+			continue
+		}
+		found = true
+		if len(values) > 0 {
+			out = append(out, key+"="+strings.Join(values, ","))
+		}
+	}
+	if !found && add && len(values) > 0 {
+		out = append(out, key+"="+strings.Join(values, ","))
+	}
+	return out
+}
@@ -0,0 +1,499 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// fakeCloudflare is a minimal in-memory implementation of the Cloudflare API endpoints used by the provider.
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	zones   map[string]string // zone ID to name
+	records map[string]fakeRecord
+	nextID  int
+	calls   map[string]int             // request counts by "METHOD /path" with IDs replaced by :id
+	header  http.Header                // headers of the most recent request
+	tunnels map[string]json.RawMessage // tunnel ID to remotely managed configuration
+	tokens  map[string]bool            // API tokens accepted by the token verification endpoint
+}
+
+type fakeRecord struct {
+	ID      string   `json:"id"`
+	ZoneID  string   `json:"zone_id"`
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Content string   `json:"content"`
+	TTL     int      `json:"ttl"`
+	Proxied bool     `json:"proxied"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+func newFakeCloudflare(zones ...string) *fakeCloudflare {
+	f := &fakeCloudflare{zones: map[string]string{}, records: map[string]fakeRecord{}, calls: map[string]int{}, tunnels: map[string]json.RawMessage{}, tokens: map[string]bool{}}
+	for i, z := range zones {
+		f.zones[fmt.Sprintf("zone%d", i)] = z
+	}
+	return f
+}
+
+// client returns an http.Client which sends Cloudflare API requests to the fake.
+func (f *fakeCloudflare) client(t *testing.T) *http.Client {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = u.Scheme, u.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// Records returns the content of the records named name, sorted.
+func (f *fakeCloudflare) Records(name string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var content []string
+	for _, r := range f.records {
+		if r.Name == name {
+			content = append(content, r.Content)
+		}
+	}
+	sort.Strings(content)
+	return content
+}
+
+// Record returns the record with the given ID.
+func (f *fakeCloudflare) Record(id string) (fakeRecord, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.records[id]
+	return r, ok
+}
+
+func (f *fakeCloudflare) add(zid string, r fakeRecord) fakeRecord {
+	f.nextID++
+	r.ID = fmt.Sprintf("rec%d", f.nextID)
+	r.ZoneID = zid
+	f.records[r.ID] = r
+	return r
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/client/v4/"), "/")
+	path := make([]string, len(parts))
+	for i, p := range parts {
+		if i%2 == 1 {
+			p = ":id"
+		}
+		path[i] = p
+	}
+	f.calls[r.Method+" /"+strings.Join(path, "/")]++
+	f.header = r.Header.Clone()
+
+	respond := func(result any) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"success":     true,
+			"errors":      []any{},
+			"messages":    []any{},
+			"result":      result,
+			"result_info": map[string]int{"page": 1, "per_page": 100, "total_pages": 1},
+		})
+	}
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 1 && parts[0] == "zones":
+		var zones []map[string]string
+		for id, name := range f.zones {
+			if q := r.URL.Query().Get("name"); q == "" || q == name {
+				zones = append(zones, map[string]string{"id": id, "name": name})
+			}
+		}
+		respond(zones)
+	case len(parts) >= 3 && parts[0] == "zones" && parts[2] == "dns_records":
+		zid := parts[1]
+		if _, ok := f.zones[zid]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"success":false,"errors":[{"code":1001,"message":"invalid zone identifier"}]}`)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && len(parts) == 3:
+			q := r.URL.Query()
+			types := strings.Split(q.Get("type"), ",")
+			var records []fakeRecord
+			for _, rec := range f.records {
+				if rec.ZoneID != zid || (q.Get("name") != "" && rec.Name != q.Get("name")) {
+					continue
+				}
+				for _, t := range types {
+					if t == "" || t == rec.Type {
+						records = append(records, rec)
+						break
+					}
+				}
+			}
+			sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+			respond(records)
+		case r.Method == http.MethodPost && len(parts) == 3:
+			var rec fakeRecord
+			json.NewDecoder(r.Body).Decode(&rec)
+			respond(f.add(zid, rec))
+		case r.Method == http.MethodDelete && len(parts) == 4:
+			delete(f.records, parts[3])
+			respond(map[string]string{"id": parts[3]})
+		case (r.Method == http.MethodPatch || r.Method == http.MethodPut) && len(parts) == 4:
+			rec, ok := f.records[parts[3]]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"success":false,"errors":[{"code":81044,"message":"record not found"}]}`)
+				return
+			}
+			// PATCH only changes the fields present in the request
+			var update map[string]json.RawMessage
+			json.NewDecoder(r.Body).Decode(&update)
+			b, _ := json.Marshal(rec)
+			var merged map[string]json.RawMessage
+			json.Unmarshal(b, &merged)
+			for k, v := range update {
+				merged[k] = v
+			}
+			b, _ = json.Marshal(merged)
+			rec = fakeRecord{}
+			json.Unmarshal(b, &rec)
+			f.records[rec.ID] = rec
+			respond(rec)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case r.Method == http.MethodGet && r.URL.Path == "/client/v4/user/tokens/verify":
+		if !f.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")] {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"success":false,"errors":[{"code":1000,"message":"Invalid API Token"}]}`)
+			return
+		}
+		respond(map[string]string{"id": "token", "status": "active"})
+	case len(parts) == 5 && parts[0] == "accounts" && parts[2] == "cfd_tunnel" && parts[4] == "configurations":
+		if r.Method == http.MethodPut {
+			var body struct {
+				Config json.RawMessage `json:"config"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			f.tunnels[parts[3]] = body.Config
+		}
+		respond(map[string]any{"tunnel_id": parts[3], "config": f.tunnels[parts[3]]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"success":false,"errors":[{"code":7003,"message":"no route for %s"}]}`, r.URL.Path)
+	}
+}
+
+func TestCloudflareZoneMatching(t *testing.T) {
+	ctx := context.Background()
+	ip := netip.MustParseAddr("192.0.2.1")
+	tests := map[string]string{
+		"apex":            "example.co.uk",
+		"subdomain":       "host.example.co.uk",
+		"wildcard":        "*.home.example.co.uk",
+		"trailing dot":    "www.example.com.",
+		"case":            "WWW.Example.COM",
+		"longest suffix":  "host.sub.example.com",
+		"apex of subzone": "sub.example.com",
+	}
+	want := map[string]string{
+		"apex":            "example.co.uk",
+		"subdomain":       "host.example.co.uk",
+		"wildcard":        "*.home.example.co.uk",
+		"trailing dot":    "www.example.com",
+		"case":            "www.example.com",
+		"longest suffix":  "host.sub.example.com",
+		"apex of subzone": "sub.example.com",
+	}
+	for name, domain := range tests {
+		name, domain := name, domain
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cf := newFakeCloudflare("example.co.uk", "example.com", "sub.example.com")
+			c, err := ddns.New(domain, ddns.NewCloudflare("token"),
+				ddns.UsingResolver(ddns.StaticIP(ip)),
+				ddns.UsingHTTPClient(cf.client(t)),
+			)
+			if err != nil {
+				t.Fatalf("New returned an error: %s", err)
+			}
+			if err := c.RunDDNS(ctx); err != nil {
+				t.Fatalf("RunDDNS failed: %s", err)
+			}
+			if got := cf.Records(want[name]); len(got) != 1 || got[0] != ip.String() {
+				t.Fatalf("Expected %s for %s; got %q", ip, want[name], got)
+			}
+		})
+	}
+}
+
+func TestCloudflareZoneLabelBoundary(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	c, err := ddns.New("notexample.com", ddns.NewCloudflare("token"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Fatalf("Expected an error for a domain outside of every zone")
+	}
+}
+
+func TestCloudflareZoneID(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	ip := netip.MustParseAddr("192.0.2.1")
+	c, err := ddns.New("www.example.com", ddns.NewCloudflare("token", ddns.CloudflareZoneID("zone0")),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if n := cf.calls["GET /zones"]; n != 0 {
+		t.Errorf("Expected zones not to be listed; got %d requests", n)
+	}
+	if got := cf.Records("www.example.com"); len(got) != 1 || got[0] != ip.String() {
+		t.Fatalf("Expected %s; got %q", ip, got)
+	}
+}
+
+func TestNewCloudflareFromEnv(t *testing.T) {
+	for _, name := range []string{"CF_API_TOKEN", "CLOUDFLARE_API_TOKEN", "CF_API_KEY", "CLOUDFLARE_API_KEY", "CF_API_EMAIL", "CLOUDFLARE_EMAIL"} {
+		t.Setenv(name, "")
+	}
+	if _, err := ddns.New("www.example.com", ddns.NewCloudflareFromEnv()); err == nil {
+		t.Fatalf("Expected an error without credentials in the environment")
+	}
+
+	t.Setenv("CF_API_KEY", "global-key")
+	t.Setenv("CF_API_EMAIL", "user@example.com")
+	cf := newFakeCloudflare("example.com")
+	c, err := ddns.New("www.example.com", ddns.NewCloudflareFromEnv(),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := cf.header.Get("X-Auth-Key"); got != "global-key" {
+		t.Errorf("Expected X-Auth-Key %q; got %q", "global-key", got)
+	}
+	if got := cf.header.Get("X-Auth-Email"); got != "user@example.com" {
+		t.Errorf("Expected X-Auth-Email %q; got %q", "user@example.com", got)
+	}
+}
+
+func TestCloudflareUpdateInPlace(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	old := cf.add("zone0", fakeRecord{Type: "A", Name: "www.example.com", Content: "192.0.2.1", TTL: 300, Proxied: true, Comment: "home router", Tags: []string{"home"}})
+	ip := netip.MustParseAddr("192.0.2.2")
+	c, err := ddns.New("www.example.com", ddns.NewCloudflare("token"),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if n := cf.calls["DELETE /zones/:id/dns_records/:id"] + cf.calls["POST /zones/:id/dns_records"]; n != 0 {
+		t.Errorf("Expected no deletes or creates; got %d", n)
+	}
+	got, ok := cf.Record(old.ID)
+	if !ok {
+		t.Fatalf("Expected record %s to be kept", old.ID)
+	}
+	if got.Content != ip.String() {
+		t.Errorf("Expected content %s; got %s", ip, got.Content)
+	}
+	if !got.Proxied || got.Comment != old.Comment || len(got.Tags) != 1 || got.TTL != old.TTL {
+		t.Errorf("Expected metadata to be preserved; got %+v", got)
+	}
+}
+
+func TestCloudflarePreservesMetadata(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	cf.add("zone0", fakeRecord{Type: "A", Name: "www.example.com", Content: "192.0.2.1", TTL: 300, Proxied: true, Comment: "home router", Tags: []string{"home"}})
+	c, err := ddns.New("www.example.com", ddns.NewCloudflare("token"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	for _, r := range cf.records {
+		if !r.Proxied || r.Comment != "home router" || len(r.Tags) != 1 || r.TTL != 300 {
+			t.Errorf("Expected metadata to be carried over to %s record %s; got %+v", r.Type, r.Content, r)
+		}
+	}
+}
+
+func TestCloudflareMappedAddress(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	c, err := ddns.New("www.example.com", ddns.NewCloudflare("token"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("::ffff:203.0.113.5"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := cf.Records("www.example.com"); len(got) != 1 || got[0] != "203.0.113.5" {
+		t.Errorf("Expected a record for 203.0.113.5; got %q", got)
+	}
+	for _, r := range cf.records {
+		if r.Type != "A" {
+			t.Errorf("Expected an A record; got %s", r.Type)
+		}
+	}
+
+	p, _ := ddns.NewCloudflare("token")()
+	if sh, ok := p.(interface{ SetHTTPClient(*http.Client) }); ok {
+		sh.SetHTTPClient(cf.client(t))
+	}
+	if err := p.SetDNSRecords(context.Background(), "www.example.com", []netip.Addr{{}}); err == nil {
+		t.Errorf("Expected an error for an invalid address")
+	}
+}
+
+func TestCloudflareHTTPSRecords(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	rec := cf.add("zone0", fakeRecord{Type: "HTTPS", Name: "www.example.com", TTL: 300, Comment: "cdn",
+		Data: json.RawMessage(`{"priority":1,"target":".","value":"alpn=\"h3,h2\" ipv4hint=192.0.2.1 ech=\"AEX+DQ BB\""}`)})
+	cf.add("zone0", fakeRecord{Type: "HTTPS", Name: "www.example.com", Data: json.RawMessage(`{"priority":0,"target":"cdn.example.net","value":""}`)})
+	c, err := ddns.New("www.example.com", ddns.NewCloudflare("token", ddns.CloudflareHTTPSRecords()),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	got, _ := cf.Record(rec.ID)
+	var data struct{ Value string }
+	json.Unmarshal(got.Data, &data)
+	expected := `alpn="h3,h2" ipv4hint=192.0.2.2 ech="AEX+DQ BB"`
+	if data.Value != expected {
+		t.Errorf("Expected %q; got %q", expected, data.Value)
+	}
+	if got.Comment != "cdn" || got.TTL != 300 {
+		t.Errorf("Expected metadata to be preserved; got %+v", got)
+	}
+	if n := cf.calls["PATCH /zones/:id/dns_records/:id"]; n != 1 {
+		t.Errorf("Expected only the ServiceMode record to be updated; got %d updates", n)
+	}
+
+	// a new record is created for a domain without one
+	c, err = ddns.New("api.example.com", ddns.NewCloudflare("token", ddns.CloudflareHTTPSRecords("h2")),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
+	expected = `{"priority":1,"target":".","value":"alpn=\"h2\" ipv4hint=192.0.2.2 ipv6hint=2001:db8::1"}`
+	found := false
+	for _, r := range cf.records {
+		if r.Type == "HTTPS" && r.Name == "api.example.com" {
+			found = true
+			if string(r.Data) != expected {
+				t.Errorf("Expected data %s; got %s", expected, r.Data)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("Expected an HTTPS record to be created for api.example.com")
+	}
+}
+
+func TestCloudflareConformance(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	client := cf.client(t)
+	ddnstest.TestProvider(t, func() (ddns.Provider, error) {
+		p, err := ddns.NewCloudflare("token")()
+		if err != nil {
+			return nil, err
+		}
+		p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(client)
+		return p, nil
+	})
+}
+
+func TestCloudflareTXTRecordTTL(t *testing.T) {
+	ctx := context.Background()
+	cf := newFakeCloudflare("example.com")
+	for _, tt := range []struct {
+		ttl  time.Duration
+		want int
+	}{
+		{0, 60},
+		{5 * time.Minute, 300},
+	} {
+		p, err := ddns.NewCloudflare("token")()
+		if err != nil {
+			t.Fatalf("NewCloudflare returned an error: %s", err)
+		}
+		p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(cf.client(t))
+		if tt.ttl > 0 {
+			p.(interface{ SetTTL(time.Duration) }).SetTTL(tt.ttl)
+		}
+		name := fmt.Sprintf("_ttl%d.example.com", tt.want)
+		if err := p.(ddns.TXTProvider).SetTXTRecords(ctx, name, []string{"value"}); err != nil {
+			t.Fatalf("SetTXTRecords failed: %s", err)
+		}
+		cf.mu.Lock()
+		for _, r := range cf.records {
+			if r.Name == name && r.TTL != tt.want {
+				t.Errorf("Expected TTL %d for TTL %s; got %d", tt.want, tt.ttl, r.TTL)
+			}
+		}
+		cf.mu.Unlock()
+	}
+}
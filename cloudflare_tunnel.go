@@ -0,0 +1,207 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// NewCloudflareTunnel is used by [ddns.New] to create a Provider which routes the domain through a Cloudflare Tunnel
+// instead of publishing its addresses, for hosts behind CGNAT or a firewall where the public IP can't be reached.
+//
+// Each update ensures that the domain is a proxied CNAME to the tunnel,
+// and that the tunnel's remotely managed configuration has an ingress rule sending the domain to service,
+// e.g. "http://localhost:8080". Other ingress rules are left alone.
+// The addresses resolved by the client are ignored, so any resolver may be used.
+//
+// The tunnel must be run by cloudflared with a token, and the API token needs the
+// Account.Cloudflare Tunnel:Edit and Zone.DNS:Edit permissions.
+// Options for [NewCloudflare] may also be given, except that the zones searched are always limited to accountID.
+func NewCloudflareTunnel(token, accountID, tunnelID, service string, options ...cloudflareOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		if tunnelID == "" {
+			return nil, errors.New("tunnel ID cannot be empty")
+		}
+		if service == "" {
+			return nil, errors.New("service cannot be empty")
+		}
+		cf, err := newCloudflareProvider(token, append([]cloudflareOption{CloudflareAccountID(accountID)}, options...)...)
+		if err != nil {
+			return nil, err
+		}
+		return &cloudflareTunnelProvider{cf: cf, tunnelID: tunnelID, service: service}, nil
+	}
+}
+
+// cloudflareTunnelProvider implements ddns.Provider by routing domains to a Cloudflare Tunnel.
+type cloudflareTunnelProvider struct {
+	cf       *cloudflareProvider
+	tunnelID string
+	service  string
+}
+
+// Capabilities reports both address families as supported because the addresses are never published.
+func (t *cloudflareTunnelProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, Proxy: true}
+}
+
+func (t *cloudflareTunnelProvider) SetLogger(logger *log.Logger) { t.cf.SetLogger(logger) }
+
+func (t *cloudflareTunnelProvider) SetHTTPClient(httpclient *http.Client) {
+	t.cf.SetHTTPClient(httpclient)
+}
+
+func (t *cloudflareTunnelProvider) SetDNSRecords(ctx context.Context, domain string, _ []netip.Addr) error {
+	domain = canonicalName(domain)
+	if err := t.routeDNS(ctx, domain); err != nil {
+		return &cfError{err: err}
+	}
+	if err := t.routeIngress(ctx, domain); err != nil {
+		return &cfError{err: err}
+	}
+	return nil
+}
+
+// target is the hostname which routes to the tunnel.
+func (t *cloudflareTunnelProvider) target() string {
+	return t.tunnelID + ".cfargotunnel.com"
+}
+
+// routeDNS ensures that domain is a proxied CNAME to the tunnel.
+// A and AAAA records for domain are deleted if they were created by ddns;
+// any other records for the name are an error because they can't coexist with a CNAME.
+func (t *cloudflareTunnelProvider) routeDNS(ctx context.Context, domain string) error {
+	cf := t.cf
+	zid, err := cf.getZoneIDFromDomain(ctx, domain)
+	if err != nil {
+		return fmt.Errorf("unable to get zone ID for %s: %w", domain, err)
+	}
+	records, _, err := cf.client().ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.ListDNSRecordsParams{Name: domain})
+	if err != nil {
+		return fmt.Errorf("error listing records for %s: %w", domain, err)
+	}
+	var cname *cloudflare.DNSRecord
+	for i, r := range records {
+		switch {
+		case r.Type == "CNAME":
+			cname = &records[i]
+		case (r.Type == "A" || r.Type == "AAAA") && r.Comment == cf.comment:
+			cf.logger.Printf("deleting %s record %s for %s to route it to the tunnel...\n", r.Type, r.Content, domain)
+			if err := cf.client().DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), r.ID); err != nil {
+				return fmt.Errorf("unable to delete DNS record %s: %w", r.ID, err)
+			}
+		default:
+			return fmt.Errorf("%s has a %s record which conflicts with the tunnel CNAME", domain, r.Type)
+		}
+	}
+	proxied := true
+	if cname == nil {
+		cf.logger.Printf("creating CNAME for %s to %s...\n", domain, t.target())
+		_, err := cf.client().CreateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.CreateDNSRecordParams{
+			Type:    "CNAME",
+			Name:    domain,
+			Content: t.target(),
+			ZoneID:  zid,
+			TTL:     1,
+			Proxied: &proxied,
+			Comment: cf.comment,
+		})
+		if err != nil {
+			return fmt.Errorf("error creating CNAME for %s: %w", domain, err)
+		}
+		return nil
+	}
+	if cname.Content == t.target() && cname.Proxied != nil && *cname.Proxied {
+		return nil
+	}
+	cf.logger.Printf("updating CNAME for %s from %s to %s...\n", domain, cname.Content, t.target())
+	_, err = cf.client().UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(zid), cloudflare.UpdateDNSRecordParams{
+		ID:      cname.ID,
+		Type:    "CNAME",
+		Name:    domain,
+		Content: t.target(),
+		TTL:     1,
+		Proxied: &proxied,
+		Comment: cname.Comment,
+		Tags:    cname.Tags,
+	})
+	if err != nil {
+		return fmt.Errorf("record ID %s: %w", cname.ID, err)
+	}
+	return nil
+}
+
+// routeIngress ensures that the tunnel's ingress rules send domain to the service.
+//
+// The configuration is edited as raw JSON so that the settings which cloudflare-go doesn't model,
+// such as the originRequest of each rule, are written back unchanged.
+func (t *cloudflareTunnelProvider) routeIngress(ctx context.Context, domain string) error {
+	cf := t.cf
+	endpoint := fmt.Sprintf("/accounts/%s/cfd_tunnel/%s/configurations", cf.accountID, t.tunnelID)
+	raw, err := cf.client().Raw(ctx, http.MethodGet, endpoint, nil, nil)
+	if err != nil {
+		return fmt.Errorf("error getting tunnel configuration: %w", err)
+	}
+	var result struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("error parsing tunnel configuration: %w", err)
+	}
+	config := result.Config
+	if config == nil {
+		config = map[string]json.RawMessage{}
+	}
+	var ingress []map[string]json.RawMessage
+	if b, ok := config["ingress"]; ok {
+		if err := json.Unmarshal(b, &ingress); err != nil {
+			return fmt.Errorf("error parsing tunnel ingress rules: %w", err)
+		}
+	}
+	ingress, changed := setIngressRule(ingress, domain, t.service)
+	if !changed {
+		return nil
+	}
+	if config["ingress"], err = json.Marshal(ingress); err != nil {
+		return err
+	}
+	cf.logger.Printf("routing %s to %s through tunnel %s...\n", domain, t.service, t.tunnelID)
+	if _, err := cf.client().Raw(ctx, http.MethodPut, endpoint, map[string]any{"config": config}, nil); err != nil {
+		return fmt.Errorf("error updating tunnel configuration: %w", err)
+	}
+	return nil
+}
+
+// setIngressRule sets the service of the rule for hostname in rules, or inserts one before the catch-all rule,
+// reporting whether rules changed.
+// A catch-all rule is added if there is none, since cloudflared requires the last rule to match every request.
+func setIngressRule(rules []map[string]json.RawMessage, hostname, service string) ([]map[string]json.RawMessage, bool) {
+	str := func(rule map[string]json.RawMessage, key string) string {
+		var s string
+		json.Unmarshal(rule[key], &s)
+		return s
+	}
+	svc, _ := json.Marshal(service)
+	for _, r := range rules {
+		if canonicalName(str(r, "hostname")) == hostname && str(r, "path") == "" {
+			if str(r, "service") == service {
+				return rules, false
+			}
+			r["service"] = svc
+			return rules, true
+		}
+	}
+	host, _ := json.Marshal(hostname)
+	rule := map[string]json.RawMessage{"hostname": host, "service": svc}
+	if n := len(rules); n > 0 && str(rules[n-1], "hostname") == "" && str(rules[n-1], "path") == "" {
+		return append(rules[:n-1], rule, rules[n-1]), true
+	}
+	notFound, _ := json.Marshal("http_status:404")
+	return append(rules, rule, map[string]json.RawMessage{"service": notFound}), true
+}
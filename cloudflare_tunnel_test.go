@@ -0,0 +1,69 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestCloudflareTunnel(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	cf.add("zone0", fakeRecord{Type: "A", Name: "home.example.com", Content: "192.0.2.1", TTL: 60, Comment: "managed by ddns"})
+	cf.tunnels["tun1"] = json.RawMessage(`{"ingress":[{"hostname":"nas.example.com","service":"https://localhost:5001","originRequest":{"noTLSVerify":true}},{"service":"http_status:404"}],"warp-routing":{"enabled":true}}`)
+	c, err := ddns.New("home.example.com", ddns.NewCloudflareTunnel("token", "acct1", "tun1", "http://localhost:8080"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("100.64.0.2"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := cf.Records("home.example.com"); len(got) != 1 || got[0] != "tun1.cfargotunnel.com" {
+		t.Errorf("Expected only the tunnel CNAME; got %q", got)
+	}
+	expected := `{"ingress":[{"hostname":"nas.example.com","originRequest":{"noTLSVerify":true},"service":"https://localhost:5001"},{"hostname":"home.example.com","service":"http://localhost:8080"},{"service":"http_status:404"}],"warp-routing":{"enabled":true}}`
+	if got := string(cf.tunnels["tun1"]); got != expected {
+		t.Errorf("Expected config %s; got %s", expected, got)
+	}
+
+	// a second run with a new address changes nothing
+	c, err = ddns.New("home.example.com", ddns.NewCloudflareTunnel("token", "acct1", "tun1", "http://localhost:8080"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("100.64.0.3"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if n := cf.calls["POST /zones/:id/dns_records"]; n != 1 {
+		t.Errorf("Expected 1 record created; got %d", n)
+	}
+	if n := cf.calls["PUT /accounts/:id/cfd_tunnel/:id/configurations"]; n != 1 {
+		t.Errorf("Expected 1 configuration update; got %d", n)
+	}
+}
+
+func TestCloudflareTunnelConflict(t *testing.T) {
+	cf := newFakeCloudflare("example.com")
+	cf.add("zone0", fakeRecord{Type: "A", Name: "home.example.com", Content: "192.0.2.1", TTL: 60, Comment: "web server"})
+	c, err := ddns.New("home.example.com", ddns.NewCloudflareTunnel("token", "acct1", "tun1", "http://localhost:8080"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("100.64.0.2"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Errorf("Expected an error for a record not created by ddns")
+	}
+	if got := cf.Records("home.example.com"); len(got) != 1 || got[0] != "192.0.2.1" {
+		t.Errorf("Expected the existing record to be kept; got %q", got)
+	}
+}
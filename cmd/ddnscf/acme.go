@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// acmeDNS01 sets or clears an ACME DNS-01 challenge record with the configured credentials.
+//
+// The domain and value are given as arguments, as by lego's exec provider:
+//
+//	ddnscf acme-dns01 present _acme-challenge.example.com. <value>
+//	ddnscf acme-dns01 cleanup _acme-challenge.example.com. <value>
+//
+// or in the CERTBOT_DOMAIN and CERTBOT_VALIDATION environment variables of certbot's manual hooks:
+//
+//	certbot certonly --manual --preferred-challenges dns \
+//		--manual-auth-hook "ddnscf acme-dns01 set" --manual-cleanup-hook "ddnscf acme-dns01 clear" -d example.com
+func acmeDNS01() error {
+	ctx := interruptContext()
+
+	action, domain, value := flag.Arg(0), flag.Arg(1), flag.Arg(2)
+	if domain == "" {
+		domain, value = os.Getenv("CERTBOT_DOMAIN"), os.Getenv("CERTBOT_VALIDATION")
+	}
+	if domain == "" {
+		return errors.New("acme-dns01: expected set or clear followed by the domain and challenge value, or the CERTBOT_DOMAIN and CERTBOT_VALIDATION environment variables")
+	}
+	if credentialsErr != nil {
+		return credentialsErr
+	}
+	key, err := credentials.Load()
+	if err != nil {
+		return fmt.Errorf("error reading key: %w", err)
+	}
+	p, err := newProvider(key)()
+	if err != nil {
+		return fmt.Errorf("error creating provider: %w", err)
+	}
+	if l, ok := p.(interface{ SetLogger(*log.Logger) }); ok {
+		l.SetLogger(logger)
+	}
+	tp, ok := p.(ddns.TXTProvider)
+	if !ok {
+		return errors.New("acme-dns01: provider does not support TXT records")
+	}
+	switch action {
+	case "set", "present":
+		if value == "" {
+			return errors.New("acme-dns01: the challenge value cannot be empty")
+		}
+		if err := ddns.SetACMEChallenge(ctx, tp, domain, value); err != nil {
+			return fmt.Errorf("acme-dns01: %w", err)
+		}
+		log.Printf("set challenge record %s", ddns.ACMEChallengeName(domain))
+	case "clear", "cleanup":
+		if err := ddns.ClearACMEChallenge(ctx, tp, domain, value); err != nil {
+			return fmt.Errorf("acme-dns01: %w", err)
+		}
+		log.Printf("cleared challenge record %s", ddns.ACMEChallengeName(domain))
+	default:
+		return fmt.Errorf("acme-dns01: unknown action \"%s\"; expected set or clear", action)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// ageIdentityEnv may hold an age identity (AGE-SECRET-KEY-1...) used to decrypt the key file.
+const ageIdentityEnv = "DDNSCF_AGE_IDENTITY"
+
+// ageStore stores the token in a file encrypted with age (https://age-encryption.org),
+// so that backups of the home directory don't leak a live token.
+//
+// The identity used to decrypt the file is read from the DDNSCF_AGE_IDENTITY environment variable
+// or from the file given by -age-identity, which should be kept somewhere else.
+type ageStore string
+
+func (a ageStore) Load() (string, error) {
+	f, err := os.Open(string(a))
+	if err != nil {
+		return "", fmt.Errorf("error reading key: %w", err)
+	}
+	defer f.Close()
+	identities, err := ageIdentities()
+	if err != nil {
+		return "", err
+	}
+	r, err := age.Decrypt(f, identities...)
+	if err != nil {
+		return "", fmt.Errorf("error decrypting \"%s\": %w", string(a), err)
+	}
+	line, _, err := bufio.NewReader(r).ReadLine()
+	if err != nil {
+		return "", fmt.Errorf("error reading line: %w", err)
+	}
+	return string(line), nil
+}
+
+func (a ageStore) Save(token string) error {
+	identity, err := ageSetupIdentity()
+	if err != nil {
+		return err
+	}
+	logger.Printf("creating encrypted key file at \"%s\"\n", string(a))
+	f, err := createPrivate(string(a))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := age.Encrypt(f, identity.Recipient())
+	if err != nil {
+		return fmt.Errorf("error encrypting key: %w", err)
+	}
+	if _, err := fmt.Fprintln(w, token); err != nil {
+		return fmt.Errorf("error writing \"%s\": %w", string(a), err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error writing \"%s\": %w", string(a), err)
+	}
+	logger.Printf("encrypted token written to \"%s\"\n", string(a))
+	return nil
+}
+
+func (a ageStore) Version() (string, error) { return fileVersion(string(a)) }
+func (a ageStore) String() string           { return "age:" + string(a) }
+
+// ageIdentities returns the configured identities for decrypting the key file.
+func ageIdentities() ([]age.Identity, error) {
+	if s := os.Getenv(ageIdentityEnv); s != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(s))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", ageIdentityEnv, err)
+		}
+		return ids, nil
+	}
+	if config.AgeIdentity == "" {
+		return nil, fmt.Errorf("an age identity is required: set %s or pass -age-identity", ageIdentityEnv)
+	}
+	f, err := os.Open(config.AgeIdentity)
+	if err != nil {
+		return nil, fmt.Errorf("error reading age identity: %w", err)
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing \"%s\": %w", config.AgeIdentity, err)
+	}
+	return ids, nil
+}
+
+// ageSetupIdentity returns the X25519 identity to encrypt a new key file to,
+// generating one at the -age-identity path if it does not exist yet.
+func ageSetupIdentity() (*age.X25519Identity, error) {
+	ids, err := ageIdentities()
+	if errors.Is(err, os.ErrNotExist) && config.AgeIdentity != "" {
+		return generateAgeIdentity(config.AgeIdentity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if x, ok := id.(*age.X25519Identity); ok {
+			return x, nil
+		}
+	}
+	return nil, errors.New("no X25519 age identity found")
+}
+
+func generateAgeIdentity(path string) (*age.X25519Identity, error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("error generating age identity: %w", err)
+	}
+	f, err := createPrivate(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fmt.Fprintf(f, "# created: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# public key: %s\n", id.Recipient())
+	fmt.Fprintln(f, id)
+	fmt.Printf("Generated age identity at \"%s\"; keep it out of the backups that contain the key file.\n", path)
+	return id, nil
+}
+
+// offerEncryption asks whether a new key file should be encrypted,
+// returning the store to save the token in.
+func offerEncryption(store credentialStore, in io.Reader) credentialStore {
+	f, ok := store.(fileStore)
+	if !ok {
+		return store
+	}
+	fmt.Printf("Encrypt the key file with age? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		return store
+	}
+	if config.AgeIdentity == "" && os.Getenv(ageIdentityEnv) == "" {
+		fmt.Printf("An age identity is required; run setup again with -age-identity <path> to encrypt the key file.\n")
+		return store
+	}
+	encrypted := ageStore(string(f) + ".age")
+	fmt.Printf("Pass \"-credential %s\" on future runs to use the encrypted key file.\n", encrypted)
+	return encrypted
+}
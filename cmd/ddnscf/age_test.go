@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func TestAgeStore(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+	t.Setenv(ageIdentityEnv, "")
+	dir := t.TempDir()
+	config.AgeIdentity = filepath.Join(dir, "identity.txt")
+	store := ageStore(filepath.Join(dir, "key.age"))
+
+	// the identity is generated on first use
+	if err := store.Save("secret-token"); err != nil {
+		t.Fatalf("Save returned an error: %s", err)
+	}
+	b, err := os.ReadFile(string(store))
+	if err != nil {
+		t.Fatalf("error reading the key file: %s", err)
+	}
+	if bytes.Contains(b, []byte("secret-token")) {
+		t.Errorf("Expected the key file to be encrypted")
+	}
+	token, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load returned an error: %s", err)
+	}
+	if token != "secret-token" {
+		t.Errorf("Expected %q; got %q", "secret-token", token)
+	}
+
+	// an identity in the environment takes precedence, and can't decrypt a file encrypted to another
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("error generating identity: %s", err)
+	}
+	t.Setenv(ageIdentityEnv, other.String())
+	if _, err := store.Load(); err == nil {
+		t.Errorf("Expected an error loading the key with the wrong identity")
+	}
+
+	config.AgeIdentity = filepath.Join(dir, "missing.txt")
+	t.Setenv(ageIdentityEnv, "")
+	if _, err := store.Load(); err == nil {
+		t.Errorf("Expected an error loading the key without an identity")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// apiTokenEnv is the environment variable holding the token required by the control API.
+const apiTokenEnv = "DDNSCF_API_TOKEN"
+
+// runAgent updates the -d domain, and any domains added at runtime, while serving the control API on -listen.
+func runAgent(ctx context.Context, newClient func(domain string) (ddns.DDNSClient, error)) error {
+	token := os.Getenv(apiTokenEnv)
+	if token == "" {
+		return fmt.Errorf("%s must be set to serve the control API", apiTokenEnv)
+	}
+	agent := ddns.NewAgent(newClient, config.Interval, log.Default(), ddns.AdaptiveInterval(config.Interval, config.MaxInterval))
+	if err := agent.Add(config.Domain); err != nil {
+		return err
+	}
+	if config.Trigger != "" {
+		err := listenTrigger(ctx, config.Trigger, func(line string) {
+			logger.Printf("received trigger %q", line)
+			if err := agent.Trigger(config.Domain); err != nil {
+				log.Printf("unable to trigger an update: %s", err)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	ln, err := net.Listen("tcp", config.Listen)
+	if err != nil {
+		return fmt.Errorf("unable to listen for the control API: %w", err)
+	}
+	srv := &http.Server{Handler: agent.Handler(token), ReadHeaderTimeout: 10 * time.Second}
+	errc := make(chan error, 1)
+	go func() {
+		logger.Printf("serving the control API on %s", ln.Addr())
+		errc <- srv.Serve(ln)
+	}()
+	agentErr := agent.Run(ctx)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdownCtx)
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("control API: %w", err)
+	}
+	return agentErr
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completion prints the completion script for the shell named by the first argument.
+//
+// For example, in ~/.bashrc:
+//
+//	source <(ddnscf completion bash)
+func completion() error {
+	return writeCompletion(os.Stdout, flag.Arg(0))
+}
+
+// writeCompletion writes the completion script for shell to w,
+// listing the current subcommands and flags.
+func writeCompletion(w io.Writer, shell string) error {
+	var names, flags []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, "-"+f.Name)
+	})
+	switch shell {
+	case "bash":
+		fmt.Fprintf(w, bashCompletion, strings.Join(names, " "), strings.Join(flags, " "))
+	case "zsh":
+		fmt.Fprintf(w, zshCompletion, strings.Join(names, " "), strings.Join(flags, " "))
+	case "fish":
+		for _, c := range commands {
+			help := strings.SplitN(c.help, "\n", 2)[0]
+			fmt.Fprintf(w, "complete -c ddnscf -n __fish_use_subcommand -a %s -d %q\n", c.name, help)
+		}
+		flag.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(w, "complete -c ddnscf -o %s -d %q\n", f.Name, f.Usage)
+		})
+		fmt.Fprintln(w, "complete -c ddnscf -n '__fish_seen_subcommand_from completion' -f -a 'bash zsh fish'")
+	default:
+		return errors.New("usage: ddnscf completion bash|zsh|fish")
+	}
+	return nil
+}
+
+const bashCompletion = `_ddnscf() {
+	local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+	if [[ "$prev" == "completion" ]]; then
+		COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur"))
+	elif [[ $COMP_CWORD -eq 1 && "$cur" != -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	elif [[ "$cur" == -* ]]; then
+		COMPREPLY=($(compgen -W "%s" -- "$cur"))
+	else
+		COMPREPLY=($(compgen -f -- "$cur"))
+	fi
+}
+complete -F _ddnscf ddnscf
+`
+
+const zshCompletion = `#compdef ddnscf
+_ddnscf() {
+	if (( CURRENT == 2 )) && [[ "$words[CURRENT]" != -* ]]; then
+		compadd -- %s
+	elif [[ "$words[2]" == "completion" ]]; then
+		compadd -- bash zsh fish
+	elif [[ "$words[CURRENT]" == -* ]]; then
+		compadd -- %s
+	else
+		_files
+	fi
+}
+compdef _ddnscf ddnscf
+`
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestWriteCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		var out bytes.Buffer
+		if err := writeCompletion(&out, shell); err != nil {
+			t.Fatalf("%s: writeCompletion returned an error: %s", shell, err)
+		}
+		script := out.String()
+		for _, c := range commands {
+			if !strings.Contains(script, c.name) {
+				t.Errorf("%s: Expected the %s command in the script", shell, c.name)
+			}
+		}
+		flag.VisitAll(func(f *flag.Flag) {
+			want := "-" + f.Name
+			if shell == "fish" {
+				want = "-o " + f.Name
+			}
+			if !strings.Contains(script, want) {
+				t.Errorf("%s: Expected the -%s flag in the script", shell, f.Name)
+			}
+		})
+	}
+	if err := writeCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Errorf("Expected an error for an unsupported shell")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile sets the flags named in the YAML file at path, such as:
+//
+//	d: home.example.com
+//	aliases: www.example.com
+//	i: 10m
+//
+// Flags given on the command line take precedence over the file.
+func loadConfigFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+	var values map[string]string
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("error parsing config file \"%s\": %w", path, err)
+	}
+	given := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "config" {
+			return errors.New("config file cannot set -config")
+		}
+		if flag.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag \"%s\" in config file \"%s\"", name, path)
+		}
+		if given[name] {
+			continue
+		}
+		if err := flag.Set(name, values[name]); err != nil {
+			return fmt.Errorf("invalid value for \"%s\" in config file \"%s\": %w", name, path, err)
+		}
+	}
+	return nil
+}
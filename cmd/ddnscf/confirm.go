@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// confirmDelete is called when an update would delete more than -max-deletes records or all of them.
+// It allows the update with -allow-mass-delete, or else asks at the terminal if there is one.
+func confirmDelete(ctx context.Context, domain string, deletes []netip.Addr) bool {
+	if config.AllowMassDelete {
+		return true
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		logger.Printf("not asking to confirm deletion: stdin is not a terminal")
+		return false
+	}
+	return askDelete(os.Stdin, domain, deletes)
+}
+
+// allowDelete is like confirmDelete, but never asks, for when the terminal is in use.
+func allowDelete(ctx context.Context, domain string, deletes []netip.Addr) bool {
+	return config.AllowMassDelete
+}
+
+func askDelete(in io.Reader, domain string, deletes []netip.Addr) bool {
+	fmt.Printf("The update would delete %d records for %s:\n", len(deletes), domain)
+	for _, a := range deletes {
+		fmt.Printf("  - %s\n", a)
+	}
+	fmt.Printf("Delete them? [y/N]: ")
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
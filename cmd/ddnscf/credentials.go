@@ -0,0 +1,151 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// credentialStore loads and saves the Cloudflare API token.
+type credentialStore interface {
+	// Load returns the stored token.
+	// The error wraps fs.ErrNotExist if no token has been stored.
+	Load() (string, error)
+	Save(token string) error
+	String() string
+}
+
+// versionedStore is implemented by credential stores which can cheaply tell when the token may have changed,
+// so that the daemon only loads it again when it has.
+// Version returns a value which changes whenever the stored token may have changed.
+type versionedStore interface {
+	Version() (string, error)
+}
+
+// fileVersion describes a key file by its modification time and size.
+func fileVersion(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+// parseCredential parses the -credential flag.
+// An empty value uses the CF_API_TOKEN or CLOUDFLARE_API_TOKEN environment variable if set,
+// or else the key file given by -k or found by defaultKeyFile.
+func parseCredential(s string) (credentialStore, error) {
+	if s == "" {
+		for _, name := range []string{"CF_API_TOKEN", "CLOUDFLARE_API_TOKEN"} {
+			if os.Getenv(name) != "" {
+				return envStore(name), nil
+			}
+		}
+		if config.KeyFile == "" {
+			return fileStore(defaultKeyFile()), nil
+		}
+		return fileStore(config.KeyFile), nil
+	}
+	scheme, value, _ := strings.Cut(s, ":")
+	switch scheme {
+	case "file":
+		if value == "" {
+			return nil, errors.New("file credential requires a path, e.g. file:/home/pi/.cloudflare")
+		}
+		return fileStore(value), nil
+	case "age":
+		if value == "" {
+			return nil, errors.New("age credential requires a path, e.g. age:/home/pi/.cloudflare.age")
+		}
+		return ageStore(value), nil
+	case "env":
+		if value == "" {
+			return nil, errors.New("env credential requires a variable name, e.g. env:CF_API_TOKEN")
+		}
+		return envStore(value), nil
+	case "keyring":
+		if value == "" {
+			return nil, errors.New("keyring credential requires a service name, e.g. keyring:ddns-cloudflare")
+		}
+		return keyringStore(value), nil
+	default:
+		return nil, fmt.Errorf("unknown credential type \"%s\"; expected file:<path>, age:<path>, env:<variable>, or keyring:<service>", scheme)
+	}
+}
+
+// fileStore stores the token in a plaintext file which must only be readable by its owner.
+type fileStore string
+
+func (f fileStore) Load() (string, error) {
+	if err := verifyPermissions(string(f)); err != nil {
+		return "", err
+	}
+	return readKey(string(f))
+}
+
+func (f fileStore) Save(token string) error {
+	logger.Printf("creating key file at \"%s\"\n", string(f))
+	file, err := createPrivate(string(f))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := fmt.Fprintln(file, token); err != nil {
+		return fmt.Errorf("error writing \"%s\": %w", string(f), err)
+	}
+	logger.Printf("token written to \"%s\"\n", string(f))
+	return nil
+}
+
+func (f fileStore) Version() (string, error) { return fileVersion(string(f)) }
+func (f fileStore) String() string           { return "file:" + string(f) }
+
+// envStore reads the token from an environment variable.
+// The variable is read again before each update, but a running process will only see a change made by itself.
+type envStore string
+
+func (e envStore) Load() (string, error) {
+	token := os.Getenv(string(e))
+	if token == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return token, nil
+}
+
+func (e envStore) Save(token string) error {
+	return fmt.Errorf("unable to save the token to environment variable %s; set it before running", string(e))
+}
+
+// Version hashes the variable so that the token isn't kept as the version.
+func (e envStore) Version() (string, error) {
+	sum := sha256.Sum256([]byte(os.Getenv(string(e))))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (e envStore) String() string { return "env:" + string(e) }
+
+// keyringStore stores the token in the operating system's credential store:
+// the macOS Keychain, the Secret Service on Linux, or the Windows Credential Manager.
+// Reading the keyring is too slow to check before every update,
+// so the daemon only reads it again after the provider rejects the current token.
+type keyringStore string
+
+// keyringAccount is the account name stored alongside the service name in the keyring.
+const keyringAccount = "ddnscf"
+
+func (k keyringStore) Load() (string, error) { return keyringGet(string(k)) }
+func (k keyringStore) Save(token string) error {
+	if err := keyringSet(string(k), token); err != nil {
+		return err
+	}
+	logger.Printf("token written to keyring service \"%s\"\n", string(k))
+	return nil
+}
+func (k keyringStore) String() string { return "keyring:" + string(k) }
+
+func errKeyringNotFound(service string) error {
+	return fmt.Errorf("no token stored in keyring for service \"%s\": %w", service, os.ErrNotExist)
+}
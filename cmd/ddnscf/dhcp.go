@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// dhcpBound are the reasons a dhclient exit hook is run with a new or renewed address.
+var dhcpBound = map[string]bool{
+	"BOUND": true, "RENEW": true, "REBIND": true, "REBOOT": true,
+	"BOUND6": true, "RENEW6": true, "REBIND6": true,
+}
+
+// runDHCPHook runs a single update with the address given to a DHCP client hook,
+// such as /etc/dhcp/dhclient-exit-hooks.d/ddnscf:
+//
+//	ddnscf -dhcp-hook -d home.example.com
+//
+// dhclient runs its hooks for every event, so updates are only made when the reason variable is a new or renewed lease.
+// udhcpc doesn't set reason; its script should only run ddnscf for the bound and renew events.
+func runDHCPHook(ctx context.Context, client ddns.DDNSClient) error {
+	if reason := os.Getenv("reason"); reason != "" && !dhcpBound[reason] {
+		logger.Printf("ignoring DHCP hook reason %s", reason)
+		return nil
+	}
+	return client.RunDDNS(ddns.WithRunInfo(ctx, ddns.RunInfo{Trigger: "dhcp"}))
+}
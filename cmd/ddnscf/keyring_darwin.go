@@ -0,0 +1,30 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func keyringGet(service string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", keyringAccount, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 { // errSecItemNotFound
+		return "", errKeyringNotFound(service)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading from keychain: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keyringSet(service, token string) error {
+	// security only accepts the password as an argument or from an interactive prompt,
+	// so the token is briefly visible in the process list during setup.
+	err := exec.Command("security", "add-generic-password", "-U", "-s", service, "-a", keyringAccount, "-w", token).Run()
+	if err != nil {
+		return fmt.Errorf("error writing to keychain: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service is accessed with secret-tool, which is provided by libsecret-tools on most distributions.
+
+func keyringGet(service string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", service, "account", keyringAccount).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(out) == 0 {
+		return "", errKeyringNotFound(service)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading from secret service: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keyringSet(service, token string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=ddnscf Cloudflare API token", "service", service, "account", keyringAccount)
+	cmd.Stdin = strings.NewReader(token)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("error writing to secret service: %w: %s", err, out)
+	}
+	return nil
+}
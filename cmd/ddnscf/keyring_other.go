@@ -0,0 +1,11 @@
+//go:build !darwin && !linux && !windows
+
+package main
+
+import "errors"
+
+var errKeyringUnsupported = errors.New("keyring storage is not supported on this platform")
+
+func keyringGet(service string) (string, error) { return "", errKeyringUnsupported }
+
+func keyringSet(service, token string) error { return errKeyringUnsupported }
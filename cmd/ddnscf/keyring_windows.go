@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The Windows Credential Manager is accessed through the WinRT PasswordVault from PowerShell.
+// The service name is passed through the environment and the token through stdin to avoid quoting issues.
+
+const passwordVault = `$ErrorActionPreference = 'Stop'
+[void][Windows.Security.Credentials.PasswordVault, Windows.Security.Credentials, ContentType = WindowsRuntime]
+$vault = New-Object Windows.Security.Credentials.PasswordVault
+`
+
+func keyringGet(service string) (string, error) {
+	script := passwordVault + `try { $c = $vault.Retrieve($env:DDNSCF_SERVICE, $env:DDNSCF_ACCOUNT) } catch { exit 44 }
+$c.RetrievePassword()
+[Console]::Out.Write($c.Password)`
+	out, err := powershell(script, service, nil)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+		return "", errKeyringNotFound(service)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading from credential manager: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keyringSet(service, token string) error {
+	script := passwordVault + `$token = [Console]::In.ReadLine()
+$vault.Add((New-Object Windows.Security.Credentials.PasswordCredential($env:DDNSCF_SERVICE, $env:DDNSCF_ACCOUNT, $token)))`
+	if _, err := powershell(script, service, strings.NewReader(token+"\n")); err != nil {
+		return fmt.Errorf("error writing to credential manager: %w", err)
+	}
+	return nil
+}
+
+func powershell(script, service string, stdin *strings.Reader) ([]byte, error) {
+	cmd := exec.Command("powershell.exe", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.Env = append(os.Environ(), "DDNSCF_SERVICE="+service, "DDNSCF_ACCOUNT="+keyringAccount)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	return cmd.Output()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// rotatingFile is a log file which is rotated when it reaches maxSize bytes or maxAge,
+// for devices without an init system to capture and rotate the program's output.
+//
+// Rotated files are renamed to path.1, path.2, and so on, with path.1 being the newest.
+// Files beyond the newest maxBackups are removed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64         // zero disables size based rotation
+	maxAge     time.Duration // zero disables age based rotation
+	maxBackups int
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	created time.Time
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	full := r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize
+	old := r.maxAge > 0 && time.Since(r.created) > r.maxAge
+	if full || old {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// open opens the log file for appending.
+// The age of an existing file is counted from its last modification,
+// as the creation time isn't available on every platform.
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("unable to open log file: %w", err)
+	}
+	r.f, r.size, r.created = f, info.Size(), time.Now()
+	if info.Size() > 0 {
+		r.created = info.ModTime()
+	}
+	return nil
+}
+
+// rotate renames the current file to path.1, shifting older backups, and opens a new file.
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("error closing log file: %w", err)
+	}
+	r.f = nil
+	if r.maxBackups > 0 {
+		os.Remove(r.backup(r.maxBackups))
+	}
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(r.backup(i), r.backup(i+1))
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, r.backup(1)); err != nil {
+			return fmt.Errorf("error rotating log file: %w", err)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return fmt.Errorf("error rotating log file: %w", err)
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) backup(i int) string {
+	return fmt.Sprintf("%s.%d", r.path, i)
+}
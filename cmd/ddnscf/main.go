@@ -11,7 +11,8 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -22,54 +23,306 @@ import (
 )
 
 var config = struct {
-	Domain     string
-	KeyFile    string
-	IP         string
-	ServiceURL string
-	Interval   time.Duration
-	Verbose    bool
-	Once       bool
-	Interface  string
+	Domain            string
+	KeyFile           string
+	IP                string
+	ServiceURL        string
+	Interval          time.Duration
+	MaxInterval       time.Duration
+	Verbose           bool
+	Once              bool
+	Interface         string
+	PPP               bool
+	DHCPLease         string
+	DHCPHook          bool
+	DNSServer         string
+	Credential        string
+	AgeIdentity       string
+	Journal           string
+	Webhook           string
+	SMTP              string
+	SMTPFrom          string
+	SMTPTo            string
+	SMTPUser          string
+	NotifyChanges     bool
+	MQTT              string
+	MQTTHomeAssistant bool
+	AlertAfter        int
+	AlertInterval     time.Duration
+	Aliases           string
+	ZoneID            string
+	AccountID         string
+	Listen            string
+	Trigger           string
+	ResolverMode      string
+	MigrateFrom       string
+	Version           bool
+	Quiet             bool
+	RequireHTTPS      bool
+	Debug             bool
+	LogFile           string
+	LogMaxSize        int
+	LogMaxAge         time.Duration
+	LogBackups        int
+
+	MaxDeletes      int
+	AllowMassDelete bool
+	TTL             time.Duration
+	Probe           string
+	ProbePorts      string
+	SRV             string
+	CGNAT           string
+	ConfigFile      string
 }{}
 
 var (
 	resolver ddns.Resolver
 	provider ddns.Provider
 	logger   *log.Logger = log.New(io.Discard, "", 0)
+	command  string
+
+	credentials    credentialStore
+	credentialsErr error
 )
 
+// subcommand is a command given as the first argument to ddnscf.
+type subcommand struct {
+	name string
+	help string
+	run  func() error
+}
+
+// commands are the subcommands of ddnscf. Running without a command is the same as run.
+// They are set by init since some of the commands refer to the list.
+var commands []subcommand
+
 func init() {
-	flag.StringVar(&config.Domain, "d", config.Domain, "DNS entry to update")
+	commands = []subcommand{
+		{"run", "keep the records updated every -i interval (the default)", run},
+		{"once", "update the records once and exit; the same as run -once", func() error {
+			config.Once = true
+			return run()
+		}},
+		{"plan", "print the effective configuration and the changes an update would make", plan},
+		{"status", "report whether the provider and public DNS match the local addresses\nexit codes: 0 ok, 1 error, 2 provider outdated, 3 public DNS not yet updated", func() error {
+			code, err := status()
+			if err != nil {
+				log.Print(err)
+			}
+			os.Exit(code)
+			return nil
+		}},
+		{"tui", "run the updates with a live status screen", tui},
+		{"acme-dns01", "set or clear an ACME DNS-01 challenge TXT record from a certbot or lego hook\nusage: acme-dns01 set|clear [domain value]", acmeDNS01},
+		{"migrate", "print the ddnscf -config files equivalent to a ddclient or inadyn config\nusage: migrate -from ddclient|inadyn <path>", migrate},
+		{"setup", "prompt for the API token and store it with -credential", setup},
+		{"version", "print the version and exit", version},
+		{"completion", "print the shell completion script for bash, zsh, or fish", completion},
+	}
+}
+
+func init() {
+	flag.StringVar(&config.Domain, "d", config.Domain, "DNS entry to update; may be a template such as {{.Hostname}}.home.example.com")
+	flag.StringVar(&config.Aliases, "aliases", "", "Comma-separated list of additional names to publish the same records to")
+	flag.StringVar(&config.ZoneID, "zone-id", "", "Cloudflare zone ID; avoids listing zones, which tokens scoped to one zone cannot do")
+	flag.StringVar(&config.AccountID, "account-id", "", "Cloudflare account ID to limit the zone search to")
 	flag.StringVar(&config.IP, "ip", config.Domain, "IP address to set")
 	flag.StringVar(&config.ServiceURL, "url", config.Domain, "URL of public IP lookup service")
-	flag.StringVar(&config.KeyFile, "k", filepath.Join(env("HOME", env("USERPROFILE", ".")), ".cloudflare"), "Path to cloudflare API credentials file")
+	flag.StringVar(&config.KeyFile, "k", "", "Path to cloudflare API credentials file (default is "+keyFileName+" in the user config directory, or ~/.cloudflare if only that exists)")
 	flag.DurationVar(&config.Interval, "i", 5*time.Minute, "Interval duration between runs")
-	flag.BoolVar(&config.Verbose, "v", false, "Enable verbose logging")
+	flag.DurationVar(&config.MaxInterval, "max-interval", 0, "Stretch the -i interval up to this, e.g. 1h, while the address doesn't change, returning to -i after it does")
+	flag.BoolVar(&config.Quiet, "q", false, "Only log errors")
+	flag.BoolVar(&config.Verbose, "v", false, "Enable verbose logging of each update")
+	flag.BoolVar(&config.Debug, "vv", false, "Enable debug logging, including each request made to the provider and IP lookup services")
 	flag.BoolVar(&config.Once, "once", false, "Run once and exit")
 	flag.StringVar(&config.Interface, "if", "", "Network interface name to use for IP address resolution")
-	flag.Parse()
+	flag.BoolVar(&config.PPP, "ppp", false, "Use the local address of the PPPoE link, e.g. ppp0, detected automatically")
+	flag.StringVar(&config.DHCPLease, "dhcp-lease", "", "Path of a dhclient or systemd-networkd lease file to read the IP address from")
+	flag.BoolVar(&config.DHCPHook, "dhcp-hook", false, "Run once with the IP address a dhclient exit hook or udhcpc script was given in its environment")
+	flag.StringVar(&config.Credential, "credential", "", "Where the API token is stored: file:<path>, age:<path>, env:<variable>, or keyring:<service> (default is CF_API_TOKEN if set, or else the -k key file)")
+	flag.StringVar(&config.AgeIdentity, "age-identity", "", "Path to the age identity for decrypting an age:<path> key file (or set "+ageIdentityEnv+")")
+	flag.StringVar(&config.Journal, "journal", "", "Append a JSON line to this file for every DNS record created or deleted")
+	flag.StringVar(&config.Webhook, "webhook", "", "URL to POST a JSON notification to when updates fail and recover")
+	flag.StringVar(&config.SMTP, "smtp", "", "Mail server host:port to email notifications through when updates fail and recover, e.g. smtp.example.com:587")
+	flag.StringVar(&config.SMTPFrom, "smtp-from", "", "Sender address of -smtp notifications (default is the first -smtp-to address)")
+	flag.StringVar(&config.SMTPTo, "smtp-to", "", "Comma-separated recipient addresses of -smtp notifications")
+	flag.StringVar(&config.SMTPUser, "smtp-user", "", "User name to authenticate to the -smtp server with; the password is read from "+smtpPasswordEnv)
+	flag.IntVar(&config.AlertAfter, "alert-after", 1, "Number of consecutive failed updates before notifying the -webhook or -smtp recipients")
+	flag.DurationVar(&config.AlertInterval, "alert-interval", time.Hour, "Minimum time between failure notifications; 0 sends every alert")
+	flag.StringVar(&config.MQTT, "mqtt", "", "MQTT broker URL and topic to publish failures and address changes to, e.g. mqtt://user@broker.lan:1883/ddns/pi1 (the password is read from "+mqttPasswordEnv+")")
+	flag.BoolVar(&config.MQTTHomeAssistant, "mqtt-ha", false, "Publish Home Assistant discovery configs to -mqtt, adding the public IP, last update time, and update failures as sensors")
+	flag.BoolVar(&config.NotifyChanges, "notify-changes", false, "Also notify the -webhook or -smtp recipients whenever the records change")
+	flag.StringVar(&config.Listen, "listen", "", "Address to serve the control API on, e.g. localhost:8053; requests must send the "+apiTokenEnv+" token")
+	flag.StringVar(&config.Trigger, "trigger", "", "Update immediately when a line is written to fifo:<path> or unix:<path>, e.g. by a firewall hook script when the WAN address changes")
+	flag.StringVar(&config.DNSServer, "dns", "1.1.1.1:53", "Public DNS server used by the status command: host:port, tls://host:port for DNS-over-TLS, or an https:// DNS-over-HTTPS URL")
+	flag.BoolVar(&config.RequireHTTPS, "require-https", false, "Reject a plain http:// -url other than localhost, as the lookup service controls the published records")
+	flag.StringVar(&config.ResolverMode, "resolver-mode", "exclusive", "How to use more than one of -ip, -if, -ppp, -dhcp-lease, -dhcp-hook, and -url: exclusive (an error) or join (publish the addresses from all of them)")
+	flag.StringVar(&config.LogFile, "log-file", "", "Write the log to this file instead of stderr, rotating it by -log-max-size and -log-max-age")
+	flag.IntVar(&config.LogMaxSize, "log-max-size", 10, "Size in megabytes at which the -log-file is rotated; 0 disables rotation by size")
+	flag.DurationVar(&config.LogMaxAge, "log-max-age", 0, "Age at which the -log-file is rotated, e.g. 24h; 0 disables rotation by age")
+	flag.IntVar(&config.LogBackups, "log-backups", 3, "Number of rotated log files to keep")
+	flag.DurationVar(&config.TTL, "ttl", 0, "TTL of new records, e.g. 5m; Cloudflare requires at least 1m (default is 1m, or the TTL of the existing records)")
+	flag.IntVar(&config.MaxDeletes, "max-deletes", 2, "Ask before an update deletes more than this many records, or all of them; without a terminal the update fails")
+	flag.BoolVar(&config.AllowMassDelete, "allow-mass-delete", false, "Allow updates exceeding -max-deletes without asking")
+	flag.StringVar(&config.Probe, "probe", "", "URL of a probe service outside the network (see ddns.ProbeHandler) to check that the published address is reachable on -probe-ports after each update")
+	flag.StringVar(&config.ProbePorts, "probe-ports", "443", "Comma-separated TCP ports checked by -probe")
+	flag.StringVar(&config.SRV, "srv", "", "SRV record to publish pointing at -d, as service:port, e.g. _minecraft._tcp:25565")
+	flag.StringVar(&config.CGNAT, "cgnat", "warn", "What to do when the address is in the carrier-grade NAT range 100.64.0.0/10: warn, fail (skip the update), or off, e.g. for addresses from an overlay network such as Tailscale")
+	flag.StringVar(&config.ConfigFile, "config", "", "YAML file of flag values, e.g. \"d: home.example.com\", as written by the migrate command; flags on the command line take precedence")
+	flag.StringVar(&config.MigrateFrom, "from", "ddclient", "Format of the config file read by the migrate command: ddclient or inadyn")
+	flag.BoolVar(&config.Version, "version", false, "Print the version and exit")
+	flag.Usage = usage
+}
+
+// parseArgs parses the optional subcommand and the flags.
+func parseArgs(args []string) error {
+	// an optional subcommand may precede the flags
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	flag.CommandLine.Parse(args)
+	if config.ConfigFile != "" {
+		if err := loadConfigFile(config.ConfigFile); err != nil {
+			return err
+		}
+	}
+	if config.DHCPHook {
+		// the hook is run again for the next lease
+		config.Once = true
+	}
+	if config.Version {
+		command = "version"
+		return nil
+	}
 
-	if config.Verbose {
+	if config.LogFile != "" {
+		log.SetOutput(&rotatingFile{
+			path:       config.LogFile,
+			maxSize:    int64(config.LogMaxSize) << 20,
+			maxAge:     config.LogMaxAge,
+			maxBackups: config.LogBackups,
+		})
+	}
+	if config.Verbose || config.Debug {
 		logger = log.Default()
 	}
+	credentials, credentialsErr = parseCredential(config.Credential)
+	var err error
+	resolver, err = newResolver()
+	return err
+}
+
+// logLevel returns the client log level selected by -q, -v, and -vv.
+// By default, each DNS record created or deleted is logged.
+func logLevel() ddns.LogLevel {
+	switch {
+	case config.Debug:
+		return ddns.LogDebug
+	case config.Verbose:
+		return ddns.LogVerbose
+	case config.Quiet:
+		return ddns.LogQuiet
+	default:
+		return ddns.LogNormal
+	}
+}
+
+// newResolver returns the resolver selected by the -ip, -if, -ppp, -dhcp-lease, -dhcp-hook, and -url flags,
+// or nil if none of them were given.
+func newResolver() (ddns.Resolver, error) {
+	var resolvers []ddns.Resolver
 	if config.IP != "" {
-		resolver = ddns.FromString(config.IP)
+		resolvers = append(resolvers, ddns.FromString(config.IP))
 	}
 	if config.Interface != "" {
-		resolver = ddns.InterfaceResolver(config.Interface)
+		resolvers = append(resolvers, ddns.InterfaceResolver(config.Interface))
 	}
-	if config.ServiceURL != "" {
-		resolver = ddns.WebResolver(config.ServiceURL)
+	if config.PPP {
+		resolvers = append(resolvers, ddns.PPPResolver())
+	}
+	if config.DHCPLease != "" {
+		resolvers = append(resolvers, ddns.DHCPLeaseResolver(config.DHCPLease))
+	}
+	if config.DHCPHook {
+		resolvers = append(resolvers, ddns.DHCPHookResolver())
+	}
+	if config.ServiceURL != "" && config.RequireHTTPS {
+		r, err := ddns.WebResolverStrict([]string{config.ServiceURL})
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, r)
+	} else if config.ServiceURL != "" {
+		resolvers = append(resolvers, ddns.WebResolver(config.ServiceURL))
+	}
+	switch config.ResolverMode {
+	case "exclusive":
+		if len(resolvers) > 1 {
+			return nil, errors.New("-ip, -if, -ppp, -dhcp-lease, -dhcp-hook, and -url cannot be combined unless -resolver-mode is join")
+		}
+	case "join":
+	default:
+		return nil, fmt.Errorf("unknown resolver mode \"%s\"", config.ResolverMode)
 	}
+	switch len(resolvers) {
+	case 0:
+		return nil, nil
+	case 1:
+		return resolvers[0], nil
+	default:
+		return ddns.Join(resolvers...), nil
+	}
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "  %s [command] [flags]\n\n", os.Args[0])
+	fmt.Fprintf(flag.CommandLine.Output(), "Commands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %-10s  %s\n", c.name, strings.ReplaceAll(c.help, "\n", "\n              "))
+	}
+	fmt.Fprintln(flag.CommandLine.Output())
+	fmt.Fprintf(flag.CommandLine.Output(), "Flags:\n")
+	flag.PrintDefaults()
 }
 
 func main() {
-	if err := run(); err != nil {
+	if err := parseArgs(os.Args[1:]); err != nil {
 		log.Fatal(err)
 	}
+	name := command
+	if name == "" {
+		name = "run"
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+	flag.Usage()
+	log.Fatalf("unknown command \"%s\"", command)
 }
 
-func run() error {
+// setup stores a new API token, replacing any existing one.
+func setup() error {
+	if credentialsErr != nil {
+		return credentialsErr
+	}
+	return runSetup(interruptContext())
+}
+
+// version prints the module version of the build.
+func version() error {
+	fmt.Printf("ddnscf %s %s/%s %s\n", ddns.Version(), runtime.GOOS, runtime.GOARCH, runtime.Version())
+	return nil
+}
+
+// interruptContext returns a context which is canceled on the first interrupt signal.
+// A second interrupt forces the program to exit.
+func interruptContext() context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
 		c := make(chan os.Signal, 1)
@@ -80,31 +333,187 @@ func run() error {
 		<-c
 		log.Fatal("received second interrupt; forcing exit")
 	}()
+	return ctx
+}
+
+func run() error {
+	ctx := interruptContext()
 
 	if err := validate(ctx); err != nil {
 		return fmt.Errorf("run: %w", err)
 	}
 	logger.Printf("config is valid: %+v", config)
-	key, err := readKey(config.KeyFile)
+	// the version is read first so that a change made while loading is picked up by the next run
+	var version string
+	if vs, ok := credentials.(versionedStore); ok {
+		version, _ = vs.Version()
+	}
+	key, err := credentials.Load()
 	if err != nil {
 		return fmt.Errorf("error reading key: %w", err)
 	}
-	logger.Println("successfully read key from key file")
-	client, err := ddns.New(config.Domain,
-		ddns.NewCloudflare(key),
-		ddns.WithLogger(logger),
+	logger.Printf("successfully read key from %s", credentials)
+	var journal io.Writer
+	if config.Journal != "" {
+		f, err := os.OpenFile(config.Journal, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("unable to open journal: %w", err)
+		}
+		defer f.Close()
+		journal = f
+	}
+	notifier, err := newNotifier()
+	if err != nil {
+		return err
+	}
+	options := optionList(
+		ddns.WithLogger(log.Default()),
+		ddns.WithLogLevel(logLevel()),
 		ddns.UsingResolver(resolver),
+		ddns.WithJournal(journal),
+		ddns.Aliases(aliases()...),
+		ddns.WithNotifier(notifier),
+		ddns.AlertAfter(config.AlertAfter),
+		ddns.AlertOnRecovery(),
+		ddns.AlertInterval(config.AlertInterval),
+		ddns.MaxDeletes(config.MaxDeletes),
+		ddns.ConfirmMassDelete(confirmDelete),
+		ddns.WithTTL(config.TTL),
 	)
+	if config.NotifyChanges || config.MQTT != "" {
+		options = append(options, ddns.NotifyOnChange())
+	}
+	if config.Probe != "" {
+		ports, err := probePorts()
+		if err != nil {
+			return err
+		}
+		options = append(options, ddns.CheckReachable(ddns.HTTPProber(config.Probe), ports...))
+	}
+	switch config.CGNAT {
+	case "warn":
+		options = append(options, ddns.DetectCGNAT(nil, false))
+	case "fail":
+		options = append(options, ddns.DetectCGNAT(nil, true))
+	case "off":
+	default:
+		return fmt.Errorf("invalid -cgnat \"%s\"; expected warn, fail, or off", config.CGNAT)
+	}
+	if config.SRV != "" {
+		service, port, ok := strings.Cut(config.SRV, ":")
+		n, err := strconv.ParseUint(port, 10, 16)
+		if !ok || err != nil {
+			return fmt.Errorf("invalid -srv \"%s\"; expected service:port", config.SRV)
+		}
+		options = append(options, ddns.PublishSRV(service, ddns.SRV{Port: uint16(n)}))
+	}
+	newClient := func(domain string) (ddns.DDNSClient, error) {
+		client, err := ddns.New(domain, newProvider(key), options...)
+		if err != nil {
+			return nil, fmt.Errorf("error creating ddns.Client: %w", err)
+		}
+		return &keyWatcher{DDNSClient: client, store: credentials, key: key, version: version}, nil
+	}
+	if config.Listen != "" && !config.Once {
+		return runAgent(ctx, newClient)
+	}
+	client, err := newClient(config.Domain)
 	if err != nil {
-		return fmt.Errorf("error creating ddns.Client: %w", err)
+		return err
+	}
+	if config.DHCPHook {
+		return runDHCPHook(ctx, client)
 	}
 	if config.Once {
 		return client.RunDDNS(ctx)
 	}
-	ddns.RunDaemon(client, ctx, config.Interval, log.Default())
+	triggers := make(chan string, 1)
+	if config.Trigger != "" {
+		err := listenTrigger(ctx, config.Trigger, func(line string) {
+			logger.Printf("received trigger %q", line)
+			select {
+			case triggers <- "notify":
+			default: // an update is already pending
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	ddns.RunDaemon(client, ctx, config.Interval, log.Default(), ddns.TriggerOn(triggers), ddns.AdaptiveInterval(config.Interval, config.MaxInterval))
 	return nil
 }
 
+// keyWatcher reloads the key before each run if it has changed,
+// so that the daemon survives token rotation without a restart.
+// Stores which implement versionedStore are loaded when their version changes.
+// Every store is loaded again when the provider rejects the current key,
+// and the run is retried once with the new key, since RunDaemon stops after an auth error.
+type keyWatcher struct {
+	ddns.DDNSClient
+	store   credentialStore
+	key     string
+	version string
+}
+
+func (kw *keyWatcher) Unwrap() ddns.DDNSClient { return kw.DDNSClient }
+
+func (kw *keyWatcher) RunDDNS(ctx context.Context) error {
+	if _, err := kw.reload(ctx, false); err != nil {
+		// keep running with the old key; it may still be valid
+		log.Printf("error reloading key: %s", err)
+	}
+	err := kw.DDNSClient.RunDDNS(ctx)
+	if !ddns.IsAuthError(err) {
+		return err
+	}
+	changed, rerr := kw.reload(ctx, true)
+	if rerr != nil {
+		log.Printf("error reloading key: %s", rerr)
+	}
+	if !changed {
+		return err
+	}
+	logger.Printf("retrying with the reloaded key")
+	return kw.DDNSClient.RunDDNS(ctx)
+}
+
+// reload loads the key from the store if its version has changed, or always if force is set,
+// and reports whether the client was given a new key.
+func (kw *keyWatcher) reload(ctx context.Context, force bool) (bool, error) {
+	vs, versioned := kw.store.(versionedStore)
+	if !versioned && !force {
+		return false, nil
+	}
+	if versioned {
+		version, err := vs.Version()
+		if err != nil {
+			return false, err
+		}
+		if version == kw.version && !force {
+			return false, nil
+		}
+		kw.version = version
+	}
+	key, err := kw.store.Load()
+	if err != nil {
+		return false, err
+	}
+	if key == kw.key {
+		return false, nil
+	}
+	rotator, ok := kw.DDNSClient.(ddns.CredentialRotator)
+	if !ok {
+		return false, errors.New("client does not support credential rotation")
+	}
+	if err := rotator.RotateCredentials(ctx, key); err != nil {
+		return false, err
+	}
+	kw.key = key
+	logger.Printf("reloaded key from %s", kw.store)
+	return true, nil
+}
+
 func runSetup(ctx context.Context) error {
 	logger.Println("running setup")
 	time.Sleep(200 * time.Millisecond) // dirty timer hack to try to get stderr and stdout output lines to display in order
@@ -139,15 +548,60 @@ func runSetup(ctx context.Context) error {
 	}
 	logger.Println("token verified successfully")
 
-	logger.Printf("creating key file at \"%s\"\n", config.KeyFile)
-	f, err := os.OpenFile(config.KeyFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
-	if err != nil {
-		return fmt.Errorf("unable to create \"%s\": %w", config.KeyFile, err)
+	credentials = offerEncryption(credentials, os.Stdin)
+	return credentials.Save(key)
+}
+
+// optionList returns its arguments, so that options for ddns.New and the notifiers can be collected and appended to
+// without naming their unexported type.
+func optionList[T any](options ...T) []T { return options }
+
+// optionIf returns a list holding option if ok, or an empty list of its type,
+// for options which are only given when a flag is set.
+func optionIf[T any](ok bool, option T) []T {
+	if !ok {
+		return nil
 	}
-	defer f.Close()
-	fmt.Fprintln(f, key)
-	logger.Printf("token written to \"%s\"\n", config.KeyFile)
-	return nil
+	return optionList(option)
+}
+
+// newProvider returns the Cloudflare provider constructor for the zone and account flags.
+func newProvider(key string) func() (ddns.Provider, error) {
+	switch {
+	case config.ZoneID != "":
+		return ddns.NewCloudflare(key, ddns.CloudflareZoneID(config.ZoneID))
+	case config.AccountID != "":
+		return ddns.NewCloudflare(key, ddns.CloudflareAccountID(config.AccountID))
+	default:
+		return ddns.NewCloudflare(key)
+	}
+}
+
+// aliases returns the names given to -aliases.
+func aliases() []string {
+	var names []string
+	for _, name := range strings.Split(config.Aliases, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// probePorts parses the -probe-ports list.
+func probePorts() ([]uint16, error) {
+	var ports []uint16
+	for _, s := range strings.Split(config.ProbePorts, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		port, err := strconv.ParseUint(s, 10, 16)
+		if err != nil || port == 0 {
+			return nil, fmt.Errorf("invalid -probe-ports port \"%s\"", s)
+		}
+		ports = append(ports, uint16(port))
+	}
+	return ports, nil
 }
 
 func env(envvar string, defaultvalue string) string {
@@ -180,17 +634,29 @@ func validate(ctx context.Context) error {
 	if !strings.Contains(config.Domain, ".") {
 		return errors.New("domain must have at least one dot")
 	}
-	_, err := os.Stat(config.KeyFile)
-	if os.IsNotExist(err) {
-		logger.Printf("key file \"%s\" does not exist\n", config.KeyFile)
+	v4, v6, err := ddns.ExpandDomain(config.Domain, resolver)
+	if err != nil {
+		return err
+	}
+	if command != "" && command != "run" && command != "once" {
+		// the other subcommands inspect a single name
+		if v4 != v6 {
+			return fmt.Errorf("the %s command does not support domain templates which use .Family", command)
+		}
+		config.Domain = v4
+	}
+	if credentialsErr != nil {
+		return credentialsErr
+	}
+	_, err = credentials.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.Printf("no key found in %s\n", credentials)
 		if err := runSetup(ctx); err != nil {
 			return fmt.Errorf("setup: %w", err)
 		}
+		_, err = credentials.Load()
 	}
-	if err := verifyPermissions(config.KeyFile); err != nil {
-		return err
-	}
-	return nil
+	return err
 }
 
 func verifyPermissions(path string) error {
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestNewResolver(t *testing.T) {
+	tests := []struct {
+		ip, url, mode string
+		want          string
+		wantErr       bool
+	}{
+		{"", "", "exclusive", "<nil>", false},
+		{"192.0.2.1", "", "exclusive", "[192.0.2.1]", false},
+		{"192.0.2.1", "http://127.0.0.1:0", "exclusive", "", true},
+		{"192.0.2.1", "", "join", "[192.0.2.1]", false},
+		// the web service fails, but the joined resolver still returns the static address
+		{"192.0.2.1", "http://127.0.0.1:0", "join", "[192.0.2.1]", false},
+		{"192.0.2.1", "", "sometimes", "", true},
+	}
+	defer func() { config.IP, config.ServiceURL, config.ResolverMode = "", "", "exclusive" }()
+	for _, tt := range tests {
+		config.IP, config.ServiceURL, config.ResolverMode = tt.ip, tt.url, tt.mode
+		r, err := newResolver()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("-ip %q -url %q -resolver-mode %s: Expected error %t; got %v", tt.ip, tt.url, tt.mode, tt.wantErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got := "<nil>"
+		if r != nil {
+			addrs, _ := r.Resolve(context.Background())
+			got = fmt.Sprint(addrs)
+		}
+		if got != tt.want {
+			t.Errorf("-ip %q -url %q -resolver-mode %s: Expected %s; got %s", tt.ip, tt.url, tt.mode, tt.want, got)
+		}
+	}
+}
+
+func TestRequireHTTPS(t *testing.T) {
+	defer func() { config.ServiceURL, config.RequireHTTPS = "", false }()
+	config.RequireHTTPS = true
+	for url, wantErr := range map[string]bool{
+		"http://ipv4.icanhazip.com":  true,
+		"https://ipv4.icanhazip.com": false,
+		"http://localhost:8080/ip":   false,
+	} {
+		config.ServiceURL = url
+		if _, err := newResolver(); (err != nil) != wantErr {
+			t.Errorf("-url %q -require-https: Expected error %t; got %v", url, wantErr, err)
+		}
+	}
+}
+
+// memoryStore is a credentialStore which counts loads.
+type memoryStore struct {
+	token string
+	loads int
+}
+
+func (m *memoryStore) Load() (string, error)   { m.loads++; return m.token, nil }
+func (m *memoryStore) Save(token string) error { m.token = token; return nil }
+func (m *memoryStore) String() string          { return "memory" }
+
+// versionedMemoryStore reports the token as its version.
+type versionedMemoryStore struct{ memoryStore }
+
+func (m *versionedMemoryStore) Version() (string, error) { return m.token, nil }
+
+type authError struct{}
+
+func (authError) Error() string               { return "invalid token" }
+func (authError) IsAuthenticationError() bool { return true }
+
+// rotatingClient records the keys given to RotateCredentials.
+type rotatingClient struct {
+	err  error
+	keys []string
+}
+
+func (c *rotatingClient) RunDDNS(ctx context.Context) error { return c.err }
+func (c *rotatingClient) RotateCredentials(ctx context.Context, token string) error {
+	c.keys = append(c.keys, token)
+	return nil
+}
+
+func TestKeyWatcherVersioned(t *testing.T) {
+	ctx := context.Background()
+	store := &versionedMemoryStore{memoryStore{token: "old"}}
+	client := &rotatingClient{}
+	kw := &keyWatcher{DDNSClient: client, store: store, key: "old", version: "old"}
+	for i := 0; i < 3; i++ {
+		kw.RunDDNS(ctx)
+	}
+	if store.loads != 0 {
+		t.Errorf("Expected the unchanged key not to be loaded; got %d loads", store.loads)
+	}
+	store.token = "new"
+	kw.RunDDNS(ctx)
+	kw.RunDDNS(ctx)
+	if store.loads != 1 || fmt.Sprint(client.keys) != "[new]" {
+		t.Errorf("Expected the changed key to be loaded once and rotated; got %d loads and %v", store.loads, client.keys)
+	}
+}
+
+func TestKeyWatcherUnversioned(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{token: "old"}
+	client := &rotatingClient{}
+	kw := &keyWatcher{DDNSClient: client, store: store, key: "old"}
+	store.token = "new"
+	kw.RunDDNS(ctx)
+	kw.RunDDNS(ctx)
+	if store.loads != 0 {
+		t.Errorf("Expected the key not to be loaded while it works; got %d loads", store.loads)
+	}
+	client.err = authError{}
+	kw.RunDDNS(ctx)
+	client.err = nil
+	kw.RunDDNS(ctx)
+	kw.RunDDNS(ctx)
+	if store.loads != 1 || fmt.Sprint(client.keys) != "[new]" {
+		t.Errorf("Expected the key to be loaded once after the auth error; got %d loads and %v", store.loads, client.keys)
+	}
+}
+
+// keyClient rejects every key but valid.
+type keyClient struct {
+	key, valid string
+	runs       int
+}
+
+func (c *keyClient) RunDDNS(ctx context.Context) error {
+	c.runs++
+	if c.key != c.valid {
+		return authError{}
+	}
+	return nil
+}
+func (c *keyClient) RotateCredentials(ctx context.Context, token string) error {
+	c.key = token
+	return nil
+}
+
+func TestKeyWatcherRejectedKey(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryStore{token: "new"}
+	client := &keyClient{key: "old", valid: "new"}
+	kw := &keyWatcher{DDNSClient: client, store: store, key: "old"}
+	if err := kw.RunDDNS(ctx); err != nil {
+		t.Errorf("Expected the run to succeed with the reloaded key; got %v", err)
+	}
+	if client.runs != 2 || client.key != "new" {
+		t.Errorf("Expected the rejected run to be retried with the new key; got %d runs with %q", client.runs, client.key)
+	}
+
+	// a key which is rejected and hasn't changed is not retried, so the daemon stops
+	client.valid = "newer"
+	if err := kw.RunDDNS(ctx); !ddns.IsAuthError(err) {
+		t.Errorf("Expected an auth error for the unchanged key; got %v", err)
+	}
+	if client.runs != 3 {
+		t.Errorf("Expected no retry with the same key; got %d runs", client.runs)
+	}
+}
@@ -0,0 +1,456 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrate prints the ddnscf -config files equivalent to a ddclient or inadyn config file:
+//
+//	ddnscf migrate -from ddclient /etc/ddclient.conf
+//	ddnscf migrate -from inadyn /etc/inadyn.conf
+//
+// Only Cloudflare entries can be translated; the others are printed as comments.
+// API tokens are not copied from the config.
+func migrate() error {
+	path := flag.Arg(0)
+	if path == "" {
+		return errors.New("migrate: expected the path of the config file, e.g. ddnscf migrate -from ddclient /etc/ddclient.conf")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	var entries []legacyEntry
+	switch config.MigrateFrom {
+	case "ddclient":
+		entries = parseDdclient(string(b))
+	case "inadyn":
+		if entries, err = parseInadyn(string(b)); err != nil {
+			return fmt.Errorf("migrate: error parsing %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("migrate: unknown config format \"%s\"; expected -from ddclient or -from inadyn", config.MigrateFrom)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("migrate: no hosts found in %s", path)
+	}
+	writeMigration(os.Stdout, path, entries)
+	return nil
+}
+
+// legacyEntry is a group of hosts updated with the same settings by another ddns client.
+type legacyEntry struct {
+	provider string   // the ddclient protocol or inadyn provider, e.g. "cloudflare"
+	hosts    []string // the first is given to -d and the rest to -aliases
+	args     []string // ddnscf flags equivalent to the entry's settings
+	notes    []string // settings which could not be translated
+}
+
+func (e *legacyEntry) flag(name, value string) {
+	for i := 0; i < len(e.args); i += 2 {
+		if e.args[i] == name {
+			if e.args[i+1] != value {
+				e.notef("%s %s is used; %s cannot also be %s", name, e.args[i+1], name, value)
+			}
+			return
+		}
+	}
+	e.args = append(e.args, name, value)
+}
+
+func (e *legacyEntry) notef(format string, a ...any) {
+	e.notes = append(e.notes, fmt.Sprintf(format, a...))
+}
+
+// resolvers joins the addresses of more than one of -ip, -if, and -url, as for separate IPv4 and IPv6 settings.
+func (e *legacyEntry) resolvers() {
+	n := 0
+	for i := 0; i < len(e.args); i += 2 {
+		switch e.args[i] {
+		case "-ip", "-if", "-url":
+			n++
+		}
+	}
+	if n > 1 {
+		e.flag("-resolver-mode", "join")
+	}
+}
+
+// writeMigration prints a YAML config file for each entry, to be loaded with -config.
+func writeMigration(w io.Writer, path string, entries []legacyEntry) {
+	fmt.Fprintf(w, "# ddnscf config equivalent to %s (%s)\n", path, config.MigrateFrom)
+	fmt.Fprintln(w, "# Each document keeps one set of records updated, so save each as its own file")
+	fmt.Fprintln(w, "# and run each as its own service with: ddnscf -config <file>")
+	fmt.Fprintln(w, "# API tokens are not copied; store the token with: ddnscf setup")
+	for _, e := range entries {
+		if e.provider != "cloudflare" {
+			fmt.Fprintf(w, "\n# skipped %s: ddnscf only supports Cloudflare, not %s\n", strings.Join(e.hosts, ", "), e.provider)
+			continue
+		}
+		fmt.Fprintln(w, "---")
+		for _, note := range e.notes {
+			fmt.Fprintf(w, "# %s\n", note)
+		}
+		args := []string{"-d", e.hosts[0]}
+		if len(e.hosts) > 1 {
+			args = append(args, "-aliases", strings.Join(e.hosts[1:], ","))
+		}
+		args = append(args, e.args...)
+		for i := 0; i < len(args); i += 2 {
+			fmt.Fprintf(w, "%s: %s\n", strings.TrimPrefix(args[i], "-"), yamlQuote(args[i+1]))
+		}
+	}
+}
+
+// yamlQuote quotes s as a YAML string if it contains anything other than safe characters.
+func yamlQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,/") == "" && !strings.HasPrefix(s, "-") {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// parseDdclient reads the entries of a ddclient.conf file.
+//
+// Settings are key=value pairs separated by commas or spaces, and the other words of a line are hosts.
+// Settings on a line without hosts are defaults for the lines after it,
+// and a line ending with a backslash continues on the next.
+//
+//	daemon=300
+//	use=web, web=https://ipv4.icanhazip.com
+//	protocol=cloudflare, zone=example.com, ttl=1, \
+//	login=token, password=secret \
+//	home.example.com,www.example.com
+func parseDdclient(s string) []legacyEntry {
+	defaults := map[string]string{}
+	var entries []legacyEntry
+	var line string
+	for _, l := range strings.Split(s, "\n") {
+		l = strings.TrimRight(l, " \t\r")
+		if strings.HasSuffix(l, `\`) {
+			line += strings.TrimSuffix(l, `\`) + " "
+			continue
+		}
+		line, l = "", line+l
+		settings := map[string]string{}
+		var hosts []string
+		for _, w := range ddclientWords(l) {
+			if k, v, ok := strings.Cut(w, "="); ok {
+				settings[strings.ToLower(strings.TrimSpace(k))] = v
+			} else {
+				hosts = append(hosts, w)
+			}
+		}
+		if len(hosts) == 0 {
+			for k, v := range settings {
+				defaults[k] = v
+			}
+			continue
+		}
+		for k, v := range defaults {
+			if _, ok := settings[k]; !ok {
+				settings[k] = v
+			}
+		}
+		entries = append(entries, ddclientEntry(settings, hosts))
+	}
+	return entries
+}
+
+// ddclientWords splits a line of ddclient.conf at commas and spaces which are not quoted, removing comments and quotes.
+func ddclientWords(line string) []string {
+	var words []string
+	var word strings.Builder
+	var quote rune
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '#':
+			return append(words, nonEmpty(word.String())...)
+		case r == ',' || r == ' ' || r == '\t':
+			words = append(words, nonEmpty(word.String())...)
+			word.Reset()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	return append(words, nonEmpty(word.String())...)
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// ddclientEntry translates the settings of a ddclient host line.
+func ddclientEntry(settings map[string]string, hosts []string) legacyEntry {
+	e := legacyEntry{provider: settings["protocol"], hosts: hosts}
+	if e.provider == "" {
+		e.provider = "dyndns2"
+	}
+	for _, key := range []string{"use", "usev4", "usev6"} {
+		use := settings[key]
+		value := settings[use]
+		switch use {
+		case "":
+			continue
+		case "web", "webv4", "webv6", "if", "ifv4", "ifv6", "ip", "ipv4", "ipv6":
+			if value == "" {
+				e.notef("%s=%s is used without setting %s; the default lookup services are used instead", key, use, use)
+				continue
+			}
+		}
+		switch use {
+		case "web", "webv4", "webv6":
+			if !strings.Contains(value, "://") {
+				if !strings.Contains(value, ".") {
+					e.notef("%s=%s names a service built into ddclient; the default lookup services are used instead", use, value)
+					continue
+				}
+				value = "https://" + value
+			}
+			e.flag("-url", value)
+		case "if", "ifv4", "ifv6":
+			e.flag("-if", value)
+		case "ip", "ipv4", "ipv6":
+			e.flag("-ip", value)
+		default:
+			e.notef("%s=%s is not supported; the default lookup services are used instead", key, use)
+		}
+	}
+	e.resolvers()
+	if s := settings["daemon"]; s != "" {
+		if d, err := parseLegacyDuration(s); err == nil {
+			e.flag("-i", formatDuration(d))
+		} else {
+			e.notef("daemon=%s is not a valid interval", s)
+		}
+	}
+	// a TTL of 1 is Cloudflare's automatic TTL
+	if s := settings["ttl"]; s != "" && s != "1" {
+		if d, err := parseLegacyDuration(s); err == nil {
+			e.flag("-ttl", formatDuration(d))
+		} else {
+			e.notef("ttl=%s is not a valid TTL", s)
+		}
+	}
+	if login := settings["login"]; e.provider == "cloudflare" && login != "" && login != "token" {
+		e.notef("login=%s is for a global API key; ddnscf requires an API token", login)
+	}
+	return e
+}
+
+// parseLegacyDuration parses a number of seconds, or a duration with a unit such as 5m or 1d.
+func parseLegacyDuration(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(s)
+}
+
+// formatDuration formats d without trailing zero units, e.g. 5m instead of 5m0s.
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// inadynSection is a block of an inadyn.conf file, e.g. provider cloudflare.com { ... }.
+type inadynSection struct {
+	kind, title string
+	settings    map[string][]string
+	sections    []inadynSection
+}
+
+// parseInadyn reads the provider sections of an inadyn 2 config file.
+//
+//	period = 300
+//	provider cloudflare.com {
+//	    username = example.com
+//	    password = secret
+//	    hostname = { "home.example.com", "www.example.com" }
+//	    ttl = 1
+//	}
+func parseInadyn(s string) ([]legacyEntry, error) {
+	tokens, err := inadynTokens(s)
+	if err != nil {
+		return nil, err
+	}
+	root, rest, err := parseInadynBlock(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("unexpected }")
+	}
+	var entries []legacyEntry
+	for _, sec := range root.sections {
+		if sec.kind != "provider" && sec.kind != "custom" {
+			continue
+		}
+		e := legacyEntry{provider: sec.title, hosts: sec.settings["hostname"]}
+		if len(e.hosts) == 0 {
+			continue
+		}
+		if sec.kind == "custom" {
+			e.provider = "custom provider " + sec.title
+		}
+		// the provider may be named default@cloudflare.com and numbered, e.g. cloudflare.com:2
+		name, _, _ := strings.Cut(strings.TrimPrefix(sec.title, "default@"), ":")
+		if sec.kind == "provider" && name == "cloudflare.com" {
+			e.provider = "cloudflare"
+		}
+		inadynEntry(&e, root.settings, sec.settings)
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// inadynEntry translates the global settings and the settings of a provider section.
+func inadynEntry(e *legacyEntry, global, settings map[string][]string) {
+	get := func(key string) string {
+		if v := settings[key]; len(v) > 0 {
+			return v[0]
+		}
+		if v := global[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	if iface := get("iface"); iface != "" {
+		e.flag("-if", iface)
+	}
+	if server := get("checkip-server"); server != "" {
+		scheme := "https://"
+		if get("checkip-ssl") == "false" {
+			scheme = "http://"
+		}
+		e.flag("-url", scheme+server+get("checkip-path"))
+	}
+	if cmd := get("checkip-command"); cmd != "" {
+		e.notef("checkip-command is not supported; the default lookup services are used instead")
+	}
+	e.resolvers()
+	if s := get("period"); s != "" {
+		if d, err := parseLegacyDuration(s); err == nil {
+			e.flag("-i", formatDuration(d))
+		} else {
+			e.notef("period = %s is not a valid interval", s)
+		}
+	}
+	if s := get("ttl"); s != "" && s != "1" {
+		if d, err := parseLegacyDuration(s); err == nil {
+			e.flag("-ttl", formatDuration(d))
+		} else {
+			e.notef("ttl = %s is not a valid TTL", s)
+		}
+	}
+	if get("proxied") == "true" {
+		e.notef("proxied is not set by ddnscf; updated records keep the proxied status they already have")
+	}
+}
+
+// inadynTokens splits an inadyn config file into words, quoted strings, and the punctuation { } = and ,
+// removing comments and quotes.
+func inadynTokens(s string) ([]string, error) {
+	var tokens []string
+	for _, line := range strings.Split(s, "\n") {
+		for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+			switch c := line[0]; {
+			case c == '#':
+				line = ""
+			case strings.ContainsRune("{}=,", rune(c)):
+				tokens, line = append(tokens, line[:1]), line[1:]
+			case c == '"' || c == '\'':
+				end := strings.IndexByte(line[1:], c)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated string %s", line)
+				}
+				tokens, line = append(tokens, line[1:end+1]), line[end+2:]
+			default:
+				end := strings.IndexAny(line, " \t{}=,#")
+				if end < 0 {
+					end = len(line)
+				}
+				tokens, line = append(tokens, line[:end]), line[end:]
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// parseInadynBlock parses settings and sections until the } closing the block or the end of tokens,
+// returning the tokens after the block.
+func parseInadynBlock(tokens []string) (inadynSection, []string, error) {
+	block := inadynSection{settings: map[string][]string{}}
+	for len(tokens) > 0 {
+		key := tokens[0]
+		if key == "}" {
+			return block, tokens, nil
+		}
+		tokens = tokens[1:]
+		if len(tokens) > 0 && tokens[0] == "=" {
+			tokens = tokens[1:]
+			if len(tokens) == 0 {
+				return block, nil, fmt.Errorf("missing value for %s", key)
+			}
+			if tokens[0] != "{" {
+				block.settings[key] = []string{tokens[0]}
+				tokens = tokens[1:]
+				continue
+			}
+			var list []string
+			for tokens = tokens[1:]; len(tokens) > 0 && tokens[0] != "}"; tokens = tokens[1:] {
+				if tokens[0] != "," {
+					list = append(list, tokens[0])
+				}
+			}
+			if len(tokens) == 0 {
+				return block, nil, fmt.Errorf("unterminated list for %s", key)
+			}
+			block.settings[key], tokens = list, tokens[1:]
+			continue
+		}
+		// a section: kind [title] { ... }
+		sec := inadynSection{kind: key}
+		if len(tokens) > 0 && tokens[0] != "{" {
+			sec.title, tokens = tokens[0], tokens[1:]
+		}
+		if len(tokens) == 0 || tokens[0] != "{" {
+			return block, nil, fmt.Errorf("expected { after %s %s", key, sec.title)
+		}
+		inner, rest, err := parseInadynBlock(tokens[1:])
+		if err != nil {
+			return block, nil, err
+		}
+		if len(rest) == 0 {
+			return block, nil, fmt.Errorf("unterminated section %s %s", key, sec.title)
+		}
+		sec.settings, sec.sections = inner.settings, inner.sections
+		block.sections, tokens = append(block.sections, sec), rest[1:]
+	}
+	return block, nil, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDdclient(t *testing.T) {
+	conf := `# ddclient.conf
+daemon=300
+usev4=webv4, webv4=ipv4.icanhazip.com
+usev6=ifv6, ifv6=eth0
+
+protocol=cloudflare, zone=example.com, ttl=1, \
+login=token, password='secret # not a comment' \
+home.example.com,www.example.com
+
+protocol=dyndns2, server=members.dyndns.org, login=me, password=secret home.dyndns.org
+`
+	entries := parseDdclient(conf)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries; got %d", len(entries))
+	}
+	cf := entries[0]
+	if cf.provider != "cloudflare" || !reflect.DeepEqual(cf.hosts, []string{"home.example.com", "www.example.com"}) {
+		t.Errorf("Expected the Cloudflare hosts; got %+v", cf)
+	}
+	want := []string{"-url", "https://ipv4.icanhazip.com", "-if", "eth0", "-resolver-mode", "join", "-i", "5m"}
+	if !reflect.DeepEqual(cf.args, want) {
+		t.Errorf("Expected %q; got %q", want, cf.args)
+	}
+	if entries[1].provider != "dyndns2" {
+		t.Errorf("Expected %q; got %q", "dyndns2", entries[1].provider)
+	}
+}
+
+func TestParseInadyn(t *testing.T) {
+	conf := `period = 600
+iface = eth0
+
+provider default@cloudflare.com:1 {
+    username = example.com
+    password = "secret"   # API token
+    hostname = { "home.example.com", "www.example.com" }
+    ttl = 3600
+    proxied = true
+}
+
+custom dyn {
+    ddns-server = example.net
+    hostname = other.example.net
+}
+`
+	entries, err := parseInadyn(conf)
+	if err != nil {
+		t.Fatalf("parseInadyn returned an error: %s", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries; got %d", len(entries))
+	}
+	cf := entries[0]
+	want := []string{"-if", "eth0", "-i", "10m", "-ttl", "1h"}
+	if cf.provider != "cloudflare" || !reflect.DeepEqual(cf.args, want) {
+		t.Errorf("Expected %q; got %q", want, cf.args)
+	}
+	if len(cf.notes) != 1 {
+		t.Errorf("Expected a note about proxied records; got %q", cf.notes)
+	}
+
+	var b strings.Builder
+	config.MigrateFrom = "inadyn"
+	defer func() { config.MigrateFrom = "ddclient" }()
+	writeMigration(&b, "/etc/inadyn.conf", entries)
+	out := b.String()
+	if !strings.Contains(out, "\n---\n# proxied is not set by ddnscf; updated records keep the proxied status they already have\nd: home.example.com\naliases: www.example.com\nif: eth0\ni: 10m\nttl: 1h\n") {
+		t.Errorf("Expected a config for the Cloudflare hosts; got:\n%s", out)
+	}
+	if !strings.Contains(out, "# skipped other.example.net") || strings.Contains(out, "secret") {
+		t.Errorf("Expected the custom provider to be skipped without copying secrets; got:\n%s", out)
+	}
+
+	// the config is loaded with -config
+	saved := config
+	defer func() { config = saved }()
+	path := filepath.Join(t.TempDir(), "home.yaml")
+	if err := os.WriteFile(path, []byte(out[strings.Index(out, "\n---\n")+len("\n---\n"):]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfigFile(path); err != nil {
+		t.Fatalf("loadConfigFile returned an error: %s", err)
+	}
+	if config.Domain != "home.example.com" || config.Aliases != "www.example.com" || config.Interface != "eth0" || config.Interval != 10*time.Minute || config.TTL != time.Hour {
+		t.Errorf("Expected the migrated settings; got %+v", config)
+	}
+	if err := os.WriteFile(path, []byte("unknown: 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := loadConfigFile(path); err == nil {
+		t.Errorf("Expected an error for an unknown flag")
+	}
+
+	if _, err := parseInadyn("provider cloudflare.com {\nhostname = a.example.com\n"); err == nil {
+		t.Errorf("Expected an error for an unterminated section")
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// smtpPasswordEnv and mqttPasswordEnv are the environment variables holding the passwords for -smtp-user and -mqtt.
+const (
+	smtpPasswordEnv = "DDNSCF_SMTP_PASSWORD"
+	mqttPasswordEnv = "DDNSCF_MQTT_PASSWORD"
+)
+
+// newNotifier returns the notifier for the -webhook, -smtp, and -mqtt flags, or nil if none were given.
+// Changes are only sent to the -webhook and -smtp recipients with -notify-changes.
+func newNotifier() (ddns.Notifier, error) {
+	var notifiers []ddns.Notifier
+	if config.Webhook != "" {
+		notifiers = append(notifiers, ddns.Webhook(config.Webhook))
+	}
+	if config.SMTP != "" {
+		var to []string
+		for _, addr := range strings.Split(config.SMTPTo, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		from := config.SMTPFrom
+		if from == "" && len(to) > 0 {
+			from = to[0]
+		}
+		options := optionIf(config.SMTPUser != "", ddns.SMTPAuth(config.SMTPUser, os.Getenv(smtpPasswordEnv)))
+		notifiers = append(notifiers, ddns.SMTP(config.SMTP, from, to, options...))
+	}
+	if len(notifiers) > 0 && !config.NotifyChanges {
+		notifiers = []ddns.Notifier{ddns.AlertsOnly(ddns.MultiNotifier(notifiers...))}
+	}
+	if config.MQTT != "" {
+		u, err := url.Parse(config.MQTT)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing -mqtt URL: %w", err)
+		}
+		topic := strings.TrimPrefix(u.Path, "/")
+		if topic == "" {
+			topic = "ddns/" + config.Domain
+		}
+		u.Path = ""
+		password := os.Getenv(mqttPasswordEnv)
+		options := optionIf(password != "" && u.User != nil, ddns.MQTTAuth(u.User.Username(), password))
+		if config.MQTTHomeAssistant {
+			options = append(options, ddns.MQTTHomeAssistant(""))
+		}
+		notifiers = append(notifiers, ddns.NotifyMQTT(u.String(), topic, options...))
+	}
+	if len(notifiers) == 0 {
+		return nil, nil
+	}
+	return ddns.MultiNotifier(notifiers...), nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// configDirName is the directory created under the user config directory for ddnscf's files.
+const configDirName = "ddnscf"
+
+// keyFileName is the name of the default key file in the config directory.
+const keyFileName = "cloudflare-token"
+
+// configDir returns the directory for ddnscf's files:
+// $XDG_CONFIG_HOME/ddnscf or ~/.config/ddnscf on Linux, %AppData%\ddnscf on Windows,
+// and ~/Library/Application Support/ddnscf on macOS.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName), nil
+}
+
+// legacyKeyFile returns the key file location used by earlier versions, ~/.cloudflare.
+func legacyKeyFile() string {
+	return filepath.Join(env("HOME", env("USERPROFILE", ".")), ".cloudflare")
+}
+
+// defaultKeyFile returns the key file used when -k is not given.
+// The key file in the config directory is preferred,
+// but a key file at the legacy location is still used if it is the only one,
+// so that existing installations keep working after an upgrade.
+func defaultKeyFile() string {
+	legacy := legacyKeyFile()
+	dir, err := configDir()
+	if err != nil {
+		// no home directory, e.g. for some service accounts
+		return legacy
+	}
+	path := filepath.Join(dir, keyFileName)
+	if exists(path) || !exists(legacy) {
+		return path
+	}
+	logger.Printf("using the key file at the legacy location \"%s\"; move it to \"%s\" or pass -k to silence this message", legacy, path)
+	return legacy
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// createPrivate creates a new file at path which is only accessible by its owner,
+// creating the parent directory if needed.
+// It fails if the file already exists.
+func createPrivate(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("unable to create directory for \"%s\": %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create \"%s\": %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultKeyFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	dir, err := configDir()
+	if err != nil {
+		t.Fatalf("configDir returned an error: %s", err)
+	}
+	path, legacy := filepath.Join(dir, keyFileName), filepath.Join(home, ".cloudflare")
+
+	if expected, got := path, defaultKeyFile(); expected != got {
+		t.Fatalf("Expected %q for a new installation; got %q", expected, got)
+	}
+	f, err := createPrivate(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if expected, got := legacy, defaultKeyFile(); expected != got {
+		t.Fatalf("Expected %q when only the legacy key file exists; got %q", expected, got)
+	}
+	f, err = createPrivate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if expected, got := path, defaultKeyFile(); expected != got {
+		t.Fatalf("Expected %q when both key files exist; got %q", expected, got)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, got := os.FileMode(0700), info.Mode().Perm(); expected != got {
+		t.Fatalf("Expected config directory permissions %q; got %q", expected, got)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// plan prints the effective configuration and the changes an update would make, without applying them.
+func plan() error {
+	ctx := interruptContext()
+
+	if err := validate(ctx); err != nil {
+		return fmt.Errorf("plan: %w", err)
+	}
+	key, err := credentials.Load()
+	if err != nil {
+		return fmt.Errorf("error reading key: %w", err)
+	}
+	client, err := ddns.New(config.Domain, newProvider(key),
+		ddns.WithLogger(logger),
+		ddns.UsingResolver(resolver),
+		ddns.WithTTL(config.TTL),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating ddns.Client: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Configuration:")
+	fmt.Fprintf(w, "  domain:\t%s\n", config.Domain)
+	fmt.Fprintf(w, "  credentials:\t%s\n", credentials)
+	fmt.Fprintf(w, "  resolver:\t%s\n", resolverDescription())
+	fmt.Fprintf(w, "  interval:\t%s\n", config.Interval)
+	w.Flush()
+	fmt.Println()
+	return printPlan(ctx, os.Stdout, client)
+}
+
+// printPlan prints the changes the next update of client would make,
+// as computed by the client itself so that they match what an update does.
+func printPlan(ctx context.Context, out io.Writer, client ddns.DDNSClient) error {
+	planner, ok := client.(ddns.Planner)
+	if !ok {
+		return errors.New("client cannot plan updates")
+	}
+	p, err := planner.Plan(ctx)
+	if err != nil {
+		return fmt.Errorf("error planning update: %w", err)
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "Planned changes for %s:\n", p.Domain)
+	printAddrs(w, "+", "create", p.Create)
+	printAddrs(w, "-", "delete", p.Delete)
+	printAddrs(w, "=", "keep", p.Keep)
+	if p.Empty() {
+		fmt.Fprintln(w, "  no changes; records are up to date")
+	}
+	return w.Flush()
+}
+
+func printAddrs(w *tabwriter.Writer, symbol, action string, addrs []netip.Addr) {
+	for _, a := range addrs {
+		fmt.Fprintf(w, "  %s %s\t(%s)\n", symbol, a, action)
+	}
+}
+
+// resolverDescription describes the resolver selected by the command line flags.
+func resolverDescription() string {
+	var desc []string
+	if config.IP != "" {
+		desc = append(desc, fmt.Sprintf("static address %s", config.IP))
+	}
+	if config.Interface != "" {
+		desc = append(desc, fmt.Sprintf("addresses of interface %s", config.Interface))
+	}
+	if config.PPP {
+		desc = append(desc, "local address of the PPP link")
+	}
+	if config.DHCPLease != "" {
+		desc = append(desc, fmt.Sprintf("DHCP lease file %s", config.DHCPLease))
+	}
+	if config.DHCPHook {
+		desc = append(desc, "address given to the DHCP hook")
+	}
+	if config.ServiceURL != "" {
+		desc = append(desc, fmt.Sprintf("web service %s", config.ServiceURL))
+	}
+	if len(desc) == 0 {
+		return "addresses of all interfaces"
+	}
+	return strings.Join(desc, " and ")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// setOnlyProvider can't list records.
+type setOnlyProvider struct{}
+
+func (setOnlyProvider) SetDNSRecords(context.Context, string, []netip.Addr) error { return nil }
+
+func TestPrintPlan(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	p.SetDNSRecords(ctx, "home.example.com", []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")})
+	// the resolved addresses are canonicalized as for an update, so the mapped address is kept rather than replaced
+	r := ddns.StaticIP(netip.MustParseAddr("::ffff:192.0.2.1"), netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::2"))
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	var out bytes.Buffer
+	if err := printPlan(ctx, &out, c); err != nil {
+		t.Fatalf("printPlan returned an error: %s", err)
+	}
+	for _, want := range []string{"+ 2001:db8::2", "- 2001:db8::1", "= 192.0.2.1"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in the plan; got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "::ffff:") || strings.Count(out.String(), "192.0.2.1") != 1 {
+		t.Errorf("Expected canonical addresses in the plan; got:\n%s", out.String())
+	}
+
+	c, err = ddns.New("home.example.com", func() (ddns.Provider, error) { return setOnlyProvider{}, nil }, ddns.UsingResolver(r))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := printPlan(ctx, &out, c); err == nil {
+		t.Errorf("Expected an error for a provider which can't list records")
+	}
+}
+
+func TestResolverDescription(t *testing.T) {
+	saved := config
+	defer func() { config = saved }()
+
+	config.IP, config.DHCPLease, config.DHCPHook = "", "/var/lib/dhcp/dhclient.leases", false
+	if got, want := resolverDescription(), "DHCP lease file /var/lib/dhcp/dhclient.leases"; got != want {
+		t.Errorf("Expected %q; got %q", want, got)
+	}
+	config.IP, config.DHCPLease, config.DHCPHook = "192.0.2.1", "", true
+	if got, want := resolverDescription(), "static address 192.0.2.1 and address given to the DHCP hook"; got != want {
+		t.Errorf("Expected %q; got %q", want, got)
+	}
+	config.IP, config.PPP, config.DHCPHook = "", true, false
+	if got, want := resolverDescription(), "local address of the PPP link"; got != want {
+		t.Errorf("Expected %q; got %q", want, got)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// Exit codes returned by the status command.
+const (
+	statusOK          = 0
+	statusError       = 1
+	statusOutdated    = 2 // provider records do not match the locally resolved addresses
+	statusPropagating = 3 // public DNS does not match the provider records yet
+)
+
+// status reports whether the provider records and public DNS match the locally resolved addresses.
+func status() (int, error) {
+	ctx := interruptContext()
+
+	if err := validate(ctx); err != nil {
+		return statusError, fmt.Errorf("status: %w", err)
+	}
+	key, err := credentials.Load()
+	if err != nil {
+		return statusError, fmt.Errorf("error reading key: %w", err)
+	}
+	p, err := newProvider(key)()
+	if err != nil {
+		return statusError, fmt.Errorf("error creating provider: %w", err)
+	}
+	if l, ok := p.(interface{ SetLogger(*log.Logger) }); ok {
+		l.SetLogger(logger)
+	}
+	r := resolver
+	if r == nil {
+		r = ddns.InterfaceResolver()
+	}
+	return checkStatus(ctx, os.Stdout, p, r, lookupPublic)
+}
+
+// checkStatus compares the addresses resolved by r with the records of p and the public DNS records found by lookup,
+// printing them to out and returning the exit code.
+func checkStatus(ctx context.Context, out io.Writer, p ddns.Provider, r ddns.Resolver, lookup func(context.Context, string) ([]netip.Addr, error)) (int, error) {
+	rg, ok := p.(ddns.RecordGetter)
+	if !ok {
+		return statusError, errors.New("provider cannot list records")
+	}
+	local, err := r.Resolve(ctx)
+	if err != nil {
+		return statusError, fmt.Errorf("error resolving addresses: %w", err)
+	}
+	published, err := rg.GetDNSRecords(ctx, config.Domain)
+	if err != nil {
+		return statusError, fmt.Errorf("error getting provider records: %w", err)
+	}
+	public, err := lookup(ctx, config.Domain)
+	if err != nil {
+		return statusError, fmt.Errorf("error looking up %s with %s: %w", config.Domain, config.DNSServer, err)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "domain:\t%s\n", config.Domain)
+	fmt.Fprintf(w, "local:\t%v\n", local)
+	fmt.Fprintf(w, "provider:\t%v\n", published)
+	fmt.Fprintf(w, "public DNS (%s):\t%v\n", config.DNSServer, public)
+	w.Flush()
+
+	if !ddns.Diff(published, local).Empty() {
+		fmt.Fprintln(out, "status: provider records do not match the local addresses")
+		return statusOutdated, nil
+	}
+	if !ddns.Diff(public, published).Empty() {
+		fmt.Fprintln(out, "status: public DNS does not match the provider records yet")
+		return statusPropagating, nil
+	}
+	fmt.Fprintln(out, "status: ok")
+	return statusOK, nil
+}
+
+// lookupPublic resolves domain using the configured public DNS server rather than the system resolver,
+// which may be a local cache.
+// The server may be a DNS-over-HTTPS URL or a tls:// DNS-over-TLS address
+// for networks which block plain DNS.
+func lookupPublic(ctx context.Context, domain string) ([]netip.Addr, error) {
+	server := config.DNSServer
+	switch {
+	case strings.HasPrefix(server, "https://"):
+		return ddns.LookupIP(ctx, domain, ddns.DNSOverHTTPS(server))
+	case strings.HasPrefix(server, "tls://"):
+		return ddns.LookupIP(ctx, domain, ddns.DNSOverTLS(strings.TrimPrefix(server, "tls://")))
+	default:
+		return ddns.LookupIP(ctx, domain, ddns.DNSServer(server))
+	}
+}
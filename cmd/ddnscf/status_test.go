@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestCheckStatus(t *testing.T) {
+	ctx := context.Background()
+	defer func(domain string) { config.Domain = domain }(config.Domain)
+	config.Domain = "home.example.com"
+	ip1, ip2 := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")
+
+	tests := []struct {
+		name             string
+		local, published []netip.Addr
+		public           []netip.Addr
+		want             int
+	}{
+		{"ok", []netip.Addr{ip1}, []netip.Addr{ip1}, []netip.Addr{ip1}, statusOK},
+		{"outdated", []netip.Addr{ip2}, []netip.Addr{ip1}, []netip.Addr{ip1}, statusOutdated},
+		{"propagating", []netip.Addr{ip2}, []netip.Addr{ip2}, []netip.Addr{ip1}, statusPropagating},
+	}
+	for _, tt := range tests {
+		p := &ddnstest.Provider{}
+		p.SetDNSRecords(ctx, config.Domain, tt.published)
+		lookup := func(context.Context, string) ([]netip.Addr, error) { return tt.public, nil }
+		code, err := checkStatus(ctx, io.Discard, p, ddns.StaticIP(tt.local...), lookup)
+		if err != nil {
+			t.Errorf("%s: checkStatus returned an error: %s", tt.name, err)
+		}
+		if code != tt.want {
+			t.Errorf("%s: Expected exit code %d; got %d", tt.name, tt.want, code)
+		}
+	}
+
+	lookup := func(context.Context, string) ([]netip.Addr, error) { return nil, nil }
+	code, err := checkStatus(ctx, io.Discard, setOnlyProvider{}, ddns.StaticIP(ip1), lookup)
+	if err == nil || code != statusError {
+		t.Errorf("Expected an error for a provider which can't list records; got %d and %v", code, err)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// listenTrigger opens the FIFO or unix socket given by -trigger, and calls notify with each line written to it until ctx is done.
+// Firewall hook scripts such as pfSense's and OPNsense's newwanip hooks can then request an update with e.g.
+//
+//	echo "$interface" > /var/run/ddnscf.fifo
+//
+// The content of the line is only logged; the addresses are resolved as usual.
+func listenTrigger(ctx context.Context, spec string, notify func(line string)) error {
+	scheme, path, _ := strings.Cut(spec, ":")
+	if path == "" {
+		return fmt.Errorf("invalid trigger \"%s\"; expected fifo:<path> or unix:<path>", spec)
+	}
+	switch scheme {
+	case "fifo":
+		f, err := openFIFO(path)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			f.Close()
+		}()
+		go readTriggers(f, notify)
+	case "unix":
+		ln, err := listenUnix(path)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			ln.Close()
+		}()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+					readTriggers(conn, notify)
+				}()
+			}
+		}()
+	default:
+		return fmt.Errorf("unknown trigger type \"%s\"; expected fifo:<path> or unix:<path>", scheme)
+	}
+	logger.Printf("listening for update triggers on %s", spec)
+	return nil
+}
+
+func readTriggers(r io.Reader, notify func(line string)) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		notify(strings.TrimSpace(s.Text()))
+	}
+}
+
+// listenUnix listens on a unix socket at path, replacing a socket left behind by an earlier run.
+// The socket is only writable by the owner and group.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("trigger path %s exists and is not a socket", path)
+		}
+		os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen for triggers: %w", err)
+	}
+	if err := os.Chmod(path, 0660); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("unable to set trigger socket permissions: %w", err)
+	}
+	return ln, nil
+}
+
+// openFIFO opens the FIFO at path for reading, creating it if it doesn't exist.
+func openFIFO(path string) (*os.File, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := mkfifo(path, 0620); err != nil {
+			return nil, fmt.Errorf("unable to create trigger FIFO: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	} else if info.Mode()&fs.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("trigger path %s exists and is not a FIFO", path)
+	}
+	// opened for writing as well, so that reads don't end each time a hook script closes the FIFO
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open trigger FIFO: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "errors"
+
+func mkfifo(path string, mode uint32) error {
+	return errors.New("FIFOs are not supported on this platform; use a unix:<path> trigger")
+}
@@ -0,0 +1,56 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenTrigger(t *testing.T) {
+	dir := t.TempDir()
+	for _, spec := range []string{"fifo:" + filepath.Join(dir, "ddnscf.fifo"), "unix:" + filepath.Join(dir, "ddnscf.sock")} {
+		t.Run(spec[:4], func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			lines := make(chan string, 2)
+			if err := listenTrigger(ctx, spec, func(line string) { lines <- line }); err != nil {
+				t.Fatalf("listenTrigger returned an error: %s", err)
+			}
+			path := spec[5:]
+			for _, expected := range []string{"wan", "opt1"} {
+				// each hook script writes a line and closes
+				var w interface {
+					Write([]byte) (int, error)
+					Close() error
+				}
+				var err error
+				if spec[:4] == "fifo" {
+					w, err = os.OpenFile(path, os.O_WRONLY, 0)
+				} else {
+					w, err = net.Dial("unix", path)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				w.Write([]byte(expected + "\n"))
+				w.Close()
+				select {
+				case got := <-lines:
+					if got != expected {
+						t.Errorf("Expected %q; got %q", expected, got)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Expected a trigger for %q", expected)
+				}
+			}
+		})
+	}
+	if err := listenTrigger(context.Background(), "tcp:localhost:8053", func(string) {}); err == nil {
+		t.Errorf("Expected an error for an unknown trigger type")
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"golang.org/x/term"
+)
+
+// tuiLogLines is the number of log lines kept for the log tail.
+const tuiLogLines = 200
+
+// tui runs the daemon while showing a live status screen instead of log output.
+// If the daemon stops, such as after an authentication error, the screen is closed and its last error is returned.
+func tui() error {
+	ctx := interruptContext()
+
+	if err := validate(ctx); err != nil {
+		return fmt.Errorf("tui: %w", err)
+	}
+	key, err := credentials.Load()
+	if err != nil {
+		return fmt.Errorf("error reading key: %w", err)
+	}
+	p, err := newProvider(key)()
+	if err != nil {
+		return fmt.Errorf("error creating provider: %w", err)
+	}
+	if !ddns.ProviderCapabilities(p).ListRecords {
+		return errors.New("the provider cannot list records")
+	}
+	state := &tuiState{}
+	tuiLogger := log.New(state, "", log.Ltime)
+	events := make(chan ddns.Event, 16)
+	client, err := ddns.New(config.Domain,
+		func() (ddns.Provider, error) { return p, nil },
+		ddns.WithLogger(tuiLogger),
+		ddns.WithLogLevel(logLevel()),
+		ddns.UsingResolver(resolver),
+		ddns.Aliases(aliases()...),
+		ddns.WithEvents(events),
+		ddns.MaxDeletes(config.MaxDeletes),
+		ddns.ConfirmMassDelete(allowDelete),
+		ddns.WithTTL(config.TTL),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating ddns.Client: %w", err)
+	}
+	// the published records are read through the client after each run
+	reporter, ok := client.(ddns.StateReporter)
+	if !ok {
+		return errors.New("the client cannot report its records")
+	}
+	go state.watch(events)
+	tc := &tuiClient{DDNSClient: client, records: reporter.CurrentRecords, lookup: lookupPublic, state: state}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	// use the alternate screen buffer so that the terminal is restored on exit
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+	return state.run(ctx, os.Stdout, ticker.C, func(ctx context.Context) {
+		ddns.RunDaemon(tc, ctx, config.Interval, tuiLogger)
+	})
+}
+
+// run starts daemon and redraws the screen on each tick until ctx is canceled or the daemon stops.
+// The daemon is waited for before returning, and if it stopped on its own then its last error is returned.
+func (s *tuiState) run(ctx context.Context, out io.Writer, ticks <-chan time.Time, daemon func(context.Context)) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		daemon(ctx)
+	}()
+	for {
+		s.draw(out)
+		select {
+		case <-ctx.Done():
+			<-done
+			return nil
+		case <-done:
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.mu.Lock()
+			s.stopped = true
+			err := s.lastErr
+			s.mu.Unlock()
+			s.draw(out)
+			if err == nil {
+				return errors.New("daemon stopped")
+			}
+			return fmt.Errorf("daemon stopped: %w", err)
+		case <-ticks:
+		}
+	}
+}
+
+// tuiState is the information shown by the tui.
+// It is also the io.Writer for the client's log.
+type tuiState struct {
+	mu          sync.Mutex
+	local       []netip.Addr
+	records     []netip.Addr
+	public      []netip.Addr
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastErr     error
+	stopped     bool // the daemon has stopped
+	logs        []string
+}
+
+func (s *tuiState) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		s.logs = append(s.logs, line)
+	}
+	if len(s.logs) > tuiLogLines {
+		s.logs = s.logs[len(s.logs)-tuiLogLines:]
+	}
+	return len(p), nil
+}
+
+// watch records the resolved addresses from the client's events.
+func (s *tuiState) watch(events <-chan ddns.Event) {
+	for e := range events {
+		if e, ok := e.(ddns.Resolved); ok {
+			s.mu.Lock()
+			s.local = e.Addrs
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *tuiState) draw(out io.Writer) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width, height = 80, 24
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ddnscf\t%s\t(%s)\n", config.Domain, time.Now().Format(time.TimeOnly))
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "IPv4:\t%s\n", formatFamily(s.local, true))
+	fmt.Fprintf(w, "IPv6:\t%s\n", formatFamily(s.local, false))
+	state := "ok"
+	if s.stopped {
+		state = "daemon stopped"
+	} else if s.lastRun.IsZero() {
+		state = "waiting for first update"
+	} else if !ddns.Diff(s.records, s.local).Empty() {
+		state = "records do not match the local addresses"
+	} else if !ddns.Diff(s.public, s.records).Empty() {
+		state = "public DNS not yet updated"
+	}
+	fmt.Fprintf(w, "records:\t%v\t%s\n", s.records, state)
+	fmt.Fprintf(w, "public DNS:\t%v\t%s\n", s.public, config.DNSServer)
+	fmt.Fprintf(w, "last update:\t%s\n", formatTime(s.lastRun))
+	fmt.Fprintf(w, "last success:\t%s\n", formatTime(s.lastSuccess))
+	if s.lastErr != nil {
+		fmt.Fprintf(w, "last error:\t%s\n", s.lastErr)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "log:")
+	w.Flush()
+
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	if tail := height - len(lines) - 1; tail > 0 {
+		if tail < len(s.logs) {
+			lines = append(lines, s.logs[len(s.logs)-tail:]...)
+		} else {
+			lines = append(lines, s.logs...)
+		}
+	}
+	// move to the top left, then clear each line as it is written so the screen doesn't flicker
+	fmt.Fprint(out, "\x1b[H")
+	for _, line := range lines {
+		if len(line) > width {
+			line = line[:width]
+		}
+		fmt.Fprintf(out, "%s\x1b[K\r\n", line)
+	}
+	fmt.Fprint(out, "\x1b[J")
+}
+
+func formatFamily(addrs []netip.Addr, v4 bool) string {
+	var family []string
+	for _, a := range addrs {
+		if a.Is4() == v4 {
+			family = append(family, a.String())
+		}
+	}
+	if len(family) == 0 {
+		return "-"
+	}
+	return strings.Join(family, " ")
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s ago)", t.Format(time.TimeOnly), time.Since(t).Round(time.Second))
+}
+
+// tuiClient refreshes the provider and public DNS records after each run.
+type tuiClient struct {
+	ddns.DDNSClient
+	records func(context.Context) ([]netip.Addr, error)         // the provider records
+	lookup  func(context.Context, string) ([]netip.Addr, error) // the public DNS records
+	state   *tuiState
+}
+
+func (c *tuiClient) Unwrap() ddns.DDNSClient { return c.DDNSClient }
+
+func (c *tuiClient) RunDDNS(ctx context.Context) error {
+	err := c.DDNSClient.RunDDNS(ctx)
+	records, _ := c.records(ctx)
+	public, _ := c.lookup(ctx, config.Domain)
+
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+	c.state.lastRun = time.Now()
+	c.state.lastErr = err
+	if err == nil {
+		c.state.lastSuccess = c.state.lastRun
+	}
+	c.state.records = ddns.Diff(nil, records).Create
+	c.state.public = ddns.Diff(nil, public).Create
+	return err
+}
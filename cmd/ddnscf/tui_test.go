@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// screenText returns the text drawn to out without the column alignment.
+func screenText(out *bytes.Buffer) string {
+	return strings.Join(strings.Fields(out.String()), " ")
+}
+
+func TestTUIClient(t *testing.T) {
+	ctx := context.Background()
+	saved := config
+	defer func() { config = saved }()
+	config.Domain = "home.example.com"
+
+	ip := netip.MustParseAddr("192.0.2.1")
+	events := make(chan ddns.Event, 16)
+	client, err := ddns.New(config.Domain, ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.WithEvents(events),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	state := &tuiState{}
+	go state.watch(events)
+	var public []netip.Addr
+	tc := &tuiClient{
+		DDNSClient: client,
+		records:    client.(ddns.StateReporter).CurrentRecords,
+		lookup:     func(context.Context, string) ([]netip.Addr, error) { return public, nil },
+		state:      state,
+	}
+
+	var out bytes.Buffer
+	state.draw(&out)
+	if !strings.Contains(out.String(), "waiting for first update") {
+		t.Errorf("Expected the first update to be pending; got:\n%s", out.String())
+	}
+
+	if err := tc.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	// wait for the Resolved event to reach the state
+	for i := 0; i < 100; i++ {
+		state.mu.Lock()
+		n := len(state.local)
+		state.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	out.Reset()
+	state.draw(&out)
+	for _, want := range []string{"IPv4: 192.0.2.1", "records: [192.0.2.1] public DNS not yet updated"} {
+		if !strings.Contains(screenText(&out), want) {
+			t.Errorf("Expected %q on the screen; got:\n%s", want, out.String())
+		}
+	}
+
+	public = []netip.Addr{ip}
+	tc.RunDDNS(ctx)
+	state.Write([]byte("first line\nsecond line\n"))
+	out.Reset()
+	state.draw(&out)
+	for _, want := range []string{"records: [192.0.2.1] ok", "second line"} {
+		if !strings.Contains(screenText(&out), want) {
+			t.Errorf("Expected %q on the screen; got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestTUIDaemonStopped(t *testing.T) {
+	state := &tuiState{}
+	var out bytes.Buffer
+	err := state.run(context.Background(), &out, nil, func(context.Context) {
+		state.mu.Lock()
+		state.lastRun, state.lastErr = time.Now(), authError{}
+		state.mu.Unlock()
+	})
+	if !errors.As(err, &authError{}) {
+		t.Errorf("Expected the daemon's last error; got %v", err)
+	}
+	if !strings.Contains(out.String(), "daemon stopped") || !strings.Contains(out.String(), "invalid token") {
+		t.Errorf("Expected the stopped daemon and its error on the screen; got:\n%s", out.String())
+	}
+
+	// the daemon is waited for when the context is canceled, without reporting an error
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := false
+	err = state.run(ctx, &out, nil, func(ctx context.Context) {
+		cancel()
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	})
+	if err != nil || !stopped {
+		t.Errorf("Expected the daemon to be waited for without an error; got %v and stopped %t", err, stopped)
+	}
+}
@@ -0,0 +1,23 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+)
+
+// CredentialRotator is the interface for providers which can replace their credentials without being recreated.
+//
+// It is implemented by the providers in this package, and by the client returned by ddns.New.
+type CredentialRotator interface {
+	RotateCredentials(ctx context.Context, token string) error
+}
+
+// RotateCredentials replaces the credentials used by the client's Provider,
+// so that long-running daemons survive token rotation without a restart.
+func (c *client) RotateCredentials(ctx context.Context, token string) error {
+	r, ok := c.Provider.(CredentialRotator)
+	if !ok {
+		return errors.New("provider does not support credential rotation")
+	}
+	return r.RotateCredentials(ctx, token)
+}
@@ -0,0 +1,111 @@
+package ddns_test
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestCloudflareRotateCredentials(t *testing.T) {
+	ctx := context.Background()
+	cf := newFakeCloudflare("example.com")
+	cf.tokens["new"] = true
+	p, err := ddns.NewCloudflare("old")()
+	if err != nil {
+		t.Fatalf("NewCloudflare returned an error: %s", err)
+	}
+	p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(cf.client(t))
+	r := p.(ddns.CredentialRotator)
+	addrs := []netip.Addr{netip.MustParseAddr("192.0.2.1")}
+
+	if err := r.RotateCredentials(ctx, "revoked"); !ddns.IsAuthError(err) {
+		t.Errorf("Expected an auth error for a rejected token; got %v", err)
+	}
+	if err := p.SetDNSRecords(ctx, "www.example.com", addrs); err != nil {
+		t.Fatalf("SetDNSRecords failed: %s", err)
+	}
+	if got := cf.header.Get("Authorization"); got != "Bearer old" {
+		t.Errorf("Expected the old token to be kept after a failed rotation; got %q", got)
+	}
+
+	if err := r.RotateCredentials(ctx, "new"); err != nil {
+		t.Fatalf("RotateCredentials failed: %s", err)
+	}
+	if err := p.SetDNSRecords(ctx, "www.example.com", addrs); err != nil {
+		t.Fatalf("SetDNSRecords failed: %s", err)
+	}
+	if got := cf.header.Get("Authorization"); got != "Bearer new" {
+		t.Errorf("Expected %q; got %q", "Bearer new", got)
+	}
+}
+
+// rotatingProvider records the credentials it was given.
+type rotatingProvider struct {
+	ddnstest.Provider
+	token string
+}
+
+func (p *rotatingProvider) RotateCredentials(ctx context.Context, token string) error {
+	p.token = token
+	return nil
+}
+
+func TestRotateCredentialsThroughWrappers(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		newClient func(p ddns.Provider) (ddns.DDNSClient, error)
+	}{
+		{"direct", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddnstest.ProviderFunc(p))
+		}},
+		{"MultiProvider", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddns.MultiProvider(ddnstest.ProviderFunc(&ddnstest.Provider{}), ddnstest.ProviderFunc(p)))
+		}},
+		{"Filter", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddns.Filter(ddnstest.ProviderFunc(p), ddns.PublicAddr))
+		}},
+		{"ProviderTimeout", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddns.ProviderTimeout(ddnstest.ProviderFunc(p), time.Second))
+		}},
+		{"Aliases", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddnstest.ProviderFunc(p), ddns.Aliases("www.example.com"))
+		}},
+		{"MapFamilyDomains", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddnstest.ProviderFunc(p), ddns.MapFamilyDomains("v4.example.com", "v6.example.com"))
+		}},
+		{"FlakyProvider", func(p ddns.Provider) (ddns.DDNSClient, error) {
+			return ddns.New("home.example.com", ddnstest.ProviderFunc(ddnstest.FlakyProvider(p, 0, 0)))
+		}},
+	}
+	for _, tt := range tests {
+		p := &rotatingProvider{}
+		c, err := tt.newClient(p)
+		if err != nil {
+			t.Fatalf("%s: New returned an error: %s", tt.name, err)
+		}
+		r, ok := c.(ddns.CredentialRotator)
+		if !ok {
+			t.Fatalf("%s: Expected the client to implement CredentialRotator", tt.name)
+		}
+		if err := r.RotateCredentials(ctx, "new"); err != nil {
+			t.Errorf("%s: RotateCredentials failed: %s", tt.name, err)
+		}
+		if p.token != "new" {
+			t.Errorf("%s: Expected the token to reach the provider; got %q", tt.name, p.token)
+		}
+	}
+
+	c, err := ddns.New("home.example.com", ddns.MultiProvider(ddnstest.ProviderFunc(&ddnstest.Provider{})))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.(ddns.CredentialRotator).RotateCredentials(ctx, "new"); err == nil {
+		t.Errorf("Expected an error when no provider supports rotation")
+	}
+}
@@ -0,0 +1,295 @@
+package ddns
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"net/netip"
+	"time"
+)
+
+// minInterval is the shortest interval RunDaemon will use unless AllowShortInterval is given.
+const minInterval = 1 * time.Minute
+
+type logf interface {
+	Printf(string, ...any)
+}
+
+// Clock is the interface the daemon uses for telling time and waiting between runs.
+//
+// Tests may supply their own implementation with [WithClock] to control the schedule without real sleeps.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the interface for a single event created by a Clock.
+// Its semantics match time.Timer.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.t.C }
+func (t realTimer) Stop() bool          { return t.t.Stop() }
+
+type daemonOption func(*daemon)
+
+// WithClock configures the daemon to use clock instead of the system clock.
+func WithClock(clock Clock) daemonOption {
+	return func(d *daemon) {
+		if clock == nil {
+			clock = realClock{}
+		}
+		d.clock = clock
+	}
+}
+
+// AllowShortInterval allows RunDaemon to run more often than once per minute.
+//
+// This is intended for providers on the local network, such as an internal RFC 2136 server,
+// and for testing.
+// Public DNS providers and IP lookup services may rate limit clients which update too frequently.
+func AllowShortInterval() daemonOption {
+	return func(d *daemon) {
+		d.allowShortInterval = true
+	}
+}
+
+// DelayFirstRun waits one interval before the first update instead of running immediately.
+func DelayFirstRun() daemonOption {
+	return func(d *daemon) {
+		d.delayFirstRun = true
+	}
+}
+
+// AlignInterval schedules runs on wall-clock multiples of the interval,
+// e.g. at :00, :05, :10 past the hour for a five minute interval.
+func AlignInterval() daemonOption {
+	return func(d *daemon) {
+		d.align = true
+	}
+}
+
+// Splay adds a random delay of up to max before each scheduled run.
+//
+// This keeps fleets of hosts from all updating at the same instant,
+// e.g. when they boot together after a power outage.
+func Splay(max time.Duration) daemonOption {
+	return func(d *daemon) {
+		d.splay = max
+	}
+}
+
+// TriggerOn runs an update as soon as a trigger is received from c instead of waiting for the interval,
+// e.g. when a hook script reports that the address has changed.
+// The trigger is used as the Trigger of the run's [RunInfo].
+//
+// A trigger received while an update is running starts another update once it finishes.
+func TriggerOn(c <-chan string) daemonOption {
+	return func(d *daemon) {
+		d.trigger = c
+	}
+}
+
+// AdaptiveInterval adjusts the interval between runs to how often the resolved addresses change,
+// to save requests to IP lookup services and providers from hosts whose address rarely changes.
+//
+// The interval starts at the interval given to RunDaemon, within min and max.
+// It grows by half after each run which resolves the same addresses as the run before, up to max,
+// and drops to min when they change, so that the next changes are caught quickly.
+// A failed run doesn't change the interval.
+// A max of 0 keeps the interval fixed.
+//
+// The client must implement [StateReporter], as the client returned by ddns.New does,
+// or wrap one with an Unwrap() DDNSClient method.
+func AdaptiveInterval(min, max time.Duration) daemonOption {
+	return func(d *daemon) {
+		d.adaptMin, d.adaptMax = min, max
+	}
+}
+
+type daemon struct {
+	client             DDNSClient
+	interval           time.Duration
+	logger             logf
+	allowShortInterval bool
+	delayFirstRun      bool
+	align              bool
+	splay              time.Duration
+	clock              Clock
+	trigger            <-chan string // runs immediately with the trigger received
+	adaptMin, adaptMax time.Duration // bounds of the adaptive interval; zero if the interval is fixed
+}
+
+// RunDaemon runs ddnsClient every interval.
+//
+// Run errors are reported to logger.
+// A nil logger indicates messages should be sent to the log package's default log.
+//
+// Intervals shorter than one minute are raised to one minute unless [AllowShortInterval] is given.
+// The first run happens immediately unless [DelayFirstRun] is given.
+// Additional scheduling options may be specified: [AdaptiveInterval], [AlignInterval], [Splay], [TriggerOn], [WithClock].
+//
+// To stop the daemon,
+// cancel the given context.
+//
+// The daemon will also exit early if it detects authentication or authorization errors,
+// rather than continue running with an expired or invalid token.
+func RunDaemon(ddnsClient DDNSClient, ctx context.Context, interval time.Duration, logger logf, options ...daemonOption) {
+	if logger == nil {
+		logger = log.Default()
+	}
+	d := &daemon{
+		client:   ddnsClient,
+		interval: interval,
+		logger:   logger,
+		clock:    realClock{},
+	}
+	for _, opt := range options {
+		opt(d)
+	}
+	d.run(ctx)
+}
+
+func (d *daemon) run(ctx context.Context) {
+	interval := d.interval
+	if interval <= 0 || (interval < minInterval && !d.allowShortInterval) {
+		interval = minInterval
+		d.logger.Printf("ddns.RunDaemon: interval %s is below the minimum; using %s instead", d.interval, interval)
+	}
+	var reporter StateReporter
+	var resolved []netip.Addr // the addresses of the previous run, for the adaptive interval
+	if d.adaptMax > 0 {
+		if reporter = stateReporter(d.client); reporter == nil {
+			d.logger.Printf("ddns.RunDaemon: client does not implement StateReporter; using a fixed interval")
+		} else {
+			if d.adaptMin <= 0 || (d.adaptMin < minInterval && !d.allowShortInterval) {
+				d.adaptMin = minInterval
+			}
+			if d.adaptMax < d.adaptMin {
+				d.adaptMax = d.adaptMin
+			}
+			interval = clampDuration(interval, d.adaptMin, d.adaptMax)
+			resolved = reporter.LastPublished().Resolved
+		}
+	}
+	trigger := TriggerScheduled
+	if d.delayFirstRun {
+		var ok bool
+		if trigger, ok = d.sleep(ctx, d.wait(d.clock.Now(), interval, 0)); !ok {
+			return
+		}
+	}
+
+	for {
+		started := d.clock.Now()
+		err := d.client.RunDDNS(WithRunInfo(ctx, RunInfo{Trigger: trigger}))
+		if err != nil {
+			d.logger.Printf("ddns.RunDaemon: %s", err)
+		}
+		if isAuthenticationError(err) {
+			d.logger.Printf("ddns.RunDaemon: bad credentials detected; stopping daemon")
+			return
+		}
+		if isAuthorizationError(err) {
+			d.logger.Printf("ddns.RunDaemon: credentials are not authorized to perform that action; stopping daemon")
+			return
+		}
+		if reporter != nil && err == nil {
+			last := reporter.LastPublished().Resolved
+			interval = d.adapt(interval, resolved, last)
+			resolved = last
+		}
+		now := d.clock.Now()
+		var ok bool
+		if trigger, ok = d.sleep(ctx, d.wait(now, interval, now.Sub(started))); !ok {
+			return
+		}
+	}
+}
+
+// adapt returns the interval to use after a run which resolved current, where the run before resolved previous.
+func (d *daemon) adapt(interval time.Duration, previous, current []netip.Addr) time.Duration {
+	if previous != nil && !sameAddrs(previous, current) {
+		return d.adaptMin
+	}
+	return clampDuration(interval+interval/2, d.adaptMin, d.adaptMax)
+}
+
+func clampDuration(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// sameAddrs reports whether a and b hold the same addresses in the same order.
+func sameAddrs(a, b []netip.Addr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stateReporter returns the StateReporter of client, unwrapping clients which wrap another, or nil if there is none.
+func stateReporter(client DDNSClient) StateReporter {
+	for client != nil {
+		if sr, ok := client.(StateReporter); ok {
+			return sr
+		}
+		u, ok := client.(interface{ Unwrap() DDNSClient })
+		if !ok {
+			return nil
+		}
+		client = u.Unwrap()
+	}
+	return nil
+}
+
+// wait returns how long to wait from now until the next run, given that the previous run took elapsed.
+func (d *daemon) wait(now time.Time, interval, elapsed time.Duration) time.Duration {
+	wait := interval - elapsed
+	if d.align {
+		wait = now.Truncate(interval).Add(interval).Sub(now)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if d.splay > 0 {
+		wait += time.Duration(rand.Int63n(int64(d.splay)))
+	}
+	return wait
+}
+
+// sleep waits for wait or until the daemon is triggered.
+// It returns the trigger for the next run, or false if ctx was done first.
+func (d *daemon) sleep(ctx context.Context, wait time.Duration) (trigger string, ok bool) {
+	timer := d.clock.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return "", false
+	case <-timer.C():
+		return TriggerScheduled, true
+	case trigger := <-d.trigger:
+		return trigger, true
+	}
+}
@@ -0,0 +1,257 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+type countingClient struct {
+	mu   sync.Mutex
+	runs int
+	err  error
+}
+
+func (c *countingClient) RunDDNS(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.runs++
+	return c.err
+}
+
+func (c *countingClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
+
+type bufferLogger struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (l *bufferLogger) Printf(format string, v ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	fmt.Fprintf(&l.buf, format+"\n", v...)
+}
+
+func (l *bufferLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.String()
+}
+
+// startDaemon calls run, which should run the daemon with clock, until the test ends.
+// It returns once the daemon is waiting for the first timer.
+func startDaemon(t *testing.T, run func(ctx context.Context, clock ddns.Clock)) *ddnstest.Clock {
+	clock := ddnstest.NewClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run(ctx, clock)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	clock.BlockUntil(1)
+	return clock
+}
+
+func TestShortInterval(t *testing.T) {
+	c := &countingClient{}
+	clock := startDaemon(t, func(ctx context.Context, clock ddns.Clock) {
+		ddns.RunDaemon(c, ctx, 10*time.Millisecond, &bufferLogger{}, ddns.AllowShortInterval(), ddns.WithClock(clock))
+	})
+	for i := 0; i < 2; i++ {
+		clock.Advance(10 * time.Millisecond)
+		clock.BlockUntil(1)
+	}
+	if runs := c.count(); runs != 3 {
+		t.Fatalf("Expected 3 runs with a short interval; got %d", runs)
+	}
+}
+
+func TestIntervalClampLogged(t *testing.T) {
+	c := &countingClient{}
+	logger := &bufferLogger{}
+	clock := startDaemon(t, func(ctx context.Context, clock ddns.Clock) {
+		ddns.RunDaemon(c, ctx, 10*time.Millisecond, logger, ddns.WithClock(clock))
+	})
+	clock.Advance(25 * time.Millisecond)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 1 {
+		t.Fatalf("Expected exactly 1 run with a clamped interval; got %d", runs)
+	}
+	if !strings.Contains(logger.String(), "below the minimum") {
+		t.Fatalf("Expected clamping to be logged; got %q", logger.String())
+	}
+}
+
+func TestDelayFirstRun(t *testing.T) {
+	c := &countingClient{}
+	clock := startDaemon(t, func(ctx context.Context, clock ddns.Clock) {
+		ddns.RunDaemon(c, ctx, time.Minute, &bufferLogger{}, ddns.DelayFirstRun(), ddns.WithClock(clock))
+	})
+	if runs := c.count(); runs != 0 {
+		t.Fatalf("Expected no runs before the first interval elapsed; got %d", runs)
+	}
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 1 {
+		t.Fatalf("Expected a run after the first interval; got %d", runs)
+	}
+}
+
+func TestSplay(t *testing.T) {
+	c := &countingClient{}
+	clock := startDaemon(t, func(ctx context.Context, clock ddns.Clock) {
+		ddns.RunDaemon(c, ctx, 5*time.Minute, &bufferLogger{}, ddns.Splay(time.Minute), ddns.WithClock(clock))
+	})
+	clock.Advance(5*time.Minute - time.Nanosecond)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 1 {
+		t.Fatalf("Expected no run before the interval; got %d runs", runs)
+	}
+	clock.Advance(time.Minute)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 2 {
+		t.Fatalf("Expected a run within the splay; got %d runs", runs)
+	}
+}
+
+func TestAlignedSchedule(t *testing.T) {
+	c := &countingClient{}
+	clock := ddnstest.NewClock(time.Date(2023, 1, 1, 12, 3, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ddns.RunDaemon(c, ctx, 5*time.Minute, &bufferLogger{}, ddns.AlignInterval(), ddns.WithClock(clock))
+	}()
+
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 1 {
+		t.Fatalf("Expected an immediate first run; got %d runs", runs)
+	}
+	clock.Advance(1 * time.Minute)
+	if runs := c.count(); runs != 1 {
+		t.Fatalf("Expected no run before 12:05; got %d runs", runs)
+	}
+	clock.Advance(1 * time.Minute)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 2 {
+		t.Fatalf("Expected a run at 12:05; got %d runs", runs)
+	}
+	clock.Advance(5 * time.Minute)
+	clock.BlockUntil(1)
+	if runs := c.count(); runs != 3 {
+		t.Fatalf("Expected a run at 12:10; got %d runs", runs)
+	}
+	cancel()
+	<-done
+}
+
+// triggerClient records the trigger of each run.
+type triggerClient struct {
+	runs chan string
+}
+
+func (c *triggerClient) RunDDNS(ctx context.Context) error {
+	c.runs <- ddns.RunInfoFrom(ctx).Trigger
+	return nil
+}
+
+func TestTriggerOn(t *testing.T) {
+	c := &triggerClient{runs: make(chan string, 2)}
+	trigger := make(chan string)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ddns.RunDaemon(c, ctx, time.Hour, &bufferLogger{}, ddns.TriggerOn(trigger))
+	}()
+	if got := <-c.runs; got != ddns.TriggerScheduled {
+		t.Errorf("Expected %q; got %q", ddns.TriggerScheduled, got)
+	}
+	trigger <- "newwanip"
+	if got := <-c.runs; got != "newwanip" {
+		t.Errorf("Expected %q; got %q", "newwanip", got)
+	}
+	cancel()
+	<-done
+}
+
+func TestAdaptiveInterval(t *testing.T) {
+	r := &ddnstest.Resolver{}
+	r.Set(netip.MustParseAddr("192.0.2.1"))
+	p := &ddnstest.Provider{}
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	counted := &countingWrapper{DDNSClient: c}
+	clock := ddnstest.NewClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ddns.RunDaemon(counted, ctx, 10*time.Minute, &bufferLogger{}, ddns.WithClock(clock), ddns.AdaptiveInterval(10*time.Minute, time.Hour))
+	}()
+
+	steps := []struct {
+		advance time.Duration
+		runs    int
+		change  bool // change the address before advancing
+	}{
+		{0, 1, false},
+		{10 * time.Minute, 1, false}, // the address didn't change, so the interval grew to 15m
+		{5 * time.Minute, 2, false},
+		{22*time.Minute + 30*time.Second, 3, true}, // then to 22.5m; this run sees a new address
+		{10 * time.Minute, 4, false},               // so the interval drops back to 10m
+	}
+	for i, step := range steps {
+		if step.change {
+			r.Set(netip.MustParseAddr("192.0.2.2"))
+		}
+		clock.Advance(step.advance)
+		clock.BlockUntil(1)
+		if got := counted.count(); got != step.runs {
+			t.Fatalf("step %d: Expected %d runs; got %d", i, step.runs, got)
+		}
+	}
+	cancel()
+	<-done
+}
+
+// countingWrapper counts the runs of the client it wraps.
+type countingWrapper struct {
+	ddns.DDNSClient
+	mu   sync.Mutex
+	runs int
+}
+
+func (c *countingWrapper) Unwrap() ddns.DDNSClient { return c.DDNSClient }
+
+func (c *countingWrapper) RunDDNS(ctx context.Context) error {
+	err := c.DDNSClient.RunDDNS(ctx)
+	c.mu.Lock()
+	c.runs++
+	c.mu.Unlock()
+	return err
+}
+
+func (c *countingWrapper) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.runs
+}
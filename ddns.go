@@ -2,16 +2,22 @@ package ddns
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/netip"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
-
-	"github.com/cloudflare/cloudflare-go"
 )
 
 var defaultResolver = InterfaceResolver()
@@ -57,47 +63,123 @@ type cache interface {
 type providerFn func() (Provider, error)
 
 // New creates a new DDNSClient for domain using the given DNS provider.
-// Additional options may be specified: [UsingResolver], [UsingHTTPClient], [WithLogger].
+// Additional options may be specified: [UsingResolver], [UsingHTTPClient], [ResolverHTTPClient], [ProviderHTTPClient], [WithLogger].
+//
+// providerFn may be nil if the provider is instead set by an option such as [UsingCloudflare].
+//
+// domain may be a template; see [NameData].
 func New(domain string, providerFn providerFn, options ...clientOption) (DDNSClient, error) {
 	if domain == "" {
 		return nil, errors.New("ddns.New: domain cannot be empty")
 	}
-	provider, err := providerFn()
-	if err != nil {
-		return nil, fmt.Errorf("ddns.New: unable to create provider: %w", err)
-	}
-	if provider == nil {
-		return nil, errors.New("ddns.New: provider cannot be nil")
-	}
 	c := &client{
 		Resolver: defaultResolver,
-		Provider: provider,
 		domain:   domain,
 	}
+	if strings.Contains(domain, "{{") {
+		tmpl, err := parseDomain(domain)
+		if err != nil {
+			return nil, fmt.Errorf("ddns.New: %w", err)
+		}
+		c.nameTemplate = tmpl
+	}
+	if providerFn != nil {
+		provider, err := providerFn()
+		if err != nil {
+			return nil, fmt.Errorf("ddns.New: unable to create provider: %w", err)
+		}
+		c.Provider = provider
+	}
 	for i, opt := range options {
 		if err := opt(c); err != nil {
 			return nil, fmt.Errorf("ddns.New: option %d returned an error: %s", i, err)
 		}
 	}
+	if c.Provider == nil {
+		return nil, errors.New("ddns.New: provider cannot be nil")
+	}
 
-	// this lets us propagate the logger to dependencies that use one if WithLogger was called before all of the dependencies were registered
-	setLog(c, c.logger)
+	if err := c.configure(); err != nil {
+		return nil, fmt.Errorf("ddns.New: %w", err)
+	}
 	return c, nil
 }
 
 type clientOption func(*client) error
 
 // NewCloudflare is used by [ddns.New] to create a new Provider for Cloudflare.
-func NewCloudflare(token string) func() (Provider, error) {
+//
+// Additional options may be specified: [CloudflareZoneID], [CloudflareAccountID], [CloudflareHTTPSRecords].
+func NewCloudflare(token string, options ...cloudflareOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		return newCloudflareProvider(token, options...)
+	}
+}
+
+// NewCloudflareKey is used by [ddns.New] to create a new Provider for Cloudflare
+// which authenticates with a legacy global API key and the account email address.
+// API tokens created with only the permissions needed should be preferred; see [NewCloudflare].
+func NewCloudflareKey(key, email string, options ...cloudflareOption) func() (Provider, error) {
 	return func() (Provider, error) {
-		return newCloudflareProvider(token)
+		if email == "" {
+			return nil, errors.New("email cannot be empty")
+		}
+		return newCloudflareProviderAuth(key, email, options...)
 	}
 }
 
+// NewCloudflareFromEnv is used by [ddns.New] to create a new Provider for Cloudflare
+// with the credentials in the environment, following the conventions of other Cloudflare tooling.
+//
+// The API token is read from CF_API_TOKEN or CLOUDFLARE_API_TOKEN.
+// If neither is set, a legacy API key is read from CF_API_KEY or CLOUDFLARE_API_KEY
+// along with the email from CF_API_EMAIL or CLOUDFLARE_EMAIL.
+func NewCloudflareFromEnv(options ...cloudflareOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		if token := firstEnv("CF_API_TOKEN", "CLOUDFLARE_API_TOKEN"); token != "" {
+			return newCloudflareProvider(token, options...)
+		}
+		key := firstEnv("CF_API_KEY", "CLOUDFLARE_API_KEY")
+		email := firstEnv("CF_API_EMAIL", "CLOUDFLARE_EMAIL")
+		if key == "" || email == "" {
+			return nil, errors.New("no cloudflare credentials found in the environment: set CF_API_TOKEN, or CF_API_KEY and CF_API_EMAIL")
+		}
+		return newCloudflareProviderAuth(key, email, options...)
+	}
+}
+
+// firstEnv returns the value of the first environment variable in names which is not empty.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// UsingCloudflare configures the client to use Cloudflare as the DNS provider.
+// It is equivalent to passing [NewCloudflare] to [ddns.New].
+func UsingCloudflare(token string, options ...cloudflareOption) clientOption {
+	return func(c *client) error {
+		p, err := newCloudflareProvider(token, options...)
+		if err != nil {
+			return err
+		}
+		c.Provider = p
+		return nil
+	}
+}
+
+// UsingWebResolver configures the client to look up our public IP address with [WebResolver].
+func UsingWebResolver(serviceURL ...string) clientOption {
+	return UsingResolver(WebResolver(serviceURL...))
+}
+
 // UsingResolver configures the client with a different resolver.
 // The default resolver gets the IP addresses of the local network interfaces.
 //
-// Available resolvers in this package: [InterfaceResolver], [WebResolver], [FromString].
+// Available resolvers in this package: [InterfaceResolver], [Iface], [PPPResolver], [WebResolver], [DNSResolver], [CloudMetadataResolver], [MikroTikResolver], [DHCPLeaseResolver], [DHCPHookResolver], [FromString], [StaticIP].
 func UsingResolver(resolver Resolver) clientOption {
 	return func(c *client) error {
 		if resolver == nil {
@@ -109,6 +191,8 @@ func UsingResolver(resolver Resolver) clientOption {
 }
 
 // WithLogger configures the client with a logger for verbose logging.
+// The logger is also given to the Resolver and Provider if they implement a SetLogger method.
+// Use [WithLogLevel] to log less.
 //
 // The default logger discards verbose log messages.
 func WithLogger(logger *log.Logger) clientOption {
@@ -120,100 +204,389 @@ func WithLogger(logger *log.Logger) clientOption {
 
 // UsingHTTPClient configures the DDNSClient to use the given httpclient for requests made by the Provider and Resolver implementations supplied by this package,
 // or for other types if they implement a SetHTTPClient method.
+// Use [ResolverHTTPClient] or [ProviderHTTPClient] to give one of them a different client.
 func UsingHTTPClient(httpclient *http.Client) clientOption {
 	return func(c *client) error {
 		if httpclient == nil {
 			httpclient = http.DefaultClient
 		}
-		type setHTTPClient interface {
-			SetHTTPClient(*http.Client)
-		}
-		switch hc := c.Resolver.(type) {
-		case *webResolver:
-			hc.httpClient = httpclient
-		case setHTTPClient:
-			hc.SetHTTPClient(httpclient)
+		c.httpClient = httpclient
+		return nil
+	}
+}
+
+// ResolverHTTPClient configures the DDNSClient to use httpclient for requests made by the Resolver only,
+// in place of the client given to [UsingHTTPClient].
+// This allows e.g. a transport which only dials IPv4 for a [WebResolver] without also restricting the Provider's API requests.
+func ResolverHTTPClient(httpclient *http.Client) clientOption {
+	return func(c *client) error {
+		if httpclient == nil {
+			httpclient = http.DefaultClient
 		}
-		switch p := c.Provider.(type) {
-		case *cloudflareProvider:
-			cloudflare.HTTPClient(httpclient)(p.api)
-		case setHTTPClient:
-			p.SetHTTPClient(httpclient)
+		c.resolverHTTPClient = httpclient
+		return nil
+	}
+}
+
+// ProviderHTTPClient configures the DDNSClient to use httpclient for requests made by the Provider only,
+// in place of the client given to [UsingHTTPClient].
+func ProviderHTTPClient(httpclient *http.Client) clientOption {
+	return func(c *client) error {
+		if httpclient == nil {
+			httpclient = http.DefaultClient
 		}
+		c.providerHTTPClient = httpclient
 		return nil
 	}
 }
 
+// orHTTPClient returns hc if it is set, or else def.
+func orHTTPClient(hc, def *http.Client) *http.Client {
+	if hc != nil {
+		return hc
+	}
+	return def
+}
+
 type client struct {
 	Resolver
 	Provider
 	cache
-	logger *log.Logger
-	domain string
+	logger             *log.Logger
+	httpClient         *http.Client
+	resolverHTTPClient *http.Client
+	providerHTTPClient *http.Client
+	transport          http.RoundTripper
+	dial               func(ctx context.Context, network, address string) (net.Conn, error)
+	httpTimeout        time.Duration
+	tlsMinVersion      uint16
+	rootCAs            *x509.CertPool
+	domain             string
+	nameTemplate       *template.Template
+	familyDomains      [2]string // IPv4 and IPv6 names given to MapFamilyDomains
+	aliases            []string
+	heartbeatID        string
+	staleAfter         time.Duration
+	appendMode         bool
+	owned              []netip.Addr // addresses published by this client in append mode
+	adopt              bool
+	adopted            bool // the existing records have been checked for adoption
+	gate               func(context.Context) error
+	fallback           []netip.Addr
+	fallbackAfter      int
+	failures           int // consecutive failed health checks or resolutions
+	dryRun             bool
+	journal            *json.Encoder
+	store              Store
+	events             chan<- Event
+	logLevel           LogLevel
+	requireHTTPS       bool
+	changeLogger       *log.Logger  // logs record changes at LogNormal and above; nil otherwise
+	published          []netip.Addr // records most recently set by this client
+	last               lastPublished
+	maxRecords         int
+	prefer             []func(netip.Addr) bool
+
+	guardDeletes    bool
+	maxDeletes      int
+	withdrawing     bool // the current update withdraws the records on purpose, see GateOn
+	allowMassDelete bool
+	confirmDeletes  func(ctx context.Context, domain string, deletes []netip.Addr) bool
+	ttl             time.Duration // TTL of new records; 0 for the provider's default
+	clampTTL        bool
+	cloudCheck      *cloudResolver // compares resolved addresses with cloud metadata if not nil
+	preferCloud     bool
+	detectCGNAT     bool
+	natCompare      Resolver // compared with the resolved addresses to detect NAT upstream of the router if not nil
+	failOnCGNAT     bool
+	natMismatches   int    // consecutive updates for which the natCompare addresses differed
+	natReported     string // the addresses of the NAT problem most recently reported
+	prober          Prober
+	probePorts      []uint16
+	srvService      string
+	srv             []SRV
+	srvPublished    bool
+
+	notifier        Notifier
+	alertAfter      int
+	alertOnRecovery bool
+	notifyChanges   bool
+	alertInterval   time.Duration
+	runFailures     int       // consecutive failed runs
+	nextAlert       int       // consecutive failures at which the next alert is sent
+	lastAlert       time.Time // time the last notification was sent
+	alerted         bool      // a failure alert was delivered during the current outage
+}
+
+// configure propagates the logger and http client to the Resolver and Provider.
+//
+// It runs once all options have been applied so that the order of options passed to ddns.New does not matter.
+func (c *client) configure() error {
+	if c.logger == nil {
+		c.logger = discard
+	}
+	if err := c.expandDomain(); err != nil {
+		return err
+	}
+	if len(c.aliases) > 0 {
+		if _, split := c.Provider.(*familyProvider); split {
+			return errors.New("aliases cannot be combined with per-family domains")
+		}
+		c.Provider = &aliasProvider{Provider: c.Provider, aliases: c.aliases}
+	}
+	if err := validateResolver(c.Resolver); err != nil {
+		return err
+	}
+	if err := validateResolver(c.natCompare); err != nil {
+		return fmt.Errorf("CGNAT detection: %w", err)
+	}
+	if err := validateResolver(c.prober); err != nil {
+		return fmt.Errorf("reachability check: %w", err)
+	}
+	if err := validateResolver(c.notifier); err != nil {
+		return fmt.Errorf("notifier: %w", err)
+	}
+	if err := c.checkHTTPS(); err != nil {
+		return err
+	}
+	if c.cloudCheck != nil {
+		if err := c.cloudCheck.validate(); err != nil {
+			return err
+		}
+	}
+	components := c.configureLogLevel()
+	setLogger(c.Resolver, components)
+	setLogger(c.Provider, components)
+	setLogger(c.notifier, components)
+	setLogger(c.natCompare, components)
+	if err := c.configureTTL(); err != nil {
+		return err
+	}
+	if err := c.configureTransport(); err != nil {
+		return err
+	}
+	if hc := orHTTPClient(c.resolverHTTPClient, c.httpClient); hc != nil {
+		setHTTPClient(c.Resolver, hc)
+		if c.cloudCheck != nil {
+			setHTTPClient(c.cloudCheck, hc)
+		}
+		setHTTPClient(c.natCompare, hc)
+	}
+	if hc := orHTTPClient(c.providerHTTPClient, c.httpClient); hc != nil {
+		setHTTPClient(c.Provider, hc)
+	}
+	if c.httpClient != nil {
+		setHTTPClient(c.notifier, c.httpClient)
+		setHTTPClient(c.prober, c.httpClient)
+	}
+	return c.checkCapabilities()
 }
 
 func (c *client) RunDDNS(ctx context.Context) error {
+	ctx, info := withRunID(ctx)
+	if info.Trigger != "" {
+		c.logger.Printf("starting %s update %s for %s\n", info.Trigger, info.ID, c.domain)
+	} else {
+		c.logger.Printf("starting update %s for %s\n", info.ID, c.domain)
+	}
+	c.emit(ctx, RunStarted{Time: time.Now(), Domain: c.domain, Run: info})
+	err := c.safeRun(ctx)
+	if err != nil {
+		c.emit(ctx, UpdateFailed{Time: time.Now(), Err: err, Run: info})
+	}
+	c.notify(ctx, err)
+	return err
+}
+
+// safeRun calls run, returning a [PanicError] if the update panics.
+func (c *client) safeRun(ctx context.Context) (err error) {
+	defer func() {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			c.logger.Printf("update panicked: %v\n%s", pe.Value, pe.Stack)
+		}
+	}()
+	defer recoverError(&err)
+	return c.run(ctx)
+}
+
+func (c *client) run(ctx context.Context) error {
+	active, err := c.active(ctx)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return nil
+	}
+	if err := c.checkHealth(ctx); err != nil {
+		return c.fail(ctx, err, true)
+	}
 	newIPs, err := c.Resolve(ctx)
+	if err == nil {
+		err = checkAddrs(newIPs)
+	}
 	if err != nil {
-		return fmt.Errorf("error getting IPs: %w", err)
+		return c.fail(ctx, fmt.Errorf("error getting IPs: %w", err), false)
 	}
+	c.failures = 0
+	newIPs = c.limitAddrs(c.checkCloudAddrs(ctx, canonicalAddrs(newIPs)))
 	c.logger.Printf("got local IPs: %+v\n", newIPs)
+	c.emit(ctx, Resolved{Time: time.Now(), Addrs: newIPs, Run: RunInfoFrom(ctx)})
+	if err := c.checkCGNAT(ctx, newIPs); err != nil {
+		return err
+	}
 
-	if err := c.SetDNSRecords(ctx, c.domain, newIPs); err != nil {
-		return fmt.Errorf("error updating %s with new IPs: %w", c.domain, err)
+	if err := c.publish(ctx, newIPs); err != nil {
+		return err
 	}
-	return nil
+	if err := c.beat(ctx); err != nil {
+		return err
+	}
+	if err := c.publishSRV(ctx); err != nil {
+		return err
+	}
+	return c.probe(ctx)
 }
 
-type logf interface {
-	Printf(string, ...any)
+// publish sets the records for the domain to our addresses.
+func (c *client) publish(ctx context.Context, addrs []netip.Addr) error {
+	records, err := c.desiredRecords(ctx, addrs)
+	if err != nil {
+		return err
+	}
+	if c.dryRun {
+		return c.logChanges(ctx, records)
+	}
+	if c.adopt && !c.adopted {
+		if unchanged, err := c.adoptExisting(ctx, records, addrs); err != nil || unchanged {
+			return err
+		}
+	}
+	return c.setRecords(ctx, records, addrs)
 }
 
-// RunDaemon runs ddnsClient every interval.
-//
-// Run errors are reported to logger.
-// A nil logger indicates messages should be sent to the log package's default log.
-//
-// To stop the daemon,
-// cancel the given context.
-//
-// The daemon will also exit early if it detects authentication or authorization errors,
-// rather than continue running with an expired or invalid token.
-func RunDaemon(ddnsClient DDNSClient, ctx context.Context, interval time.Duration, logger logf) {
-	if interval < 1*time.Minute {
-		interval = 1 * time.Minute
+// desiredRecords returns the records to publish for our addresses.
+func (c *client) desiredRecords(ctx context.Context, addrs []netip.Addr) (records []netip.Addr, err error) {
+	records = addrs
+	if c.appendMode {
+		if records, err = c.appendRecords(ctx, addrs); err != nil {
+			return nil, err
+		}
 	}
-	if logger == nil {
-		logger = log.Default()
+	records = canonicalAddrs(records)
+	// fail before making any changes rather than partway through an update
+	if err := checkProviderFamilies(c.Provider, records); err != nil {
+		return nil, err
 	}
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	return records, nil
+}
 
-	for {
-		err := ddnsClient.RunDDNS(ctx)
-		if err != nil {
-			logger.Printf("ddns.RunDaemon: %s", err)
+// setRecords sets the records for the domain and records the changes.
+// addrs are the resolved addresses the records were computed from.
+func (c *client) setRecords(ctx context.Context, records, addrs []netip.Addr) (err error) {
+	recording := c.journal != nil || c.store != nil || c.events != nil || c.changeLogger != nil || c.notifyChanges
+	var old []netip.Addr
+	if recording || c.guardDeletes {
+		if old, err = c.previousRecords(ctx); err != nil {
+			return err
 		}
-		var authentication interface{ IsAuthenticationError() bool }
-		if errors.As(err, &authentication) {
-			if authentication.IsAuthenticationError() {
-				logger.Printf("ddns.RunDaemon: bad credentials detected; stopping daemon")
-				return
-			}
+		old = sortAddrs(old)
+	}
+	if err := c.checkDeletes(ctx, old, records); err != nil {
+		return err
+	}
+	if err := c.SetDNSRecords(ctx, c.domain, records); err != nil {
+		return fmt.Errorf("error updating %s with new IPs: %w", c.domain, err)
+	}
+	if c.appendMode {
+		c.owned = addrs
+	}
+	c.published = records
+	c.setPublished(ctx, records, addrs)
+	if !recording {
+		return nil
+	}
+	if err := c.record(ctx, old, records, addrs); err != nil {
+		return err
+	}
+	if c.notifyChanges && !Diff(old, records).Empty() {
+		c.send(ctx, Notification{Domain: c.domain, Changed: true, Old: old, New: records})
+	}
+	if c.store != nil {
+		if err := c.store.SetLastPublished(ctx, c.domain, records); err != nil {
+			return fmt.Errorf("error storing published records: %w", err)
 		}
-		var authorization interface{ IsAuthorizationError() bool }
-		if errors.As(err, &authorization) {
-			if authorization.IsAuthorizationError() {
-				logger.Printf("ddns.RunDaemon: credentials are not authorized to perform that action; stopping daemon")
-				return
-			}
+	}
+	return nil
+}
+
+// checkAddrs returns an error if any of addrs can't be published,
+// such as the zero netip.Addr returned by a buggy Resolver.
+func checkAddrs(addrs []netip.Addr) error {
+	for _, a := range addrs {
+		if !a.IsValid() {
+			return errors.New("resolver returned an invalid address")
 		}
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
+		if a.Zone() != "" {
+			return fmt.Errorf("resolver returned address %s with a zone", a)
 		}
 	}
+	return nil
+}
+
+// canonicalAddrs returns addrs with IPv4-mapped IPv6 addresses (such as ::ffff:203.0.113.5) converted to IPv4,
+// without duplicates, and sorted.
+func canonicalAddrs(addrs []netip.Addr) []netip.Addr {
+	if addrs == nil {
+		return nil
+	}
+	// unmap, sort, and then remove adjacent duplicates within a single copy
+	canonical := make([]netip.Addr, len(addrs))
+	for i, a := range addrs {
+		canonical[i] = a.Unmap()
+	}
+	sort.Sort(addrSlice(canonical))
+	unique := canonical[:0]
+	for _, a := range canonical {
+		if len(unique) == 0 || a != unique[len(unique)-1] {
+			unique = append(unique, a)
+		}
+	}
+	return unique
+}
+
+// addrSlice sorts addresses without the reflection used by sort.Slice.
+type addrSlice []netip.Addr
+
+func (s addrSlice) Len() int           { return len(s) }
+func (s addrSlice) Less(i, j int) bool { return s[i].Less(s[j]) }
+func (s addrSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// sortAddrs returns a sorted copy of addrs,
+// so that logs, journals, and order-sensitive providers see the same output for the same set of addresses.
+func sortAddrs(addrs []netip.Addr) []netip.Addr {
+	if addrs == nil {
+		return nil
+	}
+	sorted := append([]netip.Addr{}, addrs...)
+	sort.Sort(addrSlice(sorted))
+	return sorted
+}
+
+// uniqueAddrs returns addrs without duplicates, keeping the first of each address.
+func uniqueAddrs(addrs []netip.Addr) []netip.Addr {
+	if addrs == nil {
+		return nil
+	}
+	seen := map[netip.Addr]bool{}
+	unique := []netip.Addr{}
+	for _, a := range addrs {
+		if !seen[a] {
+			seen[a] = true
+			unique = append(unique, a)
+		}
+	}
+	return unique
 }
 
 // The ResolverFunc type is an adapter that allows the use of ordinary functions as resolvers.
@@ -225,6 +598,7 @@ func (f ResolverFunc) Resolve(ctx context.Context) ([]netip.Addr, error) {
 }
 
 // Join constructs a resolver that combines the output of multiple resolvers into one.
+// Addresses returned by more than one resolver are only included once.
 //
 // This is useful in some instances such as when you want records for both IPv4 and IPv6,
 // but can only get one or the other from a single web service request.
@@ -249,8 +623,9 @@ func (r joinResolver) Resolve(ctx context.Context) (addrs []netip.Addr, err erro
 		go func(resolver Resolver) {
 			defer wg.Done()
 			r := result{}
+			defer func() { results <- r }()
+			defer recoverError(&r.err)
 			r.addrs, r.err = resolver.Resolve(ctx)
-			results <- r
 		}(rr)
 	}
 	wg.Wait()
@@ -259,27 +634,57 @@ func (r joinResolver) Resolve(ctx context.Context) (addrs []netip.Addr, err erro
 		addrs = append(addrs, r.addrs...)
 		errs = append(errs, r.err)
 	}
-	return addrs, errors.Join(errs...)
+	return uniqueAddrs(addrs), errors.Join(errs...)
 }
 
-func setLog(c *client, logger *log.Logger) {
-	if logger == nil {
-		logger = discard
+func (r joinResolver) validate() error {
+	for _, rr := range r.resolvers {
+		if err := validateResolver(rr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r joinResolver) lookupURLs() []*url.URL {
+	var urls []*url.URL
+	for _, rr := range r.resolvers {
+		urls = append(urls, lookupURLs(rr)...)
+	}
+	return urls
+}
+
+func (r *joinResolver) SetLogger(logger *log.Logger) {
+	for _, rr := range r.resolvers {
+		setLogger(rr, logger)
 	}
-	c.logger = logger
-	type setLogger interface{ SetLogger(*log.Logger) }
+}
+
+func (r *joinResolver) SetHTTPClient(httpclient *http.Client) {
+	for _, rr := range r.resolvers {
+		setHTTPClient(rr, httpclient)
+	}
+}
 
-	switch p := c.Provider.(type) {
-	case *cloudflareProvider:
-		p.logger = logger
-	case setLogger:
-		p.SetLogger(logger)
+// setLogger configures the logger for v if it implements a SetLogger method.
+func setLogger(v any, logger *log.Logger) {
+	if l, ok := v.(interface{ SetLogger(*log.Logger) }); ok {
+		l.SetLogger(logger)
 	}
+}
+
+// validateResolver returns the configuration error of a resolver, notifier, or prober, if it has one,
+// so that a misconfiguration is reported by New instead of on every update.
+func validateResolver(v any) error {
+	if r, ok := v.(interface{ validate() error }); ok {
+		return r.validate()
+	}
+	return nil
+}
 
-	switch r := c.Resolver.(type) {
-	case setLogger:
-		r.SetLogger(logger)
-	case *webResolver:
-	case *stringResolver:
+// setHTTPClient configures the http client for v if it implements a SetHTTPClient method.
+func setHTTPClient(v any, httpclient *http.Client) {
+	if h, ok := v.(interface{ SetHTTPClient(*http.Client) }); ok {
+		h.SetHTTPClient(httpclient)
 	}
 }
@@ -1,8 +1,14 @@
 package ddns_test
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"net/netip"
+	"strings"
 	"testing"
 	"time"
 
@@ -28,3 +34,154 @@ func TestConcurrentJoin(t *testing.T) {
 		t.Fatalf("Expected concurrent resolvers to finish before context timeout; got %q", err)
 	}
 }
+
+type configurableResolver struct {
+	ddns.Resolver
+	logger     *log.Logger
+	httpClient *http.Client
+}
+
+func (r *configurableResolver) SetLogger(logger *log.Logger)          { r.logger = logger }
+func (r *configurableResolver) SetHTTPClient(httpclient *http.Client) { r.httpClient = httpclient }
+
+func TestOptionOrder(t *testing.T) {
+	logger := log.New(io.Discard, "", 0)
+	httpclient := &http.Client{}
+	r := &configurableResolver{Resolver: ddns.FromString("192.0.2.1")}
+	p := &recordingProvider{}
+	_, err := ddns.New("example.com", p.fn(),
+		ddns.WithLogger(logger),
+		ddns.UsingHTTPClient(httpclient),
+		ddns.UsingResolver(ddns.Join(r)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if r.logger != logger {
+		t.Fatalf("Expected resolver to receive the logger regardless of option order")
+	}
+	if r.httpClient != httpclient {
+		t.Fatalf("Expected resolver to receive the http client regardless of option order")
+	}
+}
+
+func TestNewRequiresProvider(t *testing.T) {
+	_, err := ddns.New("example.com", nil, ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"))))
+	if err == nil {
+		t.Fatalf("Expected an error when no provider is configured; got err == nil")
+	}
+}
+
+func TestSortedUniqueRecords(t *testing.T) {
+	p := &recordingProvider{}
+	c, err := ddns.New("example.com", p.fn(),
+		ddns.UsingResolver(ddns.StaticIP(
+			netip.MustParseAddr("2001:db8::1"),
+			netip.MustParseAddr("192.0.2.2"),
+			netip.MustParseAddr("192.0.2.1"),
+			netip.MustParseAddr("192.0.2.2"),
+		)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	want := "[192.0.2.1 192.0.2.2 2001:db8::1]"
+	if got := fmt.Sprint(p.records); got != want {
+		t.Errorf("Expected records %s; got %s", want, got)
+	}
+}
+
+func TestMaxRecords(t *testing.T) {
+	p := &recordingProvider{}
+	c, err := ddns.New("example.com", p.fn(),
+		ddns.UsingResolver(ddns.StaticIP(
+			netip.MustParseAddr("2001:db8::1"),
+			netip.MustParseAddr("10.0.0.2"),
+			netip.MustParseAddr("203.0.113.5"),
+			netip.MustParseAddr("10.0.0.1"),
+		)),
+		ddns.MaxRecords(2, ddns.IPv4Addr, ddns.PublicAddr),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	want := "[10.0.0.1 203.0.113.5]"
+	if got := fmt.Sprint(p.records); got != want {
+		t.Errorf("Expected records %s; got %s", want, got)
+	}
+}
+
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		level         ddns.LogLevel
+		changes       bool // whether record changes are logged
+		verbose       bool // whether the client's progress is logged
+		resolverDebug bool // whether the resolver gets the logger
+	}{
+		{ddns.LogQuiet, false, false, false},
+		{ddns.LogNormal, true, false, false},
+		{ddns.LogVerbose, true, true, false},
+		{ddns.LogDebug, true, true, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		logger := log.New(&buf, "", 0)
+		r := &configurableResolver{Resolver: ddns.FromString("192.0.2.1")}
+		c, err := ddns.New("example.com", (&recordingProvider{}).fn(),
+			ddns.WithLogger(logger),
+			ddns.WithLogLevel(tt.level),
+			ddns.UsingResolver(r),
+		)
+		if err != nil {
+			t.Fatalf("New returned an error: %s", err)
+		}
+		if err := c.RunDDNS(context.Background()); err != nil {
+			t.Fatalf("RunDDNS failed: %s", err)
+		}
+		if got := strings.Contains(buf.String(), "created record 192.0.2.1 for example.com"); got != tt.changes {
+			t.Errorf("Level %d: expected record changes logged to be %t; got log %q", tt.level, tt.changes, buf.String())
+		}
+		if got := strings.Contains(buf.String(), "got local IPs"); got != tt.verbose {
+			t.Errorf("Level %d: expected progress logged to be %t; got log %q", tt.level, tt.verbose, buf.String())
+		}
+		if got := r.logger == logger; got != tt.resolverDebug {
+			t.Errorf("Level %d: expected resolver to receive the logger to be %t", tt.level, tt.resolverDebug)
+		}
+	}
+}
+
+func TestWebResolverInvalidURL(t *testing.T) {
+	_, err := ddns.New("example.com", (&recordingProvider{}).fn(),
+		ddns.UsingResolver(ddns.Join(ddns.WebResolver("https://example.com/ip", "http://[::1"))),
+	)
+	if err == nil {
+		t.Fatalf("Expected New to report the invalid URL; got err == nil")
+	}
+}
+
+func BenchmarkRunDDNS(b *testing.B) {
+	p := &recordingProvider{}
+	c, err := ddns.New("example.com", p.fn(),
+		ddns.UsingResolver(ddns.StaticIP(
+			netip.MustParseAddr("2001:db8::1"),
+			netip.MustParseAddr("192.0.2.2"),
+			netip.MustParseAddr("::ffff:192.0.2.1"),
+		)),
+	)
+	if err != nil {
+		b.Fatalf("New returned an error: %s", err)
+	}
+	ctx := context.Background()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := c.RunDDNS(ctx); err != nil {
+			b.Fatalf("RunDDNS failed: %s", err)
+		}
+	}
+}
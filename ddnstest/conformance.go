@@ -0,0 +1,163 @@
+package ddnstest
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// TestProvider runs a conformance suite against the Provider returned by newProvider,
+// so that every provider behaves the same way for the client:
+// records are created, replaced, and deleted to match the set given to SetDNSRecords,
+// repeating an update is harmless, an empty set removes the records,
+// and concurrent updates of different domains don't interfere.
+//
+//	func TestMyProviderConformance(t *testing.T) {
+//		api := newFakeAPI(t)
+//		ddnstest.TestProvider(t, NewMyProvider(api.URL, "token"))
+//	}
+//
+// The subtests run in parallel. Each calls newProvider and updates its own names under example.com,
+// so the provider should be backed by a fake of its API, such as an httptest.Server, rather than a real account.
+// Since the parallel subtests finish after TestProvider returns, the fake should be closed with t.Cleanup rather than defer.
+// Only the address families the provider reports in its [ddns.Capabilities] are used.
+// The records are checked with GetDNSRecords if the provider implements [ddns.RecordGetter];
+// otherwise only the errors returned by SetDNSRecords are checked.
+func TestProvider(t *testing.T, newProvider func() (ddns.Provider, error)) {
+	p, err := newProvider()
+	if err != nil {
+		t.Fatalf("newProvider returned an error: %s", err)
+	}
+	caps := ddns.ProviderCapabilities(p)
+	var v4, v6 []netip.Addr
+	if caps.IPv4 {
+		v4 = []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")}
+	}
+	if caps.IPv6 {
+		v6 = []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("2001:db8::2")}
+	}
+	// first and second are the addresses of each supported family, for the updates which replace one with the other
+	var first, second []netip.Addr
+	if len(v4) > 0 {
+		first, second = append(first, v4[0]), append(second, v4[1])
+	}
+	if len(v6) > 0 {
+		first, second = append(first, v6[0]), append(second, v6[1])
+	}
+	if len(first) == 0 {
+		t.Fatalf("provider supports neither IPv4 nor IPv6")
+	}
+
+	steps := func(t *testing.T, domain string, updates ...[]netip.Addr) {
+		t.Parallel()
+		p, err := newProvider()
+		if err != nil {
+			t.Fatalf("newProvider returned an error: %s", err)
+		}
+		for i, addrs := range updates {
+			set(t, p, domain, addrs, fmt.Sprintf("update %d", i+1))
+		}
+	}
+	t.Run("Create", func(t *testing.T) {
+		steps(t, "create.example.com", first)
+	})
+	t.Run("Update", func(t *testing.T) {
+		steps(t, "update.example.com", first, second)
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		steps(t, "idempotent.example.com", first, first, first)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		steps(t, "delete.example.com", append(append([]netip.Addr(nil), first...), second...), first)
+	})
+	t.Run("EmptySet", func(t *testing.T) {
+		steps(t, "empty.example.com", first, nil, nil)
+	})
+	t.Run("IPv4", func(t *testing.T) {
+		if len(v4) == 0 {
+			t.Skip("provider does not support IPv4")
+		}
+		steps(t, "ipv4.example.com", v4, v4[:1])
+	})
+	t.Run("IPv6", func(t *testing.T) {
+		if len(v6) == 0 {
+			t.Skip("provider does not support IPv6")
+		}
+		steps(t, "ipv6.example.com", v6, v6[1:])
+	})
+	t.Run("DualStack", func(t *testing.T) {
+		if len(v4) == 0 || len(v6) == 0 {
+			t.Skip("provider does not support both IPv4 and IPv6")
+		}
+		steps(t, "dualstack.example.com", []netip.Addr{v4[0], v6[0]}, v4[:1], v6[:1], []netip.Addr{v4[1], v6[1]})
+	})
+	t.Run("Concurrent", func(t *testing.T) {
+		t.Parallel()
+		p, err := newProvider()
+		if err != nil {
+			t.Fatalf("newProvider returned an error: %s", err)
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			domain := fmt.Sprintf("concurrent%d.example.com", i)
+			addrs := first
+			if i%2 == 1 {
+				addrs = second
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				set(t, p, domain, addrs, "concurrent update")
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+// set sets the records for domain and checks that they were set, if p can list them.
+func set(t *testing.T, p ddns.Provider, domain string, addrs []netip.Addr, step string) {
+	ctx := context.Background()
+	if err := p.SetDNSRecords(ctx, domain, addrs); err != nil {
+		t.Errorf("%s: SetDNSRecords(%s, %v) returned an error: %s", step, domain, addrs, err)
+		return
+	}
+	rg, ok := p.(ddns.RecordGetter)
+	if !ok || !ddns.ProviderCapabilities(p).ListRecords {
+		return
+	}
+	got, err := rg.GetDNSRecords(ctx, domain)
+	if err != nil {
+		t.Errorf("%s: GetDNSRecords(%s) returned an error: %s", step, domain, err)
+		return
+	}
+	if !sameAddrs(got, addrs) {
+		t.Errorf("%s: Expected %v for %s; got %v", step, addrs, domain, got)
+	}
+}
+
+// sameAddrs reports whether a and b contain the same addresses in any order.
+func sameAddrs(a, b []netip.Addr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sorted := func(addrs []netip.Addr) []netip.Addr {
+		s := make([]netip.Addr, len(addrs))
+		for i, a := range addrs {
+			s[i] = a.Unmap()
+		}
+		sort.Slice(s, func(i, j int) bool { return s[i].Less(s[j]) })
+		return s
+	}
+	sa, sb := sorted(a), sorted(b)
+	for i := range sa {
+		if sa[i] != sb[i] {
+			return false
+		}
+	}
+	return true
+}
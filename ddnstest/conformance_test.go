@@ -0,0 +1,11 @@
+package ddnstest_test
+
+import (
+	"testing"
+
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestProviderConformance(t *testing.T) {
+	ddnstest.TestProvider(t, ddnstest.ProviderFunc(&ddnstest.Provider{}))
+}
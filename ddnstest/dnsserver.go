@@ -0,0 +1,528 @@
+package ddnstest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dnsTypeTSIG     dnsmessage.Type   = 250
+	dnsClassNONE    dnsmessage.Class  = 254
+	dnsOpUpdate     dnsmessage.OpCode = 5
+	dnsRCodeNotAuth dnsmessage.RCode  = 9
+	dnsRCodeNotZone dnsmessage.RCode  = 10
+)
+
+// DNSServer is an in-memory authoritative name server for testing [ddns.NewRFC2136],
+// and lookups of the published records such as [ddns.LookupIP] with [ddns.DNSServer].
+//
+// The server answers queries for A, AAAA, TXT, PTR, and SOA records over UDP and TCP on the same port,
+// and applies RFC 2136 updates for the zones it serves.
+// Prerequisites in update messages are ignored, and responses are not signed.
+//
+// Close must be called when the test is done.
+type DNSServer struct {
+	// Addr is the host:port of the server, for both UDP and TCP.
+	Addr string
+
+	udp net.PacketConn
+	tcp net.Listener
+
+	mu      sync.Mutex
+	zones   []string
+	records map[string][]dnsRecord // keyed by lowercase fully qualified name
+	keys    map[string]tsigSecret  // keyed by lowercase fully qualified key name
+	rcode   dnsmessage.RCode       // answer every request with rcode, if non-zero
+	urcode  dnsmessage.RCode       // answer every update with urcode, if non-zero
+	updates int
+}
+
+type dnsRecord struct {
+	typ  dnsmessage.Type
+	ttl  uint32
+	data []byte // uncompressed rdata
+}
+
+type tsigSecret struct {
+	algorithm string
+	secret    []byte
+	hash      func() hash.Hash
+}
+
+// NewDNSServer starts a DNSServer on the loopback interface, authoritative for zones, e.g. "example.com".
+// It panics if the server cannot listen, like httptest.NewServer.
+func NewDNSServer(zones ...string) *DNSServer {
+	s := &DNSServer{records: map[string][]dnsRecord{}, keys: map[string]tsigSecret{}}
+	for _, z := range zones {
+		s.zones = append(s.zones, canonical(z))
+	}
+	// the port chosen for TCP may already be in use for UDP, so try a few times
+	for i := 0; ; i++ {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			panic(fmt.Sprintf("ddnstest: failed to listen: %s", err))
+		}
+		udp, err := net.ListenPacket("udp", tcp.Addr().String())
+		if err != nil {
+			tcp.Close()
+			if i < 10 {
+				continue
+			}
+			panic(fmt.Sprintf("ddnstest: failed to listen: %s", err))
+		}
+		s.tcp, s.udp, s.Addr = tcp, udp, tcp.Addr().String()
+		break
+	}
+	go s.serveUDP()
+	go s.serveTCP()
+	return s
+}
+
+// Close stops the server.
+func (s *DNSServer) Close() {
+	s.tcp.Close()
+	s.udp.Close()
+}
+
+// RequireTSIG makes the server reject updates which are not signed with the key.
+// algorithm is one of "hmac-sha1", "hmac-sha256", or "hmac-sha512", and secret is base64 encoded, as for [ddns.TSIG].
+// It may be called more than once to accept several keys.
+//
+// Unsigned updates are refused, and messages with an unknown key or a bad signature are answered with NOTAUTH.
+// Queries are answered without a signature, but a signature they carry is checked.
+func (s *DNSServer) RequireTSIG(keyName, algorithm, secret string) {
+	var h func() hash.Hash
+	switch canonical(algorithm) {
+	case "hmac-sha1":
+		h = sha1.New
+	case "hmac-sha256":
+		h = sha256.New
+	case "hmac-sha512":
+		h = sha512.New
+	default:
+		panic(fmt.Sprintf("ddnstest: unsupported TSIG algorithm \"%s\"", algorithm))
+	}
+	b, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		panic(fmt.Sprintf("ddnstest: invalid TSIG secret: %s", err))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[canonical(keyName)] = tsigSecret{algorithm: canonical(algorithm), secret: b, hash: h}
+}
+
+// Fail makes the server answer every request with rcode, e.g. dnsmessage.RCodeServerFailure.
+// dnsmessage.RCodeSuccess restores normal answers.
+func (s *DNSServer) Fail(rcode dnsmessage.RCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rcode = rcode
+}
+
+// FailUpdates makes the server answer every update with rcode, while queries are still answered.
+// dnsmessage.RCodeSuccess restores normal updates.
+func (s *DNSServer) FailUpdates(rcode dnsmessage.RCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.urcode = rcode
+}
+
+// SetAddrs replaces the A and AAAA records of name with addrs.
+func (s *DNSServer) SetAddrs(name string, addrs ...netip.Addr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name = canonical(name)
+	s.deleteRRset(name, dnsmessage.TypeA)
+	s.deleteRRset(name, dnsmessage.TypeAAAA)
+	for _, a := range addrs {
+		a = a.Unmap()
+		t := dnsmessage.TypeAAAA
+		if a.Is4() {
+			t = dnsmessage.TypeA
+		}
+		s.add(name, dnsRecord{typ: t, ttl: 60, data: a.AsSlice()})
+	}
+}
+
+// Addrs returns the A and AAAA records of name.
+func (s *DNSServer) Addrs(name string) []netip.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var addrs []netip.Addr
+	for _, r := range s.records[canonical(name)] {
+		if r.typ == dnsmessage.TypeA || r.typ == dnsmessage.TypeAAAA {
+			a, _ := netip.AddrFromSlice(r.data)
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs
+}
+
+// TXT returns the values of the TXT records of name.
+func (s *DNSServer) TXT(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var values []string
+	for _, r := range s.records[canonical(name)] {
+		if r.typ != dnsmessage.TypeTXT {
+			continue
+		}
+		var v strings.Builder
+		for b := r.data; len(b) > 0 && len(b) > int(b[0]); b = b[1+int(b[0]):] {
+			v.Write(b[1 : 1+int(b[0])])
+		}
+		values = append(values, v.String())
+	}
+	return values
+}
+
+// PTR returns the targets of the PTR records of the reverse name, e.g. "5.113.0.203.in-addr.arpa",
+// without the trailing dot.
+func (s *DNSServer) PTR(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var targets []string
+	for _, r := range s.records[canonical(name)] {
+		if r.typ != dnsmessage.TypePTR {
+			continue
+		}
+		var labels []string
+		for b := r.data; len(b) > 0 && b[0] > 0 && len(b) > int(b[0]); b = b[1+int(b[0]):] {
+			labels = append(labels, string(b[1:1+int(b[0])]))
+		}
+		targets = append(targets, strings.Join(labels, "."))
+	}
+	return targets
+}
+
+// Updates returns the number of updates the server has applied.
+func (s *DNSServer) Updates() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updates
+}
+
+func (s *DNSServer) serveUDP() {
+	buf := make([]byte, 65535)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.handle(append([]byte(nil), buf[:n]...)); resp != nil {
+			s.udp.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *DNSServer) serveTCP() {
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				var n uint16
+				if err := binary.Read(conn, binary.BigEndian, &n); err != nil {
+					return
+				}
+				b := make([]byte, n)
+				if _, err := io.ReadFull(conn, b); err != nil {
+					return
+				}
+				resp := s.handle(b)
+				if resp == nil {
+					return
+				}
+				conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+			}
+		}()
+	}
+}
+
+// handle returns the packed response to the packed request b, or nil if b can't be parsed.
+func (s *DNSServer) handle(b []byte) []byte {
+	var p dnsmessage.Parser
+	h, err := p.Start(b)
+	if err != nil {
+		return nil
+	}
+	questions, err := p.AllQuestions()
+	if err != nil {
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: h.ID, Response: true, OpCode: h.OpCode, Authoritative: true},
+		Questions: questions,
+	}
+
+	// the update section of an update message may hold records which don't parse by type, e.g. an RRset deletion without rdata,
+	// so the answer and authority sections are read as raw records
+	var prereq, update []rawRecord
+	for _, sec := range []*[]rawRecord{&prereq, &update} {
+		for {
+			var rh dnsmessage.ResourceHeader
+			if sec == &prereq {
+				rh, err = p.AnswerHeader()
+			} else {
+				rh, err = p.AuthorityHeader()
+			}
+			if err == dnsmessage.ErrSectionDone {
+				break
+			}
+			if err != nil {
+				return nil
+			}
+			body, err := p.UnknownResource()
+			if err != nil {
+				return nil
+			}
+			*sec = append(*sec, rawRecord{name: canonical(rh.Name.String()), class: rh.Class, typ: rh.Type, ttl: rh.TTL, data: body.Data})
+		}
+	}
+	signed, rcode := s.verify(b, &p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case s.rcode != dnsmessage.RCodeSuccess:
+		resp.RCode = s.rcode
+	case rcode != dnsmessage.RCodeSuccess:
+		resp.RCode = rcode
+	case h.OpCode == dnsOpUpdate:
+		resp.RCode = s.applyUpdate(questions, update, signed)
+	case h.OpCode == 0 && len(questions) == 1:
+		s.answer(&resp, questions[0])
+	default:
+		resp.RCode = dnsmessage.RCodeNotImplemented
+	}
+	out, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+type rawRecord struct {
+	name  string
+	class dnsmessage.Class
+	typ   dnsmessage.Type
+	ttl   uint32
+	data  []byte
+}
+
+// verify checks the TSIG record of the packed message b, if any, once p has read the answer and authority sections.
+// It reports whether the message was signed with a known key, or the rcode for a signature which doesn't verify.
+func (s *DNSServer) verify(b []byte, p *dnsmessage.Parser) (signed bool, rcode dnsmessage.RCode) {
+	var tsig *dnsmessage.Resource
+	for {
+		r, err := p.Additional()
+		if err != nil {
+			break
+		}
+		if r.Header.Type == dnsTypeTSIG {
+			tsig = &r
+		}
+	}
+	if tsig == nil {
+		return false, dnsmessage.RCodeSuccess
+	}
+	s.mu.Lock()
+	key, ok := s.keys[canonical(tsig.Header.Name.String())]
+	s.mu.Unlock()
+	if !ok {
+		return false, dnsRCodeNotAuth
+	}
+	rdata := tsig.Body.(*dnsmessage.UnknownResource).Data
+	// the TSIG record is last, with an uncompressed owner name
+	name := wireName(tsig.Header.Name.String())
+	start := len(b) - len(name) - 10 - len(rdata)
+	if start < 12 {
+		return false, dnsRCodeNotAuth
+	}
+	alg := wireName(key.algorithm)
+	if !bytes.HasPrefix(rdata, alg) || len(rdata) < len(alg)+10 {
+		return false, dnsRCodeNotAuth
+	}
+	fixed := rdata[len(alg):]
+	timeSigned, fudge := fixed[:6], binary.BigEndian.Uint16(fixed[6:8])
+	size := int(binary.BigEndian.Uint16(fixed[8:10]))
+	if len(fixed) < 10+size+6 {
+		return false, dnsRCodeNotAuth
+	}
+	sum, other := fixed[10:10+size], fixed[10+size+2:]
+
+	msg := append([]byte(nil), b[:start]...)
+	binary.BigEndian.PutUint16(msg[10:12], binary.BigEndian.Uint16(msg[10:12])-1)
+	mac := hmac.New(key.hash, key.secret)
+	mac.Write(msg)
+	mac.Write(name)
+	mac.Write(binary.BigEndian.AppendUint16(nil, uint16(dnsmessage.ClassANY)))
+	mac.Write(binary.BigEndian.AppendUint32(nil, 0))
+	mac.Write(alg)
+	mac.Write(timeSigned)
+	mac.Write(fixed[6:8])
+	mac.Write(other)
+	if !hmac.Equal(mac.Sum(nil), sum) {
+		return false, dnsRCodeNotAuth
+	}
+	var signedAt int64
+	for _, c := range timeSigned {
+		signedAt = signedAt<<8 | int64(c)
+	}
+	if d := time.Since(time.Unix(signedAt, 0)); d > time.Duration(fudge)*time.Second || d < -time.Duration(fudge)*time.Second {
+		return false, dnsRCodeNotAuth
+	}
+	return true, dnsmessage.RCodeSuccess
+}
+
+// applyUpdate applies the update section of an RFC 2136 message for the zone in questions.
+func (s *DNSServer) applyUpdate(questions []dnsmessage.Question, update []rawRecord, signed bool) dnsmessage.RCode {
+	if s.urcode != dnsmessage.RCodeSuccess {
+		return s.urcode
+	}
+	if len(s.keys) > 0 && !signed {
+		return dnsmessage.RCodeRefused
+	}
+	if len(questions) != 1 || questions[0].Type != dnsmessage.TypeSOA {
+		return dnsmessage.RCodeFormatError
+	}
+	zone := canonical(questions[0].Name.String())
+	if s.zone(zone) != zone {
+		return dnsRCodeNotAuth
+	}
+	for _, r := range update {
+		if r.name != zone && !strings.HasSuffix(r.name, "."+zone) {
+			return dnsRCodeNotZone
+		}
+	}
+	for _, r := range update {
+		switch r.class {
+		case dnsmessage.ClassINET:
+			s.add(r.name, dnsRecord{typ: r.typ, ttl: r.ttl, data: r.data})
+		case dnsmessage.ClassANY:
+			s.deleteRRset(r.name, r.typ)
+		case dnsClassNONE:
+			records := s.records[r.name][:0]
+			for _, e := range s.records[r.name] {
+				if e.typ != r.typ || !bytes.Equal(e.data, r.data) {
+					records = append(records, e)
+				}
+			}
+			s.records[r.name] = records
+		default:
+			return dnsmessage.RCodeFormatError
+		}
+	}
+	s.updates++
+	return dnsmessage.RCodeSuccess
+}
+
+// answer sets the answer to the query q in resp.
+func (s *DNSServer) answer(resp *dnsmessage.Message, q dnsmessage.Question) {
+	name := canonical(q.Name.String())
+	zone := s.zone(name)
+	if zone == "" {
+		resp.Header.Authoritative = false
+		resp.RCode = dnsmessage.RCodeRefused
+		return
+	}
+	soa := dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(zone + "."), Class: dnsmessage.ClassINET, TTL: 60},
+		Body: &dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns1." + zone + "."),
+			MBox:    dnsmessage.MustNewName("hostmaster." + zone + "."),
+			Serial:  uint32(s.updates + 1),
+			Refresh: 3600, Retry: 600, Expire: 86400, MinTTL: 60,
+		},
+	}
+	if q.Type == dnsmessage.TypeSOA && name == zone {
+		resp.Answers = append(resp.Answers, soa)
+		return
+	}
+	for _, r := range s.records[name] {
+		if r.typ == q.Type {
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: r.ttl},
+				Body:   &dnsmessage.UnknownResource{Type: r.typ, Data: r.data},
+			})
+		}
+	}
+	if len(resp.Answers) > 0 {
+		return
+	}
+	if len(s.records[name]) == 0 && name != zone {
+		resp.RCode = dnsmessage.RCodeNameError
+	}
+	resp.Authorities = append(resp.Authorities, soa)
+}
+
+// zone returns the longest zone served by s which contains name, or "" if there is none.
+func (s *DNSServer) zone(name string) string {
+	var zone string
+	for _, z := range s.zones {
+		if (name == z || strings.HasSuffix(name, "."+z)) && len(z) > len(zone) {
+			zone = z
+		}
+	}
+	return zone
+}
+
+func (s *DNSServer) add(name string, r dnsRecord) {
+	for i, e := range s.records[name] {
+		if e.typ == r.typ && bytes.Equal(e.data, r.data) {
+			s.records[name][i].ttl = r.ttl
+			return
+		}
+	}
+	s.records[name] = append(s.records[name], r)
+}
+
+// deleteRRset deletes the records of type t at name, or every record if t is ANY.
+func (s *DNSServer) deleteRRset(name string, t dnsmessage.Type) {
+	records := s.records[name][:0]
+	for _, e := range s.records[name] {
+		if t != dnsmessage.TypeALL && e.typ != t {
+			records = append(records, e)
+		}
+	}
+	s.records[name] = records
+	if len(records) == 0 {
+		delete(s.records, name)
+	}
+}
+
+// canonical returns name in lowercase without the trailing dot.
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// wireName returns the uncompressed wire format of name in lowercase.
+func wireName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(canonical(name), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
@@ -0,0 +1,4 @@
+/*
+Package ddnstest provides utilities for testing code which uses package ddns.
+*/
+package ddnstest
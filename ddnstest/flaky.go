@@ -0,0 +1,131 @@
+package ddnstest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// ErrInjected is returned by the calls which a flaky Resolver or Provider fails.
+// It is classified as temporary by [ddns.IsTemporary], like a timeout or a server error,
+// so that the client retries with backoff.
+var ErrInjected error = injectedError{}
+
+type injectedError struct{}
+
+func (injectedError) Error() string     { return "ddnstest: injected failure" }
+func (injectedError) IsTemporary() bool { return true }
+
+// FlakyResolver wraps r so that each call to Resolve waits for latency
+// and then fails with [ErrInjected] with probability failureRate, from 0 to 1,
+// to test code under realistic failure patterns such as an unreliable IP lookup service.
+func FlakyResolver(r ddns.Resolver, failureRate float64, latency time.Duration) ddns.Resolver {
+	return &flakyResolver{Resolver: r, faults: faults{rate: failureRate, latency: latency}}
+}
+
+// FlakyProvider wraps p so that each call to SetDNSRecords and GetDNSRecords waits for latency
+// and then fails with [ErrInjected] with probability failureRate, from 0 to 1,
+// to test code under realistic failure patterns such as an overloaded provider API.
+//
+// The wrapper implements [ddns.RecordGetter] and [ddns.CredentialRotator] if p does,
+// and passes on the logger, http client, and TTL given to the client.
+// TXT and SRV records are not supported.
+func FlakyProvider(p ddns.Provider, failureRate float64, latency time.Duration) ddns.Provider {
+	return &flakyProvider{Provider: p, faults: faults{rate: failureRate, latency: latency}}
+}
+
+// faults injects latency and failures into calls.
+type faults struct {
+	rate    float64
+	latency time.Duration
+}
+
+// inject waits for the latency, and returns an error if the call should fail.
+func (f faults) inject(ctx context.Context) error {
+	if f.latency > 0 {
+		t := time.NewTimer(f.latency)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if rand.Float64() < f.rate {
+		return ErrInjected
+	}
+	return nil
+}
+
+type flakyResolver struct {
+	ddns.Resolver
+	faults
+}
+
+func (r *flakyResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	if err := r.inject(ctx); err != nil {
+		return nil, err
+	}
+	return r.Resolver.Resolve(ctx)
+}
+
+type flakyProvider struct {
+	ddns.Provider
+	faults
+}
+
+func (p *flakyProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	if err := p.inject(ctx); err != nil {
+		return err
+	}
+	return p.Provider.SetDNSRecords(ctx, domain, records)
+}
+
+func (p *flakyProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	rg, ok := p.Provider.(ddns.RecordGetter)
+	if !ok {
+		return nil, errors.New("provider cannot list records")
+	}
+	if err := p.inject(ctx); err != nil {
+		return nil, err
+	}
+	return rg.GetDNSRecords(ctx, domain)
+}
+
+func (p *flakyProvider) RotateCredentials(ctx context.Context, token string) error {
+	r, ok := p.Provider.(ddns.CredentialRotator)
+	if !ok {
+		return errors.New("provider does not support credential rotation")
+	}
+	return r.RotateCredentials(ctx, token)
+}
+
+func (p *flakyProvider) Capabilities() ddns.Capabilities {
+	caps := ddns.ProviderCapabilities(p.Provider)
+	caps.TXT, caps.SRV = false, false
+	return caps
+}
+
+func (p *flakyProvider) SetLogger(logger *log.Logger) {
+	if l, ok := p.Provider.(interface{ SetLogger(*log.Logger) }); ok {
+		l.SetLogger(logger)
+	}
+}
+
+func (p *flakyProvider) SetHTTPClient(httpclient *http.Client) {
+	if h, ok := p.Provider.(interface{ SetHTTPClient(*http.Client) }); ok {
+		h.SetHTTPClient(httpclient)
+	}
+}
+
+func (p *flakyProvider) SetTTL(ttl time.Duration) {
+	if s, ok := p.Provider.(interface{ SetTTL(time.Duration) }); ok {
+		s.SetTTL(ttl)
+	}
+}
@@ -0,0 +1,62 @@
+package ddnstest_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestFlaky(t *testing.T) {
+	ctx := context.Background()
+	addr := netip.MustParseAddr("203.0.113.5")
+	r := &ddnstest.Resolver{}
+	r.Set(addr)
+
+	if _, err := ddnstest.FlakyResolver(r, 1, 0).Resolve(ctx); !errors.Is(err, ddnstest.ErrInjected) || !ddns.IsTemporary(err) {
+		t.Errorf("Expected a temporary injected error; got %v", err)
+	}
+	if addrs, err := ddnstest.FlakyResolver(r, 0, 0).Resolve(ctx); err != nil || len(addrs) != 1 {
+		t.Errorf("Expected %s; got %v, %v", addr, addrs, err)
+	}
+	failures := 0
+	flaky := ddnstest.FlakyResolver(r, 0.5, 0)
+	for i := 0; i < 1000; i++ {
+		if _, err := flaky.Resolve(ctx); err != nil {
+			failures++
+		}
+	}
+	if failures < 400 || failures > 600 {
+		t.Errorf("Expected about 500 failures; got %d", failures)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := ddnstest.FlakyResolver(r, 0, time.Hour).Resolve(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the latency to end with the context; got %v", err)
+	}
+}
+
+func TestFlakyProvider(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(ddnstest.FlakyProvider(p, 1, 0)),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); !ddns.IsTemporary(err) {
+		t.Errorf("Expected a temporary error; got %v", err)
+	}
+	if got := p.Records("home.example.com"); len(got) != 0 {
+		t.Errorf("Expected no records; got %v", got)
+	}
+	if _, err := ddns.New("home.example.com", ddnstest.ProviderFunc(ddnstest.FlakyProvider(p, 0, 0)), ddns.AppendMode()); err != nil {
+		t.Errorf("Expected the wrapper to list records: %s", err)
+	}
+}
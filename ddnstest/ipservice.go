@@ -0,0 +1,57 @@
+package ddnstest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+)
+
+// IPService is an IP lookup service for testing [ddns.WebResolver].
+// It answers every request with its current address, like https://icanhazip.com.
+//
+// Close must be called when the test is done.
+type IPService struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	addr     netip.Addr
+	requests int
+}
+
+// NewIPService starts an IPService answering with addr.
+// The service listens on the loopback interface whatever the family of addr,
+// so that IPv6 answers can be tested on hosts without IPv6 connectivity.
+func NewIPService(addr netip.Addr) *IPService {
+	s := &IPService{addr: addr}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// SetAddr changes the address the service answers with.
+// The zero netip.Addr makes the service fail with status 503 Service Unavailable.
+func (s *IPService) SetAddr(addr netip.Addr) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addr = addr
+}
+
+// Requests returns the number of requests the service has received.
+func (s *IPService) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *IPService) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	addr := s.addr
+	s.requests++
+	s.mu.Unlock()
+	if !addr.IsValid() {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, addr)
+}
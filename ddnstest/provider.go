@@ -0,0 +1,78 @@
+package ddnstest
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// Provider is an in-memory implementation of [ddns.Provider], [ddns.RecordGetter], [ddns.TXTProvider], and [ddns.SRVProvider].
+//
+// It is safe for concurrent use.
+// The zero value is ready to use.
+type Provider struct {
+	mu      sync.Mutex
+	records map[string][]netip.Addr
+	txt     map[string][]string
+	srv     map[string][]ddns.SRV
+}
+
+// ProviderFunc adapts p for use with [ddns.New].
+func ProviderFunc(p ddns.Provider) func() (ddns.Provider, error) {
+	return func() (ddns.Provider, error) { return p, nil }
+}
+
+func (p *Provider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.records == nil {
+		p.records = map[string][]netip.Addr{}
+	}
+	p.records[domain] = append([]netip.Addr(nil), records...)
+	return nil
+}
+
+// Records returns the addresses most recently set for domain.
+func (p *Provider) Records(domain string) []netip.Addr {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]netip.Addr(nil), p.records[domain]...)
+}
+
+func (p *Provider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	return p.Records(domain), nil
+}
+
+func (p *Provider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.txt[name]...), nil
+}
+
+func (p *Provider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.txt == nil {
+		p.txt = map[string][]string{}
+	}
+	p.txt[name] = append([]string(nil), values...)
+	return nil
+}
+
+func (p *Provider) GetSRVRecords(ctx context.Context, name string) ([]ddns.SRV, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ddns.SRV(nil), p.srv[name]...), nil
+}
+
+func (p *Provider) SetSRVRecords(ctx context.Context, name string, records []ddns.SRV) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.srv == nil {
+		p.srv = map[string][]ddns.SRV{}
+	}
+	p.srv[name] = append([]ddns.SRV(nil), records...)
+	return nil
+}
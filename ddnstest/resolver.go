@@ -0,0 +1,38 @@
+package ddnstest
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+)
+
+// Resolver is a [ddns.Resolver] whose addresses are set by the test,
+// e.g. to simulate an interface gaining and losing addresses.
+//
+// It is safe for concurrent use.
+// The zero value resolves no addresses.
+type Resolver struct {
+	mu    sync.Mutex
+	addrs []netip.Addr
+	err   error
+}
+
+// Set sets the addresses returned by Resolve.
+func (r *Resolver) Set(addrs ...netip.Addr) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addrs, r.err = append([]netip.Addr(nil), addrs...), nil
+}
+
+// Fail makes Resolve return err until Set is called.
+func (r *Resolver) Fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.addrs, r.err = nil, err
+}
+
+func (r *Resolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]netip.Addr(nil), r.addrs...), r.err
+}
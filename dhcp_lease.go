@@ -0,0 +1,187 @@
+package ddns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DHCPLeaseResolver constructs a resolver that returns the address assigned by the DHCP lease in the file at path,
+// for routers whose WAN address is kept in the DHCP client's lease state.
+//
+// Two formats are read:
+//   - dhclient lease files (e.g. /var/lib/dhcp/dhclient.eth0.leases), using the last lease of each family.
+//     IPv4 leases which have expired are skipped.
+//   - KEY=value files, such as the systemd-networkd lease files in /run/systemd/netif/leases,
+//     or the environment of a udhcpc script saved with e.g. env > /tmp/udhcpc.eth0.
+//     The ADDRESS, ip, new_ip_address, and new_ip6_address keys are used.
+func DHCPLeaseResolver(path string) Resolver {
+	return leaseResolver(path)
+}
+
+type leaseResolver string
+
+func (path leaseResolver) Resolve(context.Context) ([]netip.Addr, error) {
+	b, err := os.ReadFile(string(path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read DHCP lease: %w", err)
+	}
+	var addrs []netip.Addr
+	if isDhclientLease(string(b)) {
+		addrs, err = parseDhclientLeases(bytes.NewReader(b), time.Now())
+	} else {
+		addrs, err = parseLeaseEnv(strings.Split(string(b), "\n"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DHCP lease %s: %w", path, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no address found in DHCP lease %s", path)
+	}
+	return addrs, nil
+}
+
+// DHCPHookResolver constructs a resolver that returns the address given to a DHCP client hook in its environment:
+// the new_ip_address and new_ip6_address variables of a dhclient exit hook,
+// or the ip variable of a udhcpc script.
+//
+// It is meant for running a single update from the hook each time a lease is bound or renewed,
+// instead of polling on an interval.
+func DHCPHookResolver() Resolver {
+	return ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		addrs, err := parseLeaseEnv(os.Environ())
+		if err != nil {
+			return nil, fmt.Errorf("error parsing DHCP hook environment: %w", err)
+		}
+		if len(addrs) == 0 {
+			return nil, errors.New("no address found in DHCP hook environment")
+		}
+		return addrs, nil
+	})
+}
+
+// leaseKeys are the KEY=value names of leased addresses.
+var leaseKeys = map[string]bool{
+	"ADDRESS":         true, // systemd-networkd
+	"ip":              true, // udhcpc
+	"new_ip_address":  true, // dhclient
+	"new_ip6_address": true, // dhclient -6
+}
+
+// parseLeaseEnv returns the addresses in lines of KEY=value pairs.
+// Values may be quoted, as in the output of set.
+func parseLeaseEnv(lines []string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, line := range lines {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok || !leaseKeys[k] {
+			continue
+		}
+		v = strings.Trim(v, `"'`)
+		if v == "" {
+			continue
+		}
+		a, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %w", k, err)
+		}
+		addrs = append(addrs, a)
+	}
+	return canonicalAddrs(addrs), nil
+}
+
+func isDhclientLease(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "lease {" || line == "lease6 {" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDhclientLeases returns the address of the last unexpired IPv4 lease and the last IPv6 lease in a dhclient lease file.
+// dhclient appends each new lease to the file, so the last lease is the current one.
+//
+//	lease {
+//	  interface "eth0";
+//	  fixed-address 192.0.2.10;
+//	  expire 4 2024/01/04 12:00:00;
+//	}
+//	lease6 {
+//	  ia-na 1a:2b:3c:4d {
+//	    iaaddr 2001:db8::10 {
+//	      max-life 7200;
+//	    }
+//	  }
+//	}
+func parseDhclientLeases(r io.Reader, now time.Time) ([]netip.Addr, error) {
+	var v4, v6 netip.Addr
+	var lease netip.Addr // the address of the lease being read
+	var expired bool
+	depth := 0
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(s.Text()), ";"))
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "}":
+			depth--
+			if depth == 0 && lease.IsValid() && !expired {
+				if lease.Is4() {
+					v4 = lease
+				} else {
+					v6 = lease
+				}
+			}
+			continue
+		case depth == 0 && (fields[0] == "lease" || fields[0] == "lease6"):
+			lease, expired = netip.Addr{}, false
+		case fields[0] == "fixed-address" && len(fields) == 2, fields[0] == "iaaddr" && len(fields) >= 2:
+			a, err := netip.ParseAddr(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse %s: %w", fields[0], err)
+			}
+			lease = a
+		case fields[0] == "expire" && depth == 1:
+			expired = leaseExpired(fields[1:], now)
+		}
+		if fields[len(fields)-1] == "{" {
+			depth++
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	for _, a := range []netip.Addr{v4, v6} {
+		if a.IsValid() {
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs, nil
+}
+
+// leaseExpired reports whether the date of a dhclient expire statement is before now.
+// Dates are given as "weekday yyyy/mm/dd hh:mm:ss" in UTC, "epoch seconds", or "never".
+func leaseExpired(date []string, now time.Time) bool {
+	switch {
+	case len(date) == 2 && date[0] == "epoch":
+		sec, err := strconv.ParseInt(date[1], 10, 64)
+		return err == nil && time.Unix(sec, 0).Before(now)
+	case len(date) == 3:
+		t, err := time.Parse("2006/01/02 15:04:05", date[1]+" "+date[2])
+		return err == nil && t.Before(now)
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestDHCPLeaseResolver(t *testing.T) {
+	tt := map[string]struct {
+		lease    string
+		expected []netip.Addr
+	}{
+		"dhclient": {`lease {
+  interface "eth0";
+  fixed-address 192.0.2.10;
+  option subnet-mask 255.255.255.0;
+  expire 4 2000/01/06 12:00:00;
+}
+lease {
+  interface "eth0";
+  fixed-address 192.0.2.11;
+  renew 3 2099/01/07 10:00:00;
+  expire 3 2099/01/07 12:00:00;
+}
+lease {
+  interface "eth0";
+  fixed-address 192.0.2.12;
+  expire epoch 946728000;
+}
+lease6 {
+  interface "eth0";
+  ia-na 1a:2b:3c:4d {
+    starts 1700000000;
+    iaaddr 2001:db8::10 {
+      starts 1700000000;
+      max-life 7200;
+    }
+  }
+}
+`, []netip.Addr{netip.MustParseAddr("192.0.2.11"), netip.MustParseAddr("2001:db8::10")}},
+		"systemd-networkd": {`# This is private data. Do not parse.
+ADDRESS=192.0.2.20
+NETMASK=255.255.255.0
+ROUTER=192.0.2.1
+`, []netip.Addr{netip.MustParseAddr("192.0.2.20")}},
+		"udhcpc": {"interface=eth0\nip='192.0.2.30'\nrouter=192.0.2.1\n", []netip.Addr{netip.MustParseAddr("192.0.2.30")}},
+	}
+	for name, tc := range tt {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lease")
+			os.WriteFile(path, []byte(tc.lease), 0600)
+			addrs, err := ddns.DHCPLeaseResolver(path).Resolve(context.Background())
+			if err != nil {
+				t.Fatalf("Resolve returned an error: %s", err)
+			}
+			if !reflect.DeepEqual(addrs, tc.expected) {
+				t.Errorf("Expected %q; got %q", tc.expected, addrs)
+			}
+		})
+	}
+
+	expired := filepath.Join(t.TempDir(), "lease")
+	os.WriteFile(expired, []byte("lease {\n  fixed-address 192.0.2.10;\n  expire 4 2000/01/06 12:00:00;\n}\n"), 0600)
+	if _, err := ddns.DHCPLeaseResolver(expired).Resolve(context.Background()); err == nil {
+		t.Errorf("Expected an error for an expired lease")
+	}
+}
+
+func TestDHCPHookResolver(t *testing.T) {
+	t.Setenv("reason", "BOUND")
+	t.Setenv("new_ip_address", "192.0.2.40")
+	addrs, err := ddns.DHCPHookResolver().Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %s", err)
+	}
+	if expected := []netip.Addr{netip.MustParseAddr("192.0.2.40")}; !reflect.DeepEqual(addrs, expected) {
+		t.Errorf("Expected %q; got %q", expected, addrs)
+	}
+}
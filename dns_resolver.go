@@ -0,0 +1,295 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSResolver constructs a resolver which returns the addresses of an existing DNS name,
+// so that they are republished under the managed domain.
+// This works like a CNAME which is flattened by the client, for providers which can't flatten CNAME records.
+//
+// recordType is "A" or "AAAA" to look up one address family, or "" for both.
+// The system resolver is used unless a name server is given with [DNSServer], [DNSOverTLS], or [DNSOverHTTPS].
+//
+//	ddns.DNSResolver("other.example.net", "A", ddns.DNSOverHTTPS("https://cloudflare-dns.com/dns-query"))
+func DNSResolver(name, recordType string, options ...dnsOption) Resolver {
+	r := &dnsResolver{name: name}
+	switch strings.ToUpper(recordType) {
+	case "A":
+		r.types = []dnsmessage.Type{dnsmessage.TypeA}
+	case "AAAA":
+		r.types = []dnsmessage.Type{dnsmessage.TypeAAAA}
+	case "":
+		r.types = []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA}
+	default:
+		r.err = fmt.Errorf("unsupported record type \"%s\"", recordType)
+	}
+	for _, opt := range options {
+		if err := opt(&r.lookup); err != nil {
+			r.err = err
+		}
+	}
+	return r
+}
+
+type dnsResolver struct {
+	name   string
+	types  []dnsmessage.Type
+	lookup dnsLookup
+	err    error // configuration error, returned by Resolve
+}
+
+func (r *dnsResolver) validate() error { return r.err }
+
+func (r *dnsResolver) lookupURLs() []*url.URL {
+	if u, err := url.Parse(r.lookup.dohURL); err == nil && r.lookup.dohURL != "" {
+		return []*url.URL{u}
+	}
+	return nil
+}
+
+func (r *dnsResolver) SetHTTPClient(httpclient *http.Client) {
+	// an http client given with DNSHTTPClient takes precedence
+	if r.lookup.httpClient == nil {
+		r.lookup.httpClient = httpclient
+	}
+}
+
+func (r *dnsResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	addrs, err := r.lookup.lookup(ctx, r.name, r.types)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", r.name)
+	}
+	return addrs, nil
+}
+
+// LookupIP returns the IPv4 and IPv6 addresses of name, e.g. to check whether an update has propagated.
+// The system resolver is used unless a name server is given with [DNSServer], [DNSOverTLS], or [DNSOverHTTPS].
+// A name which does not exist has no addresses and is not an error.
+func LookupIP(ctx context.Context, name string, options ...dnsOption) ([]netip.Addr, error) {
+	var l dnsLookup
+	for _, opt := range options {
+		if err := opt(&l); err != nil {
+			return nil, err
+		}
+	}
+	return l.lookup(ctx, name, []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA})
+}
+
+type dnsOption func(*dnsLookup) error
+
+// DNSServer configures DNS lookups to query the name server at addr (host:port) instead of the system resolver.
+func DNSServer(addr string) dnsOption {
+	return func(l *dnsLookup) error {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("invalid name server address: %w", err)
+		}
+		l.server = addr
+		return nil
+	}
+}
+
+// DNSOverTLS configures DNS lookups to use the DNS-over-TLS (RFC 7858) name server at addr,
+// e.g. "1.1.1.1:853" or "dns.example.net". The port defaults to 853.
+//
+// The TLS configuration of the [DNSHTTPClient] transport is used, if any,
+// so that a custom certificate pool applies to DNS lookups as well.
+func DNSOverTLS(addr string) dnsOption {
+	return func(l *dnsLookup) error {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			addr = net.JoinHostPort(addr, "853")
+		}
+		l.server, l.tls = addr, true
+		return nil
+	}
+}
+
+// DNSOverHTTPS configures DNS lookups to use the DNS-over-HTTPS (RFC 8484) endpoint at url,
+// e.g. "https://cloudflare-dns.com/dns-query".
+// Requests are made with the client's http.Client.
+func DNSOverHTTPS(url string) dnsOption {
+	return func(l *dnsLookup) error {
+		if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			return fmt.Errorf("invalid DNS-over-HTTPS url \"%s\"", url)
+		}
+		l.dohURL = url
+		return nil
+	}
+}
+
+// DNSHTTPClient configures DNS lookups to use httpclient for DNS-over-HTTPS requests
+// and the TLS configuration of its transport for DNS-over-TLS.
+// Resolvers otherwise use the http.Client given to [UsingHTTPClient].
+func DNSHTTPClient(httpclient *http.Client) dnsOption {
+	return func(l *dnsLookup) error {
+		if httpclient == nil {
+			return errors.New("http client cannot be nil")
+		}
+		l.httpClient = httpclient
+		return nil
+	}
+}
+
+// dnsLookup looks up records using the configured upstream.
+type dnsLookup struct {
+	server     string // host:port of a name server
+	tls        bool   // whether server is DNS-over-TLS
+	dohURL     string
+	httpClient *http.Client
+}
+
+// lookup returns the addresses of each of types for name.
+func (l *dnsLookup) lookup(ctx context.Context, name string, types []dnsmessage.Type) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, t := range types {
+		a, err := l.addrs(ctx, name, t)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up %s: %w", name, err)
+		}
+		addrs = append(addrs, a...)
+	}
+	return addrs, nil
+}
+
+// tlsConfig returns the TLS configuration for a DNS-over-TLS connection to host.
+func (l *dnsLookup) tlsConfig(host string) *tls.Config {
+	config := &tls.Config{}
+	if l.httpClient != nil {
+		if t, ok := l.httpClient.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+			config = t.TLSClientConfig.Clone()
+		}
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	return config
+}
+
+// addrs returns the addresses of type t (A or AAAA) for name.
+// A name which does not exist has no addresses.
+func (l *dnsLookup) addrs(ctx context.Context, name string, t dnsmessage.Type) ([]netip.Addr, error) {
+	if l.dohURL != "" {
+		return l.exchangeHTTPS(ctx, name, t)
+	}
+	network := "ip4"
+	if t == dnsmessage.TypeAAAA {
+		network = "ip6"
+	}
+	r := net.DefaultResolver
+	if l.server != "" {
+		r = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				dial := dialer(l.httpClient)
+				if !l.tls {
+					return dial(ctx, network, l.server)
+				}
+				// the resolver uses TCP framing for connections which aren't a net.PacketConn
+				conn, err := dial(ctx, "tcp", l.server)
+				if err != nil {
+					return nil, err
+				}
+				host, _, _ := net.SplitHostPort(l.server)
+				tlsConn := tls.Client(conn, l.tlsConfig(host))
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					conn.Close()
+					return nil, err
+				}
+				return tlsConn, nil
+			},
+		}
+	}
+	addrs, err := r.LookupNetIP(ctx, network, name)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, nil
+	}
+	for i := range addrs {
+		addrs[i] = addrs[i].Unmap()
+	}
+	return addrs, err
+}
+
+// exchangeHTTPS sends a query for name over DNS-over-HTTPS.
+func (l *dnsLookup) exchangeHTTPS(ctx context.Context, name string, t dnsmessage.Type) ([]netip.Addr, error) {
+	qname, err := dnsmessage.NewName(canonicalName(name) + ".")
+	if err != nil {
+		return nil, fmt.Errorf("invalid name: %w", err)
+	}
+	// the ID is zero as recommended by RFC 8484 for HTTP caching
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: qname, Type: t, Class: dnsmessage.ClassINET}},
+	}
+	b, err := msg.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("error packing query: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.dohURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	setUserAgent(req)
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	httpClient := l.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+	var reply dnsmessage.Message
+	if err := reply.Unpack(body); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	switch reply.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, nil
+	default:
+		return nil, &dnsError{op: "query", rcode: reply.RCode}
+	}
+	// answers may include the CNAME chain, which is skipped
+	var addrs []netip.Addr
+	for _, ans := range reply.Answers {
+		switch body := ans.Body.(type) {
+		case *dnsmessage.AResource:
+			if t == dnsmessage.TypeA {
+				addrs = append(addrs, netip.AddrFrom4(body.A))
+			}
+		case *dnsmessage.AAAAResource:
+			if t == dnsmessage.TypeAAAA {
+				addrs = append(addrs, netip.AddrFrom16(body.AAAA).Unmap())
+			}
+		}
+	}
+	return addrs, nil
+}
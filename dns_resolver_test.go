@@ -0,0 +1,151 @@
+package ddns_test
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dohServer answers DNS-over-HTTPS queries for other.example.net with a CNAME chain to its addresses.
+func dohServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		var msg dnsmessage.Message
+		if err := msg.Unpack(b); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q := msg.Questions[0]
+		msg.Header.Response = true
+		if q.Name.String() != "other.example.net." {
+			msg.Header.RCode = dnsmessage.RCodeNameError
+		} else {
+			target := dnsmessage.MustNewName("host.example.net.")
+			msg.Answers = append(msg.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET},
+				Body:   &dnsmessage.CNAMEResource{CNAME: target},
+			})
+			h := dnsmessage.ResourceHeader{Name: target, Type: q.Type, Class: dnsmessage.ClassINET}
+			switch q.Type {
+			case dnsmessage.TypeA:
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: h, Body: &dnsmessage.AResource{A: [4]byte{203, 0, 113, 5}}})
+			case dnsmessage.TypeAAAA:
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{Header: h, Body: &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}})
+			}
+		}
+		out, err := msg.Pack()
+		if err != nil {
+			t.Errorf("error packing response: %s", err)
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(out)
+	}))
+}
+
+func TestDNSResolverHTTPS(t *testing.T) {
+	srv := dohServer(t)
+	defer srv.Close()
+
+	tests := []struct {
+		name, recordType string
+		want             string
+		wantErr          bool
+	}{
+		{"other.example.net", "A", "[203.0.113.5]", false},
+		{"other.example.net", "AAAA", "[2001:db8::1]", false},
+		{"other.example.net", "", "[203.0.113.5 2001:db8::1]", false},
+		{"missing.example.net", "A", "[]", true},
+		{"other.example.net", "MX", "[]", true},
+	}
+	for _, tt := range tests {
+		addrs, err := ddns.DNSResolver(tt.name, tt.recordType, ddns.DNSOverHTTPS(srv.URL)).Resolve(context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s %s: Expected error %t; got %v", tt.name, tt.recordType, tt.wantErr, err)
+		}
+		if got := fmt.Sprint(addrs); got != tt.want && !tt.wantErr {
+			t.Errorf("%s %s: Expected %s; got %s", tt.name, tt.recordType, tt.want, got)
+		}
+	}
+}
+
+func TestLookupIPOverTLS(t *testing.T) {
+	// borrow the test certificate and a client which trusts it from an httptest server
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: srv.TLS.Certificates})
+	if err != nil {
+		t.Fatalf("error listening: %s", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveDNSStream(conn)
+		}
+	}()
+
+	addrs, err := ddns.LookupIP(context.Background(), "other.example.net",
+		ddns.DNSOverTLS(ln.Addr().String()),
+		ddns.DNSHTTPClient(srv.Client()),
+	)
+	if err != nil {
+		t.Fatalf("LookupIP failed: %s", err)
+	}
+	if got, want := fmt.Sprint(addrs), "[203.0.113.5]"; got != want {
+		t.Errorf("Expected %s; got %s", want, got)
+	}
+
+	if _, err := ddns.LookupIP(context.Background(), "other.example.net", ddns.DNSOverTLS(ln.Addr().String())); err == nil {
+		t.Errorf("Expected an error for an untrusted certificate")
+	}
+}
+
+// serveDNSStream answers length-prefixed DNS queries on conn with an A record for every name.
+func serveDNSStream(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var n uint16
+		if err := binary.Read(conn, binary.BigEndian, &n); err != nil {
+			return
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(conn, b); err != nil {
+			return
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(b); err != nil {
+			return
+		}
+		msg.Header.Response = true
+		for _, q := range msg.Questions {
+			if q.Type == dnsmessage.TypeA {
+				msg.Answers = append(msg.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET},
+					Body:   &dnsmessage.AResource{A: [4]byte{203, 0, 113, 5}},
+				})
+			}
+		}
+		out, err := msg.Pack()
+		if err != nil {
+			return
+		}
+		conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(out))))
+		conn.Write(out)
+	}
+}
@@ -0,0 +1,81 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// Changes describes the difference between the records published for a domain and the desired records.
+type Changes struct {
+	Create []netip.Addr
+	Delete []netip.Addr
+	Keep   []netip.Addr
+}
+
+// Empty reports whether there are no records to create or delete.
+func (c Changes) Empty() bool {
+	return len(c.Create) == 0 && len(c.Delete) == 0
+}
+
+// Diff compares the existing records for a domain with the desired records.
+// The changes are sorted by address.
+func Diff(existing, desired []netip.Addr) Changes {
+	existing, desired = sortAddrs(existing), sortAddrs(desired)
+	// both lists are sorted, so they can be merged without building sets
+	var ch Changes
+	i, j := 0, 0
+	for i < len(existing) || j < len(desired) {
+		switch {
+		case j > 0 && j < len(desired) && desired[j] == desired[j-1]:
+			j++
+		case j == len(desired) || i < len(existing) && existing[i].Less(desired[j]):
+			ch.Delete = append(ch.Delete, existing[i])
+			i++
+		case i == len(existing) || desired[j].Less(existing[i]):
+			ch.Create = append(ch.Create, desired[j])
+			j++
+		default:
+			ch.Keep = append(ch.Keep, existing[i])
+			// duplicates of a kept record are neither kept again nor deleted
+			for i < len(existing) && existing[i] == desired[j] {
+				i++
+			}
+			j++
+		}
+	}
+	return ch
+}
+
+// DryRun configures the client to log the changes it would make instead of applying them.
+//
+// The Provider must implement [RecordGetter].
+func DryRun() clientOption {
+	return func(c *client) error {
+		c.dryRun = true
+		return nil
+	}
+}
+
+// logChanges logs the changes that publishing records would make.
+func (c *client) logChanges(ctx context.Context, records []netip.Addr) error {
+	rg, ok := c.Provider.(RecordGetter)
+	if !ok {
+		return errGetRecordsUnsupported
+	}
+	existing, err := rg.GetDNSRecords(ctx, c.domain)
+	if err != nil {
+		return fmt.Errorf("error getting existing records: %w", err)
+	}
+	ch := Diff(existing, records)
+	if ch.Empty() {
+		c.logger.Printf("dry run: records for %s are up to date\n", c.domain)
+	}
+	for _, a := range ch.Delete {
+		c.logger.Printf("dry run: would delete record %s for %s\n", a, c.domain)
+	}
+	for _, a := range ch.Create {
+		c.logger.Printf("dry run: would create record %s for %s\n", a, c.domain)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestDiff(t *testing.T) {
+	a, b, c := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1")
+	ch := ddns.Diff([]netip.Addr{a, b}, []netip.Addr{b, c})
+	if len(ch.Create) != 1 || ch.Create[0] != c {
+		t.Fatalf("Expected to create %s; got %q", c, ch.Create)
+	}
+	if len(ch.Delete) != 1 || ch.Delete[0] != a {
+		t.Fatalf("Expected to delete %s; got %q", a, ch.Delete)
+	}
+	if len(ch.Keep) != 1 || ch.Keep[0] != b {
+		t.Fatalf("Expected to keep %s; got %q", b, ch.Keep)
+	}
+}
+
+func TestDiffDuplicates(t *testing.T) {
+	a, b, c := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1")
+	ch := ddns.Diff([]netip.Addr{b, a, b, a}, []netip.Addr{c, b, c, b})
+	if expected, got := "[2001:db8::1]", fmt.Sprint(ch.Create); expected != got {
+		t.Errorf("Expected to create %s; got %s", expected, got)
+	}
+	if expected, got := "[192.0.2.1 192.0.2.1]", fmt.Sprint(ch.Delete); expected != got {
+		t.Errorf("Expected to delete %s; got %s", expected, got)
+	}
+	if expected, got := "[192.0.2.2]", fmt.Sprint(ch.Keep); expected != got {
+		t.Errorf("Expected to keep %s; got %s", expected, got)
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	existing := []netip.Addr{
+		netip.MustParseAddr("2001:db8::2"),
+		netip.MustParseAddr("203.0.113.5"),
+		netip.MustParseAddr("192.0.2.1"),
+	}
+	desired := []netip.Addr{
+		netip.MustParseAddr("203.0.113.6"),
+		netip.MustParseAddr("2001:db8::2"),
+		netip.MustParseAddr("192.0.2.1"),
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ddns.Diff(existing, desired)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	existing := netip.MustParseAddr("192.0.2.1")
+	p.SetDNSRecords(ctx, "example.com", []netip.Addr{existing})
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.2"))),
+		ddns.DryRun(),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("example.com"); len(got) != 1 || got[0] != existing {
+		t.Fatalf("Expected dry run to leave records unchanged; got %q", got)
+	}
+}
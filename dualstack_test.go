@@ -0,0 +1,116 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// runAndCollect runs c once and returns its record changes as "create"/"delete" strings.
+func runAndCollect(t *testing.T, c ddns.DDNSClient, events chan ddns.Event) []string {
+	t.Helper()
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	var changes []string
+	for {
+		select {
+		case e := <-events:
+			switch e := e.(type) {
+			case ddns.RecordCreated:
+				changes = append(changes, "create "+e.Addr.String())
+			case ddns.RecordDeleted:
+				changes = append(changes, "delete "+e.Addr.String())
+			}
+		default:
+			return changes
+		}
+	}
+}
+
+// TestDualStackWebResolver follows the A and AAAA records for a host with both address families
+// from IP lookup services, through the client, to the provider.
+func TestDualStackWebResolver(t *testing.T) {
+	v4 := ddnstest.NewIPService(netip.MustParseAddr("203.0.113.5"))
+	defer v4.Close()
+	v6 := ddnstest.NewIPService(netip.MustParseAddr("2001:db8:1::5"))
+	defer v6.Close()
+	p := &ddnstest.Provider{}
+	events := make(chan ddns.Event, 32)
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.Join(ddns.WebResolver(v4.URL), ddns.WebResolver(v6.URL))),
+		ddns.WithEvents(events),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	steps := []struct {
+		name    string
+		change  func()
+		records string
+		changes string
+	}{
+		{"first update", func() {}, "[203.0.113.5 2001:db8:1::5]", "[create 203.0.113.5 create 2001:db8:1::5]"},
+		{"no change", func() {}, "[203.0.113.5 2001:db8:1::5]", "[]"},
+		{"IPv6 prefix renumbered", func() { v6.SetAddr(netip.MustParseAddr("2001:db8:2::5")) }, "[203.0.113.5 2001:db8:2::5]", "[delete 2001:db8:1::5 create 2001:db8:2::5]"},
+		{"IPv4 changed", func() { v4.SetAddr(netip.MustParseAddr("203.0.113.6")) }, "[203.0.113.6 2001:db8:2::5]", "[delete 203.0.113.5 create 203.0.113.6]"},
+	}
+	for _, step := range steps {
+		step.change()
+		changes := runAndCollect(t, c, events)
+		if got := fmt.Sprint(changes); got != step.changes {
+			t.Errorf("%s: Expected changes %s; got %s", step.name, step.changes, got)
+		}
+		if got := fmt.Sprint(p.Records("home.example.com")); got != step.records {
+			t.Errorf("%s: Expected records %s; got %s", step.name, step.records, got)
+		}
+	}
+
+	// a failing lookup service must not remove the records of its family
+	v6.SetAddr(netip.Addr{})
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Errorf("Expected an error when the IPv6 lookup service fails; got err == nil")
+	}
+	if expected, got := "[203.0.113.6 2001:db8:2::5]", fmt.Sprint(p.Records("home.example.com")); expected != got {
+		t.Errorf("Expected records %s to be kept after a failed lookup; got %s", expected, got)
+	}
+}
+
+// TestDualStackAAAALifecycle follows the AAAA record as a host gains and loses its IPv6 address.
+func TestDualStackAAAALifecycle(t *testing.T) {
+	r := &ddnstest.Resolver{}
+	p := &ddnstest.Provider{}
+	events := make(chan ddns.Event, 32)
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r), ddns.WithEvents(events))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	a := netip.MustParseAddr("192.0.2.1")
+	steps := []struct {
+		name    string
+		addrs   []netip.Addr
+		records string
+		changes string
+	}{
+		{"IPv4 only", []netip.Addr{a}, "[192.0.2.1]", "[create 192.0.2.1]"},
+		{"IPv6 added", []netip.Addr{a, netip.MustParseAddr("2001:db8::1")}, "[192.0.2.1 2001:db8::1]", "[create 2001:db8::1]"},
+		{"second IPv6 added", []netip.Addr{netip.MustParseAddr("2001:db8::2"), a, netip.MustParseAddr("2001:db8::1")}, "[192.0.2.1 2001:db8::1 2001:db8::2]", "[create 2001:db8::2]"},
+		{"IPv6 lost", []netip.Addr{a}, "[192.0.2.1]", "[delete 2001:db8::1 delete 2001:db8::2]"},
+		{"IPv6 only", []netip.Addr{netip.MustParseAddr("2001:db8::3")}, "[2001:db8::3]", "[delete 192.0.2.1 create 2001:db8::3]"},
+	}
+	for _, step := range steps {
+		r.Set(step.addrs...)
+		changes := runAndCollect(t, c, events)
+		if got := fmt.Sprint(changes); got != step.changes {
+			t.Errorf("%s: Expected changes %s; got %s", step.name, step.changes, got)
+		}
+		if got := fmt.Sprint(p.Records("home.example.com")); got != step.records {
+			t.Errorf("%s: Expected records %s; got %s", step.name, step.records, got)
+		}
+	}
+}
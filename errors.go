@@ -0,0 +1,87 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// IsAuthError reports whether err was caused by credentials which were rejected,
+// or which are not authorized to make the change.
+// [RunDaemon] stops when an update fails with such an error, as retrying won't help until the credentials are fixed.
+//
+// Errors are classified by the methods IsAuthenticationError() bool and IsAuthorizationError() bool,
+// which custom Provider implementations may also implement.
+func IsAuthError(err error) bool {
+	return isAuthenticationError(err) || isAuthorizationError(err)
+}
+
+// IsRateLimited reports whether err was caused by a provider or service limiting the rate of requests,
+// in which case the next attempt should be delayed.
+//
+// Errors are classified by the method IsRateLimited() bool.
+func IsRateLimited(err error) bool {
+	var e interface{ IsRateLimited() bool }
+	return errors.As(err, &e) && e.IsRateLimited()
+}
+
+// IsTemporary reports whether err is likely to be resolved by retrying later,
+// such as a timeout, a network error, a server error, or rate limiting.
+//
+// Errors are classified by the methods IsTemporary() bool, Temporary() bool, and Timeout() bool.
+// The latter two are implemented by errors from the net package.
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsRateLimited(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var t interface{ IsTemporary() bool }
+	if errors.As(err, &t) && t.IsTemporary() {
+		return true
+	}
+	var nt interface{ Temporary() bool }
+	if errors.As(err, &nt) && nt.Temporary() {
+		return true
+	}
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
+
+func isAuthenticationError(err error) bool {
+	var e interface{ IsAuthenticationError() bool }
+	return errors.As(err, &e) && e.IsAuthenticationError()
+}
+
+func isAuthorizationError(err error) bool {
+	var e interface{ IsAuthorizationError() bool }
+	return errors.As(err, &e) && e.IsAuthorizationError()
+}
+
+// PanicError is returned by RunDDNS when a Resolver, Provider, or other part of an update panics,
+// so that a bug which only affects some updates doesn't stop a long-running daemon.
+type PanicError struct {
+	Value any    // the value passed to panic
+	Stack []byte // the stack trace of the goroutine which panicked
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("recovered from panic: %v", e.Value) }
+
+// Unwrap returns the value passed to panic if it is an error, such as a runtime.Error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recoverError recovers from a panic and sets *err to a [PanicError].
+// It must be deferred directly, e.g. defer recoverError(&err).
+//
+// Code which runs extensions such as resolvers and providers in a new goroutine should also defer it,
+// since RunDDNS can only recover from panics in its own goroutine.
+func recoverError(err *error) {
+	if v := recover(); v != nil {
+		*err = &PanicError{Value: v, Stack: debug.Stack()}
+	}
+}
@@ -0,0 +1,138 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		status                       int
+		auth, rateLimited, temporary bool
+	}{
+		{http.StatusUnauthorized, true, false, false},
+		{http.StatusForbidden, true, false, false},
+		{http.StatusTooManyRequests, false, true, true},
+		{http.StatusServiceUnavailable, false, false, true},
+		{http.StatusBadRequest, false, false, false},
+	}
+	for _, tt := range tests {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		p, err := ddns.NewHTTPProvider(srv.URL)()
+		if err != nil {
+			t.Fatalf("NewHTTPProvider returned an error: %s", err)
+		}
+		err = p.SetDNSRecords(context.Background(), "example.com", []netip.Addr{netip.MustParseAddr("192.0.2.1")})
+		srv.Close()
+		// wrap the error as the client does
+		err = fmt.Errorf("error updating example.com with new IPs: %w", err)
+		if got := ddns.IsAuthError(err); got != tt.auth {
+			t.Errorf("Status %d: expected IsAuthError to be %t; got %t", tt.status, tt.auth, got)
+		}
+		if got := ddns.IsRateLimited(err); got != tt.rateLimited {
+			t.Errorf("Status %d: expected IsRateLimited to be %t; got %t", tt.status, tt.rateLimited, got)
+		}
+		if got := ddns.IsTemporary(err); got != tt.temporary {
+			t.Errorf("Status %d: expected IsTemporary to be %t; got %t", tt.status, tt.temporary, got)
+		}
+	}
+}
+
+func TestIsTemporary(t *testing.T) {
+	timeout := &net.DNSError{Err: "i/o timeout", IsTimeout: true}
+	if !ddns.IsTemporary(fmt.Errorf("error getting IPs: %w", timeout)) {
+		t.Errorf("Expected a DNS timeout to be temporary")
+	}
+	if !ddns.IsTemporary(context.DeadlineExceeded) {
+		t.Errorf("Expected %q to be temporary", context.DeadlineExceeded)
+	}
+	if ddns.IsTemporary(errors.New("invalid address")) || ddns.IsTemporary(nil) {
+		t.Errorf("Expected other errors not to be temporary")
+	}
+}
+
+func TestRunDDNSRecoversPanic(t *testing.T) {
+	ctx := context.Background()
+	panicking := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		var addrs []netip.Addr
+		return addrs[:1], nil
+	})
+	tests := []struct {
+		name     string
+		resolver ddns.Resolver
+		provider ddns.Provider
+	}{
+		{"resolver", panicking, &ddnstest.Provider{}},
+		{"joined resolver", ddns.Join(ddns.StaticIP(netip.MustParseAddr("203.0.113.5")), panicking), &ddnstest.Provider{}},
+		{"provider", ddns.StaticIP(netip.MustParseAddr("203.0.113.5")), panicProvider{}},
+	}
+	for _, tt := range tests {
+		c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(tt.provider), ddns.UsingResolver(tt.resolver))
+		if err != nil {
+			t.Fatalf("%s: New returned an error: %s", tt.name, err)
+		}
+		err = c.RunDDNS(ctx)
+		var pe *ddns.PanicError
+		if !errors.As(err, &pe) || len(pe.Stack) == 0 {
+			t.Errorf("%s: Expected a PanicError with a stack trace; got %v", tt.name, err)
+		}
+		// the client keeps working after a panic
+		if err := c.RunDDNS(ctx); !errors.As(err, &pe) {
+			t.Errorf("%s: Expected a PanicError from the second update; got %v", tt.name, err)
+		}
+	}
+}
+
+type panicProvider struct{}
+
+func (panicProvider) SetDNSRecords(context.Context, string, []netip.Addr) error {
+	panic("provider bug")
+}
+
+func TestRunDDNSInvalidAddr(t *testing.T) {
+	p := &ddnstest.Provider{}
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(ddns.StaticIP(netip.Addr{})))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err == nil {
+		t.Errorf("Expected an error for the zero netip.Addr")
+	}
+	if got := p.Records("home.example.com"); len(got) != 0 {
+		t.Errorf("Expected no records; got %v", got)
+	}
+}
+
+// txtLiar reports TXT support without implementing ddns.TXTProvider.
+type txtLiar struct{}
+
+func (txtLiar) Capabilities() ddns.Capabilities {
+	return ddns.Capabilities{IPv4: true, IPv6: true, TXT: true}
+}
+
+func (txtLiar) SetDNSRecords(context.Context, string, []netip.Addr) error { return nil }
+
+func TestRunDDNSMissingInterface(t *testing.T) {
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(txtLiar{}),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+		ddns.Heartbeat("primary"),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	var pe *ddns.PanicError
+	if err := c.RunDDNS(context.Background()); err == nil || errors.As(err, &pe) {
+		t.Errorf("Expected an error for the missing TXTProvider methods; got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package ddns
+
+import (
+	"context"
+	"net/netip"
+	"time"
+)
+
+// Event is a typed report of client activity sent to the channel given to [WithEvents].
+// The concrete types are [RunStarted], [Resolved], [RecordCreated], [RecordDeleted], and [UpdateFailed],
+// [NATDetected] if [DetectCGNAT] is given, and [ReachabilityChecked] if [CheckReachable] is given.
+// Each has the [RunInfo] of the update which sent it.
+type Event interface {
+	event()
+}
+
+// RunStarted is sent at the start of each update.
+type RunStarted struct {
+	Time   time.Time
+	Domain string
+	Run    RunInfo
+}
+
+// Resolved is sent when the resolver has returned the addresses for an update.
+type Resolved struct {
+	Time  time.Time
+	Addrs []netip.Addr
+	Run   RunInfo
+}
+
+// RecordCreated is sent for each record created by an update.
+type RecordCreated struct {
+	Time   time.Time
+	Domain string
+	Addr   netip.Addr
+	Run    RunInfo
+}
+
+// RecordDeleted is sent for each record deleted by an update.
+type RecordDeleted struct {
+	Time   time.Time
+	Domain string
+	Addr   netip.Addr
+	Run    RunInfo
+}
+
+// UpdateFailed is sent when an update returns an error.
+type UpdateFailed struct {
+	Time time.Time
+	Err  error
+	Run  RunInfo
+}
+
+func (RunStarted) event()    {}
+func (Resolved) event()      {}
+func (RecordCreated) event() {}
+func (RecordDeleted) event() {}
+func (UpdateFailed) event()  {}
+
+// WithEvents configures the client to send an [Event] to ch for its activity,
+// e.g. for a custom UI, metrics, or tests:
+//
+//	events := make(chan ddns.Event, 16)
+//	go func() {
+//		for e := range events {
+//			switch e := e.(type) {
+//			case ddns.RecordCreated:
+//				fmt.Println("created", e.Addr)
+//			case ddns.UpdateFailed:
+//				fmt.Println("failed:", e.Err)
+//			}
+//		}
+//	}()
+//
+// Sends block until ch is received from or the update's context is done,
+// so ch should be buffered or drained promptly.
+// Created and deleted records are found the same way as for [WithJournal].
+// The channel is never closed by the client.
+func WithEvents(ch chan<- Event) clientOption {
+	return func(c *client) error {
+		c.events = ch
+		return nil
+	}
+}
+
+// emit sends e to the events channel, if any.
+func (c *client) emit(ctx context.Context, e Event) {
+	if c.events == nil {
+		return
+	}
+	select {
+	case c.events <- e:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,103 @@
+package ddns_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	p.SetDNSRecords(ctx, "example.com", []netip.Addr{netip.MustParseAddr("192.0.2.1")})
+	var resolveErr error
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		return []netip.Addr{netip.MustParseAddr("192.0.2.2")}, resolveErr
+	})
+	events := make(chan ddns.Event, 16)
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r), ddns.WithEvents(events))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	resolveErr = errors.New("resolver failed")
+	c.RunDDNS(ctx)
+	close(events)
+
+	var got []string
+	for e := range events {
+		switch e := e.(type) {
+		case ddns.RunStarted:
+			got = append(got, "started "+e.Domain)
+		case ddns.Resolved:
+			got = append(got, fmt.Sprint("resolved ", e.Addrs))
+		case ddns.RecordCreated:
+			got = append(got, fmt.Sprint("created ", e.Addr))
+		case ddns.RecordDeleted:
+			got = append(got, fmt.Sprint("deleted ", e.Addr))
+		case ddns.UpdateFailed:
+			got = append(got, "failed")
+		}
+	}
+	want := []string{
+		"started example.com", "resolved [192.0.2.2]", "deleted 192.0.2.1", "created 192.0.2.2",
+		"started example.com", "failed",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected events %q; got %q", want, got)
+	}
+}
+
+func TestRunInfo(t *testing.T) {
+	ctx := context.Background()
+	var journal bytes.Buffer
+	events := make(chan ddns.Event, 16)
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(ddns.FromString("192.0.2.1")),
+		ddns.WithEvents(events),
+		ddns.WithJournal(&journal),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ddns.WithRunInfo(ctx, ddns.RunInfo{Trigger: "netlink"})); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	close(events)
+	var id string
+	for e := range events {
+		var run ddns.RunInfo
+		switch e := e.(type) {
+		case ddns.RunStarted:
+			run = e.Run
+		case ddns.Resolved:
+			run = e.Run
+		case ddns.RecordCreated:
+			run = e.Run
+		default:
+			t.Fatalf("Unexpected event %#v", e)
+		}
+		if id == "" {
+			id = run.ID
+		}
+		if run.ID == "" || run.ID != id || run.Trigger != "netlink" {
+			t.Errorf("Expected every event to have run %q triggered by %q; got %+v", id, "netlink", run)
+		}
+	}
+	var entry ddns.JournalEntry
+	if err := json.Unmarshal(journal.Bytes(), &entry); err != nil {
+		t.Fatalf("error decoding journal: %s", err)
+	}
+	if expected := (ddns.RunInfo{ID: id, Trigger: "netlink"}); entry.Run != expected {
+		t.Errorf("Expected journal entry for run %+v; got %+v", expected, entry.Run)
+	}
+}
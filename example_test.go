@@ -123,3 +123,35 @@ func ExampleResolverFunc() {
 		log.Fatalf("ddns update failed: %s", err)
 	}
 }
+
+func ExampleNewRFC2136() {
+	ddnsClient, err := ddns.New("pi1.home.example.com",
+		ddns.NewRFC2136("192.168.1.2:53",
+			ddns.TSIG("ddns-key", "hmac-sha256", os.Getenv("TSIG_SECRET")),
+			ddns.UpdatePTR(),
+		),
+	)
+	if err != nil {
+		log.Fatalf("error creating ddns client: %s", err)
+	}
+	// run once:
+	err = ddnsClient.RunDDNS(context.Background())
+	if err != nil {
+		log.Fatalf("ddns update failed: %s", err)
+	}
+}
+
+func ExampleUsingCloudflare() {
+	ddnsClient, err := ddns.New("dynamic-ip.example.com", nil,
+		ddns.UsingCloudflare(os.Getenv("CLOUDFLARE_ZONE_TOKEN")),
+		ddns.UsingWebResolver("https://ipv4.icanhazip.com/"),
+	)
+	if err != nil {
+		log.Fatalf("error creating ddns client: %s", err)
+	}
+	// run once:
+	err = ddnsClient.RunDDNS(context.Background())
+	if err != nil {
+		log.Fatalf("ddns update failed: %s", err)
+	}
+}
@@ -0,0 +1,4 @@
+package ddns
+
+// IfaceAddr is exported for the fuzz tests in package ddns_test.
+var IfaceAddr = ifaceAddr
@@ -0,0 +1,90 @@
+package ddns_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// checkAddrs fails the test unless every address is valid and could be published.
+func checkAddrs(t *testing.T, input any, addrs []netip.Addr) {
+	for _, a := range addrs {
+		if !a.IsValid() || a.Zone() != "" {
+			t.Errorf("%q: Expected a valid address without a zone; got %q", input, a)
+		}
+	}
+}
+
+func FuzzWebResolver(f *testing.F) {
+	f.Add("203.0.113.5\n")
+	f.Add("2001:db8::1")
+	f.Add("<html><head><title>Current IP Check</title></head><body>Current IP Address: 203.0.113.5</body></html>")
+	f.Add("IP Address: fe80::1%eth0")
+	f.Add("::ffff:203.0.113.5")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, body string) {
+		r, err := ddns.WebResolverStrict([]string{"https://ip.example.com"})
+		if err != nil {
+			t.Fatalf("WebResolverStrict returned an error: %s", err)
+		}
+		r.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(&http.Client{Transport: roundTripFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})})
+		addrs, err := r.Resolve(context.Background())
+		if err != nil {
+			return
+		}
+		checkAddrs(t, body, addrs)
+	})
+}
+
+func FuzzFromString(f *testing.F) {
+	f.Add("203.0.113.5")
+	f.Add("2001:db8::1")
+	f.Add("fe80::1%eth0")
+	f.Add("203.0.113.5/24")
+	f.Fuzz(func(t *testing.T, s string) {
+		addrs, err := ddns.FromString(s).Resolve(context.Background())
+		if err != nil {
+			return
+		}
+		if len(addrs) != 1 {
+			t.Errorf("%q: Expected 1 address; got %v", s, addrs)
+		}
+		checkAddrs(t, s, addrs)
+	})
+}
+
+// stringAddr is a net.Addr which is not one of the types from package net.
+type stringAddr struct{ network, s string }
+
+func (a stringAddr) Network() string { return a.network }
+func (a stringAddr) String() string  { return a.s }
+
+func FuzzIfaceAddr(f *testing.F) {
+	f.Add([]byte{192, 168, 86, 253}, []byte{255, 255, 255, 0}, "ip+net", "ip+net:192.168.86.253/24")
+	f.Add([]byte(net.ParseIP("fe80::2cc9:801b:3551:9a43")), []byte(net.CIDRMask(64, 128)), "ip+net", "fe80::2cc9:801b:3551:9a43/64")
+	f.Add([]byte(nil), []byte(nil), "ip", "fe80::1%eth0")
+	f.Fuzz(func(t *testing.T, ip, mask []byte, network, s string) {
+		for _, addr := range []net.Addr{
+			&net.IPNet{IP: ip, Mask: mask},
+			&net.IPAddr{IP: ip},
+			stringAddr{network, s},
+		} {
+			a, err := ddns.IfaceAddr(addr)
+			if err != nil {
+				continue
+			}
+			checkAddrs(t, addr, []netip.Addr{a})
+			if a.Is4In6() {
+				t.Errorf("%q: Expected an IPv4 address to be unmapped; got %s", addr, a)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// GateOn configures the client to only publish records while probe returns a nil error.
+//
+// The probe runs before each update.
+// When it fails, the client withdraws its records and RunDDNS returns an error wrapping the probe's error.
+// In [AppendMode] only this client's own addresses are withdrawn.
+// If [FallbackAddrs] is given then the fallback addresses are published instead.
+//
+// This is useful for a simple form of failover,
+// e.g. only advertising a host while its web server is actually listening.
+func GateOn(probe func(ctx context.Context) error) clientOption {
+	return func(c *client) error {
+		if probe == nil {
+			return errors.New("probe cannot be nil")
+		}
+		c.gate = probe
+		return nil
+	}
+}
+
+// FallbackAddrs configures the client to publish addrs when the resolver or [GateOn] health probe fails,
+// e.g. to point the domain at a VPS while the home connection is down.
+//
+// By default the fallback is published after the first failed run.
+// Use [FallbackAfter] to wait for more consecutive failures.
+// Until then the existing records are left alone.
+func FallbackAddrs(addrs ...netip.Addr) clientOption {
+	return func(c *client) error {
+		if len(addrs) == 0 {
+			return errors.New("at least one fallback address is required")
+		}
+		c.fallback = append([]netip.Addr(nil), addrs...)
+		return nil
+	}
+}
+
+// FallbackAfter sets the number of consecutive failed runs before [FallbackAddrs] are published.
+func FallbackAfter(n int) clientOption {
+	return func(c *client) error {
+		if n < 1 {
+			return errors.New("n must be at least 1")
+		}
+		c.fallbackAfter = n
+		return nil
+	}
+}
+
+// checkHealth runs the health probe.
+func (c *client) checkHealth(ctx context.Context) error {
+	if c.gate == nil {
+		return nil
+	}
+	if err := c.gate(ctx); err != nil {
+		c.logger.Printf("health check failed: %s\n", err)
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
+
+// fail records a failed health check or resolution.
+// The fallback addresses are published once enough consecutive runs have failed,
+// otherwise our records are withdrawn if withdraw is true.
+func (c *client) fail(ctx context.Context, cause error, withdraw bool) error {
+	c.failures++
+	if len(c.fallback) > 0 {
+		after := c.fallbackAfter
+		if after < 1 {
+			after = 1
+		}
+		if c.failures < after {
+			return cause
+		}
+		c.logger.Printf("%d consecutive failures; publishing fallback addresses %+v\n", c.failures, c.fallback)
+		if err := c.publish(ctx, c.fallback); err != nil {
+			return fmt.Errorf("%w; unable to publish fallback addresses: %w", cause, err)
+		}
+		return fmt.Errorf("%w; published fallback addresses", cause)
+	}
+	if !withdraw {
+		return cause
+	}
+	c.withdrawing = true
+	err := c.publish(ctx, nil)
+	c.withdrawing = false
+	if err != nil {
+		return fmt.Errorf("%w; unable to withdraw records: %w", cause, err)
+	}
+	return fmt.Errorf("%w; records withdrawn", cause)
+}
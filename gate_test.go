@@ -0,0 +1,113 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestGateOn(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	ip := netip.MustParseAddr("192.0.2.1")
+	errDown := errors.New("connection refused")
+	var healthErr error
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.GateOn(func(context.Context) error { return healthErr }),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("www.example.com"); len(got) != 1 {
+		t.Fatalf("Expected record to be published while healthy; got %q", got)
+	}
+
+	healthErr = errDown
+	if err := c.RunDDNS(ctx); !errors.Is(err, errDown) {
+		t.Fatalf("Expected RunDDNS to return the probe error; got %v", err)
+	}
+	if got := p.Records("www.example.com"); len(got) != 0 {
+		t.Fatalf("Expected records to be withdrawn while unhealthy; got %q", got)
+	}
+}
+
+func TestFallbackAddrs(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	ip, fallback := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("198.51.100.1")
+	var resolveErr error
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		return []netip.Addr{ip}, nil
+	})
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(r),
+		ddns.FallbackAddrs(fallback),
+		ddns.FallbackAfter(2),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+
+	resolveErr = errors.New("network unreachable")
+	if err := c.RunDDNS(ctx); err == nil {
+		t.Fatalf("Expected an error; got err == nil")
+	}
+	if got := p.Records("www.example.com"); len(got) != 1 || got[0] != ip {
+		t.Fatalf("Expected records to be kept before reaching the failure threshold; got %q", got)
+	}
+	if err := c.RunDDNS(ctx); !errors.Is(err, resolveErr) {
+		t.Fatalf("Expected RunDDNS to return the resolver error; got %v", err)
+	}
+	if got := p.Records("www.example.com"); len(got) != 1 || got[0] != fallback {
+		t.Fatalf("Expected fallback address after consecutive failures; got %q", got)
+	}
+
+	resolveErr = nil
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("www.example.com"); len(got) != 1 || got[0] != ip {
+		t.Fatalf("Expected records to recover after resolution succeeds; got %q", got)
+	}
+}
+
+func TestGateOnWithMaxDeletes(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	errDown := errors.New("connection refused")
+	var healthErr error
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("192.0.2.3"))),
+		ddns.GateOn(func(context.Context) error { return healthErr }),
+		ddns.MaxDeletes(1),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+
+	healthErr = errDown
+	err = c.RunDDNS(ctx)
+	if !errors.Is(err, errDown) || errors.Is(err, ddns.ErrMassDelete) {
+		t.Fatalf("Expected RunDDNS to return only the probe error; got %v", err)
+	}
+	if got := p.Records("www.example.com"); len(got) != 0 {
+		t.Fatalf("Expected records to be withdrawn despite MaxDeletes; got %q", got)
+	}
+}
@@ -3,15 +3,18 @@ module github.com/Travis-Britz/ddns
 go 1.20
 
 require (
+	filippo.io/age v1.1.1
 	github.com/cloudflare/cloudflare-go v0.66.0
 	golang.org/x/net v0.9.0
 	golang.org/x/term v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/google/go-querystring v1.1.0 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
 	github.com/hashicorp/go-retryablehttp v0.7.2 // indirect
+	golang.org/x/crypto v0.4.0 // indirect
 	golang.org/x/sys v0.7.0 // indirect
 	golang.org/x/text v0.9.0 // indirect
 	golang.org/x/time v0.3.0 // indirect
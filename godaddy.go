@@ -0,0 +1,214 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// godaddyAPI is the production GoDaddy API.
+const godaddyAPI = "https://api.godaddy.com"
+
+// NewGoDaddy is used by [ddns.New] to create a Provider which updates records using the GoDaddy Domains API.
+//
+// key and secret are a production API key pair created at https://developer.godaddy.com/keys.
+// The zone of each domain is derived with [ZoneFromDomain],
+// and all A or AAAA records for the name are replaced at once with the PUT records endpoint.
+// GoDaddy requires a TTL of at least 600 seconds, which is also the default.
+func NewGoDaddy(key, secret string) func() (Provider, error) {
+	return func() (Provider, error) {
+		if key == "" || secret == "" {
+			return nil, errors.New("API key and secret cannot be empty")
+		}
+		u, _ := url.Parse(godaddyAPI)
+		return &godaddyProvider{server: u, key: key, secret: secret, logger: discard, ttl: 600}, nil
+	}
+}
+
+type godaddyProvider struct {
+	httpClient *http.Client
+	logger     *log.Logger
+	server     *url.URL
+	key        string
+	secret     string
+	ttl        int
+}
+
+func (p *godaddyProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *godaddyProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *godaddyProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, TTL: true, MinTTL: 600 * time.Second}
+}
+
+func (p *godaddyProvider) SetTTL(ttl time.Duration) {
+	p.ttl = ttlSeconds(ttl)
+}
+
+// godaddyRecord is a record in the GoDaddy API.
+type godaddyRecord struct {
+	Data string `json:"data"`
+	Name string `json:"name,omitempty"`
+	TTL  int    `json:"ttl,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+func (p *godaddyProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	existing, err := p.GetDNSRecords(ctx, domain)
+	if err != nil {
+		return err
+	}
+	for _, typ := range []string{"A", "AAAA"} {
+		have, want := addrsOfType(existing, typ), addrsOfType(canonicalAddrs(records), typ)
+		if Diff(have, want).Empty() {
+			continue
+		}
+		path, err := godaddyPath(domain, typ)
+		if err != nil {
+			return err
+		}
+		if len(want) == 0 {
+			p.logger.Printf("deleting %s records for %s...\n", typ, domain)
+			if err := p.call(ctx, http.MethodDelete, path, nil, nil); err != nil {
+				return fmt.Errorf("unable to delete %s records: %w", typ, err)
+			}
+			continue
+		}
+		body := make([]godaddyRecord, len(want))
+		for i, a := range want {
+			body[i] = godaddyRecord{Data: a.String(), TTL: p.ttl}
+		}
+		p.logger.Printf("replacing %s records for %s with %+v...\n", typ, domain, want)
+		if err := p.call(ctx, http.MethodPut, path, body, nil); err != nil {
+			return fmt.Errorf("unable to replace %s records: %w", typ, err)
+		}
+	}
+	return nil
+}
+
+func (p *godaddyProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, typ := range []string{"A", "AAAA"} {
+		path, err := godaddyPath(domain, typ)
+		if err != nil {
+			return nil, err
+		}
+		var records []godaddyRecord
+		if err := p.call(ctx, http.MethodGet, path, nil, &records); err != nil {
+			return nil, fmt.Errorf("unable to get %s records for %s: %w", typ, domain, err)
+		}
+		for _, r := range records {
+			a, err := netip.ParseAddr(r.Data)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing IP from record: %w", err)
+			}
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs, nil
+}
+
+// godaddyPath returns the API path of the records of type typ for domain,
+// e.g. "/v1/domains/example.com/records/A/home" for home.example.com.
+func godaddyPath(domain, typ string) (string, error) {
+	zone, err := ZoneFromDomain(domain, nil)
+	if err != nil {
+		return "", err
+	}
+	name := "@"
+	if d := canonicalName(domain); d != zone {
+		name = strings.TrimSuffix(d, "."+zone)
+	}
+	return "/v1/domains/" + zone + "/records/" + typ + "/" + name, nil
+}
+
+// addrsOfType returns the addresses in addrs for records of type typ, "A" or "AAAA".
+func addrsOfType(addrs []netip.Addr, typ string) []netip.Addr {
+	var matched []netip.Addr
+	for _, a := range addrs {
+		if a.Unmap().Is4() == (typ == "A") {
+			matched = append(matched, a.Unmap())
+		}
+	}
+	return matched
+}
+
+// call makes an API request with body encoded as JSON, and decodes the response into result if it is not nil.
+func (p *godaddyProvider) call(ctx context.Context, method, path string, body, result any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	u := *p.server
+	u.Path = path
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	req.Header.Set("Authorization", "sso-key "+p.key+":"+p.secret)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &godaddyError{status: resp.StatusCode, msg: godaddyErrorMessage(resp)}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+func godaddyErrorMessage(resp *http.Response) string {
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(b, &body) != nil || body.Message == "" {
+		return "unexpected response status " + resp.Status
+	}
+	return fmt.Sprintf("%s: %s", body.Code, body.Message)
+}
+
+type godaddyError struct {
+	status int
+	msg    string
+}
+
+func (e *godaddyError) Error() string               { return e.msg }
+func (e *godaddyError) IsAuthenticationError() bool { return e.status == http.StatusUnauthorized }
+func (e *godaddyError) IsAuthorizationError() bool  { return e.status == http.StatusForbidden }
+func (e *godaddyError) IsRateLimited() bool         { return e.status == http.StatusTooManyRequests }
+func (e *godaddyError) IsTemporary() bool           { return e.status >= 500 }
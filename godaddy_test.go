@@ -0,0 +1,112 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// fakeGoDaddy is a minimal in-memory implementation of the GoDaddy records endpoints.
+type fakeGoDaddy struct {
+	mu      sync.Mutex
+	records map[string][]string // "TYPE name" to data
+	puts    int
+	auth    string
+}
+
+func (f *fakeGoDaddy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization")
+	// /v1/domains/{zone}/records/{type}/{name}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 6 || parts[2] != "example.com" {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"code": "NOT_FOUND", "message": "domain not found"})
+		return
+	}
+	key := parts[4] + " " + parts[5]
+	switch r.Method {
+	case http.MethodGet:
+		var records []map[string]any
+		for _, d := range f.records[key] {
+			records = append(records, map[string]any{"data": d, "name": parts[5], "type": parts[4], "ttl": 600})
+		}
+		if records == nil {
+			records = []map[string]any{}
+		}
+		json.NewEncoder(w).Encode(records)
+	case http.MethodPut:
+		f.puts++
+		var body []struct {
+			Data string `json:"data"`
+			TTL  int    `json:"ttl"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.records[key] = nil
+		for _, rec := range body {
+			if rec.TTL < 600 {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			f.records[key] = append(f.records[key], rec.Data)
+		}
+	case http.MethodDelete:
+		delete(f.records, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestGoDaddy(t *testing.T) {
+	f := &fakeGoDaddy{records: map[string][]string{"AAAA home": {"2001:db8::1"}}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = u.Scheme, u.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	ip := netip.MustParseAddr("192.0.2.1")
+	c, err := ddns.New("home.example.com", ddns.NewGoDaddy("key", "secret"),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.UsingHTTPClient(httpClient),
+		ddns.WithTTL(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	want := map[string][]string{"A home": {"192.0.2.1"}}
+	if !reflect.DeepEqual(f.records, want) {
+		t.Errorf("Expected %q; got %q", want, f.records)
+	}
+	if f.auth != "sso-key key:secret" {
+		t.Errorf("Expected %q; got %q", "sso-key key:secret", f.auth)
+	}
+
+	// records that are up to date are not replaced
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	if f.puts != 1 {
+		t.Errorf("Expected 1 PUT request; got %d", f.puts)
+	}
+
+	if _, err := ddns.New("home.example.com", ddns.NewGoDaddy("key", "secret"), ddns.WithTTL(time.Minute)); err == nil {
+		t.Errorf("Expected an error for a TTL below GoDaddy's minimum")
+	}
+}
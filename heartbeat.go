@@ -0,0 +1,125 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TXTProvider is the interface for providers which can also manage TXT records.
+//
+// name is the fully qualified record name, which may differ from the domain passed to SetDNSRecords.
+// The given values are the desired set for name.
+type TXTProvider interface {
+	GetTXTRecords(ctx context.Context, name string) ([]string, error)
+	SetTXTRecords(ctx context.Context, name string, values []string) error
+}
+
+var errTXTUnsupported = errors.New("provider does not support TXT records")
+
+// heartbeatPrefix is prepended to the domain to form the name of the heartbeat TXT record.
+const heartbeatPrefix = "_ddns-heartbeat."
+
+// Heartbeat configures the client to publish a TXT heartbeat record after each successful update,
+// identifying this host as id.
+//
+// Use it on the primary host for a domain which has a backup host configured with [Standby].
+// The Provider must implement [TXTProvider].
+func Heartbeat(id string) clientOption {
+	return func(c *client) error {
+		if id == "" {
+			return errors.New("heartbeat id cannot be empty")
+		}
+		c.heartbeatID = id
+		return nil
+	}
+}
+
+// Standby configures the client as a backup for a primary host which publishes a [Heartbeat] for the same domain.
+//
+// Records are only updated when the primary's heartbeat is older than staleAfter,
+// in which case this client takes over and publishes its own heartbeat as id.
+// Once the primary publishes a fresh heartbeat again the standby stops updating.
+// staleAfter should be several times the primary's update interval.
+//
+// The Provider must implement [TXTProvider].
+func Standby(id string, staleAfter time.Duration) clientOption {
+	return func(c *client) error {
+		if staleAfter <= 0 {
+			return errors.New("staleAfter must be positive")
+		}
+		if err := Heartbeat(id)(c); err != nil {
+			return err
+		}
+		c.staleAfter = staleAfter
+		return nil
+	}
+}
+
+// active reports whether the client should update records,
+// which is always true unless it is a standby and the primary's heartbeat is fresh.
+func (c *client) active(ctx context.Context) (bool, error) {
+	if c.staleAfter == 0 {
+		return true, nil
+	}
+	tp, ok := c.Provider.(TXTProvider)
+	if !ok {
+		return false, errTXTUnsupported
+	}
+	values, err := tp.GetTXTRecords(ctx, heartbeatPrefix+c.domain)
+	if err != nil {
+		return false, fmt.Errorf("error reading heartbeat: %w", err)
+	}
+	for _, v := range values {
+		id, t, ok := parseHeartbeat(v)
+		if !ok {
+			continue
+		}
+		if id == c.heartbeatID {
+			return true, nil
+		}
+		if age := time.Since(t); age < c.staleAfter {
+			c.logger.Printf("heartbeat from %s is %s old; staying on standby\n", id, age.Round(time.Second))
+			return false, nil
+		}
+		c.logger.Printf("heartbeat from %s is stale; taking over\n", id)
+	}
+	return true, nil
+}
+
+func (c *client) beat(ctx context.Context) error {
+	if c.heartbeatID == "" || c.dryRun {
+		return nil
+	}
+	tp, ok := c.Provider.(TXTProvider)
+	if !ok {
+		return errTXTUnsupported
+	}
+	v := formatHeartbeat(c.heartbeatID, time.Now())
+	if err := tp.SetTXTRecords(ctx, heartbeatPrefix+c.domain, []string{v}); err != nil {
+		return fmt.Errorf("error publishing heartbeat: %w", err)
+	}
+	return nil
+}
+
+func formatHeartbeat(id string, t time.Time) string {
+	return fmt.Sprintf("id=%s t=%d", id, t.Unix())
+}
+
+// parseHeartbeat parses a value written by formatHeartbeat.
+// The time is found from the end, since id may contain spaces.
+func parseHeartbeat(v string) (id string, t time.Time, ok bool) {
+	v, ok = strings.CutPrefix(strings.Trim(v, "\""), "id=")
+	i := strings.LastIndex(v, " t=")
+	if !ok || i < 0 {
+		return "", time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(v[i+len(" t="):], 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	return v[:i], time.Unix(unix, 0), true
+}
@@ -0,0 +1,63 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestStandby(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	// the primary's id contains a space, which must not break parsing of its heartbeat
+	primaryIP, standbyIP := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")
+
+	primary, err := ddns.New("nas.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(primaryIP)),
+		ddns.Heartbeat("home nas"),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	standby, err := ddns.New("nas.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(standbyIP)),
+		ddns.Standby("standby", 15*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	if err := primary.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if err := standby.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("nas.example.com"); len(got) != 1 || got[0] != primaryIP {
+		t.Fatalf("Expected standby to leave records alone while the primary is alive; got %q", got)
+	}
+
+	stale := fmt.Sprintf("id=home nas t=%d", time.Now().Add(-1*time.Hour).Unix())
+	p.SetTXTRecords(ctx, "_ddns-heartbeat.nas.example.com", []string{stale})
+	if err := standby.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("nas.example.com"); len(got) != 1 || got[0] != standbyIP {
+		t.Fatalf("Expected standby to take over from a stale primary; got %q", got)
+	}
+
+	if err := primary.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if err := standby.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("nas.example.com"); len(got) != 1 || got[0] != primaryIP {
+		t.Fatalf("Expected standby to stand down when the primary returns; got %q", got)
+	}
+}
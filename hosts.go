@@ -0,0 +1,206 @@
+package ddns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	hostsBegin = "# BEGIN ddns managed block"
+	hostsEnd   = "# END ddns managed block"
+)
+
+// NewHostsFile is used by [ddns.New] to create a Provider which maintains entries in a hosts-format file such as /etc/hosts.
+//
+// Entries are kept in a block between marker comments,
+// and the rest of the file is left as it is.
+// The file is created if it does not exist.
+// This is useful for air-gapped networks, or as a simple target for other tools to read.
+func NewHostsFile(path string) func() (Provider, error) {
+	return func() (Provider, error) {
+		if path == "" {
+			return nil, errors.New("path cannot be empty")
+		}
+		return &hostsProvider{path: path, logger: discard}, nil
+	}
+}
+
+type hostsProvider struct {
+	mu     sync.Mutex
+	path   string
+	logger *log.Logger
+}
+
+// hostsEntry is a single address and name in the managed block.
+type hostsEntry struct {
+	addr netip.Addr
+	name string
+}
+
+func (p *hostsProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *hostsProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, Batch: true}
+}
+
+func (p *hostsProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	domain = canonicalName(domain)
+	before, entries, after, mode, err := p.read()
+	if err != nil {
+		return err
+	}
+	var existing []Record
+	for _, e := range entries {
+		if e.name == domain {
+			existing = append(existing, Record{Addr: e.addr})
+		}
+	}
+	applier := &hostsApplier{entries: entries, domain: domain}
+	if err := Reconcile(ctx, existing, records, applier); err != nil {
+		return err
+	}
+	kept := applier.entries
+	p.logger.Printf("writing %d entries for %s to %s\n", len(records), domain, p.path)
+	return p.write(before, kept, after, mode)
+}
+
+// hostsApplier changes the entries for one domain in the managed block before it is written.
+type hostsApplier struct {
+	entries []hostsEntry
+	domain  string
+}
+
+func (a *hostsApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.entries = append(a.entries, hostsEntry{addr: addr, name: a.domain})
+	return nil
+}
+
+func (a *hostsApplier) Delete(ctx context.Context, r Record) error {
+	for i, e := range a.entries {
+		if e.name == a.domain && e.addr == r.Addr {
+			a.entries = append(a.entries[:i], a.entries[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (p *hostsProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, entries, _, _, err := p.read()
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	for _, e := range entries {
+		if e.name == canonicalName(domain) {
+			addrs = append(addrs, e.addr)
+		}
+	}
+	return addrs, nil
+}
+
+// read splits the file into the lines before the managed block, the entries in the block, and the lines after it.
+func (p *hostsProvider) read() (before []string, entries []hostsEntry, after []string, mode fs.FileMode, err error) {
+	mode = 0644
+	b, err := os.ReadFile(p.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil, mode, nil
+	}
+	if err != nil {
+		return nil, nil, nil, mode, fmt.Errorf("error reading hosts file: %w", err)
+	}
+	if info, err := os.Stat(p.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	const (
+		outside = iota
+		inside
+		done
+	)
+	state := outside
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case state == outside && strings.TrimSpace(line) == hostsBegin:
+			state = inside
+		case state == outside:
+			before = append(before, line)
+		case state == inside && strings.TrimSpace(line) == hostsEnd:
+			state = done
+		case state == inside:
+			fields := strings.Fields(line)
+			if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+				continue
+			}
+			a, err := netip.ParseAddr(fields[0])
+			if err != nil {
+				return nil, nil, nil, mode, fmt.Errorf("error parsing managed hosts entry \"%s\": %w", line, err)
+			}
+			for _, name := range fields[1:] {
+				entries = append(entries, hostsEntry{addr: a, name: canonicalName(name)})
+			}
+		default:
+			after = append(after, line)
+		}
+	}
+	if state == inside {
+		return nil, nil, nil, mode, fmt.Errorf("hosts file %s has no end marker for the managed block", p.path)
+	}
+	return before, entries, after, mode, s.Err()
+}
+
+// write replaces the file, keeping the lines outside of the managed block.
+func (p *hostsProvider) write(before []string, entries []hostsEntry, after []string, mode fs.FileMode) error {
+	var b bytes.Buffer
+	for _, line := range before {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString(hostsBegin + "\n")
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%s\t%s\n", e.addr, e.name)
+	}
+	b.WriteString(hostsEnd + "\n")
+	for _, line := range after {
+		b.WriteString(line + "\n")
+	}
+	// write to a temporary file first so that readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), ".hosts-ddns-*")
+	if err != nil {
+		return fmt.Errorf("error writing hosts file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing hosts file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing hosts file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("error writing hosts file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.path); err != nil {
+		// files such as a container's bind-mounted /etc/hosts can't be replaced, only rewritten
+		if err := os.WriteFile(p.path, b.Bytes(), mode); err != nil {
+			return fmt.Errorf("error replacing hosts file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestHostsFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "hosts")
+	original := "127.0.0.1\tlocalhost\n::1\tlocalhost\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ddns.NewHostsFile(path)()
+	if err != nil {
+		t.Fatalf("NewHostsFile returned an error: %s", err)
+	}
+	v4, v6 := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")
+	if err := p.SetDNSRecords(ctx, "a.example.com", []netip.Addr{v4, v6}); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(ctx, "b.example.com", []netip.Addr{v4}); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(ctx, "a.example.com", []netip.Addr{v6}); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+
+	// the entry kept for a.example.com stays in place
+	b, _ := os.ReadFile(path)
+	want := original +
+		"# BEGIN ddns managed block\n" +
+		"2001:db8::1\ta.example.com\n" +
+		"192.0.2.1\tb.example.com\n" +
+		"# END ddns managed block\n"
+	if string(b) != want {
+		t.Fatalf("Expected file:\n%s\ngot:\n%s", want, b)
+	}
+	got, err := p.(ddns.RecordGetter).GetDNSRecords(ctx, "a.example.com")
+	if err != nil {
+		t.Fatalf("GetDNSRecords returned an error: %s", err)
+	}
+	if len(got) != 1 || got[0] != v6 {
+		t.Fatalf("Expected %s; got %q", v6, got)
+	}
+}
+
+func TestHostsFileConformance(t *testing.T) {
+	ddnstest.TestProvider(t, ddns.NewHostsFile(filepath.Join(t.TempDir(), "hosts")))
+}
@@ -0,0 +1,199 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"text/template"
+)
+
+// NewHTTPProvider is used by [ddns.New] to create a Provider which sends the desired records for a domain to url,
+// so that in-house DNS systems can be updated without writing Go.
+//
+// By default the records are POSTed as JSON:
+//
+//	{"domain":"www.example.com","records":["192.0.2.1","2001:db8::1"]}
+//
+// and any 2xx response is considered a success.
+//
+// Additional options may be specified: [BearerToken], [BodyTemplate], [SuccessWhen].
+func NewHTTPProvider(url string, options ...httpProviderOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		if url == "" {
+			return nil, errors.New("url cannot be empty")
+		}
+		p := &httpProvider{
+			url:         url,
+			method:      http.MethodPost,
+			contentType: "application/json",
+			logger:      discard,
+			success:     statusOK,
+		}
+		for i, opt := range options {
+			if err := opt(p); err != nil {
+				return nil, fmt.Errorf("http provider option %d returned an error: %w", i, err)
+			}
+		}
+		return p, nil
+	}
+}
+
+type httpProviderOption func(*httpProvider) error
+
+// HTTPRecords is the data available to an HTTP provider's [BodyTemplate].
+type HTTPRecords struct {
+	Domain  string       `json:"domain"`
+	Records []netip.Addr `json:"records"`
+	IPv4    []netip.Addr `json:"-"`
+	IPv6    []netip.Addr `json:"-"`
+}
+
+// BearerToken configures an HTTP provider to send token in the Authorization header.
+func BearerToken(token string) httpProviderOption {
+	return func(p *httpProvider) error {
+		if token == "" {
+			return errors.New("token cannot be empty")
+		}
+		p.token = token
+		return nil
+	}
+}
+
+// BodyTemplate configures an HTTP provider to send the request body produced by the [text/template] tmpl,
+// executed with [HTTPRecords], using the given HTTP method and content type.
+// The template function "json" encodes its argument as JSON.
+//
+// For example, to send a form with only the first IPv4 address:
+//
+//	ddns.BodyTemplate(http.MethodPut, "application/x-www-form-urlencoded",
+//		`hostname={{.Domain}}&myip={{index .IPv4 0}}`)
+func BodyTemplate(method, contentType, tmpl string) httpProviderOption {
+	return func(p *httpProvider) error {
+		t, err := template.New("body").Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("invalid body template: %w", err)
+		}
+		p.method, p.contentType, p.body = method, contentType, t
+		return nil
+	}
+}
+
+// SuccessWhen configures an HTTP provider to check each response with check instead of requiring a 2xx status.
+// The response body may be read by check.
+func SuccessWhen(check func(*http.Response) error) httpProviderOption {
+	return func(p *httpProvider) error {
+		if check == nil {
+			return errors.New("check cannot be nil")
+		}
+		p.success = check
+		return nil
+	}
+}
+
+func statusOK(resp *http.Response) error {
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+type httpProvider struct {
+	httpClient  *http.Client
+	logger      *log.Logger
+	url         string
+	method      string
+	contentType string
+	token       string
+	body        *template.Template // nil sends HTTPRecords as JSON
+	success     func(*http.Response) error
+}
+
+func (p *httpProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *httpProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *httpProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	data := HTTPRecords{Domain: domain, Records: records}
+	for _, a := range records {
+		if a.Unmap().Is4() {
+			data.IPv4 = append(data.IPv4, a)
+		} else {
+			data.IPv6 = append(data.IPv6, a)
+		}
+	}
+	if data.Records == nil {
+		// send an empty list rather than null when records are withdrawn
+		data.Records = []netip.Addr{}
+	}
+	var body bytes.Buffer
+	if p.body != nil {
+		if err := p.body.Execute(&body, data); err != nil {
+			return fmt.Errorf("error executing body template: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(data); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, p.method, p.url, &body)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	setUserAgent(req)
+	req.Header.Set("Content-Type", p.contentType)
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	p.logger.Printf("sending %d records for %s to %s\n", len(records), domain, p.url)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := p.success(resp); err != nil {
+		return &httpProviderError{status: resp.StatusCode, err: err}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (p *httpProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true}
+}
+
+type httpProviderError struct {
+	status int
+	err    error
+}
+
+func (e *httpProviderError) Error() string { return e.err.Error() }
+func (e *httpProviderError) Unwrap() error { return e.err }
+func (e *httpProviderError) IsAuthenticationError() bool {
+	return e.status == http.StatusUnauthorized
+}
+func (e *httpProviderError) IsAuthorizationError() bool {
+	return e.status == http.StatusForbidden
+}
+func (e *httpProviderError) IsRateLimited() bool {
+	return e.status == http.StatusTooManyRequests
+}
+func (e *httpProviderError) IsTemporary() bool {
+	return e.status >= 500
+}
@@ -0,0 +1,74 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestHTTPProvider(t *testing.T) {
+	var got struct {
+		Domain  string   `json:"domain"`
+		Records []string `json:"records"`
+	}
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	p, err := ddns.NewHTTPProvider(srv.URL, ddns.BearerToken("secret"))()
+	if err != nil {
+		t.Fatalf("NewHTTPProvider returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(context.Background(), "www.example.com", []netip.Addr{netip.MustParseAddr("192.0.2.1")}); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected %q; got %q", "Bearer secret", auth)
+	}
+	if got.Domain != "www.example.com" || len(got.Records) != 1 || got.Records[0] != "192.0.2.1" {
+		t.Errorf("Unexpected request body: %+v", got)
+	}
+}
+
+func TestHTTPProviderTemplate(t *testing.T) {
+	var body, method string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body, method = string(b), r.Method
+		io.WriteString(w, "badauth")
+	}))
+	defer srv.Close()
+
+	p, err := ddns.NewHTTPProvider(srv.URL,
+		ddns.BodyTemplate(http.MethodPut, "application/x-www-form-urlencoded", `hostname={{.Domain}}&myip={{index .IPv4 0}}`),
+		ddns.SuccessWhen(func(resp *http.Response) error {
+			b, _ := io.ReadAll(resp.Body)
+			if strings.HasPrefix(string(b), "bad") {
+				return errors.New(string(b))
+			}
+			return nil
+		}),
+	)()
+	if err != nil {
+		t.Fatalf("NewHTTPProvider returned an error: %s", err)
+	}
+	addrs := []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("192.0.2.1")}
+	err = p.SetDNSRecords(context.Background(), "www.example.com", addrs)
+	if err == nil || err.Error() != "badauth" {
+		t.Errorf("Expected the success check error; got %v", err)
+	}
+	if want := "hostname=www.example.com&myip=192.0.2.1"; body != want || method != http.MethodPut {
+		t.Errorf("Expected PUT %q; got %s %q", want, method, body)
+	}
+}
@@ -0,0 +1,59 @@
+package ddns
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// RequireHTTPS configures the client to reject resolvers which look up addresses over plain http://,
+// such as a [WebResolver] or a [DNSResolver] using [DNSOverHTTPS],
+// since anyone able to intercept the lookup controls the records that get published.
+// [New] returns an error if any such URL is found, including within [Join].
+//
+// URLs for a loopback host, such as http://localhost or http://127.0.0.1, are allowed for testing.
+func RequireHTTPS() clientOption {
+	return func(c *client) error {
+		c.requireHTTPS = true
+		return nil
+	}
+}
+
+// checkHTTPS returns an error if the resolver looks up addresses from a plaintext URL.
+func (c *client) checkHTTPS() error {
+	if !c.requireHTTPS {
+		return nil
+	}
+	for _, u := range lookupURLs(c.Resolver) {
+		if plaintext(u) {
+			return fmt.Errorf("IP lookup URL \"%s\" must use https", u)
+		}
+	}
+	return nil
+}
+
+// lookupURLs returns the URLs a resolver looks up addresses from.
+func lookupURLs(v any) []*url.URL {
+	if r, ok := v.(interface{ lookupURLs() []*url.URL }); ok {
+		return r.lookupURLs()
+	}
+	return nil
+}
+
+// plaintext reports whether u is a plain http URL for a host other than the loopback interface.
+func plaintext(u *url.URL) bool {
+	if !strings.EqualFold(u.Scheme, "http") {
+		return false
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		a, _ := netip.AddrFromSlice(ip)
+		return !a.Unmap().IsLoopback()
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"time"
+)
+
+// JournalEntry records a single record created or deleted by the client.
+//
+// Entries are written to the journal as JSON lines.
+type JournalEntry struct {
+	Time     time.Time    `json:"time"`
+	Domain   string       `json:"domain"`
+	Action   string       `json:"action"` // "create" or "delete"
+	Addr     netip.Addr   `json:"addr"`
+	Old      []netip.Addr `json:"old"`      // records before the update; null if unknown
+	New      []netip.Addr `json:"new"`      // records after the update
+	Resolved []netip.Addr `json:"resolved"` // addresses reported by the resolver which motivated the change
+	Resolver string       `json:"resolver"`
+	Run      RunInfo      `json:"run"` // the update which made the change
+}
+
+// WithJournal configures the client to append an entry to w for every record it creates or deletes,
+// so that unexpected DNS changes can be traced back to this client and the addresses it resolved.
+//
+// If the Provider implements [RecordGetter] the existing records are read before each update.
+// Otherwise the previous records are those last published by the client,
+// and are unknown for the first update.
+//
+// No journal is written if w is nil.
+func WithJournal(w io.Writer) clientOption {
+	return func(c *client) error {
+		if w == nil {
+			c.journal = nil
+			return nil
+		}
+		c.journal = json.NewEncoder(w)
+		return nil
+	}
+}
+
+// previousRecords returns the records published for the domain before an update.
+func (c *client) previousRecords(ctx context.Context) ([]netip.Addr, error) {
+	if rg, ok := c.Provider.(RecordGetter); ok {
+		existing, err := rg.GetDNSRecords(ctx, c.domain)
+		if err != nil {
+			return nil, fmt.Errorf("error getting existing records: %w", err)
+		}
+		return existing, nil
+	}
+	if c.published == nil && c.store != nil {
+		return c.store.LastPublished(ctx, c.domain)
+	}
+	return c.published, nil
+}
+
+// record writes journal entries for the changes between old and records to the journal and store,
+// and sends them as events.
+func (c *client) record(ctx context.Context, old, records, resolved []netip.Addr) error {
+	now := time.Now()
+	run := RunInfoFrom(ctx)
+	entry := func(action string, a netip.Addr) JournalEntry {
+		return JournalEntry{
+			Time:     now,
+			Domain:   c.domain,
+			Action:   action,
+			Addr:     a,
+			Old:      old,
+			New:      records,
+			Resolved: resolved,
+			Resolver: fmt.Sprintf("%T", c.Resolver),
+			Run:      run,
+		}
+	}
+	// when the previous records are unknown, everything published is recorded as created
+	ch := Diff(old, records)
+	var entries []JournalEntry
+	for _, a := range ch.Delete {
+		entries = append(entries, entry("delete", a))
+	}
+	for _, a := range ch.Create {
+		entries = append(entries, entry("create", a))
+	}
+	for _, e := range entries {
+		if c.changeLogger != nil {
+			c.changeLogger.Printf("%sd record %s for %s (update %s)\n", e.Action, e.Addr, c.domain, run.ID)
+		}
+		if e.Action == "create" {
+			c.emit(ctx, RecordCreated{Time: now, Domain: c.domain, Addr: e.Addr, Run: run})
+		} else {
+			c.emit(ctx, RecordDeleted{Time: now, Domain: c.domain, Addr: e.Addr, Run: run})
+		}
+		if c.journal != nil {
+			if err := c.journal.Encode(e); err != nil {
+				return fmt.Errorf("error writing journal: %w", err)
+			}
+		}
+		if c.store != nil {
+			if err := c.store.AppendEvent(ctx, e); err != nil {
+				return fmt.Errorf("error storing event: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package ddns_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestJournal(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	old, kept, added := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1")
+	p.SetDNSRecords(ctx, "example.com", []netip.Addr{old, kept})
+	var buf bytes.Buffer
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(kept, added)),
+		ddns.WithJournal(&buf),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	// a second run with no changes should not add entries
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+
+	var entries []ddns.JournalEntry
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e ddns.JournalEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("Unable to decode journal entry: %s", err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 journal entries; got %d", len(entries))
+	}
+	if entries[0].Action != "delete" || entries[0].Addr != old {
+		t.Errorf("Expected delete of %s; got %s of %s", old, entries[0].Action, entries[0].Addr)
+	}
+	if entries[1].Action != "create" || entries[1].Addr != added {
+		t.Errorf("Expected create of %s; got %s of %s", added, entries[1].Action, entries[1].Addr)
+	}
+	if len(entries[1].Resolved) != 2 || len(entries[1].Old) != 2 {
+		t.Errorf("Expected resolver evidence and old records; got %+v", entries[1])
+	}
+}
@@ -0,0 +1,50 @@
+package ddns
+
+import (
+	"errors"
+	"net/netip"
+	"sort"
+)
+
+// MaxRecords configures the client to publish at most n of the resolved addresses,
+// for hosts with many addresses (such as VMs with many virtual interfaces) where publishing all of them is undesirable.
+//
+// Addresses are chosen in order of preference:
+// addresses for which the first function returns true are preferred, with ties broken by the next function, and so on.
+// Remaining ties are broken by address order.
+// The filter functions in this package may be used as preferences, e.g. to prefer public IPv4 addresses:
+//
+//	ddns.MaxRecords(2, ddns.IPv4Addr, ddns.PublicAddr)
+func MaxRecords(n int, prefer ...func(netip.Addr) bool) clientOption {
+	return func(c *client) error {
+		if n < 1 {
+			return errors.New("max records must be at least 1")
+		}
+		for _, p := range prefer {
+			if p == nil {
+				return errors.New("preference cannot be nil")
+			}
+		}
+		c.maxRecords = n
+		c.prefer = prefer
+		return nil
+	}
+}
+
+// limitAddrs returns the c.maxRecords most preferred addresses in addrs, which must be sorted.
+func (c *client) limitAddrs(addrs []netip.Addr) []netip.Addr {
+	if c.maxRecords == 0 || len(addrs) <= c.maxRecords {
+		return addrs
+	}
+	ranked := append([]netip.Addr{}, addrs...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		for _, p := range c.prefer {
+			if pi, pj := p(ranked[i]), p(ranked[j]); pi != pj {
+				return pi
+			}
+		}
+		return false
+	})
+	c.logger.Printf("publishing %d of %d addresses\n", c.maxRecords, len(addrs))
+	return sortAddrs(ranked[:c.maxRecords])
+}
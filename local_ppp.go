@@ -0,0 +1,66 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// PPPResolver constructs a resolver that returns the addresses of the PPP interfaces which are up,
+// such as ppp0 or OpenWrt's pppoe-wan, for routers which connect to a DSL or fiber ISP with PPPoE.
+//
+// The address of a point-to-point interface is the local side of the link;
+// the peer address of the ISP's end and link-local IPv6 addresses are skipped.
+// [InterfaceResolver] and [Iface] treat named point-to-point interfaces the same way.
+func PPPResolver() Resolver {
+	return ResolverFunc(func(ctx context.Context) ([]netip.Addr, error) {
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return nil, fmt.Errorf("error listing interfaces: %w", err)
+		}
+		var names []string
+		for _, iface := range ifaces {
+			if isPPP(iface) {
+				names = append(names, iface.Name)
+			}
+		}
+		if len(names) == 0 {
+			return nil, errors.New("no PPP interface is up")
+		}
+		return interfaceResolver{ifaces: names}.Resolve(ctx)
+	})
+}
+
+// isPPP reports whether iface is a PPP link which is up.
+// Other point-to-point interfaces such as tunnels and WireGuard are not, as their addresses are rarely the ones to publish.
+func isPPP(iface net.Interface) bool {
+	return iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagPointToPoint != 0 &&
+		(strings.HasPrefix(iface.Name, "ppp") || strings.HasPrefix(iface.Name, "pppoe-"))
+}
+
+// pointToPointAddrs returns the local addresses of a point-to-point interface, without the peer or link-local addresses.
+//
+// Linux reports the peer of a point-to-point link as IFA_ADDRESS and our side as IFA_LOCAL, each as a /32 (or /128).
+// The net package returns the IFA_LOCAL address when there is one, so the peer is never seen here;
+// an unnumbered link has only IFA_ADDRESS, which is then our own address.
+func pointToPointAddrs(iface *net.Interface) ([]netip.Addr, error) {
+	a, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("error looking up addresses for interface %s: %w", iface.Name, err)
+	}
+	var addrs []netip.Addr
+	for _, addr := range a {
+		ip, err := ifaceAddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing local ip for interface %s: %w", iface.Name, err)
+		}
+		// the link-local address only reaches the ISP's end of the link
+		if !ip.IsLinkLocalUnicast() {
+			addrs = append(addrs, ip)
+		}
+	}
+	return addrs, nil
+}
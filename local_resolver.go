@@ -6,10 +6,13 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"strings"
 )
 
 // InterfaceResolver constructs a resolver that returns the IP addresses reported by the given network interfaces.
 // If no interfaces are provided then all interfaces will be used.
+//
+// For a point-to-point interface such as ppp0, only the local side of the link is returned; see [PPPResolver].
 func InterfaceResolver(iface ...string) Resolver {
 	if len(iface) == 0 {
 		return ResolverFunc(resolveLocalIPs)
@@ -17,8 +20,41 @@ func InterfaceResolver(iface ...string) Resolver {
 	return interfaceResolver{ifaces: iface}
 }
 
+// Iface constructs a resolver like [InterfaceResolver] for a single network interface,
+// with options to limit the addresses it returns.
+// Combine it with others using [Join], e.g. for a host with public IPv6 but only a private IPv4 address behind the router:
+//
+//	ddns.Join(
+//		ddns.Iface("eth0", ddns.IPv6Only()),
+//		ddns.WebResolver("https://ipv4.icanhazip.com"),
+//	)
+func Iface(name string, options ...ifaceOption) Resolver {
+	r := interfaceResolver{ifaces: []string{name}}
+	for _, opt := range options {
+		opt(&r)
+	}
+	return r
+}
+
+type ifaceOption func(*interfaceResolver)
+
+// IPv4Only configures an [Iface] resolver to only return IPv4 addresses.
+func IPv4Only() ifaceOption {
+	return func(r *interfaceResolver) {
+		r.keep = IPv4Addr
+	}
+}
+
+// IPv6Only configures an [Iface] resolver to only return IPv6 addresses.
+func IPv6Only() ifaceOption {
+	return func(r *interfaceResolver) {
+		r.keep = IPv6Addr
+	}
+}
+
 type interfaceResolver struct {
 	ifaces []string
+	keep   func(netip.Addr) bool // nil keeps every address
 }
 
 func (r interfaceResolver) Resolve(ctx context.Context) (addrs []netip.Addr, err error) {
@@ -27,6 +63,20 @@ func (r interfaceResolver) Resolve(ctx context.Context) (addrs []netip.Addr, err
 		iface, err := net.InterfaceByName(ifs)
 		if err != nil {
 			errs = append(errs, fmt.Errorf("error getting interface %s by name: %w", ifs, err))
+			continue
+		}
+		if iface.Flags&net.FlagPointToPoint != 0 {
+			a, err := pointToPointAddrs(iface)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, ip := range a {
+				if r.keep == nil || r.keep(ip) {
+					addrs = append(addrs, ip)
+				}
+			}
+			continue
 		}
 		a, err := iface.Addrs()
 		if err != nil {
@@ -34,15 +84,15 @@ func (r interfaceResolver) Resolve(ctx context.Context) (addrs []netip.Addr, err
 			continue
 		}
 		for _, addr := range a {
-			ip, err := netip.ParsePrefix(addr.String())
+			ip, err := ifaceAddr(addr)
 			if err != nil {
-				errs = append(errs, fmt.Errorf("error parsing local ip %s for interface %s: %s", addr.String(), ifs, err))
+				errs = append(errs, fmt.Errorf("error parsing local ip for interface %s: %w", ifs, err))
 				continue
 			}
-			if ip.Addr().IsLoopback() {
+			if ip.IsLoopback() || (r.keep != nil && !r.keep(ip)) {
 				continue
 			}
-			addrs = append(addrs, ip.Addr())
+			addrs = append(addrs, ip)
 		}
 	}
 	return addrs, errors.Join(errs...)
@@ -53,20 +103,56 @@ func resolveLocalIPs(ctx context.Context) (addrs []netip.Addr, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("error getting addresses for interface: %w", err)
 	}
-	// addr: ip+net:192.168.86.253/24
-	// addr: ip+net:fd64:9f44:fc30:0:b951:8b16:2812:a227/64
-	// addr: ip+net:fe80::2cc9:801b:3551:9a43/64
 	var parseErrors []error
 	for _, addr := range adds {
-		ip, err := netip.ParsePrefix(addr.String())
+		ip, err := ifaceAddr(addr)
 		if err != nil {
-			parseErrors = append(parseErrors, fmt.Errorf("error parsing local ip %s: %s", addr.String(), err))
+			parseErrors = append(parseErrors, fmt.Errorf("error parsing local ip: %w", err))
 			continue
 		}
-		if ip.Addr().IsLoopback() {
+		if ip.IsLoopback() {
 			continue
 		}
-		addrs = append(addrs, ip.Addr())
+		addrs = append(addrs, ip)
 	}
 	return addrs, errors.Join(parseErrors...)
 }
+
+// ifaceAddr returns the IP address of an interface address.
+// The addresses reported by the net package are normally a *net.IPNet, e.g.:
+//
+//	ip+net:192.168.86.253/24
+//	ip+net:fd64:9f44:fc30:0:b951:8b16:2812:a227/64
+//	ip+net:fe80::2cc9:801b:3551:9a43/64
+//
+// Other implementations of net.Addr are parsed from their string form, with or without the network prefix.
+func ifaceAddr(addr net.Addr) (netip.Addr, error) {
+	var ip net.IP
+	switch a := addr.(type) {
+	case nil:
+		return netip.Addr{}, errors.New("nil address")
+	case *net.IPNet:
+		if a != nil {
+			ip = a.IP
+		}
+	case *net.IPAddr:
+		if a != nil {
+			ip = a.IP
+		}
+	default:
+		s := strings.TrimPrefix(addr.String(), addr.Network()+":")
+		if p, err := netip.ParsePrefix(s); err == nil {
+			return p.Addr().Unmap(), nil
+		}
+		parsed, err := netip.ParseAddr(s)
+		if err != nil || parsed.Zone() != "" {
+			return netip.Addr{}, fmt.Errorf("invalid interface address %q", truncate(addr.String(), 64))
+		}
+		return parsed.Unmap(), nil
+	}
+	a, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("invalid interface address %q", truncate(addr.String(), 64))
+	}
+	return a.Unmap(), nil
+}
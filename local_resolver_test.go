@@ -0,0 +1,71 @@
+package ddns_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestIfaceFamily(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("error listing interfaces: %s", err)
+	}
+	tested := false
+	for _, iface := range ifaces {
+		all, err := ddns.InterfaceResolver(iface.Name).Resolve(context.Background())
+		if err != nil || len(all) == 0 {
+			continue
+		}
+		tested = true
+		v4, err := ddns.Iface(iface.Name, ddns.IPv4Only()).Resolve(context.Background())
+		if err != nil {
+			t.Fatalf("Resolve failed: %s", err)
+		}
+		v6, err := ddns.Iface(iface.Name, ddns.IPv6Only()).Resolve(context.Background())
+		if err != nil {
+			t.Fatalf("Resolve failed: %s", err)
+		}
+		for _, a := range v4 {
+			if !a.Is4() {
+				t.Errorf("Expected only IPv4 addresses for %s; got %s", iface.Name, a)
+			}
+		}
+		for _, a := range v6 {
+			if a.Is4() {
+				t.Errorf("Expected only IPv6 addresses for %s; got %s", iface.Name, a)
+			}
+		}
+		if len(v4)+len(v6) != len(all) {
+			t.Errorf("Expected %s to have %d addresses across both families; got %d and %d", iface.Name, len(all), len(v4), len(v6))
+		}
+	}
+	if !tested {
+		t.Skip("no interfaces with addresses available")
+	}
+}
+
+func TestInterfaceResolverMissing(t *testing.T) {
+	_, err := ddns.InterfaceResolver("ddns-test-missing0").Resolve(context.Background())
+	if err == nil {
+		t.Fatalf("Expected an error for a missing interface")
+	}
+}
+
+func TestPPPResolver(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("error listing interfaces: %s", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagPointToPoint != 0 && iface.Flags&net.FlagUp != 0 && strings.HasPrefix(iface.Name, "ppp") {
+			t.Skipf("%s is a PPP interface", iface.Name)
+		}
+	}
+	if _, err := ddns.PPPResolver().Resolve(context.Background()); err == nil {
+		t.Errorf("Expected an error without a PPP interface")
+	}
+}
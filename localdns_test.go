@@ -0,0 +1,120 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestTechnitium(t *testing.T) {
+	var mu sync.Mutex
+	records := map[string]bool{"192.0.2.1": true}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		q := r.URL.Query()
+		if q.Get("token") != "secret" {
+			json.NewEncoder(w).Encode(map[string]string{"status": "invalid-token"})
+			return
+		}
+		switch r.URL.Path {
+		case "/api/zones/records/get":
+			var rs []map[string]any
+			for ip := range records {
+				typ := "A"
+				if strings.Contains(ip, ":") {
+					typ = "AAAA"
+				}
+				rs = append(rs, map[string]any{"name": q.Get("domain"), "type": typ, "rData": map[string]string{"ipAddress": ip}})
+			}
+			json.NewEncoder(w).Encode(map[string]any{"status": "ok", "response": map[string]any{"records": rs}})
+			return
+		case "/api/zones/records/add":
+			records[q.Get("ipAddress")] = true
+		case "/api/zones/records/delete":
+			delete(records, q.Get("ipAddress"))
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	want := []netip.Addr{netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1")}
+	p, err := ddns.NewTechnitium(srv.URL, "secret")()
+	if err != nil {
+		t.Fatalf("NewTechnitium returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(ctx, "host.lan", want); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	if len(records) != 2 || !records["192.0.2.2"] || !records["2001:db8::1"] {
+		t.Fatalf("Unexpected records: %v", records)
+	}
+
+	p, _ = ddns.NewTechnitium(srv.URL, "wrong")()
+	err = p.SetDNSRecords(ctx, "host.lan", want)
+	var authErr interface{ IsAuthenticationError() bool }
+	if !errors.As(err, &authErr) || !authErr.IsAuthenticationError() {
+		t.Fatalf("Expected an authentication error; got %v", err)
+	}
+}
+
+func TestPihole(t *testing.T) {
+	var mu sync.Mutex
+	hosts := []string{"192.0.2.1 host.lan", "192.0.2.9 other.lan"}
+	logins := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/auth" {
+			logins++
+			json.NewEncoder(w).Encode(map[string]any{"session": map[string]any{"valid": true, "sid": "sid1"}})
+			return
+		}
+		if r.Header.Get("X-FTL-SID") != "sid1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		entry, _ := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/api/config/dns/hosts/"))
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(map[string]any{"config": map[string]any{"dns": map[string]any{"hosts": hosts}}})
+		case http.MethodPut:
+			hosts = append(hosts, entry)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			for i, h := range hosts {
+				if h == entry {
+					hosts = append(hosts[:i], hosts[i+1:]...)
+					break
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := ddns.NewPihole(srv.URL, "password")()
+	if err != nil {
+		t.Fatalf("NewPihole returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(context.Background(), "host.lan", []netip.Addr{netip.MustParseAddr("192.0.2.2")}); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	want := "192.0.2.9 other.lan,192.0.2.2 host.lan"
+	if got := strings.Join(hosts, ","); got != want {
+		t.Fatalf("Expected hosts %q; got %q", want, got)
+	}
+	if logins != 1 {
+		t.Errorf("Expected the session to be reused; got %d logins", logins)
+	}
+}
@@ -0,0 +1,59 @@
+package ddns
+
+import (
+	"log"
+)
+
+// LogLevel selects which messages a client writes to the logger given to [WithLogger].
+type LogLevel int
+
+const (
+	// LogQuiet logs nothing.
+	LogQuiet LogLevel = iota + 1
+	// LogNormal logs each DNS record created or deleted.
+	LogNormal
+	// LogVerbose also logs the client's progress through each update, such as the resolved addresses.
+	LogVerbose
+	// LogDebug also logs the requests made by the Resolver and Provider.
+	LogDebug
+)
+
+// WithLogLevel configures which messages are written to the logger given to [WithLogger].
+//
+// Without WithLogLevel, the client, Resolver, and Provider log everything except the record changes of [LogNormal],
+// which need the existing records to be listed before each update, as with [WithJournal].
+func WithLogLevel(level LogLevel) clientOption {
+	return func(c *client) error {
+		c.logLevel = level
+		return nil
+	}
+}
+
+// configureLogLevel sets the client's loggers for c.logLevel,
+// returning the logger for the Resolver and Provider.
+func (c *client) configureLogLevel() *log.Logger {
+	if c.logLevel == 0 {
+		return c.logger
+	}
+	logger := c.logger
+	// record changes are only found when they will be seen
+	if c.logLevel >= LogNormal && logger != discard {
+		c.changeLogger = logger
+	}
+	if c.logLevel < LogVerbose {
+		c.logger = discard
+	}
+	if c.logLevel < LogDebug {
+		return discard
+	}
+	return logger
+}
+
+// warnf logs a warning, which is shown along with the record changes at LogNormal.
+func (c *client) warnf(format string, v ...any) {
+	if c.changeLogger != nil {
+		c.changeLogger.Printf(format, v...)
+		return
+	}
+	c.logger.Printf(format, v...)
+}
@@ -0,0 +1,65 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// ErrMassDelete is returned by RunDDNS when an update would delete more records than allowed by [MaxDeletes]
+// and the deletion was not confirmed.
+var ErrMassDelete = errors.New("refusing to delete records without confirmation")
+
+// MaxDeletes configures the client to refuse updates which would delete more than n records,
+// or which would leave the domain with no records at all,
+// so that a misconfigured resolver cannot wipe out the records of a production domain.
+// Such updates fail with [ErrMassDelete] unless confirmed with [ConfirmMassDelete] or allowed with [AllowMassDelete].
+//
+// Deletions are counted against the existing records if the Provider implements [RecordGetter],
+// or else against the records last published by the client.
+// Records withdrawn by [GateOn] are deleted on purpose, so they are not limited.
+func MaxDeletes(n int) clientOption {
+	return func(c *client) error {
+		if n < 0 {
+			return fmt.Errorf("max deletes cannot be negative: %d", n)
+		}
+		c.guardDeletes = true
+		c.maxDeletes = n
+		return nil
+	}
+}
+
+// ConfirmMassDelete configures the client to call confirm for updates refused by [MaxDeletes],
+// e.g. to prompt the user.
+// The update continues only if confirm returns true.
+func ConfirmMassDelete(confirm func(ctx context.Context, domain string, deletes []netip.Addr) bool) clientOption {
+	return func(c *client) error {
+		c.confirmDeletes = confirm
+		return nil
+	}
+}
+
+// AllowMassDelete configures the client to allow updates refused by [MaxDeletes] without confirmation.
+func AllowMassDelete() clientOption {
+	return func(c *client) error {
+		c.allowMassDelete = true
+		return nil
+	}
+}
+
+// checkDeletes returns an error if replacing old with records deletes too many records without confirmation.
+func (c *client) checkDeletes(ctx context.Context, old, records []netip.Addr) error {
+	if !c.guardDeletes || c.allowMassDelete || c.withdrawing {
+		return nil
+	}
+	deletes := Diff(old, records).Delete
+	if len(deletes) <= c.maxDeletes && (len(records) > 0 || len(old) == 0) {
+		return nil
+	}
+	if c.confirmDeletes != nil && c.confirmDeletes(ctx, c.domain, deletes) {
+		c.logger.Printf("deletion of %d records for %s was confirmed\n", len(deletes), c.domain)
+		return nil
+	}
+	return fmt.Errorf("%w: update would delete %d of %d records for %s", ErrMassDelete, len(deletes), len(old), c.domain)
+}
@@ -0,0 +1,77 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestMaxDeletes(t *testing.T) {
+	ctx := context.Background()
+	existing := []netip.Addr{
+		netip.MustParseAddr("192.0.2.1"),
+		netip.MustParseAddr("192.0.2.2"),
+		netip.MustParseAddr("192.0.2.3"),
+	}
+	changed := []netip.Addr{netip.MustParseAddr("192.0.2.9")}
+
+	tt := []struct {
+		name      string
+		resolved  []netip.Addr
+		confirm   bool
+		allow     bool
+		wantError bool
+	}{
+		{name: "within limit", resolved: existing[:1]},
+		{name: "too many", resolved: changed, wantError: true},
+		{name: "all records", resolved: nil, wantError: true},
+		{name: "confirmed", resolved: changed, confirm: true},
+		{name: "allowed", resolved: nil, allow: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &ddnstest.Provider{}
+			p.SetDNSRecords(ctx, "example.com", existing)
+			r := &ddnstest.Resolver{}
+			r.Set(tc.resolved...)
+			var asked []netip.Addr
+			confirm := func(ctx context.Context, domain string, deletes []netip.Addr) bool {
+				asked = deletes
+				return tc.confirm
+			}
+			var c ddns.DDNSClient
+			var err error
+			if tc.allow {
+				c, err = ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r), ddns.MaxDeletes(2), ddns.AllowMassDelete())
+			} else {
+				c, err = ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r), ddns.MaxDeletes(2), ddns.ConfirmMassDelete(confirm))
+			}
+			if err != nil {
+				t.Fatalf("New returned an error: %s", err)
+			}
+			err = c.RunDDNS(ctx)
+			if tc.wantError {
+				if !errors.Is(err, ddns.ErrMassDelete) {
+					t.Fatalf("Expected %q; got %q", ddns.ErrMassDelete, err)
+				}
+				if got := p.Records("example.com"); len(got) != len(existing) {
+					t.Errorf("Expected records to be unchanged; got %q", got)
+				}
+				if len(asked) == 0 {
+					t.Errorf("Expected confirmation to be requested")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunDDNS returned an error: %s", err)
+			}
+			if got := p.Records("example.com"); len(got) != len(tc.resolved) {
+				t.Errorf("Expected %q; got %q", tc.resolved, got)
+			}
+		})
+	}
+}
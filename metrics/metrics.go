@@ -0,0 +1,274 @@
+/*
+Package metrics exports the activity of ddns clients as Prometheus metrics with conventional names,
+so that dashboards and alerts can be shared between programs using package ddns.
+
+The metrics are recorded with a [Registerer]. Programs which already serve /metrics
+can implement it with their own metrics library; with the Prometheus client library, for example,
+Counter can return a CounterVec with a "domain" label registered with the program's registry:
+
+	m := metrics.New(promRegisterer{prometheus.DefaultRegisterer})
+	client = m.Instrument("home.example.com", client)
+
+Programs without a metrics library can use a [Registry], which serves the metrics in the Prometheus text format without any dependencies:
+
+	reg := metrics.NewRegistry()
+	client = reg.Instrument("home.example.com", client)
+	http.Handle("/metrics", reg)
+
+Every series has a domain label. [ExampleAlerts] has alerting rules for them.
+*/
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// The names of the metrics.
+const (
+	RunsTotal            = "ddns_runs_total"                     // counter of updates run
+	RunFailuresTotal     = "ddns_run_failures_total"             // counter of updates which returned an error
+	IPChangesTotal       = "ddns_ip_changes_total"               // counter of updates which changed the published records
+	LastRunTimestamp     = "ddns_last_run_timestamp_seconds"     // gauge of the Unix time the last update finished
+	LastSuccessTimestamp = "ddns_last_success_timestamp_seconds" // gauge of the Unix time the last successful update finished
+)
+
+// ExampleAlerts are Prometheus alerting rules for the metrics of a Registry,
+// which can be saved to a rules file and adjusted to the update interval.
+const ExampleAlerts = `groups:
+  - name: ddns
+    rules:
+      - alert: DDNSUpdateStale
+        expr: time() - ddns_last_success_timestamp_seconds > 3600
+        for: 5m
+        annotations:
+          summary: "{{ $labels.domain }} has not been updated successfully for over an hour"
+      - alert: DDNSUpdatesFailing
+        expr: increase(ddns_run_failures_total[30m]) >= 3
+        annotations:
+          summary: "{{ $labels.domain }} failed {{ $value }} updates in the last 30 minutes"
+      - alert: DDNSAddressFlapping
+        expr: increase(ddns_ip_changes_total[1h]) > 4
+        annotations:
+          summary: "The address of {{ $labels.domain }} changed {{ $value }} times in the last hour"
+`
+
+// Registerer creates the metrics recorded for instrumented clients.
+// Each metric is created once, with one of the names above, and every series of it is labeled with a domain.
+type Registerer interface {
+	Counter(name, help string) Counter
+	Gauge(name, help string) Gauge
+}
+
+// Counter is a counter with a domain label.
+type Counter interface {
+	Add(domain string, delta float64)
+}
+
+// Gauge is a gauge with a domain label.
+type Gauge interface {
+	Set(domain string, value float64)
+}
+
+// Metrics records the updates of instrumented clients with a Registerer.
+type Metrics struct {
+	runs, failures, changes Counter
+	lastRun, lastSuccess    Gauge
+}
+
+// New creates the metrics with reg.
+func New(reg Registerer) *Metrics {
+	return &Metrics{
+		runs:        reg.Counter(RunsTotal, "Updates run by the ddns client."),
+		failures:    reg.Counter(RunFailuresTotal, "Updates which returned an error."),
+		changes:     reg.Counter(IPChangesTotal, "Updates which changed the published records."),
+		lastRun:     reg.Gauge(LastRunTimestamp, "Unix time the last update finished."),
+		lastSuccess: reg.Gauge(LastSuccessTimestamp, "Unix time the last successful update finished."),
+	}
+}
+
+// Instrument returns a client which records each update of client in m, labeled with domain.
+// The series for domain are created immediately, with timestamps of 0 until it has run or succeeded.
+//
+// IP changes are counted if client implements [ddns.StateReporter], as the client returned by ddns.New does,
+// or unwraps to one with an Unwrap() ddns.DDNSClient method.
+func (m *Metrics) Instrument(domain string, client ddns.DDNSClient) ddns.DDNSClient {
+	m.runs.Add(domain, 0)
+	m.failures.Add(domain, 0)
+	m.changes.Add(domain, 0)
+	m.lastRun.Set(domain, 0)
+	m.lastSuccess.Set(domain, 0)
+	return &instrumented{DDNSClient: client, m: m, domain: domain, state: stateReporter(client)}
+}
+
+// stateReporter returns the StateReporter of client or the first client it wraps which implements it.
+func stateReporter(client ddns.DDNSClient) ddns.StateReporter {
+	for client != nil {
+		if sr, ok := client.(ddns.StateReporter); ok {
+			return sr
+		}
+		u, ok := client.(interface{ Unwrap() ddns.DDNSClient })
+		if !ok {
+			return nil
+		}
+		client = u.Unwrap()
+	}
+	return nil
+}
+
+type instrumented struct {
+	ddns.DDNSClient
+	m      *Metrics
+	domain string
+	state  ddns.StateReporter // nil if changes can't be counted
+}
+
+func (c *instrumented) Unwrap() ddns.DDNSClient { return c.DDNSClient }
+
+func (c *instrumented) RunDDNS(ctx context.Context) error {
+	var before ddns.Published
+	if c.state != nil {
+		before = c.state.LastPublished()
+	}
+	err := c.DDNSClient.RunDDNS(ctx)
+	changed := false
+	if c.state != nil {
+		after := c.state.LastPublished()
+		changed = !before.Time.IsZero() && after.Time.After(before.Time) && !sameAddrs(before.Records, after.Records)
+	}
+	now := unixSeconds(time.Now())
+	c.m.runs.Add(c.domain, 1)
+	c.m.lastRun.Set(c.domain, now)
+	if err != nil {
+		c.m.failures.Add(c.domain, 1)
+	} else {
+		c.m.lastSuccess.Set(c.domain, now)
+	}
+	if changed {
+		c.m.changes.Add(c.domain, 1)
+	}
+	return err
+}
+
+func sameAddrs(a, b []netip.Addr) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixMilli()) / 1000
+}
+
+// Registry is a Registerer which keeps the metrics in memory.
+// It is an http.Handler which serves them in the Prometheus text format.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*registryMetric // in the order they were created
+	once    sync.Once
+	m       *Metrics // created by Instrument
+}
+
+// registryMetric is a metric of a Registry. It is both a Counter and a Gauge.
+type registryMetric struct {
+	reg             *Registry
+	name, help, typ string
+	values          map[string]float64 // by domain
+}
+
+// NewRegistry creates a Registry with no metrics.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter creates a counter in r, or returns the existing metric named name.
+func (r *Registry) Counter(name, help string) Counter {
+	return r.metric(name, help, "counter")
+}
+
+// Gauge creates a gauge in r, or returns the existing metric named name.
+func (r *Registry) Gauge(name, help string) Gauge {
+	return r.metric(name, help, "gauge")
+}
+
+func (r *Registry) metric(name, help, typ string) *registryMetric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.metrics {
+		if m.name == name {
+			return m
+		}
+	}
+	m := &registryMetric{reg: r, name: name, help: help, typ: typ, values: map[string]float64{}}
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+func (m *registryMetric) Add(domain string, delta float64) {
+	m.reg.mu.Lock()
+	defer m.reg.mu.Unlock()
+	m.values[domain] += delta
+}
+
+func (m *registryMetric) Set(domain string, value float64) {
+	m.reg.mu.Lock()
+	defer m.reg.mu.Unlock()
+	m.values[domain] = value
+}
+
+// Instrument returns a client which records each update of client in r, labeled with domain.
+// It is [Metrics.Instrument] with the metrics created in r.
+func (r *Registry) Instrument(domain string, client ddns.DDNSClient) ddns.DDNSClient {
+	r.once.Do(func() { r.m = New(r) })
+	return r.m.Instrument(domain, client)
+}
+
+// WriteTo writes the metrics to w in the Prometheus text format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	r.mu.Lock()
+	for _, m := range r.metrics {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.typ)
+		domains := make([]string, 0, len(m.values))
+		for d := range m.values {
+			domains = append(domains, d)
+		}
+		sort.Strings(domains)
+		for _, d := range domains {
+			fmt.Fprintf(&buf, "%s{domain=%s} %s\n", m.name, strconv.Quote(d), strconv.FormatFloat(m.values[d], 'f', -1, 64))
+		}
+	}
+	r.mu.Unlock()
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if req.Method == http.MethodGet {
+		r.WriteTo(w)
+	}
+}
@@ -0,0 +1,94 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+	"github.com/Travis-Britz/ddns/metrics"
+)
+
+type failingClient struct{}
+
+func (failingClient) RunDDNS(context.Context) error { return errors.New("update failed") }
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	ip := netip.MustParseAddr("192.0.2.1")
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) { return []netip.Addr{ip}, nil })
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}), ddns.UsingResolver(r))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	reg := metrics.NewRegistry()
+	c = reg.Instrument("home.example.com", c)
+	c.RunDDNS(ctx)
+	c.RunDDNS(ctx)
+	ip = netip.MustParseAddr("192.0.2.2")
+	c.RunDDNS(ctx)
+
+	failing := reg.Instrument("bad.example.com", failingClient{})
+	failing.RunDDNS(ctx)
+
+	w := httptest.NewRecorder()
+	reg.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	out := w.Body.String()
+	for _, want := range []string{
+		"# TYPE ddns_runs_total counter\n",
+		`ddns_runs_total{domain="home.example.com"} 3` + "\n",
+		`ddns_ip_changes_total{domain="home.example.com"} 1` + "\n",
+		`ddns_run_failures_total{domain="bad.example.com"} 1` + "\n",
+		`ddns_last_success_timestamp_seconds{domain="bad.example.com"} 0` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in the metrics; got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `ddns_last_success_timestamp_seconds{domain="home.example.com"} 0`+"\n") {
+		t.Errorf("Expected a success timestamp for home.example.com; got:\n%s", out)
+	}
+}
+
+// fakeRegisterer records the metrics created by metrics.New, like a program's own metrics library would.
+type fakeRegisterer map[string]map[string]float64
+
+func (f fakeRegisterer) Counter(name, help string) metrics.Counter { return f.metric(name) }
+func (f fakeRegisterer) Gauge(name, help string) metrics.Gauge     { return f.metric(name) }
+func (f fakeRegisterer) metric(name string) fakeMetric {
+	f[name] = map[string]float64{}
+	return fakeMetric(f[name])
+}
+
+type fakeMetric map[string]float64
+
+func (m fakeMetric) Add(domain string, delta float64) { m[domain] += delta }
+func (m fakeMetric) Set(domain string, value float64) { m[domain] = value }
+
+func TestRegisterer(t *testing.T) {
+	ctx := context.Background()
+	reg := fakeRegisterer{}
+	m := metrics.New(reg)
+	if len(reg) != 5 {
+		t.Errorf("Expected 5 metrics to be created; got %d", len(reg))
+	}
+	c := m.Instrument("bad.example.com", failingClient{})
+	if v, ok := reg[metrics.RunFailuresTotal]["bad.example.com"]; !ok || v != 0 {
+		t.Errorf("Expected the failure count to start at 0; got %v", v)
+	}
+	c.RunDDNS(ctx)
+	c.RunDDNS(ctx)
+	if got := reg[metrics.RunsTotal]["bad.example.com"]; got != 2 {
+		t.Errorf("Expected 2 runs; got %v", got)
+	}
+	if got := reg[metrics.RunFailuresTotal]["bad.example.com"]; got != 2 {
+		t.Errorf("Expected 2 failures; got %v", got)
+	}
+	if reg[metrics.LastRunTimestamp]["bad.example.com"] == 0 || reg[metrics.LastSuccessTimestamp]["bad.example.com"] != 0 {
+		t.Errorf("Expected a run timestamp and no success timestamp; got %v and %v", reg[metrics.LastRunTimestamp], reg[metrics.LastSuccessTimestamp])
+	}
+}
@@ -0,0 +1,295 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mikrotikAPI is a client of the RouterOS REST API, available in RouterOS 7.1 and later.
+type mikrotikAPI struct {
+	httpClient *http.Client
+	server     *url.URL
+	user       string
+	password   string
+}
+
+func newMikroTikAPI(routerURL, user, password string) (*mikrotikAPI, error) {
+	u, err := url.Parse(routerURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing router URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("router URL must be http or https; got \"%s\"", routerURL)
+	}
+	return &mikrotikAPI{server: u, user: user, password: password}, nil
+}
+
+// call makes a request to path under /rest with body encoded as JSON, and decodes the response into result if it is not nil.
+func (m *mikrotikAPI) call(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	u := *m.server
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/rest" + path
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	req.SetBasicAuth(m.user, m.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := m.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &mikrotikError{status: resp.StatusCode, msg: mikrotikErrorMessage(resp)}
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+// MikroTikResolver constructs a resolver that returns the addresses of iface on a MikroTik router,
+// e.g. its WAN interface "ether1" or "pppoe-out1", read with the RouterOS REST API.
+//
+// routerURL is the address of the router's web service, e.g. "https://192.168.88.1";
+// the www-ssl service must be enabled with a certificate the http.Client trusts (see [UsingHTTPClient]).
+// user should be a RouterOS user in a group with only the read and api policies.
+// Disabled, invalid, and link-local addresses are skipped.
+func MikroTikResolver(routerURL, user, password, iface string) Resolver {
+	r := &mikrotikResolver{iface: iface}
+	r.api, r.err = newMikroTikAPI(routerURL, user, password)
+	if r.err == nil && iface == "" {
+		r.err = errors.New("interface cannot be empty")
+	}
+	return r
+}
+
+type mikrotikResolver struct {
+	api   *mikrotikAPI
+	iface string
+	err   error // configuration error, returned by Resolve
+}
+
+func (r *mikrotikResolver) validate() error { return r.err }
+
+func (r *mikrotikResolver) SetHTTPClient(httpclient *http.Client) {
+	if r.api != nil {
+		r.api.httpClient = httpclient
+	}
+}
+
+func (r *mikrotikResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var addrs []netip.Addr
+	for _, path := range []string{"/ip/address", "/ipv6/address"} {
+		var entries []struct {
+			Address   string `json:"address"`
+			Disabled  string `json:"disabled"`
+			Invalid   string `json:"invalid"`
+			LinkLocal string `json:"link-local"`
+		}
+		query := url.Values{"interface": {r.iface}}
+		if err := r.api.call(ctx, http.MethodGet, path, query, nil, &entries); err != nil {
+			return nil, fmt.Errorf("unable to get addresses of %s: %w", r.iface, err)
+		}
+		for _, e := range entries {
+			if e.Disabled == "true" || e.Invalid == "true" || e.LinkLocal == "true" {
+				continue
+			}
+			// the address of a PPPoE client is a /32 whose network is the peer
+			p, err := netip.ParsePrefix(e.Address)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing IP from address \"%s\": %w", e.Address, err)
+			}
+			if !p.Addr().IsLinkLocalUnicast() {
+				addrs = append(addrs, p.Addr())
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("interface %s has no addresses", r.iface)
+	}
+	return addrs, nil
+}
+
+// NewMikroTik is used by [ddns.New] to create a Provider which maintains static DNS entries on a MikroTik router
+// with the RouterOS REST API, e.g. to resolve the public name to an internal address for split-horizon DNS.
+//
+// routerURL, user, and password are as for [MikroTikResolver];
+// user needs the write policy as well.
+// Entries created by the provider are given the comment "ddns".
+func NewMikroTik(routerURL, user, password string) func() (Provider, error) {
+	return func() (Provider, error) {
+		api, err := newMikroTikAPI(routerURL, user, password)
+		if err != nil {
+			return nil, err
+		}
+		return &mikrotikProvider{api: api, logger: discard}, nil
+	}
+}
+
+type mikrotikProvider struct {
+	api    *mikrotikAPI
+	logger *log.Logger
+	ttl    int // seconds, or 0 for the router's default
+}
+
+func (p *mikrotikProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *mikrotikProvider) SetHTTPClient(httpclient *http.Client) {
+	p.api.httpClient = httpclient
+}
+
+func (p *mikrotikProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, TTL: true}
+}
+
+func (p *mikrotikProvider) SetTTL(ttl time.Duration) {
+	p.ttl = ttlSeconds(ttl)
+}
+
+// mikrotikEntry is a static DNS entry on a RouterOS router.
+// RouterOS encodes every value as a string, and omits the type of A records.
+type mikrotikEntry struct {
+	ID       string `json:".id,omitempty"`
+	Name     string `json:"name"`
+	Type     string `json:"type,omitempty"`
+	Address  string `json:"address"`
+	TTL      string `json:"ttl,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	Disabled string `json:"disabled,omitempty"`
+}
+
+func (p *mikrotikProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	existing, err := p.entries(ctx, domain)
+	if err != nil {
+		return err
+	}
+	return Reconcile(ctx, existing, records, mikrotikApplier{p: p, domain: canonicalName(domain)})
+}
+
+func (p *mikrotikProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	existing, err := p.entries(ctx, domain)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	for _, r := range existing {
+		addrs = append(addrs, r.Addr)
+	}
+	return addrs, nil
+}
+
+// entries returns the A and AAAA entries for domain.
+func (p *mikrotikProvider) entries(ctx context.Context, domain string) ([]Record, error) {
+	var entries []mikrotikEntry
+	query := url.Values{"name": {canonicalName(domain)}}
+	if err := p.api.call(ctx, http.MethodGet, "/ip/dns/static", query, nil, &entries); err != nil {
+		return nil, fmt.Errorf("unable to get static DNS entries for %s: %w", domain, err)
+	}
+	var records []Record
+	for _, e := range entries {
+		if (e.Type != "" && e.Type != "A" && e.Type != "AAAA") || e.Disabled == "true" {
+			continue
+		}
+		a, err := netip.ParseAddr(e.Address)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing IP from entry %s: %w", e.ID, err)
+		}
+		records = append(records, Record{ID: e.ID, Addr: a})
+	}
+	return records, nil
+}
+
+// mikrotikApplier applies record changes for one domain as RouterOS static DNS entries.
+type mikrotikApplier struct {
+	p      *mikrotikProvider
+	domain string
+}
+
+func (a mikrotikApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.p.logger.Printf("adding static DNS entry %s for %s...\n", addr, a.domain)
+	e := mikrotikEntry{Name: a.domain, Address: addr.String(), Comment: "ddns"}
+	if !addr.Unmap().Is4() {
+		e.Type = "AAAA"
+	}
+	if a.p.ttl > 0 {
+		e.TTL = fmt.Sprintf("%ds", a.p.ttl)
+	}
+	return a.p.api.call(ctx, http.MethodPut, "/ip/dns/static", nil, e, nil)
+}
+
+func (a mikrotikApplier) Update(ctx context.Context, r Record, addr netip.Addr) error {
+	a.p.logger.Printf("updating static DNS entry %s for %s to %s...\n", r.Addr, a.domain, addr)
+	body := map[string]string{"address": addr.String()}
+	if a.p.ttl > 0 {
+		body["ttl"] = fmt.Sprintf("%ds", a.p.ttl)
+	}
+	if err := a.p.api.call(ctx, http.MethodPatch, "/ip/dns/static/"+r.ID, nil, body, nil); err != nil {
+		return fmt.Errorf("entry %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+func (a mikrotikApplier) Delete(ctx context.Context, r Record) error {
+	a.p.logger.Printf("deleting static DNS entry %s for %s...\n", r.Addr, a.domain)
+	if err := a.p.api.call(ctx, http.MethodDelete, "/ip/dns/static/"+r.ID, nil, nil, nil); err != nil {
+		return fmt.Errorf("entry %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+func mikrotikErrorMessage(resp *http.Response) string {
+	var body struct {
+		Message string `json:"message"`
+		Detail  string `json:"detail"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(b, &body) != nil || (body.Message == "" && body.Detail == "") {
+		return "unexpected response status " + resp.Status
+	}
+	return strings.TrimSpace(body.Message + ": " + body.Detail)
+}
+
+type mikrotikError struct {
+	status int
+	msg    string
+}
+
+func (e *mikrotikError) Error() string               { return e.msg }
+func (e *mikrotikError) IsAuthenticationError() bool { return e.status == http.StatusUnauthorized }
+func (e *mikrotikError) IsAuthorizationError() bool  { return e.status == http.StatusForbidden }
+func (e *mikrotikError) IsRateLimited() bool         { return e.status == http.StatusTooManyRequests }
+func (e *mikrotikError) IsTemporary() bool           { return e.status >= 500 }
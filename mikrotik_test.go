@@ -0,0 +1,114 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// fakeRouterOS is a minimal implementation of the RouterOS REST API endpoints used by the MikroTik resolver and provider.
+type fakeRouterOS struct {
+	mu     sync.Mutex
+	static map[string]map[string]string // static DNS entries by .id
+	nextID int
+}
+
+func (f *fakeRouterOS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if user, pass, ok := r.BasicAuth(); !ok || user != "ddns" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":401,"message":"Unauthorized"}`)
+		return
+	}
+	id, _ := strings.CutPrefix(r.URL.Path, "/rest/ip/dns/static/")
+	switch {
+	case r.URL.Path == "/rest/ip/address" && r.URL.Query().Get("interface") == "pppoe-out1":
+		fmt.Fprint(w, `[{".id":"*1","address":"203.0.113.7/32","network":"100.64.0.1","interface":"pppoe-out1","dynamic":"true","disabled":"false","invalid":"false"}]`)
+	case r.URL.Path == "/rest/ipv6/address" && r.URL.Query().Get("interface") == "pppoe-out1":
+		fmt.Fprint(w, `[{".id":"*2","address":"fe80::1/64","interface":"pppoe-out1","link-local":"true","disabled":"false","invalid":"false"},`+
+			`{".id":"*3","address":"2001:db8::7/64","interface":"pppoe-out1","disabled":"false","invalid":"false"}]`)
+	case r.URL.Path == "/rest/ip/dns/static" && r.Method == http.MethodGet:
+		entries := []map[string]string{}
+		for _, e := range f.static {
+			if e["name"] == r.URL.Query().Get("name") {
+				entries = append(entries, e)
+			}
+		}
+		json.NewEncoder(w).Encode(entries)
+	case r.URL.Path == "/rest/ip/dns/static" && r.Method == http.MethodPut:
+		var e map[string]string
+		json.NewDecoder(r.Body).Decode(&e)
+		f.nextID++
+		e[".id"] = fmt.Sprintf("*%X", f.nextID)
+		f.static[e[".id"]] = e
+		json.NewEncoder(w).Encode(e)
+	case f.static[id] != nil && r.Method == http.MethodPatch:
+		var e map[string]string
+		json.NewDecoder(r.Body).Decode(&e)
+		for k, v := range e {
+			f.static[id][k] = v
+		}
+		json.NewEncoder(w).Encode(f.static[id])
+	case f.static[id] != nil && r.Method == http.MethodDelete:
+		delete(f.static, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":404,"message":"Not Found"}`)
+	}
+}
+
+func TestMikroTik(t *testing.T) {
+	f := &fakeRouterOS{static: map[string]map[string]string{
+		"*A0": {".id": "*A0", "name": "home.example.com", "address": "192.168.88.5", "ttl": "1d", "disabled": "false"},
+		"*A1": {".id": "*A1", "name": "home.example.com", "type": "AAAA", "address": "2001:db8::5", "ttl": "1d", "disabled": "false"},
+		"*A2": {".id": "*A2", "name": "other.example.com", "address": "192.168.88.6", "ttl": "1d", "disabled": "false"},
+	}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	addrs, err := ddns.MikroTikResolver(srv.URL, "ddns", "secret", "pppoe-out1").Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %s", err)
+	}
+	if expected := []netip.Addr{netip.MustParseAddr("203.0.113.7"), netip.MustParseAddr("2001:db8::7")}; !reflect.DeepEqual(addrs, expected) {
+		t.Errorf("Expected %q; got %q", expected, addrs)
+	}
+
+	c, err := ddns.New("home.example.com", ddns.NewMikroTik(srv.URL, "ddns", "secret"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.168.88.10"))),
+		ddns.WithTTL(5*time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	var got []string
+	for id, e := range f.static {
+		got = append(got, id+" "+e["name"]+" "+e["address"]+" "+e["ttl"])
+	}
+	sort.Strings(got)
+	// the A entry is updated in place and the AAAA entry deleted
+	expected := []string{"*A0 home.example.com 192.168.88.10 300s", "*A2 other.example.com 192.168.88.6 1d"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q; got %q", expected, got)
+	}
+
+	if _, err := ddns.MikroTikResolver(srv.URL, "ddns", "wrong", "pppoe-out1").Resolve(context.Background()); !ddns.IsAuthError(err) {
+		t.Errorf("Expected an authentication error; got %v", err)
+	}
+}
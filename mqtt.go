@@ -0,0 +1,363 @@
+package ddns
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotifyMQTT constructs a Notifier which publishes notifications to an MQTT broker,
+// e.g. for Home Assistant to show the public IP as a sensor and trigger automations when it changes.
+//
+// broker is a URL such as "mqtt://broker.lan:1883", or "mqtts://broker.example.com:8883" for TLS.
+// Each notification is published as JSON to topic, and the state of the domain is retained in subtopics:
+//   - topic/ip: the records as a comma-separated list of addresses, published by change notifications (see [NotifyOnChange])
+//   - topic/last_update: the time the records last changed, in RFC 3339 format
+//   - topic/failing: ON while updates are failing, and OFF once they succeed
+//
+// Messages are published with QoS 1 over a new connection for each notification.
+// Use [MQTTHomeAssistant] to add the state to Home Assistant as sensors.
+func NotifyMQTT(broker, topic string, options ...mqttOption) Notifier {
+	m := &mqttNotifier{topic: topic}
+	m.err = m.configure(broker, options)
+	return m
+}
+
+type mqttOption func(*mqttNotifier) error
+
+// MQTTAuth authenticates to the broker with a user name and password.
+func MQTTAuth(username, password string) mqttOption {
+	return func(m *mqttNotifier) error {
+		m.username, m.password = username, password
+		return nil
+	}
+}
+
+// MQTTClientID sets the client identifier sent to the broker.
+// The default is "ddns-" followed by the host name.
+func MQTTClientID(id string) mqttOption {
+	return func(m *mqttNotifier) error {
+		if id == "" {
+			return errors.New("client ID cannot be empty")
+		}
+		m.clientID = id
+		return nil
+	}
+}
+
+// MQTTTLSConfig sets the TLS configuration for mqtts:// brokers, e.g. to trust a private CA.
+func MQTTTLSConfig(config *tls.Config) mqttOption {
+	return func(m *mqttNotifier) error {
+		m.tlsConfig = config
+		return nil
+	}
+}
+
+// MQTTHomeAssistant publishes Home Assistant MQTT discovery configs with the first notification,
+// so that the public IP, last update time, and a problem sensor for failing updates appear as a device in Home Assistant.
+// prefix is the discovery prefix; "" uses the default, "homeassistant".
+func MQTTHomeAssistant(prefix string) mqttOption {
+	return func(m *mqttNotifier) error {
+		if prefix == "" {
+			prefix = "homeassistant"
+		}
+		m.discoveryPrefix = prefix
+		return nil
+	}
+}
+
+type mqttNotifier struct {
+	addr      string // host:port
+	useTLS    bool
+	topic     string
+	clientID  string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+	err       error // configuration error, returned by Notify
+
+	discoveryPrefix string // Home Assistant discovery is published if not empty
+	mu              sync.Mutex
+	discovered      bool
+}
+
+func (m *mqttNotifier) configure(broker string, options []mqttOption) error {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return fmt.Errorf("error parsing broker URL: %w", err)
+	}
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		m.useTLS, port = true, "8883"
+	default:
+		return fmt.Errorf("broker URL must be mqtt:// or mqtts://; got \"%s\"", broker)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	m.addr = net.JoinHostPort(u.Hostname(), port)
+	if m.topic == "" || strings.ContainsAny(m.topic, "+#") {
+		return fmt.Errorf("invalid MQTT topic \"%s\"", m.topic)
+	}
+	if u.User != nil {
+		m.username = u.User.Username()
+		m.password, _ = u.User.Password()
+	}
+	hostname, _ := os.Hostname()
+	m.clientID = "ddns-" + hostname
+	for _, opt := range options {
+		if err := opt(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mqttNotifier) validate() error { return m.err }
+
+func (m *mqttNotifier) Notify(ctx context.Context, n Notification) error {
+	if m.err != nil {
+		return m.err
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("unable to connect to MQTT broker: %w", err)
+	}
+	defer conn.Close()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+	if m.useTLS {
+		config := &tls.Config{}
+		if m.tlsConfig != nil {
+			config = m.tlsConfig.Clone()
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(m.addr)
+		}
+		conn = tls.Client(conn, config)
+	}
+	s := &mqttSession{w: conn, r: bufio.NewReader(conn)}
+	if err := s.connect(m.clientID, m.username, m.password); err != nil {
+		return err
+	}
+	if err := m.discover(s, n.Domain); err != nil {
+		return err
+	}
+	if err := s.publish(m.topic, payload, false); err != nil {
+		return err
+	}
+	for _, msg := range mqttState(n) {
+		if err := s.publish(m.topic+"/"+msg.subtopic, []byte(msg.payload), true); err != nil {
+			return err
+		}
+	}
+	// DISCONNECT
+	_, err = conn.Write([]byte{0xe0, 0})
+	return err
+}
+
+// mqttState returns the retained state messages for n.
+func mqttState(n Notification) []struct{ subtopic, payload string } {
+	if !n.Changed {
+		failing := "ON"
+		if n.Recovered {
+			failing = "OFF"
+		}
+		return []struct{ subtopic, payload string }{{"failing", failing}}
+	}
+	ips := make([]string, len(n.New))
+	for i, a := range n.New {
+		ips[i] = a.String()
+	}
+	return []struct{ subtopic, payload string }{
+		{"ip", strings.Join(ips, ",")},
+		{"last_update", n.Time.Format(time.RFC3339)},
+		{"failing", "OFF"},
+	}
+}
+
+// discover publishes the Home Assistant discovery configs, if enabled, unless they have already been published.
+func (m *mqttNotifier) discover(s *mqttSession, domain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.discoveryPrefix == "" || m.discovered {
+		return nil
+	}
+	node := mqttNodeID(m.topic)
+	device := map[string]any{"identifiers": []string{node}, "name": domain, "model": "ddns", "sw_version": Version()}
+	configs := []struct {
+		component, object string
+		config            map[string]any
+	}{
+		{"sensor", "ip", map[string]any{"name": "Public IP", "icon": "mdi:ip-network"}},
+		{"sensor", "last_update", map[string]any{"name": "Last update", "device_class": "timestamp"}},
+		{"binary_sensor", "failing", map[string]any{"name": "Update failing", "device_class": "problem", "payload_on": "ON", "payload_off": "OFF"}},
+	}
+	for _, c := range configs {
+		c.config["unique_id"] = node + "_" + c.object
+		c.config["state_topic"] = m.topic + "/" + c.object
+		c.config["device"] = device
+		b, err := json.Marshal(c.config)
+		if err != nil {
+			return err
+		}
+		if err := s.publish(strings.Join([]string{m.discoveryPrefix, c.component, node, c.object, "config"}, "/"), b, true); err != nil {
+			return err
+		}
+	}
+	m.discovered = true
+	return nil
+}
+
+// mqttNodeID returns topic with the characters not allowed in a Home Assistant node ID replaced with underscores.
+func mqttNodeID(topic string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, topic)
+}
+
+// mqttSession writes MQTT 3.1.1 packets and reads their acknowledgements.
+type mqttSession struct {
+	w      io.Writer
+	r      *bufio.Reader
+	nextID uint16
+}
+
+// mqttConnectErrors are the CONNACK return codes.
+var mqttConnectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+func (s *mqttSession) connect(clientID, username, password string) error {
+	flags := byte(0x02) // clean session
+	body := mqttString(nil, "MQTT")
+	payload := mqttString(nil, clientID)
+	if username != "" {
+		flags |= 0x80
+		payload = mqttString(payload, username)
+		if password != "" {
+			flags |= 0x40
+			payload = mqttString(payload, password)
+		}
+	}
+	body = append(body, 4, flags, 0, 60) // protocol level 4, keep alive 60 seconds
+	if err := s.send(0x10, append(body, payload...)); err != nil {
+		return fmt.Errorf("error sending MQTT CONNECT: %w", err)
+	}
+	typ, ack, err := s.receive()
+	if err != nil {
+		return fmt.Errorf("error reading MQTT CONNACK: %w", err)
+	}
+	if typ != 0x20 || len(ack) != 2 {
+		return fmt.Errorf("unexpected MQTT packet type %d; expected CONNACK", typ>>4)
+	}
+	if ack[1] != 0 {
+		msg, ok := mqttConnectErrors[ack[1]]
+		if !ok {
+			msg = fmt.Sprintf("return code %d", ack[1])
+		}
+		return fmt.Errorf("MQTT broker refused connection: %s", msg)
+	}
+	return nil
+}
+
+// publish sends payload to topic with QoS 1 and waits for the broker's PUBACK.
+func (s *mqttSession) publish(topic string, payload []byte, retain bool) error {
+	s.nextID++
+	header := byte(0x30 | 1<<1) // PUBLISH, QoS 1
+	if retain {
+		header |= 1
+	}
+	body := binary.BigEndian.AppendUint16(mqttString(nil, topic), s.nextID)
+	if err := s.send(header, append(body, payload...)); err != nil {
+		return fmt.Errorf("error publishing to %s: %w", topic, err)
+	}
+	typ, ack, err := s.receive()
+	if err != nil {
+		return fmt.Errorf("error reading PUBACK for %s: %w", topic, err)
+	}
+	if typ != 0x40 || len(ack) != 2 || binary.BigEndian.Uint16(ack) != s.nextID {
+		return fmt.Errorf("unexpected MQTT packet type %d; expected PUBACK", typ>>4)
+	}
+	return nil
+}
+
+// send writes a packet with the fixed header byte and the body.
+func (s *mqttSession) send(header byte, body []byte) error {
+	packet := []byte{header}
+	// the remaining length is encoded 7 bits at a time, least significant first
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	_, err := s.w.Write(append(packet, body...))
+	return err
+}
+
+// receive reads a packet, returning the fixed header byte and the body.
+func (s *mqttSession) receive() (byte, []byte, error) {
+	header, err := s.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, errors.New("invalid MQTT remaining length")
+		}
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+// mqttString appends s to b with its two byte length prefix.
+func mqttString(b []byte, s string) []byte {
+	return append(binary.BigEndian.AppendUint16(b, uint16(len(s))), s...)
+}
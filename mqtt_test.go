@@ -0,0 +1,137 @@
+package ddns_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+type mqttMessage struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// fakeBroker accepts one MQTT connection, returning the CONNECT body and the messages published.
+func fakeBroker(t *testing.T) (url string, connect <-chan []byte, messages <-chan []mqttMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	connectc, messagesc := make(chan []byte, 1), make(chan []mqttMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []mqttMessage
+		defer func() { messagesc <- msgs }()
+		for {
+			header, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			n, shift := 0, 0
+			for {
+				b, _ := r.ReadByte()
+				n |= int(b&0x7f) << shift
+				shift += 7
+				if b&0x80 == 0 {
+					break
+				}
+			}
+			body := make([]byte, n)
+			io.ReadFull(r, body)
+			switch header >> 4 {
+			case 1: // CONNECT
+				connectc <- body
+				conn.Write([]byte{0x20, 2, 0, 0})
+			case 3: // PUBLISH with QoS 1
+				l := int(binary.BigEndian.Uint16(body))
+				topic, id := string(body[2:2+l]), body[2+l:4+l]
+				msgs = append(msgs, mqttMessage{topic, string(body[4+l:]), header&1 == 1})
+				conn.Write([]byte{0x40, 2, id[0], id[1]})
+			case 14: // DISCONNECT
+				return
+			}
+		}
+	}()
+	return "mqtt://ha:secret@" + ln.Addr().String(), connectc, messagesc
+}
+
+func TestNotifyMQTT(t *testing.T) {
+	broker, connect, messages := fakeBroker(t)
+	n := ddns.NotifyMQTT(broker, "ddns/home", ddns.MQTTClientID("ddns-test"))
+	err := n.Notify(context.Background(), ddns.Notification{
+		Time:    time.Now(),
+		Domain:  "home.example.com",
+		Changed: true,
+		New:     []netip.Addr{netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::2")},
+	})
+	if err != nil {
+		t.Fatalf("Notify returned an error: %s", err)
+	}
+	// protocol name, level, flags, keep alive, then the client ID, user name, and password
+	want := "\x00\x04MQTT\x04\xc2\x00\x3c\x00\x09ddns-test\x00\x02ha\x00\x06secret"
+	if got := string(<-connect); got != want {
+		t.Errorf("Expected CONNECT %q; got %q", want, got)
+	}
+	msgs := <-messages
+	if len(msgs) != 4 {
+		t.Fatalf("Expected the event and 3 state messages; got %+v", msgs)
+	}
+	var event ddns.Notification
+	if err := json.Unmarshal([]byte(msgs[0].payload), &event); err != nil || msgs[0].topic != "ddns/home" || msgs[0].retain || event.Domain != "home.example.com" {
+		t.Errorf("Expected a change event on ddns/home; got %+v", msgs[0])
+	}
+	if want := (mqttMessage{"ddns/home/ip", "192.0.2.2,2001:db8::2", true}); msgs[1] != want {
+		t.Errorf("Expected %+v; got %+v", want, msgs[1])
+	}
+	if want := (mqttMessage{"ddns/home/failing", "OFF", true}); msgs[3] != want {
+		t.Errorf("Expected %+v; got %+v", want, msgs[3])
+	}
+
+	for _, broker := range []string{"http://broker.lan", "mqtt://broker.lan/"} {
+		if err := ddns.NotifyMQTT(broker, "ddns/#").Notify(context.Background(), ddns.Notification{}); err == nil {
+			t.Errorf("Expected an error for broker %s and a wildcard topic", broker)
+		}
+	}
+}
+
+func TestMQTTHomeAssistant(t *testing.T) {
+	broker, _, messages := fakeBroker(t)
+	n := ddns.NotifyMQTT(broker, "ddns/home", ddns.MQTTHomeAssistant(""))
+	if err := n.Notify(context.Background(), ddns.Notification{Domain: "home.example.com", Failures: 1, Error: "update failed"}); err != nil {
+		t.Fatalf("Notify returned an error: %s", err)
+	}
+	msgs := <-messages
+	if len(msgs) != 5 {
+		t.Fatalf("Expected 3 discovery configs, the event, and the failing state; got %+v", msgs)
+	}
+	var config struct {
+		UniqueID    string `json:"unique_id"`
+		StateTopic  string `json:"state_topic"`
+		DeviceClass string `json:"device_class"`
+	}
+	if msgs[2].topic != "homeassistant/binary_sensor/ddns_home/failing/config" || !msgs[2].retain {
+		t.Errorf("Expected the binary sensor config; got %+v", msgs[2])
+	}
+	json.Unmarshal([]byte(msgs[2].payload), &config)
+	if config.UniqueID != "ddns_home_failing" || config.StateTopic != "ddns/home/failing" || config.DeviceClass != "problem" {
+		t.Errorf("Unexpected binary sensor config %s", msgs[2].payload)
+	}
+	if want := (mqttMessage{"ddns/home/failing", "ON", true}); msgs[4] != want {
+		t.Errorf("Expected %+v; got %+v", want, msgs[4])
+	}
+}
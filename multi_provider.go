@@ -0,0 +1,428 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MultiProvider is used by [ddns.New] to create a Provider which sets the same records with each of the given providers.
+//
+// Combined with [Filter], this allows one resolver run to maintain different views of a domain,
+// e.g. publishing the public IP to Cloudflare and the LAN IP to an internal name server:
+//
+//	ddns.MultiProvider(
+//		ddns.Filter(ddns.NewCloudflare(token), ddns.PublicAddr),
+//		ddns.Filter(ddns.NewRFC2136("192.168.1.2"), ddns.PrivateAddr),
+//	)
+//
+// The providers are updated concurrently, and every provider is updated even if another one fails.
+// Failures are returned as a [*MultiProviderError].
+// Wrap slow providers with [ProviderTimeout] so that they don't hold up the update.
+func MultiProvider(providers ...providerFn) func() (Provider, error) {
+	return func() (Provider, error) {
+		if len(providers) == 0 {
+			return nil, errors.New("no providers were given")
+		}
+		var mp multiProvider
+		for i, fn := range providers {
+			p, err := fn()
+			if err != nil {
+				return nil, fmt.Errorf("unable to create provider %d: %w", i, err)
+			}
+			if p == nil {
+				return nil, fmt.Errorf("provider %d cannot be nil", i)
+			}
+			mp = append(mp, p)
+		}
+		return mp, nil
+	}
+}
+
+type multiProvider []Provider
+
+func (mp multiProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	return mp.each(func(p Provider) error {
+		return p.SetDNSRecords(ctx, domain, records)
+	})
+}
+
+// each calls fn concurrently for every provider and collects the errors.
+func (mp multiProvider) each(fn func(Provider) error) error {
+	errs := make([]error, len(mp))
+	var wg sync.WaitGroup
+	for i, p := range mp {
+		wg.Add(1)
+		go func(i int, p Provider) {
+			defer wg.Done()
+			defer recoverError(&errs[i])
+			errs[i] = fn(p)
+		}(i, p)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return &MultiProviderError{Errors: errs}
+		}
+	}
+	return nil
+}
+
+// MultiProviderError is returned by a [MultiProvider] when any of its providers fail.
+type MultiProviderError struct {
+	// Errors holds the error of each provider, in the order given to MultiProvider.
+	// The error is nil for providers which succeeded.
+	Errors []error
+}
+
+// Failed returns the indexes of the providers which failed.
+func (e *MultiProviderError) Failed() []int {
+	var failed []int
+	for i, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+func (e *MultiProviderError) Error() string {
+	var msgs []string
+	for _, i := range e.Failed() {
+		msgs = append(msgs, fmt.Sprintf("provider %d: %s", i, e.Errors[i]))
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *MultiProviderError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// GetDNSRecords returns the records from the first provider which can list them.
+func (mp multiProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	for _, p := range mp {
+		if rg, ok := p.(RecordGetter); ok {
+			return rg.GetDNSRecords(ctx, domain)
+		}
+	}
+	return nil, errGetRecordsUnsupported
+}
+
+// GetTXTRecords returns the TXT records from the first provider which supports them.
+func (mp multiProvider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	for _, p := range mp {
+		if tp, ok := p.(TXTProvider); ok {
+			return tp.GetTXTRecords(ctx, name)
+		}
+	}
+	return nil, errTXTUnsupported
+}
+
+// SetTXTRecords sets the TXT records with every provider which supports them.
+func (mp multiProvider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	supported := false
+	for _, p := range mp {
+		if _, ok := p.(TXTProvider); ok {
+			supported = true
+		}
+	}
+	if !supported {
+		return errTXTUnsupported
+	}
+	return mp.each(func(p Provider) error {
+		if tp, ok := p.(TXTProvider); ok {
+			return tp.SetTXTRecords(ctx, name, values)
+		}
+		return nil
+	})
+}
+
+// GetSRVRecords returns the SRV records from the first provider which supports them.
+func (mp multiProvider) GetSRVRecords(ctx context.Context, name string) ([]SRV, error) {
+	for _, p := range mp {
+		if sp, ok := p.(SRVProvider); ok {
+			return sp.GetSRVRecords(ctx, name)
+		}
+	}
+	return nil, errSRVUnsupported
+}
+
+// SetSRVRecords sets the SRV records with every provider which supports them.
+func (mp multiProvider) SetSRVRecords(ctx context.Context, name string, records []SRV) error {
+	supported := false
+	for _, p := range mp {
+		if _, ok := p.(SRVProvider); ok {
+			supported = true
+		}
+	}
+	if !supported {
+		return errSRVUnsupported
+	}
+	return mp.each(func(p Provider) error {
+		if sp, ok := p.(SRVProvider); ok {
+			return sp.SetSRVRecords(ctx, name, records)
+		}
+		return nil
+	})
+}
+
+// Capabilities reports the address families supported by all of the providers,
+// since SetDNSRecords sends the same records to each of them,
+// and the other features supported by any of the providers,
+// matching the behavior of the optional methods which use the first capable provider.
+// A provider wrapped with [Filter] supports the families its filter drops, so it doesn't limit the others.
+// Batch updates are never reported since each provider is updated separately.
+func (mp multiProvider) Capabilities() Capabilities {
+	caps := Capabilities{IPv4: len(mp) > 0, IPv6: len(mp) > 0}
+	for _, p := range mp {
+		pc := ProviderCapabilities(p)
+		caps.IPv4 = caps.IPv4 && pc.IPv4
+		caps.IPv6 = caps.IPv6 && pc.IPv6
+		caps.TXT = caps.TXT || pc.TXT
+		caps.SRV = caps.SRV || pc.SRV
+		caps.ListRecords = caps.ListRecords || pc.ListRecords
+		caps.Proxy = caps.Proxy || pc.Proxy
+		caps.TTL = caps.TTL || pc.TTL
+		if pc.TTL && pc.MinTTL > caps.MinTTL {
+			caps.MinTTL = pc.MinTTL
+		}
+	}
+	return caps
+}
+
+// checkFamilies checks each address against the providers which will receive it,
+// rather than requiring every provider to support every family.
+func (mp multiProvider) checkFamilies(addrs []netip.Addr) error {
+	for i, p := range mp {
+		if err := checkProviderFamilies(p, addrs); err != nil {
+			return fmt.Errorf("provider %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// RotateCredentials replaces the credentials of every provider which supports it,
+// so the providers must share a credential, such as several Cloudflare zones using one API token.
+func (mp multiProvider) RotateCredentials(ctx context.Context, token string) error {
+	supported := false
+	for _, p := range mp {
+		if _, ok := p.(CredentialRotator); ok {
+			supported = true
+		}
+	}
+	if !supported {
+		return errors.New("provider does not support credential rotation")
+	}
+	return mp.each(func(p Provider) error {
+		if r, ok := p.(CredentialRotator); ok {
+			return r.RotateCredentials(ctx, token)
+		}
+		return nil
+	})
+}
+
+func (mp multiProvider) SetLogger(logger *log.Logger) {
+	for _, p := range mp {
+		setLogger(p, logger)
+	}
+}
+
+func (mp multiProvider) SetHTTPClient(httpclient *http.Client) {
+	for _, p := range mp {
+		setHTTPClient(p, httpclient)
+	}
+}
+
+// SetTTL sets the TTL for the providers which support it.
+func (mp multiProvider) SetTTL(ttl time.Duration) {
+	for _, p := range mp {
+		setTTL(p, ttl)
+	}
+}
+
+// Filter is used by [ddns.New] to wrap a Provider so that it only receives the addresses for which keep returns true.
+//
+// Filter functions in this package: [PublicAddr], [PrivateAddr], [IPv4Addr], [IPv6Addr].
+func Filter(provider providerFn, keep func(netip.Addr) bool) func() (Provider, error) {
+	return func() (Provider, error) {
+		p, err := provider()
+		if err != nil {
+			return nil, err
+		}
+		if p == nil {
+			return nil, errors.New("provider cannot be nil")
+		}
+		if keep == nil {
+			return nil, errors.New("filter function cannot be nil")
+		}
+		return &filterProvider{Provider: p, keep: keep}, nil
+	}
+}
+
+// ProviderTimeout is used by [ddns.New] to wrap a Provider so that each update is canceled after d.
+// This is useful with [MultiProvider] so that a slow provider fails on its own
+// instead of delaying the update past the daemon interval.
+func ProviderTimeout(provider providerFn, d time.Duration) func() (Provider, error) {
+	return func() (Provider, error) {
+		if d <= 0 {
+			return nil, errors.New("timeout must be positive")
+		}
+		p, err := Filter(provider, func(netip.Addr) bool { return true })()
+		if err != nil {
+			return nil, err
+		}
+		p.(*filterProvider).timeout = d
+		return p, nil
+	}
+}
+
+// filterProvider wraps a Provider to limit the addresses it receives and how long its updates may take.
+type filterProvider struct {
+	Provider
+	keep    func(netip.Addr) bool
+	timeout time.Duration
+}
+
+func (fp *filterProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	filtered := fp.filter(records)
+	if fp.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fp.timeout)
+		defer cancel()
+	}
+	return fp.Provider.SetDNSRecords(ctx, domain, filtered)
+}
+
+func (fp *filterProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	if rg, ok := fp.Provider.(RecordGetter); ok {
+		return rg.GetDNSRecords(ctx, domain)
+	}
+	return nil, errGetRecordsUnsupported
+}
+
+func (fp *filterProvider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	if tp, ok := fp.Provider.(TXTProvider); ok {
+		return tp.GetTXTRecords(ctx, name)
+	}
+	return nil, errTXTUnsupported
+}
+
+func (fp *filterProvider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	if fp.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fp.timeout)
+		defer cancel()
+	}
+	if tp, ok := fp.Provider.(TXTProvider); ok {
+		return tp.SetTXTRecords(ctx, name, values)
+	}
+	return errTXTUnsupported
+}
+
+func (fp *filterProvider) GetSRVRecords(ctx context.Context, name string) ([]SRV, error) {
+	if sp, ok := fp.Provider.(SRVProvider); ok {
+		return sp.GetSRVRecords(ctx, name)
+	}
+	return nil, errSRVUnsupported
+}
+
+func (fp *filterProvider) SetSRVRecords(ctx context.Context, name string, records []SRV) error {
+	if fp.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fp.timeout)
+		defer cancel()
+	}
+	if sp, ok := fp.Provider.(SRVProvider); ok {
+		return sp.SetSRVRecords(ctx, name, records)
+	}
+	return errSRVUnsupported
+}
+
+func (fp *filterProvider) RotateCredentials(ctx context.Context, token string) error {
+	if r, ok := fp.Provider.(CredentialRotator); ok {
+		return r.RotateCredentials(ctx, token)
+	}
+	return errors.New("provider does not support credential rotation")
+}
+
+// filter returns the addresses in addrs which are sent to the wrapped provider.
+func (fp *filterProvider) filter(addrs []netip.Addr) []netip.Addr {
+	var filtered []netip.Addr
+	for _, a := range addrs {
+		if fp.keep(a) {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+// familyProbes are addresses from each kind of range, used to find the address families a filter drops entirely.
+var familyProbes = [2][]netip.Addr{
+	{
+		netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("100.64.0.1"),
+		netip.MustParseAddr("127.0.0.1"), netip.MustParseAddr("169.254.0.1"),
+	},
+	{
+		netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("fd00::1"), netip.MustParseAddr("::1"),
+		netip.MustParseAddr("fe80::1"),
+	},
+}
+
+// Capabilities reports the capabilities of the wrapped provider,
+// except that the address families which the filter drops are reported as supported,
+// since the wrapped provider never receives them.
+// Addresses which are kept are still checked against the wrapped provider before each update.
+func (fp *filterProvider) Capabilities() Capabilities {
+	caps := ProviderCapabilities(fp.Provider)
+	caps.IPv4 = caps.IPv4 || len(fp.filter(familyProbes[0])) == 0
+	caps.IPv6 = caps.IPv6 || len(fp.filter(familyProbes[1])) == 0
+	return caps
+}
+
+func (fp *filterProvider) checkFamilies(addrs []netip.Addr) error {
+	return checkProviderFamilies(fp.Provider, fp.filter(addrs))
+}
+
+func (fp *filterProvider) SetLogger(logger *log.Logger) {
+	setLogger(fp.Provider, logger)
+}
+
+func (fp *filterProvider) SetHTTPClient(httpclient *http.Client) {
+	setHTTPClient(fp.Provider, httpclient)
+}
+
+func (fp *filterProvider) SetTTL(ttl time.Duration) {
+	setTTL(fp.Provider, ttl)
+}
+
+// PublicAddr reports whether a is a global unicast address outside of the private address ranges.
+func PublicAddr(a netip.Addr) bool {
+	return a.IsGlobalUnicast() && !a.IsPrivate()
+}
+
+// IPv4Addr reports whether a is an IPv4 address.
+func IPv4Addr(a netip.Addr) bool {
+	return a.Unmap().Is4()
+}
+
+// IPv6Addr reports whether a is an IPv6 address.
+func IPv6Addr(a netip.Addr) bool {
+	return !a.Unmap().Is4() && a.Is6()
+}
+
+// PrivateAddr reports whether a is in one of the private address ranges (RFC 1918 or RFC 4193).
+func PrivateAddr(a netip.Addr) bool {
+	return a.IsPrivate()
+}
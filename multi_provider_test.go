@@ -0,0 +1,100 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+type recordingProvider struct {
+	records []netip.Addr
+	err     error
+}
+
+func (p *recordingProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	p.records = records
+	return p.err
+}
+
+func (p *recordingProvider) fn() func() (ddns.Provider, error) {
+	return func() (ddns.Provider, error) { return p, nil }
+}
+
+func TestMultiProviderFilter(t *testing.T) {
+	public, private := &recordingProvider{}, &recordingProvider{}
+	mp, err := ddns.MultiProvider(
+		ddns.Filter(public.fn(), ddns.PublicAddr),
+		ddns.Filter(private.fn(), ddns.PrivateAddr),
+	)()
+	if err != nil {
+		t.Fatalf("MultiProvider returned an error: %s", err)
+	}
+	addrs := []netip.Addr{
+		netip.MustParseAddr("203.0.113.5"),
+		netip.MustParseAddr("192.168.1.10"),
+		netip.MustParseAddr("fe80::1"),
+	}
+	if err := mp.SetDNSRecords(context.Background(), "example.com", addrs); err != nil {
+		t.Fatalf("SetDNSRecords failed: %s", err)
+	}
+	if len(public.records) != 1 || public.records[0] != addrs[0] {
+		t.Fatalf("Expected public provider to get %q; got %q", addrs[:1], public.records)
+	}
+	if len(private.records) != 1 || private.records[0] != addrs[1] {
+		t.Fatalf("Expected private provider to get %q; got %q", addrs[1:2], private.records)
+	}
+}
+
+func TestMultiProviderError(t *testing.T) {
+	errFailed := errors.New("failed")
+	failing, ok := &recordingProvider{err: errFailed}, &recordingProvider{}
+	mp, err := ddns.MultiProvider(failing.fn(), ok.fn())()
+	if err != nil {
+		t.Fatalf("MultiProvider returned an error: %s", err)
+	}
+	addrs := []netip.Addr{netip.MustParseAddr("203.0.113.5")}
+	err = mp.SetDNSRecords(context.Background(), "example.com", addrs)
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected error to wrap %q; got %q", errFailed, err)
+	}
+	if len(ok.records) != 1 {
+		t.Fatalf("Expected remaining providers to be updated after a failure; got %q", ok.records)
+	}
+}
+
+type blockingProvider struct{}
+
+func (blockingProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestMultiProviderTimeout(t *testing.T) {
+	ok := &recordingProvider{}
+	mp, err := ddns.MultiProvider(
+		ddns.ProviderTimeout(func() (ddns.Provider, error) { return blockingProvider{}, nil }, 10*time.Millisecond),
+		ok.fn(),
+	)()
+	if err != nil {
+		t.Fatalf("MultiProvider returned an error: %s", err)
+	}
+	addrs := []netip.Addr{netip.MustParseAddr("203.0.113.5")}
+	err = mp.SetDNSRecords(context.Background(), "example.com", addrs)
+	var mpErr *ddns.MultiProviderError
+	if !errors.As(err, &mpErr) {
+		t.Fatalf("Expected a MultiProviderError; got %v", err)
+	}
+	if failed := mpErr.Failed(); len(failed) != 1 || failed[0] != 0 {
+		t.Errorf("Expected only provider 0 to fail; got %v", failed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error to wrap %q; got %q", context.DeadlineExceeded, err)
+	}
+	if len(ok.records) != 1 {
+		t.Errorf("Expected the other provider to be updated; got %q", ok.records)
+	}
+}
@@ -0,0 +1,237 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Uplink is one WAN connection of a multihomed host, for [MultiWAN].
+type Uplink struct {
+	Name string
+
+	// Resolver returns the public addresses of the uplink,
+	// e.g. [Iface] for the router's WAN interface, or a [WebResolver] pinned with [PinHTTPClient] to an [HTTPClientFrom]
+	// the address the host uses on the uplink, so that its lookups leave through that uplink.
+	Resolver Resolver
+
+	// Check reports whether the uplink is up, e.g. by connecting to a well known host through it.
+	// If Check is nil the uplink is up while Resolver returns addresses.
+	Check func(ctx context.Context) error
+}
+
+// UplinkPolicy selects the uplinks whose addresses are published by [MultiWAN].
+type UplinkPolicy int
+
+const (
+	// AllUplinks publishes the addresses of every uplink which is up,
+	// e.g. for clients which try each address of a name in turn.
+	AllUplinks UplinkPolicy = iota
+
+	// ActiveUplink publishes only the addresses of the first uplink in the list which is up,
+	// failing over to the next when it goes down and back once it recovers.
+	ActiveUplink
+)
+
+// MultiWAN constructs a resolver for a host with several WAN uplinks,
+// which publishes the addresses of the uplinks selected by policy and leaves out those which are down.
+// Resolve returns an error only if every uplink is down.
+//
+// Updates only notice an uplink going down at the next interval;
+// use [MultiWANResolver.Watch] with [TriggerOn] to update as soon as it does.
+func MultiWAN(policy UplinkPolicy, uplinks ...Uplink) *MultiWANResolver {
+	r := &MultiWANResolver{policy: policy, uplinks: uplinks, logger: discard}
+	r.err = r.configure()
+	return r
+}
+
+// MultiWANResolver is the resolver returned by [MultiWAN].
+type MultiWANResolver struct {
+	policy  UplinkPolicy
+	uplinks []Uplink
+	logger  *log.Logger
+	err     error // configuration error, returned by Resolve
+
+	mu       sync.Mutex
+	selected string // names of the uplinks selected by the last Resolve or Watch check; empty before the first
+}
+
+func (r *MultiWANResolver) configure() error {
+	if len(r.uplinks) == 0 {
+		return errors.New("at least one uplink is required")
+	}
+	if r.policy != AllUplinks && r.policy != ActiveUplink {
+		return fmt.Errorf("unknown uplink policy %d", r.policy)
+	}
+	names := map[string]bool{}
+	for i, u := range r.uplinks {
+		if u.Name == "" {
+			return fmt.Errorf("uplink %d has no name", i)
+		}
+		if names[u.Name] {
+			return fmt.Errorf("duplicate uplink name \"%s\"", u.Name)
+		}
+		names[u.Name] = true
+		if u.Resolver == nil {
+			return fmt.Errorf("uplink %s has no resolver", u.Name)
+		}
+	}
+	return nil
+}
+
+func (r *MultiWANResolver) validate() error {
+	if r.err != nil {
+		return r.err
+	}
+	for _, u := range r.uplinks {
+		if err := validateResolver(u.Resolver); err != nil {
+			return fmt.Errorf("uplink %s: %w", u.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *MultiWANResolver) SetLogger(logger *log.Logger) {
+	r.logger = logger
+	for _, u := range r.uplinks {
+		setLogger(u.Resolver, logger)
+	}
+}
+
+func (r *MultiWANResolver) SetHTTPClient(httpclient *http.Client) {
+	for _, u := range r.uplinks {
+		setHTTPClient(u.Resolver, httpclient)
+	}
+}
+
+func (r *MultiWANResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var addrs []netip.Addr
+	var names []string
+	var errs []error
+	for _, u := range r.uplinks {
+		a, err := r.resolveUplink(ctx, u)
+		if err != nil {
+			r.logger.Printf("uplink %s is down: %s\n", u.Name, err)
+			errs = append(errs, fmt.Errorf("uplink %s: %w", u.Name, err))
+			continue
+		}
+		addrs = append(addrs, a...)
+		names = append(names, u.Name)
+		if r.policy == ActiveUplink {
+			break
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("all uplinks are down: %w", errors.Join(errs...))
+	}
+	r.logger.Printf("publishing the addresses of uplinks %s\n", strings.Join(names, ", "))
+	r.setSelected(strings.Join(names, ","))
+	return uniqueAddrs(addrs), nil
+}
+
+// resolveUplink returns the addresses of u, or an error if it is down.
+func (r *MultiWANResolver) resolveUplink(ctx context.Context, u Uplink) ([]netip.Addr, error) {
+	if u.Check != nil {
+		if err := u.Check(ctx); err != nil {
+			return nil, fmt.Errorf("check failed: %w", err)
+		}
+	}
+	addrs, err := u.Resolver.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses")
+	}
+	return addrs, nil
+}
+
+// setSelected records the selected uplinks, reporting whether they changed since they were last recorded.
+func (r *MultiWANResolver) setSelected(names string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := r.selected != "" && r.selected != names
+	r.selected = names
+	return changed
+}
+
+// Watch checks the uplinks every interval until ctx is done,
+// and sends a trigger to trigger as soon as the uplinks selected by the policy change,
+// so that an update fails over without waiting for the next interval:
+//
+//	wan := ddns.MultiWAN(ddns.ActiveUplink, primary, backup)
+//	trigger := make(chan string)
+//	go wan.Watch(ctx, 10*time.Second, trigger)
+//	ddns.RunDaemon(client, ctx, 5*time.Minute, nil, ddns.TriggerOn(trigger))
+//
+// Uplinks are checked with their Check function if they have one, or else by resolving their addresses,
+// so uplinks with a Check are cheaper to watch often.
+func (r *MultiWANResolver) Watch(ctx context.Context, interval time.Duration, trigger chan<- string) {
+	if r.err != nil {
+		return
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		var names []string
+		for _, u := range r.uplinks {
+			if err := r.checkUplink(ctx, u); err != nil {
+				continue
+			}
+			names = append(names, u.Name)
+			if r.policy == ActiveUplink {
+				break
+			}
+		}
+		selected := strings.Join(names, ",")
+		if selected == "" || !r.setSelected(selected) {
+			continue
+		}
+		r.logger.Printf("selected uplinks changed to %s\n", selected)
+		select {
+		case trigger <- "uplink " + selected:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkUplink runs the Check of u, or resolves its addresses if it has none.
+func (r *MultiWANResolver) checkUplink(ctx context.Context, u Uplink) error {
+	if u.Check != nil {
+		return u.Check(ctx)
+	}
+	_, err := r.resolveUplink(ctx, u)
+	return err
+}
+
+// HTTPClientFrom returns an http.Client which connects from the local address source,
+// so that on a host with policy routing by source address a [WebResolver] reports the public address of the matching uplink.
+// Use [PinHTTPClient] to give it to one resolver.
+func HTTPClientFrom(source netip.Addr) *http.Client {
+	t := cloneDefaultTransport()
+	network := "tcp4"
+	if !source.Unmap().Is4() {
+		network = "tcp6"
+	}
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, LocalAddr: net.TCPAddrFromAddrPort(netip.AddrPortFrom(source.Unmap(), 0))}
+	t.DialContext = func(ctx context.Context, _, address string) (net.Conn, error) {
+		return d.DialContext(ctx, network, address)
+	}
+	return &http.Client{Transport: t}
+}
@@ -0,0 +1,108 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// uplink returns an Uplink resolving addr whose Check fails while down is set.
+func uplink(name, addr string, down *atomic.Bool) ddns.Uplink {
+	return ddns.Uplink{
+		Name:     name,
+		Resolver: ddns.StaticIP(netip.MustParseAddr(addr)),
+		Check: func(context.Context) error {
+			if down.Load() {
+				return errors.New("no route to host")
+			}
+			return nil
+		},
+	}
+}
+
+func TestMultiWANPolicies(t *testing.T) {
+	ctx := context.Background()
+	var fiberDown, lteDown atomic.Bool
+	fiber, lte := uplink("fiber", "203.0.113.5", &fiberDown), uplink("lte", "198.51.100.7", &lteDown)
+	tests := []struct {
+		policy   ddns.UplinkPolicy
+		fiber    bool // fiber is down
+		lte      bool // lte is down
+		expected []string
+	}{
+		{ddns.AllUplinks, false, false, []string{"203.0.113.5", "198.51.100.7"}},
+		{ddns.AllUplinks, true, false, []string{"198.51.100.7"}},
+		{ddns.ActiveUplink, false, false, []string{"203.0.113.5"}},
+		{ddns.ActiveUplink, true, false, []string{"198.51.100.7"}},
+		{ddns.ActiveUplink, true, true, nil},
+	}
+	for _, tt := range tests {
+		fiberDown.Store(tt.fiber)
+		lteDown.Store(tt.lte)
+		addrs, err := ddns.MultiWAN(tt.policy, fiber, lte).Resolve(ctx)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("policy %d: Expected an error with every uplink down; got %v", tt.policy, addrs)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("policy %d: Resolve returned an error: %s", tt.policy, err)
+			continue
+		}
+		var got []string
+		for _, a := range addrs {
+			got = append(got, a.String())
+		}
+		if len(got) != len(tt.expected) || got[0] != tt.expected[0] || got[len(got)-1] != tt.expected[len(tt.expected)-1] {
+			t.Errorf("policy %d with fiber down %t: Expected %q; got %q", tt.policy, tt.fiber, tt.expected, got)
+		}
+	}
+	if _, err := ddns.New("home.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(ddns.MultiWAN(ddns.ActiveUplink, fiber, fiber)),
+	); err == nil {
+		t.Errorf("Expected New to reject duplicate uplink names")
+	}
+}
+
+func TestMultiWANWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var fiberDown, lteDown atomic.Bool
+	wan := ddns.MultiWAN(ddns.ActiveUplink, uplink("fiber", "203.0.113.5", &fiberDown), uplink("lte", "198.51.100.7", &lteDown))
+	if _, err := wan.Resolve(ctx); err != nil {
+		t.Fatalf("Resolve returned an error: %s", err)
+	}
+	trigger := make(chan string)
+	go wan.Watch(ctx, 5*time.Millisecond, trigger)
+
+	fiberDown.Store(true)
+	select {
+	case got := <-trigger:
+		if got != "uplink lte" {
+			t.Errorf("Expected trigger %q; got %q", "uplink lte", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a trigger when the active uplink went down")
+	}
+	addrs, err := wan.Resolve(ctx)
+	if err != nil || len(addrs) != 1 || addrs[0] != netip.MustParseAddr("198.51.100.7") {
+		t.Errorf("Expected the lte address after failover; got %v, %v", addrs, err)
+	}
+
+	fiberDown.Store(false)
+	select {
+	case got := <-trigger:
+		if got != "uplink fiber" {
+			t.Errorf("Expected trigger %q; got %q", "uplink fiber", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected a trigger when the primary uplink recovered")
+	}
+}
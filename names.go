@@ -0,0 +1,219 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NameData is the data available to record name templates.
+//
+// A domain passed to [ddns.New] containing "{{" is parsed as a [text/template],
+// so that one configuration can be deployed to many machines producing distinct names,
+// e.g. "{{.Hostname}}.home.example.com" or "{{.Family}}.{{.Hostname}}.example.com".
+//
+// If the expanded name differs by address family,
+// then IPv4 addresses are published to the name expanded with Family "v4" and IPv6 addresses to the name expanded with Family "v6".
+type NameData struct {
+	Hostname  string // the first label of the host name reported by the operating system
+	Interface string // the first interface given to InterfaceResolver, if any
+	Family    string // "v4" or "v6"
+}
+
+// ExpandDomain expands a domain template for the IPv4 and IPv6 address families.
+// The names are equal unless the template uses the family.
+// A domain without a template is returned unchanged.
+//
+// resolver is used to find the interface name; it may be nil.
+func ExpandDomain(domain string, resolver Resolver) (v4, v6 string, err error) {
+	if !strings.Contains(domain, "{{") {
+		return domain, domain, nil
+	}
+	tmpl, err := parseDomain(domain)
+	if err != nil {
+		return "", "", err
+	}
+	return expandDomain(tmpl, resolver)
+}
+
+func parseDomain(domain string) (*template.Template, error) {
+	tmpl, err := template.New("domain").Option("missingkey=error").Parse(domain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func expandDomain(tmpl *template.Template, resolver Resolver) (v4, v6 string, err error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", "", fmt.Errorf("error getting hostname: %w", err)
+	}
+	data := NameData{
+		Hostname: strings.ToLower(strings.SplitN(hostname, ".", 2)[0]),
+	}
+	if r, ok := resolver.(interfaceResolver); ok && len(r.ifaces) > 0 {
+		data.Interface = r.ifaces[0]
+	}
+	expand := func(family string) (string, error) {
+		data.Family = family
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("error expanding domain template: %w", err)
+		}
+		name := strings.ToLower(b.String())
+		if name == "" || strings.Contains(name, "..") || strings.HasPrefix(name, ".") || strings.ContainsAny(name, " \t\n") {
+			return "", fmt.Errorf("domain template expanded to invalid name \"%s\"", name)
+		}
+		return name, nil
+	}
+	if v4, err = expand("v4"); err != nil {
+		return "", "", err
+	}
+	if v6, err = expand("v6"); err != nil {
+		return "", "", err
+	}
+	return v4, v6, nil
+}
+
+// expandDomain sets the domain from the name template,
+// splitting records by address family if the template uses the family or MapFamilyDomains was given.
+func (c *client) expandDomain() error {
+	if c.familyDomains[0] != "" {
+		if c.nameTemplate != nil {
+			return errors.New("domain templates cannot be combined with MapFamilyDomains")
+		}
+		c.Provider = &familyProvider{Provider: c.Provider, v4: c.familyDomains[0], v6: c.familyDomains[1]}
+		return nil
+	}
+	if c.nameTemplate == nil {
+		return nil
+	}
+	v4, v6, err := expandDomain(c.nameTemplate, c.Resolver)
+	if err != nil {
+		return err
+	}
+	c.domain = v4
+	if v4 != v6 {
+		c.Provider = &familyProvider{Provider: c.Provider, v4: v4, v6: v6}
+	}
+	c.logger.Printf("expanded domain template to %s (IPv4) and %s (IPv6)\n", v4, v6)
+	return nil
+}
+
+// MapFamilyDomains configures the client to publish IPv4 addresses to v4 and IPv6 addresses to v6,
+// for services which misbehave with dual-stack names.
+//
+// The domain given to [ddns.New] is still used for heartbeats and logging.
+func MapFamilyDomains(v4, v6 string) clientOption {
+	return func(c *client) error {
+		if v4 == "" || v6 == "" {
+			return errors.New("domains cannot be empty")
+		}
+		c.familyDomains = [2]string{v4, v6}
+		return nil
+	}
+}
+
+// familyProvider publishes IPv4 and IPv6 addresses to separate names,
+// regardless of the domain it is given.
+type familyProvider struct {
+	Provider
+	v4, v6 string
+}
+
+func (fp *familyProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	var v4, v6 []netip.Addr
+	for _, a := range records {
+		if a.Unmap().Is4() {
+			v4 = append(v4, a)
+		} else {
+			v6 = append(v6, a)
+		}
+	}
+	return errors.Join(
+		fp.Provider.SetDNSRecords(ctx, fp.v4, v4),
+		fp.Provider.SetDNSRecords(ctx, fp.v6, v6),
+	)
+}
+
+// GetDNSRecords returns the IPv4 records of the IPv4 name and the IPv6 records of the IPv6 name.
+func (fp *familyProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	rg, ok := fp.Provider.(RecordGetter)
+	if !ok {
+		return nil, errGetRecordsUnsupported
+	}
+	var addrs []netip.Addr
+	for _, name := range []string{fp.v4, fp.v6} {
+		records, err := rg.GetDNSRecords(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range records {
+			if a.Unmap().Is4() == (name == fp.v4) {
+				addrs = append(addrs, a)
+			}
+		}
+	}
+	return addrs, nil
+}
+
+func (fp *familyProvider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	if tp, ok := fp.Provider.(TXTProvider); ok {
+		return tp.GetTXTRecords(ctx, name)
+	}
+	return nil, errTXTUnsupported
+}
+
+func (fp *familyProvider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	if tp, ok := fp.Provider.(TXTProvider); ok {
+		return tp.SetTXTRecords(ctx, name, values)
+	}
+	return errTXTUnsupported
+}
+
+func (fp *familyProvider) GetSRVRecords(ctx context.Context, name string) ([]SRV, error) {
+	if sp, ok := fp.Provider.(SRVProvider); ok {
+		return sp.GetSRVRecords(ctx, name)
+	}
+	return nil, errSRVUnsupported
+}
+
+func (fp *familyProvider) SetSRVRecords(ctx context.Context, name string, records []SRV) error {
+	if sp, ok := fp.Provider.(SRVProvider); ok {
+		return sp.SetSRVRecords(ctx, name, records)
+	}
+	return errSRVUnsupported
+}
+
+func (fp *familyProvider) RotateCredentials(ctx context.Context, token string) error {
+	if r, ok := fp.Provider.(CredentialRotator); ok {
+		return r.RotateCredentials(ctx, token)
+	}
+	return errors.New("provider does not support credential rotation")
+}
+
+func (fp *familyProvider) Capabilities() Capabilities {
+	caps := ProviderCapabilities(fp.Provider)
+	caps.Batch = false
+	return caps
+}
+
+func (fp *familyProvider) SetLogger(logger *log.Logger) {
+	setLogger(fp.Provider, logger)
+}
+
+func (fp *familyProvider) SetHTTPClient(httpclient *http.Client) {
+	setHTTPClient(fp.Provider, httpclient)
+}
+
+func (fp *familyProvider) SetTTL(ttl time.Duration) {
+	setTTL(fp.Provider, ttl)
+}
@@ -0,0 +1,74 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestDomainTemplate(t *testing.T) {
+	ctx := context.Background()
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Skipf("unable to get hostname: %s", err)
+	}
+	host := strings.ToLower(strings.SplitN(hostname, ".", 2)[0])
+	p := &ddnstest.Provider{}
+	v4, v6 := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")
+	c, err := ddns.New("{{.Family}}.{{.Hostname}}.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(v4, v6)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("v4." + host + ".example.com"); len(got) != 1 || got[0] != v4 {
+		t.Errorf("Expected %s on the v4 name; got %q", v4, got)
+	}
+	if got := p.Records("v6." + host + ".example.com"); len(got) != 1 || got[0] != v6 {
+		t.Errorf("Expected %s on the v6 name; got %q", v6, got)
+	}
+}
+
+func TestDomainTemplateInvalid(t *testing.T) {
+	p := &ddnstest.Provider{}
+	for _, domain := range []string{"{{.Nope}}.example.com", "{{.Interface}}.example.com", "{{.Hostname"} {
+		if _, err := ddns.New(domain, ddnstest.ProviderFunc(p)); err == nil {
+			t.Errorf("Expected an error for domain %q", domain)
+		}
+	}
+}
+
+func TestMapFamilyDomains(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	v4, v6 := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")
+	stale := netip.MustParseAddr("2001:db8::2")
+	p.SetDNSRecords(ctx, "v6.example.com", []netip.Addr{stale})
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(v4, v6)),
+		ddns.MapFamilyDomains("v4.example.com", "v6.example.com"),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := p.Records("v4.example.com"); len(got) != 1 || got[0] != v4 {
+		t.Errorf("Expected %s on the v4 name; got %q", v4, got)
+	}
+	if got := p.Records("v6.example.com"); len(got) != 1 || got[0] != v6 {
+		t.Errorf("Expected %s on the v6 name; got %q", v6, got)
+	}
+	if got := p.Records("example.com"); len(got) != 0 {
+		t.Errorf("Expected no records on the base name; got %q", got)
+	}
+}
@@ -0,0 +1,301 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// netcupAPI is the endpoint of the netcup CCP DNS API.
+const netcupAPI = "https://ccp.netcup.net/run/webservice/servers/endpoint.php?JSON"
+
+// Status codes of the netcup API.
+const (
+	netcupSessionInvalid = 4001 // the session ID is malformed or has expired
+	netcupNoRecords      = 5029 // the zone has no records
+)
+
+// NewNetcup is used by [ddns.New] to create a Provider which updates records using the netcup CCP DNS API.
+//
+// customerNumber is the netcup customer number, and apiKey and apiPassword are created in the CCP under "API".
+// The provider logs in on first use and keeps the API session until it expires.
+// The zone of each domain is derived with [ZoneFromDomain],
+// and all changes for a domain are sent in a single update.
+// netcup sets the TTL for the whole zone, so it can't be set with [WithTTL].
+func NewNetcup(customerNumber, apiKey, apiPassword string) func() (Provider, error) {
+	return func() (Provider, error) {
+		if customerNumber == "" || apiKey == "" || apiPassword == "" {
+			return nil, errors.New("customer number, API key, and API password cannot be empty")
+		}
+		return &netcupProvider{endpoint: netcupAPI, customer: customerNumber, key: apiKey, password: apiPassword, logger: discard}, nil
+	}
+}
+
+type netcupProvider struct {
+	httpClient *http.Client
+	logger     *log.Logger
+	endpoint   string
+	customer   string
+	key        string
+	password   string
+
+	mu      sync.Mutex
+	session string // the API session ID, or empty before logging in
+}
+
+func (p *netcupProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *netcupProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *netcupProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, Batch: true}
+}
+
+// netcupRecord is a record in the netcup API.
+type netcupRecord struct {
+	ID           string `json:"id,omitempty"`
+	Hostname     string `json:"hostname"`
+	Type         string `json:"type"`
+	Priority     string `json:"priority,omitempty"`
+	Destination  string `json:"destination"`
+	DeleteRecord bool   `json:"deleterecord"`
+}
+
+func (p *netcupProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	zone, host, err := netcupName(domain)
+	if err != nil {
+		return err
+	}
+	existing, err := p.records(ctx, zone, host)
+	if err != nil {
+		return err
+	}
+	applier := &netcupApplier{host: host, logger: p.logger}
+	if err := Reconcile(ctx, existing, records, applier); err != nil {
+		return err
+	}
+	if len(applier.changes) == 0 {
+		p.logger.Printf("records for %s are up to date\n", domain)
+		return nil
+	}
+	param := map[string]any{
+		"domainname":   zone,
+		"dnsrecordset": map[string]any{"dnsrecords": applier.changes},
+	}
+	if err := p.call(ctx, "updateDnsRecords", param, nil); err != nil {
+		return fmt.Errorf("unable to update records for %s: %w", domain, err)
+	}
+	return nil
+}
+
+func (p *netcupProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	zone, host, err := netcupName(domain)
+	if err != nil {
+		return nil, err
+	}
+	records, err := p.records(ctx, zone, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]netip.Addr, len(records))
+	for i, r := range records {
+		addrs[i] = r.Addr
+	}
+	return addrs, nil
+}
+
+// records returns the A and AAAA records for host in zone.
+func (p *netcupProvider) records(ctx context.Context, zone, host string) ([]Record, error) {
+	var resp struct {
+		DNSRecords []netcupRecord `json:"dnsrecords"`
+	}
+	err := p.call(ctx, "infoDnsRecords", map[string]any{"domainname": zone}, &resp)
+	var ne *netcupError
+	if errors.As(err, &ne) && ne.code == netcupNoRecords {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get records for %s: %w", zone, err)
+	}
+	var records []Record
+	for _, r := range resp.DNSRecords {
+		if (r.Type != "A" && r.Type != "AAAA") || !strings.EqualFold(r.Hostname, host) {
+			continue
+		}
+		a, err := netip.ParseAddr(r.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing IP from record: %w", err)
+		}
+		records = append(records, Record{ID: r.ID, Addr: a})
+	}
+	return records, nil
+}
+
+// netcupName returns the zone of domain and the hostname of domain within it, "@" for the zone itself.
+func netcupName(domain string) (zone, host string, err error) {
+	zone, err = ZoneFromDomain(domain, nil)
+	if err != nil {
+		return "", "", err
+	}
+	host = "@"
+	if d := canonicalName(domain); d != zone {
+		host = strings.TrimSuffix(d, "."+zone)
+	}
+	return zone, host, nil
+}
+
+// netcupApplier collects the changes found by Reconcile into a single record set update.
+type netcupApplier struct {
+	host    string
+	logger  *log.Logger
+	changes []netcupRecord
+}
+
+func (a *netcupApplier) Create(ctx context.Context, addr netip.Addr) error {
+	typ, err := recordType(addr)
+	if err != nil {
+		return err
+	}
+	a.logger.Printf("creating record for %s...\n", addr)
+	a.changes = append(a.changes, netcupRecord{Hostname: a.host, Type: typ, Destination: addr.String()})
+	return nil
+}
+
+func (a *netcupApplier) Update(ctx context.Context, r Record, addr netip.Addr) error {
+	typ, err := recordType(addr)
+	if err != nil {
+		return err
+	}
+	a.logger.Printf("updating record %s to %s...\n", r.Addr, addr)
+	a.changes = append(a.changes, netcupRecord{ID: r.ID, Hostname: a.host, Type: typ, Destination: addr.String()})
+	return nil
+}
+
+func (a *netcupApplier) Delete(ctx context.Context, r Record) error {
+	typ, err := recordType(r.Addr)
+	if err != nil {
+		return err
+	}
+	a.logger.Printf("deleting record for %s...\n", r.Addr)
+	a.changes = append(a.changes, netcupRecord{ID: r.ID, Hostname: a.host, Type: typ, Destination: r.Addr.String(), DeleteRecord: true})
+	return nil
+}
+
+// call makes an API request in the current session, logging in first if needed,
+// and decodes the response data into result if it is not nil.
+// The request is retried once with a new session if the session has expired.
+func (p *netcupProvider) call(ctx context.Context, action string, param map[string]any, result any) error {
+	for attempt := 0; ; attempt++ {
+		session, err := p.login(ctx)
+		if err != nil {
+			return err
+		}
+		param["customernumber"] = p.customer
+		param["apikey"] = p.key
+		param["apisessionid"] = session
+		err = p.request(ctx, action, param, result)
+		var ne *netcupError
+		if attempt == 0 && errors.As(err, &ne) && ne.code == netcupSessionInvalid {
+			p.logger.Printf("netcup session expired; logging in again\n")
+			p.mu.Lock()
+			if p.session == session {
+				p.session = ""
+			}
+			p.mu.Unlock()
+			continue
+		}
+		return err
+	}
+}
+
+// login returns the current session ID, logging in if there is none.
+func (p *netcupProvider) login(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.session != "" {
+		return p.session, nil
+	}
+	var resp struct {
+		SessionID string `json:"apisessionid"`
+	}
+	param := map[string]any{"customernumber": p.customer, "apikey": p.key, "apipassword": p.password}
+	if err := p.request(ctx, "login", param, &resp); err != nil {
+		var ne *netcupError
+		if errors.As(err, &ne) && !ne.temporary {
+			ne.auth = true
+		}
+		return "", fmt.Errorf("unable to log in: %w", err)
+	}
+	p.logger.Printf("logged in to netcup\n")
+	p.session = resp.SessionID
+	return p.session, nil
+}
+
+// request posts an action to the API and decodes the response data into result if it is not nil.
+func (p *netcupProvider) request(ctx context.Context, action string, param map[string]any, result any) error {
+	b, err := json.Marshal(map[string]any{"action": action, "param": param})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return &netcupError{msg: "unexpected response status " + resp.Status, temporary: true}
+	}
+	var body struct {
+		Status       string          `json:"status"`
+		StatusCode   int             `json:"statuscode"`
+		ShortMessage string          `json:"shortmessage"`
+		LongMessage  string          `json:"longmessage"`
+		ResponseData json.RawMessage `json:"responsedata"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("error decoding response (status %s): %w", resp.Status, err)
+	}
+	if body.Status != "success" {
+		msg := body.ShortMessage
+		if body.LongMessage != "" {
+			msg += ": " + body.LongMessage
+		}
+		return &netcupError{code: body.StatusCode, msg: fmt.Sprintf("%s failed with status %d: %s", action, body.StatusCode, msg)}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body.ResponseData, result)
+}
+
+type netcupError struct {
+	code      int
+	msg       string
+	auth      bool
+	temporary bool
+}
+
+func (e *netcupError) Error() string               { return e.msg }
+func (e *netcupError) IsAuthenticationError() bool { return e.auth }
+func (e *netcupError) IsTemporary() bool           { return e.temporary }
@@ -0,0 +1,209 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// fakeNetcup is a minimal in-memory implementation of the netcup CCP DNS API for the zone example.com.
+type fakeNetcup struct {
+	mu       sync.Mutex
+	records  map[string]fakeNetcupRecord // by ID
+	nextID   int
+	sessions map[string]bool
+	logins   int
+	updates  int
+}
+
+type fakeNetcupRecord struct {
+	ID           string `json:"id"`
+	Hostname     string `json:"hostname"`
+	Type         string `json:"type"`
+	Destination  string `json:"destination"`
+	DeleteRecord bool   `json:"deleterecord"`
+}
+
+func newFakeNetcup() *fakeNetcup {
+	return &fakeNetcup{records: map[string]fakeNetcupRecord{}, sessions: map[string]bool{}}
+}
+
+// client returns an http.Client which sends netcup API requests to the fake.
+func (f *fakeNetcup) client(t *testing.T) *http.Client {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		r.URL.Scheme, r.URL.Host = u.Scheme, u.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+// expire ends every session, as after a period of inactivity.
+func (f *fakeNetcup) expire() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sessions = map[string]bool{}
+}
+
+// Hosts returns the destinations of the records for hostname, sorted.
+func (f *fakeNetcup) Hosts(hostname string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var destinations []string
+	for _, r := range f.records {
+		if r.Hostname == hostname {
+			destinations = append(destinations, r.Destination)
+		}
+	}
+	sort.Strings(destinations)
+	return destinations
+}
+
+func (f *fakeNetcup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var req struct {
+		Action string `json:"action"`
+		Param  struct {
+			CustomerNumber string `json:"customernumber"`
+			APIKey         string `json:"apikey"`
+			APIPassword    string `json:"apipassword"`
+			SessionID      string `json:"apisessionid"`
+			DomainName     string `json:"domainname"`
+			RecordSet      struct {
+				Records []fakeNetcupRecord `json:"dnsrecords"`
+			} `json:"dnsrecordset"`
+		} `json:"param"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	respond := func(code int, data any) {
+		status := "success"
+		if code >= 4000 {
+			status = "error"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"action": req.Action, "status": status, "statuscode": code, "shortmessage": fmt.Sprintf("status %d", code), "responsedata": data})
+	}
+	if req.Param.CustomerNumber != "12345" || req.Param.APIKey != "key" {
+		respond(4013, "")
+		return
+	}
+	if req.Action == "login" {
+		if req.Param.APIPassword != "password" {
+			respond(4013, "")
+			return
+		}
+		f.logins++
+		session := fmt.Sprintf("session%d", f.logins)
+		f.sessions[session] = true
+		respond(2000, map[string]string{"apisessionid": session})
+		return
+	}
+	if !f.sessions[req.Param.SessionID] {
+		respond(4001, "")
+		return
+	}
+	if req.Param.DomainName != "example.com" {
+		respond(5028, "")
+		return
+	}
+	switch req.Action {
+	case "infoDnsRecords":
+		if len(f.records) == 0 {
+			respond(5029, "")
+			return
+		}
+		records := []fakeNetcupRecord{}
+		for _, rec := range f.records {
+			records = append(records, rec)
+		}
+		respond(2000, map[string]any{"dnsrecords": records})
+	case "updateDnsRecords":
+		f.updates++
+		for _, rec := range req.Param.RecordSet.Records {
+			switch {
+			case rec.DeleteRecord:
+				delete(f.records, rec.ID)
+			case rec.ID != "":
+				f.records[rec.ID] = rec
+			default:
+				f.nextID++
+				rec.ID = fmt.Sprint(f.nextID)
+				f.records[rec.ID] = rec
+			}
+		}
+		respond(2000, map[string]any{"dnsrecords": []fakeNetcupRecord{}})
+	default:
+		respond(4002, "")
+	}
+}
+
+func TestNetcupConformance(t *testing.T) {
+	f := newFakeNetcup()
+	httpClient := f.client(t)
+	ddnstest.TestProvider(t, func() (ddns.Provider, error) {
+		p, err := ddns.NewNetcup("12345", "key", "password")()
+		if err != nil {
+			return nil, err
+		}
+		p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(httpClient)
+		return p, nil
+	})
+}
+
+func TestNetcup(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeNetcup()
+	resolver := &ddnstest.Resolver{}
+	resolver.Set(netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1"))
+	c, err := ddns.New("home.example.com", ddns.NewNetcup("12345", "key", "password"),
+		ddns.UsingResolver(resolver),
+		ddns.UsingHTTPClient(f.client(t)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := f.Hosts("home"); strings.Join(got, " ") != "192.0.2.1 2001:db8::1" {
+		t.Errorf("Expected [192.0.2.1 2001:db8::1]; got %q", got)
+	}
+	if f.updates != 1 {
+		t.Errorf("Expected both records in 1 update; got %d", f.updates)
+	}
+
+	// an expired session is replaced, and the changed address is updated in place
+	f.expire()
+	resolver.Set(netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1"))
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := f.Hosts("home"); strings.Join(got, " ") != "192.0.2.2 2001:db8::1" {
+		t.Errorf("Expected [192.0.2.2 2001:db8::1]; got %q", got)
+	}
+	if f.logins != 2 || f.updates != 2 || len(f.records) != 2 {
+		t.Errorf("Expected 2 logins, 2 updates, and 2 records; got %d, %d, and %d", f.logins, f.updates, len(f.records))
+	}
+
+	p, err := ddns.NewNetcup("12345", "key", "wrong")()
+	if err != nil {
+		t.Fatalf("NewNetcup returned an error: %s", err)
+	}
+	p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(f.client(t))
+	if err := p.SetDNSRecords(ctx, "home.example.com", nil); !ddns.IsAuthError(err) {
+		t.Errorf("Expected an auth error for the wrong password; got %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Notification is an alert about failing or recovered updates, or changed records.
+type Notification struct {
+	Time      time.Time    `json:"time"`
+	Domain    string       `json:"domain"`
+	Failures  int          `json:"failures"` // consecutive failed runs, or the length of the outage on recovery
+	Error     string       `json:"error,omitempty"`
+	Recovered bool         `json:"recovered"`
+	Changed   bool         `json:"changed,omitempty"` // set by NotifyOnChange
+	Old       []netip.Addr `json:"old,omitempty"`     // the records before a change
+	New       []netip.Addr `json:"new,omitempty"`     // the records after a change
+}
+
+// Subject returns a one-line summary of n, e.g. for the subject of an email.
+func (n Notification) Subject() string {
+	switch {
+	case n.Changed:
+		return fmt.Sprintf("ddns: %s changed to %s", n.Domain, joinAddrs(n.New))
+	case n.Recovered:
+		return fmt.Sprintf("ddns: updates of %s recovered after %d failures", n.Domain, n.Failures)
+	default:
+		return fmt.Sprintf("ddns: updates of %s failed %d times", n.Domain, n.Failures)
+	}
+}
+
+func joinAddrs(addrs []netip.Addr) string {
+	if len(addrs) == 0 {
+		return "no records"
+	}
+	s := make([]string, len(addrs))
+	for i, a := range addrs {
+		s[i] = a.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// Notifier is the interface for sending alerts.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// The NotifierFunc type is an adapter that allows the use of ordinary functions as notifiers.
+type NotifierFunc func(context.Context, Notification) error
+
+// Notify calls f(ctx, n)
+func (f NotifierFunc) Notify(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// Webhook constructs a Notifier which POSTs each notification as JSON to url.
+// Any response status other than 2xx is considered an error.
+//
+// The http.Client used to make requests can be configured with [UsingHTTPClient].
+func Webhook(url string) Notifier {
+	return &webhook{url: url}
+}
+
+type webhook struct {
+	httpClient *http.Client
+	url        string
+}
+
+func (w *webhook) SetHTTPClient(httpclient *http.Client) {
+	w.httpClient = httpclient
+}
+
+func (w *webhook) Notify(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	setUserAgent(req)
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := w.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// MultiNotifier constructs a Notifier which sends each notification to every one of notifiers,
+// e.g. to both a webhook and email.
+// The errors of the notifiers are joined.
+func MultiNotifier(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
+
+type multiNotifier []Notifier
+
+func (m multiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		errs = append(errs, notifier.Notify(ctx, n))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiNotifier) validate() error {
+	for _, notifier := range m {
+		if err := validateResolver(notifier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiNotifier) SetLogger(logger *log.Logger) {
+	for _, notifier := range m {
+		setLogger(notifier, logger)
+	}
+}
+
+func (m multiNotifier) SetHTTPClient(httpclient *http.Client) {
+	for _, notifier := range m {
+		setHTTPClient(notifier, httpclient)
+	}
+}
+
+// AlertsOnly constructs a Notifier which sends the failure and recovery notifications to n, dropping change notifications,
+// e.g. to email alerts while [NotifyMQTT] is also sent changes:
+//
+//	ddns.WithNotifier(ddns.MultiNotifier(ddns.AlertsOnly(email), mqtt)), ddns.NotifyOnChange()
+func AlertsOnly(n Notifier) Notifier {
+	return alertsOnly{n}
+}
+
+type alertsOnly struct{ n Notifier }
+
+func (a alertsOnly) Notify(ctx context.Context, n Notification) error {
+	if n.Changed {
+		return nil
+	}
+	return a.n.Notify(ctx, n)
+}
+
+func (a alertsOnly) validate() error                       { return validateResolver(a.n) }
+func (a alertsOnly) SetLogger(logger *log.Logger)          { setLogger(a.n, logger) }
+func (a alertsOnly) SetHTTPClient(httpclient *http.Client) { setHTTPClient(a.n, httpclient) }
+
+// WithNotifier configures the client to send alerts to n when updates fail.
+//
+// By default an alert is sent for the first failed run.
+// While the failures continue, further alerts are sent with exponentially decreasing frequency:
+// after 2, 4, 8, ... times as many consecutive failures as the first alert.
+// Use [AlertAfter], [AlertOnRecovery], and [AlertInterval] to adjust the policy.
+//
+// No notifications are sent if n is nil.
+func WithNotifier(n Notifier) clientOption {
+	return func(c *client) error {
+		c.notifier = n
+		return nil
+	}
+}
+
+// AlertAfter sets the number of consecutive failed runs before the first alert is sent,
+// so that brief outages of a flapping connection do not cause alerts.
+func AlertAfter(n int) clientOption {
+	return func(c *client) error {
+		if n < 1 {
+			return errors.New("n must be at least 1")
+		}
+		c.alertAfter = n
+		return nil
+	}
+}
+
+// AlertOnRecovery configures the client to send a notification when a run succeeds after an alert was sent.
+func AlertOnRecovery() clientOption {
+	return func(c *client) error {
+		c.alertOnRecovery = true
+		return nil
+	}
+}
+
+// NotifyOnChange configures the client to send a notification whenever an update changes the records of the domain.
+// Changes are found the same way as for [WithJournal],
+// so the first update is reported as a change if the previous records are unknown.
+// Change notifications are not limited by [AlertInterval].
+func NotifyOnChange() clientOption {
+	return func(c *client) error {
+		c.notifyChanges = true
+		return nil
+	}
+}
+
+// AlertInterval sets the minimum time between failure alerts.
+// Alerts within the interval are dropped.
+// Recovery and change notifications are always sent.
+func AlertInterval(d time.Duration) clientOption {
+	return func(c *client) error {
+		if d < 0 {
+			return errors.New("interval cannot be negative")
+		}
+		c.alertInterval = d
+		return nil
+	}
+}
+
+// notify applies the alert policy to the result of a run.
+func (c *client) notify(ctx context.Context, err error) {
+	if c.notifier == nil {
+		return
+	}
+	if err == nil {
+		failures, alerted := c.runFailures, c.alerted
+		c.runFailures, c.nextAlert, c.alerted = 0, 0, false
+		// only report recovery from an outage the user was told about
+		if alerted && c.alertOnRecovery {
+			c.send(ctx, Notification{Domain: c.domain, Failures: failures, Recovered: true})
+		}
+		return
+	}
+	c.runFailures++
+	if c.nextAlert == 0 {
+		c.nextAlert = c.threshold()
+	}
+	if c.runFailures < c.nextAlert {
+		return
+	}
+	c.nextAlert *= 2
+	if c.send(ctx, Notification{Domain: c.domain, Failures: c.runFailures, Error: err.Error()}) {
+		c.alerted = true
+	}
+}
+
+// threshold returns the number of consecutive failures before the first alert.
+func (c *client) threshold() int {
+	if c.alertAfter < 1 {
+		return 1
+	}
+	return c.alertAfter
+}
+
+// send sends n unless it is a failure alert within the AlertInterval,
+// reporting whether it was delivered.
+func (c *client) send(ctx context.Context, n Notification) bool {
+	n.Time = time.Now()
+	if !n.Recovered && !n.Changed && c.alertInterval > 0 && !c.lastAlert.IsZero() && n.Time.Sub(c.lastAlert) < c.alertInterval {
+		c.logger.Printf("suppressing notification; last alert was sent at %s\n", c.lastAlert)
+		return false
+	}
+	if err := c.notifier.Notify(ctx, n); err != nil {
+		c.logger.Printf("error sending notification: %s\n", err)
+		return false
+	}
+	if !n.Changed {
+		c.lastAlert = n.Time
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestNotifyPolicy(t *testing.T) {
+	ctx := context.Background()
+	var resolveErr error
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	})
+	var sent []ddns.Notification
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(r),
+		ddns.WithNotifier(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+			sent = append(sent, n)
+			return nil
+		})),
+		ddns.AlertAfter(2),
+		ddns.AlertOnRecovery(),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	resolveErr = errors.New("network unreachable")
+	for i := 0; i < 8; i++ {
+		c.RunDDNS(ctx)
+	}
+	// alerts after 2, 4, and 8 consecutive failures
+	if len(sent) != 3 {
+		t.Fatalf("Expected 3 alerts; got %d: %+v", len(sent), sent)
+	}
+	for i, want := range []int{2, 4, 8} {
+		if sent[i].Failures != want {
+			t.Errorf("Expected alert %d after %d failures; got %d", i, want, sent[i].Failures)
+		}
+	}
+
+	resolveErr = nil
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if len(sent) != 4 || !sent[3].Recovered {
+		t.Fatalf("Expected a recovery notification; got %+v", sent)
+	}
+
+	// a single failure is below the threshold and should not alert, nor should the recovery from it
+	resolveErr = errors.New("network unreachable")
+	c.RunDDNS(ctx)
+	resolveErr = nil
+	c.RunDDNS(ctx)
+	if len(sent) != 4 {
+		t.Fatalf("Expected no alerts for a brief failure; got %+v", sent[4:])
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var got ddns.Notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Unable to decode webhook body: %s", err)
+		}
+	}))
+	defer srv.Close()
+	n := ddns.Notification{Domain: "www.example.com", Failures: 1, Error: "boom"}
+	if err := ddns.Webhook(srv.URL).Notify(context.Background(), n); err != nil {
+		t.Fatalf("Notify returned an error: %s", err)
+	}
+	if got.Domain != n.Domain || got.Error != n.Error {
+		t.Fatalf("Expected %+v; got %+v", n, got)
+	}
+}
+
+func TestNotifyRecoveryAfterSuppressedAlert(t *testing.T) {
+	ctx := context.Background()
+	var resolveErr error
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	})
+	var sent []ddns.Notification
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(r),
+		ddns.WithNotifier(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+			sent = append(sent, n)
+			return nil
+		})),
+		ddns.AlertOnRecovery(),
+		ddns.AlertInterval(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	// the first outage is alerted and its recovery reported
+	resolveErr = errors.New("network unreachable")
+	c.RunDDNS(ctx)
+	resolveErr = nil
+	c.RunDDNS(ctx)
+	if len(sent) != 2 || !sent[1].Recovered {
+		t.Fatalf("Expected an alert and a recovery; got %+v", sent)
+	}
+
+	// the alert for the second outage is within the interval, so its recovery is not reported either
+	resolveErr = errors.New("network unreachable")
+	c.RunDDNS(ctx)
+	resolveErr = nil
+	c.RunDDNS(ctx)
+	if len(sent) != 2 {
+		t.Errorf("Expected no notifications for a suppressed outage; got %+v", sent[2:])
+	}
+}
+
+func TestNotifyRecoveryAfterFailedAlert(t *testing.T) {
+	ctx := context.Background()
+	var resolveErr error
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		return []netip.Addr{netip.MustParseAddr("192.0.2.1")}, nil
+	})
+	var sent []ddns.Notification
+	var notifyErr error
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(r),
+		ddns.WithNotifier(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+			if notifyErr != nil {
+				return notifyErr
+			}
+			sent = append(sent, n)
+			return nil
+		})),
+		ddns.AlertOnRecovery(),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	resolveErr, notifyErr = errors.New("network unreachable"), errors.New("smtp unavailable")
+	c.RunDDNS(ctx)
+	resolveErr, notifyErr = nil, nil
+	c.RunDDNS(ctx)
+	if len(sent) != 0 {
+		t.Errorf("Expected no recovery for an alert which was not delivered; got %+v", sent)
+	}
+}
+
+func TestNotifyIntervalAfterFailedAlert(t *testing.T) {
+	ctx := context.Background()
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) {
+		return nil, errors.New("network unreachable")
+	})
+	var sent []ddns.Notification
+	notifyErr := errors.New("smtp unavailable")
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(r),
+		ddns.WithNotifier(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+			if notifyErr != nil {
+				err := notifyErr
+				notifyErr = nil
+				return err
+			}
+			sent = append(sent, n)
+			return nil
+		})),
+		ddns.AlertInterval(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	c.RunDDNS(ctx)
+	c.RunDDNS(ctx)
+	if len(sent) != 1 || sent[0].Failures != 2 {
+		t.Errorf("Expected the alert after the failed delivery to be sent; got %+v", sent)
+	}
+}
@@ -0,0 +1,304 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NewOCI is used by [ddns.New] to create a Provider which updates records in Oracle Cloud Infrastructure (OCI) DNS,
+// authenticating with an API signing key from the OCI CLI configuration file.
+//
+// configFile is the path of the file, or "" for ~/.oci/config.
+// profile is the section of the file to use, or "" for DEFAULT.
+// The region is read from the profile unless [OCIRegion] is given.
+//
+// The zone of each domain is derived with [ZoneFromDomain] unless [OCIZone] is given,
+// and records are changed with a single PatchZoneRecords request.
+//
+// Additional options may be specified: [OCIZone], [OCICompartment], [OCIRegion].
+func NewOCI(configFile, profile string, options ...ociOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		key, region, err := readOCIConfig(configFile, profile)
+		if err != nil {
+			return nil, err
+		}
+		return newOCIProvider(key, region, options...)
+	}
+}
+
+// NewOCIInstancePrincipal is used by [ddns.New] to create a Provider like [NewOCI]
+// which authenticates as the OCI compute instance it runs on, so that no key needs to be stored on the instance.
+// The instance must be in a dynamic group allowed to manage the zone's DNS records.
+//
+// The region is read from the instance metadata unless [OCIRegion] is given.
+func NewOCIInstancePrincipal(options ...ociOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		return newOCIProvider(&ociInstancePrincipal{}, "", options...)
+	}
+}
+
+type ociOption func(*ociProvider) error
+
+// OCIZone configures an OCI provider to update records in zone, a zone name or OCID,
+// instead of deriving the zone from each domain.
+func OCIZone(zone string) ociOption {
+	return func(p *ociProvider) error {
+		if zone == "" {
+			return errors.New("zone cannot be empty")
+		}
+		p.zone = zone
+		return nil
+	}
+}
+
+// OCICompartment configures an OCI provider with the OCID of the compartment which contains the zone,
+// which is needed to find a zone by name outside of the tenancy's root compartment.
+func OCICompartment(id string) ociOption {
+	return func(p *ociProvider) error {
+		p.compartment = id
+		return nil
+	}
+}
+
+// OCIRegion configures the region of the OCI DNS API, e.g. "us-ashburn-1".
+func OCIRegion(region string) ociOption {
+	return func(p *ociProvider) error {
+		if region == "" {
+			return errors.New("region cannot be empty")
+		}
+		p.region = region
+		return nil
+	}
+}
+
+func newOCIProvider(auth ociAuth, region string, options ...ociOption) (*ociProvider, error) {
+	p := &ociProvider{auth: auth, region: region, logger: discard, ttl: 60}
+	for i, opt := range options {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("oci option %d returned an error: %w", i, err)
+		}
+	}
+	if ip, ok := auth.(*ociInstancePrincipal); ok && ip.region == "" {
+		// the auth service is in the same region as the API
+		ip.region = p.region
+	}
+	return p, nil
+}
+
+// ociProvider implements ddns.Provider with the OCI DNS API.
+type ociProvider struct {
+	httpClient  *http.Client
+	logger      *log.Logger
+	auth        ociAuth
+	region      string // empty until read from the instance metadata
+	zone        string
+	compartment string
+	ttl         int
+}
+
+func (p *ociProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *ociProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *ociProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, Batch: true, TTL: true}
+}
+
+func (p *ociProvider) SetTTL(ttl time.Duration) {
+	p.ttl = ttlSeconds(ttl)
+}
+
+// ociRecord is a record in the OCI DNS API.
+type ociRecord struct {
+	Domain    string `json:"domain"`
+	RType     string `json:"rtype"`
+	RData     string `json:"rdata"`
+	TTL       int    `json:"ttl"`
+	Operation string `json:"operation,omitempty"`
+}
+
+func (p *ociProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	existing, err := p.GetDNSRecords(ctx, domain)
+	if err != nil {
+		return err
+	}
+	ch := Diff(existing, records)
+	if ch.Empty() {
+		return nil
+	}
+	domain = canonicalName(domain)
+	var items []ociRecord
+	for _, a := range ch.Delete {
+		items = append(items, ociRecord{Domain: domain, RType: ociType(a), RData: a.String(), TTL: p.ttl, Operation: "REMOVE"})
+	}
+	for _, a := range ch.Create {
+		items = append(items, ociRecord{Domain: domain, RType: ociType(a), RData: a.String(), TTL: p.ttl, Operation: "ADD"})
+	}
+	zone, err := p.zoneFor(domain)
+	if err != nil {
+		return err
+	}
+	p.logger.Printf("patching records for %s: delete %+v, create %+v...\n", domain, ch.Delete, ch.Create)
+	body := struct {
+		Items []ociRecord `json:"items"`
+	}{items}
+	if err := p.call(ctx, http.MethodPatch, "/zones/"+zone+"/records", nil, body, nil); err != nil {
+		return fmt.Errorf("unable to update records for %s: %w", domain, err)
+	}
+	return nil
+}
+
+func (p *ociProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	domain = canonicalName(domain)
+	zone, err := p.zoneFor(domain)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []netip.Addr
+	query := url.Values{}
+	for {
+		var resp struct {
+			Items []ociRecord `json:"items"`
+		}
+		next, err := p.callPage(ctx, "/zones/"+zone+"/records/"+domain, query, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get records for %s: %w", domain, err)
+		}
+		for _, r := range resp.Items {
+			if r.RType != "A" && r.RType != "AAAA" {
+				continue
+			}
+			a, err := netip.ParseAddr(r.RData)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing IP from record: %w", err)
+			}
+			addrs = append(addrs, a)
+		}
+		if next == "" {
+			return addrs, nil
+		}
+		query.Set("page", next)
+	}
+}
+
+func (p *ociProvider) zoneFor(domain string) (string, error) {
+	if p.zone != "" {
+		return p.zone, nil
+	}
+	return ZoneFromDomain(domain, nil)
+}
+
+func ociType(a netip.Addr) string {
+	if a.Unmap().Is4() {
+		return "A"
+	}
+	return "AAAA"
+}
+
+// callPage makes a GET request and returns the token of the next page, if any.
+func (p *ociProvider) callPage(ctx context.Context, path string, query url.Values, result any) (string, error) {
+	var next string
+	err := p.do(ctx, http.MethodGet, path, query, nil, func(resp *http.Response) error {
+		next = resp.Header.Get("Opc-Next-Page")
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+	return next, err
+}
+
+// call makes a signed API request with body encoded as JSON, and decodes the response into result if it is not nil.
+func (p *ociProvider) call(ctx context.Context, method, path string, query url.Values, body, result any) error {
+	return p.do(ctx, method, path, query, body, func(resp *http.Response) error {
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+func (p *ociProvider) do(ctx context.Context, method, path string, query url.Values, body any, decode func(*http.Response) error) error {
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	keyID, key, err := p.auth.signingKey(ctx, httpClient)
+	if err != nil {
+		return err
+	}
+	if ip, ok := p.auth.(*ociInstancePrincipal); ok && p.region == "" {
+		p.region = ip.region
+	}
+	var b []byte
+	if body != nil {
+		if b, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	endpoint := "https://dns." + p.region + ".oraclecloud.com/20180115"
+	if query == nil {
+		query = url.Values{}
+	}
+	if p.compartment != "" {
+		query.Set("compartmentId", p.compartment)
+	}
+	u := endpoint + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	if err := ociSign(req, b, keyID, key); err != nil {
+		return fmt.Errorf("error signing request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &ociError{status: resp.StatusCode, msg: ociErrorMessage(resp)}
+	}
+	if err := decode(resp); err != nil {
+		return fmt.Errorf("error decoding response: %w", err)
+	}
+	return nil
+}
+
+func ociErrorMessage(resp *http.Response) string {
+	var body struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(b, &body) != nil || body.Message == "" {
+		return "unexpected response status " + resp.Status
+	}
+	return strings.TrimSpace(body.Code + ": " + body.Message)
+}
+
+type ociError struct {
+	status int
+	msg    string
+}
+
+func (e *ociError) Error() string               { return e.msg }
+func (e *ociError) IsAuthenticationError() bool { return e.status == http.StatusUnauthorized }
+func (e *ociError) IsAuthorizationError() bool  { return e.status == http.StatusForbidden }
+func (e *ociError) IsRateLimited() bool         { return e.status == http.StatusTooManyRequests }
+func (e *ociError) IsTemporary() bool           { return e.status >= 500 }
@@ -0,0 +1,358 @@
+package ddns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ociAuth provides the key used to sign requests to the OCI API.
+type ociAuth interface {
+	// signingKey returns the keyId of the Authorization header and the private key to sign with.
+	signingKey(ctx context.Context, httpClient *http.Client) (keyID string, key *rsa.PrivateKey, err error)
+}
+
+// ociAPIKey authenticates as an IAM user with an API signing key.
+type ociAPIKey struct {
+	keyID string // tenancy/user/fingerprint
+	key   *rsa.PrivateKey
+}
+
+func (a *ociAPIKey) signingKey(context.Context, *http.Client) (string, *rsa.PrivateKey, error) {
+	return a.keyID, a.key, nil
+}
+
+// readOCIConfig reads profile from the OCI CLI configuration file at path,
+// returning the API key it describes and the region.
+//
+// The file is an INI file such as:
+//
+//	[DEFAULT]
+//	user=ocid1.user.oc1..aaaa
+//	fingerprint=12:34:...
+//	tenancy=ocid1.tenancy.oc1..aaaa
+//	region=us-ashburn-1
+//	key_file=~/.oci/oci_api_key.pem
+func readOCIConfig(path, profile string) (*ociAPIKey, string, error) {
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", err
+		}
+		path = filepath.Join(home, ".oci", "config")
+	}
+	if profile == "" {
+		profile = "DEFAULT"
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to open OCI config: %w", err)
+	}
+	defer f.Close()
+	values := map[string]string{}
+	section := ""
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		// values in the DEFAULT profile are inherited by the others
+		if section == profile || (section == "DEFAULT" && values[k] == "") {
+			values[k] = strings.TrimSpace(v)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, "", fmt.Errorf("error reading OCI config: %w", err)
+	}
+	for _, k := range []string{"user", "fingerprint", "tenancy", "region", "key_file"} {
+		if values[k] == "" {
+			return nil, "", fmt.Errorf("OCI config profile %s has no %s", profile, k)
+		}
+	}
+	if values["pass_phrase"] != "" {
+		return nil, "", errors.New("encrypted OCI API keys are not supported")
+	}
+	keyFile := values["key_file"]
+	if rest, ok := strings.CutPrefix(keyFile, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, "", err
+		}
+		keyFile = filepath.Join(home, rest)
+	}
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read OCI API key: %w", err)
+	}
+	key, err := parseRSAKey(b)
+	if err != nil {
+		return nil, "", fmt.Errorf("error parsing OCI API key: %w", err)
+	}
+	return &ociAPIKey{
+		keyID: values["tenancy"] + "/" + values["user"] + "/" + values["fingerprint"],
+		key:   key,
+	}, values["region"], nil
+}
+
+// parseRSAKey parses a PEM encoded PKCS #1 or PKCS #8 RSA private key.
+func parseRSAKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not an RSA key")
+	}
+	return key, nil
+}
+
+// ociMetadata is the instance metadata service of OCI compute instances.
+const ociMetadata = "http://169.254.169.254/opc/v2"
+
+// ociInstancePrincipal authenticates as the compute instance the client runs on,
+// exchanging the instance's certificate for a security token signed with a session key.
+type ociInstancePrincipal struct {
+	region string
+
+	mu         sync.Mutex
+	token      string
+	expires    time.Time
+	sessionKey *rsa.PrivateKey
+}
+
+func (a *ociInstancePrincipal) signingKey(ctx context.Context, httpClient *http.Client) (string, *rsa.PrivateKey, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// renew early so that a token doesn't expire during an update
+	if a.token == "" || time.Until(a.expires) < 5*time.Minute {
+		if err := a.renew(ctx, httpClient); err != nil {
+			return "", nil, fmt.Errorf("unable to get instance principal token: %w", err)
+		}
+	}
+	return "ST$" + a.token, a.sessionKey, nil
+}
+
+// renew requests a new security token from the auth service.
+func (a *ociInstancePrincipal) renew(ctx context.Context, httpClient *http.Client) error {
+	get := func(path string) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ociMetadata+path, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer Oracle")
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("metadata request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("metadata request for %s returned %s", path, resp.Status)
+		}
+		return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	}
+	leafPEM, err := get("/identity/cert.pem")
+	if err != nil {
+		return err
+	}
+	keyPEM, err := get("/identity/key.pem")
+	if err != nil {
+		return err
+	}
+	intermediatePEM, err := get("/identity/intermediate.pem")
+	if err != nil {
+		return err
+	}
+	if a.region == "" {
+		region, err := get("/instance/canonicalRegionName")
+		if err != nil {
+			return err
+		}
+		a.region = strings.TrimSpace(string(region))
+	}
+	block, _ := pem.Decode(leafPEM)
+	if block == nil {
+		return errors.New("no instance certificate found")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("error parsing instance certificate: %w", err)
+	}
+	tenancy := ociTenancy(leaf)
+	if tenancy == "" {
+		return errors.New("no tenancy found in instance certificate")
+	}
+	leafKey, err := parseRSAKey(keyPEM)
+	if err != nil {
+		return fmt.Errorf("error parsing instance key: %w", err)
+	}
+	sessionKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&sessionKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(map[string]any{
+		"certificate":              pemBody(leafPEM),
+		"publicKey":                base64.StdEncoding.EncodeToString(pub),
+		"intermediateCertificates": []string{pemBody(intermediatePEM)},
+		"purpose":                  "DEFAULT",
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://auth."+a.region+".oraclecloud.com/v1/x509", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	req.Header.Set("Content-Type", "application/json")
+	fingerprint := sha1.Sum(leaf.Raw)
+	if err := ociSign(req, body, tenancy+"/fed-x509/"+colonHex(fingerprint[:]), leafKey); err != nil {
+		return fmt.Errorf("error signing request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &ociError{status: resp.StatusCode, msg: ociErrorMessage(resp)}
+	}
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("error decoding token: %w", err)
+	}
+	a.token, a.sessionKey = token.Token, sessionKey
+	a.expires = jwtExpiry(token.Token)
+	return nil
+}
+
+// ociTenancy returns the tenancy OCID from the subject of an instance certificate.
+func ociTenancy(cert *x509.Certificate) string {
+	for _, v := range append(cert.Subject.OrganizationalUnit, cert.Subject.Organization...) {
+		for _, prefix := range []string{"opc-tenant:", "opc-identity:"} {
+			if id, ok := strings.CutPrefix(v, prefix); ok {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// pemBody returns the base64 content of the first PEM block in b, without the header, footer, or line breaks.
+func pemBody(b []byte) string {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(block.Bytes)
+}
+
+func colonHex(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+// jwtExpiry returns the expiry time of a JWT, or a time in the near future if it can't be read.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+		var claims struct {
+			Exp int64 `json:"exp"`
+		}
+		if err == nil && json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+			return time.Unix(claims.Exp, 0)
+		}
+	}
+	return time.Now().Add(10 * time.Minute)
+}
+
+// ociSign signs req with key using the HTTP signature scheme of the OCI API.
+// body must be the content of the request body.
+func ociSign(req *http.Request, body []byte, keyID string, key *rsa.PrivateKey) error {
+	req.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	headers := []string{"date", "(request-target)", "host"}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		sum := sha256.Sum256(body)
+		req.Header.Set("Content-Length", fmt.Sprint(len(body)))
+		req.Header.Set("X-Content-Sha256", base64.StdEncoding.EncodeToString(sum[:]))
+		headers = append(headers, "content-length", "content-type", "x-content-sha256")
+	}
+	sum := sha256.Sum256([]byte(ociSigningString(req, headers)))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf(`Signature version="1",keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// ociSigningString returns the string signed for the given headers of req.
+func ociSigningString(req *http.Request, headers []string) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		var v string
+		switch h {
+		case "(request-target)":
+			v = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			v = req.URL.Host
+			if req.Host != "" {
+				v = req.Host
+			}
+		default:
+			v = req.Header.Get(h)
+		}
+		lines[i] = h + ": " + v
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,159 @@
+package ddns_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// fakeOCI is a minimal in-memory implementation of the OCI DNS record endpoints which checks request signatures.
+type fakeOCI struct {
+	t       *testing.T
+	key     *rsa.PublicKey
+	mu      sync.Mutex
+	records map[string]bool // "rtype rdata" for home.example.com
+	patches int
+}
+
+var ociAuthHeader = regexp.MustCompile(`^Signature version="1",keyId="([^"]+)",algorithm="rsa-sha256",headers="([^"]+)",signature="([^"]+)"$`)
+
+func (f *fakeOCI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := ociAuthHeader.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil || m[1] != "ocid1.tenancy.oc1..t/ocid1.user.oc1..u/aa:bb" {
+		f.t.Errorf("unexpected Authorization header %q", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var lines []string
+	for _, h := range strings.Fields(m[2]) {
+		switch h {
+		case "(request-target)":
+			lines = append(lines, h+": "+strings.ToLower(r.Method)+" "+r.URL.RequestURI())
+		case "host":
+			lines = append(lines, h+": "+r.Host)
+		default:
+			lines = append(lines, h+": "+r.Header.Get(h))
+		}
+	}
+	sig, _ := base64.StdEncoding.DecodeString(m[3])
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	if err := rsa.VerifyPKCS1v15(f.key, crypto.SHA256, sum[:], sig); err != nil {
+		f.t.Errorf("invalid signature for %s %s: %s", r.Method, r.URL, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/20180115/zones/example.com/records/home.example.com":
+		var items []map[string]any
+		for rec := range f.records {
+			typ, data, _ := strings.Cut(rec, " ")
+			items = append(items, map[string]any{"domain": "home.example.com", "rtype": typ, "rdata": data, "ttl": 60})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	case r.Method == http.MethodPatch && r.URL.Path == "/20180115/zones/example.com/records":
+		f.patches++
+		var body struct {
+			Items []struct {
+				RType     string `json:"rtype"`
+				RData     string `json:"rdata"`
+				Operation string `json:"operation"`
+			} `json:"items"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, it := range body.Items {
+			switch it.Operation {
+			case "ADD":
+				f.records[it.RType+" "+it.RData] = true
+			case "REMOVE":
+				delete(f.records, it.RType+" "+it.RData)
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": []any{}})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"code":"NotFound","message":"not found"}`)
+	}
+}
+
+func TestOCI(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key.pem")
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	config := filepath.Join(dir, "config")
+	os.WriteFile(config, []byte(fmt.Sprintf(`[DEFAULT]
+tenancy=ocid1.tenancy.oc1..t
+region=us-ashburn-1
+key_file=%s
+
+[home]
+user=ocid1.user.oc1..u
+fingerprint=aa:bb
+`, keyFile)), 0600)
+
+	f := &fakeOCI{t: t, key: &key.PublicKey, records: map[string]bool{"A 192.0.2.1": true, "AAAA 2001:db8::1": true}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	var hosts []string
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		hosts = append(hosts, r.URL.Host)
+		r = r.Clone(r.Context())
+		r.Host = r.URL.Host
+		r.URL.Scheme, r.URL.Host = "http", srv.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	c, err := ddns.New("home.example.com", ddns.NewOCI(config, "home"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("192.0.2.2"))),
+		ddns.UsingHTTPClient(httpClient),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	var got []string
+	for rec := range f.records {
+		got = append(got, rec)
+	}
+	sort.Strings(got)
+	if want := []string{"A 192.0.2.2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q; got %q", want, got)
+	}
+	if f.patches != 1 {
+		t.Errorf("Expected a single PATCH request; got %d", f.patches)
+	}
+	if hosts[0] != "dns.us-ashburn-1.oraclecloud.com" {
+		t.Errorf("Expected %q; got %q", "dns.us-ashburn-1.oraclecloud.com", hosts[0])
+	}
+
+	if _, err := ddns.NewOCI(config, "missing")(); err == nil {
+		t.Errorf("Expected an error for a profile without a user")
+	}
+}
@@ -0,0 +1,226 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// NewPihole is used by [ddns.New] to create a Provider which maintains Pi-hole local DNS records
+// (the custom hosts list) using the Pi-hole v6 API.
+//
+// serverURL is the address of the web interface, e.g. "http://pi.hole".
+// password is the web interface password or an application password.
+// Pi-hole does not support TTLs or wildcards for local DNS records.
+func NewPihole(serverURL, password string) func() (Provider, error) {
+	return func() (Provider, error) {
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing server URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("server URL must be http or https; got \"%s\"", serverURL)
+		}
+		return &piholeProvider{server: u, password: password, logger: discard}, nil
+	}
+}
+
+type piholeProvider struct {
+	httpClient *http.Client
+	logger     *log.Logger
+	server     *url.URL
+	password   string
+
+	mu  sync.Mutex // guards sid
+	sid string     // session ID, empty until authenticated
+}
+
+func (p *piholeProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *piholeProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *piholeProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true}
+}
+
+func (p *piholeProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	existing, err := p.GetDNSRecords(ctx, domain)
+	if err != nil {
+		return err
+	}
+	return Reconcile(ctx, addrRecords(existing), records, piholeApplier{p: p, domain: canonicalName(domain)})
+}
+
+// piholeApplier applies record changes for one domain as Pi-hole local DNS records.
+type piholeApplier struct {
+	p      *piholeProvider
+	domain string
+}
+
+func (a piholeApplier) Delete(ctx context.Context, r Record) error {
+	a.p.logger.Printf("deleting local DNS record %s for %s...\n", r.Addr, a.domain)
+	return a.p.call(ctx, http.MethodDelete, hostsPath(r.Addr, a.domain), nil)
+}
+
+func (a piholeApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.p.logger.Printf("adding local DNS record %s for %s...\n", addr, a.domain)
+	return a.p.call(ctx, http.MethodPut, hostsPath(addr, a.domain), nil)
+}
+
+// hostsPath returns the API path of the hosts entry mapping domain to a.
+func hostsPath(a netip.Addr, domain string) string {
+	return "/api/config/dns/hosts/" + url.PathEscape(a.String()+" "+domain)
+}
+
+func (p *piholeProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	var resp struct {
+		Config struct {
+			DNS struct {
+				Hosts []string `json:"hosts"`
+			} `json:"dns"`
+		} `json:"config"`
+	}
+	if err := p.call(ctx, http.MethodGet, "/api/config/dns/hosts", &resp); err != nil {
+		return nil, fmt.Errorf("unable to get local DNS records: %w", err)
+	}
+	var addrs []netip.Addr
+	for _, entry := range resp.Config.DNS.Hosts {
+		fields := strings.Fields(entry)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, name := range fields[1:] {
+			if !strings.EqualFold(name, canonicalName(domain)) {
+				continue
+			}
+			a, err := netip.ParseAddr(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("error parsing IP from hosts entry \"%s\": %w", entry, err)
+			}
+			addrs = append(addrs, a)
+		}
+	}
+	return addrs, nil
+}
+
+// call makes an API request, authenticating first if there is no session or the session has expired.
+func (p *piholeProvider) call(ctx context.Context, method, path string, result any) error {
+	sid, err := p.session(ctx, false)
+	if err != nil {
+		return err
+	}
+	resp, err := p.do(ctx, method, path, sid, nil)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if sid, err = p.session(ctx, true); err != nil {
+			return err
+		}
+		resp, err = p.do(ctx, method, path, sid, nil)
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &piholeError{status: resp.StatusCode, msg: piholeErrorMessage(resp)}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// session returns the session ID, logging in if there is none or renew is true.
+func (p *piholeProvider) session(ctx context.Context, renew bool) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.sid != "" && !renew {
+		return p.sid, nil
+	}
+	body, _ := json.Marshal(map[string]string{"password": p.password})
+	resp, err := p.do(ctx, http.MethodPost, "/api/auth", "", body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", &piholeError{status: resp.StatusCode, msg: piholeErrorMessage(resp)}
+	}
+	var auth struct {
+		Session struct {
+			Valid bool   `json:"valid"`
+			SID   string `json:"sid"`
+		} `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&auth); err != nil {
+		return "", fmt.Errorf("error decoding auth response: %w", err)
+	}
+	if !auth.Session.Valid {
+		return "", &piholeError{status: http.StatusUnauthorized, msg: "invalid password"}
+	}
+	// a Pi-hole without a password returns a valid session with no ID
+	p.sid = auth.Session.SID
+	return p.sid, nil
+}
+
+func (p *piholeProvider) do(ctx context.Context, method, path, sid string, body []byte) (*http.Response, error) {
+	u := *p.server
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	req, err := http.NewRequestWithContext(ctx, method, u.String()+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	setUserAgent(req)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if sid != "" {
+		req.Header.Set("X-FTL-SID", sid)
+	}
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
+
+func piholeErrorMessage(resp *http.Response) string {
+	var body struct {
+		Error struct {
+			Message string `json:"message"`
+			Hint    string `json:"hint"`
+		} `json:"error"`
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(b, &body) != nil || body.Error.Message == "" {
+		return "unexpected response status " + resp.Status
+	}
+	return strings.TrimSpace(body.Error.Message + " " + body.Error.Hint)
+}
+
+type piholeError struct {
+	status int
+	msg    string
+}
+
+func (e *piholeError) Error() string               { return e.msg }
+func (e *piholeError) IsAuthenticationError() bool { return e.status == http.StatusUnauthorized }
+func (e *piholeError) IsAuthorizationError() bool  { return e.status == http.StatusForbidden }
+func (e *piholeError) IsRateLimited() bool         { return e.status == http.StatusTooManyRequests }
+func (e *piholeError) IsTemporary() bool           { return e.status >= 500 }
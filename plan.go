@@ -0,0 +1,128 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// Planner is the interface for clients which can show the changes an update would make before making them.
+//
+// It is implemented by the client returned by ddns.New.
+type Planner interface {
+	Plan(ctx context.Context) (*Plan, error)
+}
+
+// Plan is an update proposed by [Planner.Plan].
+//
+// The Create and Delete lists may be edited before calling [Plan.Apply],
+// e.g. to require confirmation before deleting records:
+//
+//	plan, err := client.(ddns.Planner).Plan(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	if len(plan.Delete) > 0 && !confirm(plan.Delete) {
+//		plan.Delete = nil
+//	}
+//	return plan.Apply(ctx)
+type Plan struct {
+	Domain   string
+	Existing []netip.Addr // the records published when the plan was made
+	Changes               // the records to create, delete, and keep
+
+	c        *client
+	resolved []netip.Addr
+	run      RunInfo
+	standby  bool // another client's heartbeat is current, so nothing is changed
+	applied  bool
+}
+
+// Plan resolves our addresses and compares the records they would publish with the existing records,
+// without changing anything.
+// Unlike RunDDNS, a failed health check or resolution is returned without publishing fallback addresses or withdrawing records.
+//
+// The Provider must implement [RecordGetter].
+func (c *client) Plan(ctx context.Context) (*Plan, error) {
+	rg, ok := c.Provider.(RecordGetter)
+	if !ok {
+		return nil, errGetRecordsUnsupported
+	}
+	ctx, info := withRunID(ctx)
+	p := &Plan{Domain: c.domain, c: c, run: info}
+	existing, err := rg.GetDNSRecords(ctx, c.domain)
+	if err != nil {
+		return nil, fmt.Errorf("error getting existing records: %w", err)
+	}
+	p.Existing = sortAddrs(existing)
+	active, err := c.active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		p.standby = true
+		p.Keep = p.Existing
+		return p, nil
+	}
+	if err := c.checkHealth(ctx); err != nil {
+		return nil, err
+	}
+	addrs, err := c.Resolve(ctx)
+	if err == nil {
+		err = checkAddrs(addrs)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting IPs: %w", err)
+	}
+	p.resolved = c.limitAddrs(c.checkCloudAddrs(ctx, canonicalAddrs(addrs)))
+	c.emit(ctx, Resolved{Time: time.Now(), Addrs: p.resolved, Run: info})
+	records, err := c.desiredRecords(ctx, p.resolved)
+	if err != nil {
+		return nil, err
+	}
+	p.Changes = Diff(p.Existing, records)
+	return p, nil
+}
+
+// Records returns the records the domain will have once the plan is applied:
+// the existing records, without those in Delete, and with those in Create.
+func (p *Plan) Records() []netip.Addr {
+	deleted := map[netip.Addr]bool{}
+	for _, a := range p.Delete {
+		deleted[a.Unmap()] = true
+	}
+	var records []netip.Addr
+	for _, a := range p.Existing {
+		if !deleted[a.Unmap()] {
+			records = append(records, a)
+		}
+	}
+	return canonicalAddrs(append(records, p.Create...))
+}
+
+// Apply publishes the records of the plan.
+// A plan can only be applied once.
+func (p *Plan) Apply(ctx context.Context) error {
+	if p.applied {
+		return errors.New("plan has already been applied")
+	}
+	p.applied = true
+	if p.standby {
+		return nil
+	}
+	c := p.c
+	ctx = WithRunInfo(ctx, p.run)
+	records := p.Records()
+	if err := checkProviderFamilies(c.Provider, records); err != nil {
+		return err
+	}
+	if c.dryRun {
+		return c.logChanges(ctx, records)
+	}
+	if err := c.setRecords(ctx, records, p.resolved); err != nil {
+		return err
+	}
+	return c.beat(ctx)
+}
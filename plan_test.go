@@ -0,0 +1,68 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestPlan(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	old := netip.MustParseAddr("192.0.2.1")
+	keep := netip.MustParseAddr("2001:db8::1")
+	p.SetDNSRecords(ctx, "example.com", []netip.Addr{old, keep})
+
+	ip := netip.MustParseAddr("192.0.2.2")
+	r := &ddnstest.Resolver{}
+	r.Set(ip, keep)
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(r))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	plan, err := c.(ddns.Planner).Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan returned an error: %s", err)
+	}
+	if want := []netip.Addr{ip}; !reflect.DeepEqual(plan.Create, want) {
+		t.Errorf("Expected plan to create %q; got %q", want, plan.Create)
+	}
+	if want := []netip.Addr{old}; !reflect.DeepEqual(plan.Delete, want) {
+		t.Errorf("Expected plan to delete %q; got %q", want, plan.Delete)
+	}
+	if got := p.Records("example.com"); len(got) != 2 {
+		t.Fatalf("Expected Plan to leave the records unchanged; got %q", got)
+	}
+
+	// veto the deletion
+	plan.Delete = nil
+	if err := plan.Apply(ctx); err != nil {
+		t.Fatalf("Apply returned an error: %s", err)
+	}
+	want := []netip.Addr{old, ip, keep}
+	if got := p.Records("example.com"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q; got %q", want, got)
+	}
+	if err := plan.Apply(ctx); err == nil {
+		t.Errorf("Expected an error applying a plan twice")
+	}
+}
+
+func TestPlanInvalidAddrs(t *testing.T) {
+	ctx := context.Background()
+	for _, addr := range []netip.Addr{{}, netip.MustParseAddr("fe80::1%eth0")} {
+		r := &ddnstest.Resolver{}
+		r.Set(netip.MustParseAddr("192.0.2.1"), addr)
+		c, err := ddns.New("example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}), ddns.UsingResolver(r))
+		if err != nil {
+			t.Fatalf("New returned an error: %s", err)
+		}
+		if _, err := c.(ddns.Planner).Plan(ctx); err == nil {
+			t.Errorf("Expected an error planning with the resolved address %q", addr)
+		}
+	}
+}
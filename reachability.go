@@ -0,0 +1,233 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prober checks whether a TCP port on a published address can be reached.
+//
+// A Prober should connect from outside the local network, as a connection from the host to its own public address
+// only tests the router's NAT loopback. See [HTTPProber] and [ProbeHandler].
+type Prober interface {
+	Probe(ctx context.Context, addr netip.AddrPort) error
+}
+
+// ProberFunc is a function which implements [Prober].
+type ProberFunc func(ctx context.Context, addr netip.AddrPort) error
+
+func (f ProberFunc) Probe(ctx context.Context, addr netip.AddrPort) error { return f(ctx, addr) }
+
+// CheckReachable configures the client to probe each published address on ports after every successful update,
+// reporting whether the host is actually reachable at the records, e.g. when port forwarding is missing or the ISP blocks the port.
+//
+// When a probe fails, RunDDNS returns an [*UnreachableError] after the records were published,
+// so unreachable ports are alerted and recovered like other failed updates (see [WithNotifier])
+// and reported in the agent's status.
+// Each probe is also sent as a [ReachabilityChecked] event.
+func CheckReachable(prober Prober, ports ...uint16) clientOption {
+	return func(c *client) error {
+		if prober == nil {
+			return errors.New("prober cannot be nil")
+		}
+		if len(ports) == 0 {
+			return errors.New("at least one port is required")
+		}
+		c.prober = prober
+		c.probePorts = append([]uint16(nil), ports...)
+		return nil
+	}
+}
+
+// UnreachableError is returned by RunDDNS when published addresses failed a [CheckReachable] probe.
+type UnreachableError struct {
+	Addrs []netip.AddrPort // the addresses which could not be reached
+	Err   error            // the errors returned by the probes
+}
+
+func (e *UnreachableError) Error() string {
+	s := make([]string, len(e.Addrs))
+	for i, a := range e.Addrs {
+		s[i] = a.String()
+	}
+	return fmt.Sprintf("published records are not reachable at %s: %s", strings.Join(s, ", "), e.Err)
+}
+
+func (e *UnreachableError) Unwrap() error { return e.Err }
+
+// ReachabilityChecked is sent for each address and port probed by [CheckReachable].
+// Err is nil if the port was reachable.
+type ReachabilityChecked struct {
+	Time   time.Time
+	Domain string
+	Addr   netip.AddrPort
+	Err    error
+	Run    RunInfo
+}
+
+func (ReachabilityChecked) event() {}
+
+// probe checks that the published records are reachable on the configured ports.
+func (c *client) probe(ctx context.Context) error {
+	if c.prober == nil || c.dryRun {
+		return nil
+	}
+	addrs := c.published
+	if c.appendMode {
+		// the other records belong to other hosts
+		addrs = c.owned
+	}
+	var failed []netip.AddrPort
+	var errs []error
+	for _, a := range addrs {
+		for _, port := range c.probePorts {
+			ap := netip.AddrPortFrom(a, port)
+			err := c.prober.Probe(ctx, ap)
+			c.emit(ctx, ReachabilityChecked{Time: time.Now(), Domain: c.domain, Addr: ap, Err: err, Run: RunInfoFrom(ctx)})
+			if err != nil {
+				c.logger.Printf("%s is not reachable: %s\n", ap, err)
+				failed = append(failed, ap)
+				errs = append(errs, err)
+				continue
+			}
+			c.logger.Printf("%s is reachable\n", ap)
+		}
+	}
+	if len(failed) > 0 {
+		return &UnreachableError{Addrs: failed, Err: errors.Join(errs...)}
+	}
+	return nil
+}
+
+// TCPProber constructs a Prober which connects to the address directly, waiting up to timeout for each connection.
+// It is only meaningful when the client runs outside of the network it publishes, or to implement a probe service.
+func TCPProber(timeout time.Duration) Prober {
+	return ProberFunc(func(ctx context.Context, addr netip.AddrPort) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr.String())
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// HTTPProber constructs a Prober which asks the probe service at serviceURL to connect to the address,
+// e.g. a [ProbeHandler] running on a VPS or a friend's network.
+//
+// The address is sent as the addr and port query parameters of a GET request.
+// The port is reachable if the service responds with status "200 OK";
+// otherwise the first line of the response body is returned as the error.
+func HTTPProber(serviceURL string) Prober {
+	p := &httpProber{}
+	p.url, p.err = url.Parse(serviceURL)
+	if p.err == nil && p.url.Scheme != "http" && p.url.Scheme != "https" {
+		p.err = fmt.Errorf("probe service URL must be http:// or https://; got \"%s\"", serviceURL)
+	}
+	return p
+}
+
+type httpProber struct {
+	url        *url.URL
+	httpClient *http.Client
+	err        error // configuration error, returned by Probe
+}
+
+func (p *httpProber) validate() error { return p.err }
+
+func (p *httpProber) SetHTTPClient(httpclient *http.Client) { p.httpClient = httpclient }
+
+func (p *httpProber) Probe(ctx context.Context, addr netip.AddrPort) error {
+	if p.err != nil {
+		return p.err
+	}
+	u := *p.url
+	q := u.Query()
+	q.Set("addr", addr.Addr().String())
+	q.Set("port", strconv.Itoa(int(addr.Port())))
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	httpclient := p.httpClient
+	if httpclient == nil {
+		httpclient = http.DefaultClient
+	}
+	resp, err := httpclient.Do(req)
+	if err != nil {
+		return fmt.Errorf("probe service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg, _, _ := strings.Cut(strings.TrimSpace(string(body)), "\n")
+	if resp.StatusCode != http.StatusBadGateway {
+		return fmt.Errorf("probe service returned %s: %s", resp.Status, msg)
+	}
+	return errors.New(msg)
+}
+
+// ProbeHandler returns the http.Handler of a probe service for [HTTPProber],
+// which connects to the addr and port in the query with [TCPProber] and responds with
+// "200 OK" if the port is reachable or "502 Bad Gateway" and the error if it is not.
+//
+// Only public addresses are probed, so that the service can't be used to scan the network it runs in.
+// If ports are given, then only those ports may be probed.
+func ProbeHandler(ports ...uint16) http.Handler {
+	prober := TCPProber(5 * time.Second)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, err := netip.ParseAddr(r.URL.Query().Get("addr"))
+		if err != nil {
+			http.Error(w, "invalid addr", http.StatusBadRequest)
+			return
+		}
+		addr = addr.Unmap()
+		if !isPublicAddr(addr) {
+			http.Error(w, "addr must be a public address", http.StatusBadRequest)
+			return
+		}
+		port, err := strconv.ParseUint(r.URL.Query().Get("port"), 10, 16)
+		if err != nil || port == 0 {
+			http.Error(w, "invalid port", http.StatusBadRequest)
+			return
+		}
+		if len(ports) > 0 && !containsPort(ports, uint16(port)) {
+			http.Error(w, "port is not allowed", http.StatusForbidden)
+			return
+		}
+		if err := prober.Probe(r.Context(), netip.AddrPortFrom(addr, uint16(port))); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintln(w, "reachable")
+	})
+}
+
+// isPublicAddr reports whether a is a public address outside of the shared address space of carrier-grade NAT.
+func isPublicAddr(a netip.Addr) bool {
+	return PublicAddr(a) && !sharedAddressSpace.Contains(a)
+}
+
+func containsPort(ports []uint16, port uint16) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
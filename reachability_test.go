@@ -0,0 +1,113 @@
+package ddns_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestCheckReachable(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	open := map[netip.AddrPort]bool{}
+	prober := ddns.ProberFunc(func(ctx context.Context, addr netip.AddrPort) error {
+		if !open[addr] {
+			return errors.New("connection timed out")
+		}
+		return nil
+	})
+	var notifications []ddns.Notification
+	ip := netip.MustParseAddr("203.0.113.5")
+	c, err := ddns.New("home.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(ip)),
+		ddns.CheckReachable(prober, 443, 22),
+		ddns.WithNotifier(ddns.NotifierFunc(func(ctx context.Context, n ddns.Notification) error {
+			notifications = append(notifications, n)
+			return nil
+		})),
+		ddns.AlertOnRecovery(),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+
+	open[netip.AddrPortFrom(ip, 22)] = true
+	err = c.RunDDNS(ctx)
+	var unreachable *ddns.UnreachableError
+	if !errors.As(err, &unreachable) {
+		t.Fatalf("Expected an UnreachableError; got %v", err)
+	}
+	if len(unreachable.Addrs) != 1 || unreachable.Addrs[0] != netip.AddrPortFrom(ip, 443) {
+		t.Errorf("Expected %s to be unreachable; got %v", netip.AddrPortFrom(ip, 443), unreachable.Addrs)
+	}
+	if got := p.Records("home.example.com"); len(got) != 1 || got[0] != ip {
+		t.Errorf("Expected the records to be published before probing; got %q", got)
+	}
+	if len(notifications) != 1 || notifications[0].Recovered {
+		t.Fatalf("Expected an alert; got %+v", notifications)
+	}
+
+	open[netip.AddrPortFrom(ip, 443)] = true
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if len(notifications) != 2 || !notifications[1].Recovered {
+		t.Errorf("Expected a recovery notification; got %+v", notifications)
+	}
+}
+
+func TestHTTPProber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "secret" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if q := r.URL.Query(); q.Get("port") != "443" {
+			http.Error(w, fmt.Sprintf("dial tcp %s:%s: connection refused", q.Get("addr"), q.Get("port")), http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintln(w, "reachable")
+	}))
+	defer srv.Close()
+	prober := ddns.HTTPProber(srv.URL + "/probe?key=secret")
+	if err := prober.Probe(context.Background(), netip.MustParseAddrPort("203.0.113.5:443")); err != nil {
+		t.Errorf("Expected port 443 to be reachable; got %s", err)
+	}
+	expected := "dial tcp 203.0.113.5:80: connection refused"
+	if err := prober.Probe(context.Background(), netip.MustParseAddrPort("203.0.113.5:80")); err == nil || err.Error() != expected {
+		t.Errorf("Expected %q; got %v", expected, err)
+	}
+	if _, err := ddns.New("home.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.CheckReachable(ddns.HTTPProber("ftp://probe.example.com"), 443),
+	); err == nil {
+		t.Errorf("Expected New to reject an invalid probe service URL")
+	}
+}
+
+func TestProbeHandler(t *testing.T) {
+	h := ddns.ProbeHandler(443)
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{"addr=192.168.1.10&port=443", http.StatusBadRequest},
+		{"addr=127.0.0.1&port=443", http.StatusBadRequest},
+		{"addr=100.64.0.1&port=443", http.StatusBadRequest},
+		{"addr=203.0.113.5&port=0", http.StatusBadRequest},
+		{"addr=203.0.113.5&port=22", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: Expected status %d; got %d", tt.query, tt.status, w.Code)
+		}
+	}
+}
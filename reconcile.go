@@ -0,0 +1,158 @@
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// Record is an address record for a domain as stored by a provider.
+type Record struct {
+	ID   string // the provider's identifier for the record, if it has one
+	Addr netip.Addr
+}
+
+// Applier makes the changes found by [Reconcile] to the records for one domain.
+type Applier interface {
+	Create(ctx context.Context, addr netip.Addr) error
+	Delete(ctx context.Context, r Record) error
+}
+
+// Updater may be implemented by an [Applier] which can change the address of an existing record in place.
+// Reconcile then updates a stale record of the same address family instead of deleting it and creating another,
+// which keeps any other settings of the record and avoids a moment with no record.
+type Updater interface {
+	Update(ctx context.Context, r Record, addr netip.Addr) error
+}
+
+type reconcileOption func(*reconcileConfig)
+
+type reconcileConfig struct {
+	createFirst bool
+	appendOnly  bool
+	owned       map[netip.Addr]bool // nil if every record is owned
+}
+
+// CreateBeforeDelete makes [Reconcile] create new records before deleting stale ones,
+// so that the domain always resolves while it is updated.
+func CreateBeforeDelete() reconcileOption {
+	return func(c *reconcileConfig) {
+		c.createFirst = true
+	}
+}
+
+// AppendOnly makes [Reconcile] only create records, leaving existing records in place.
+func AppendOnly() reconcileOption {
+	return func(c *reconcileConfig) {
+		c.appendOnly = true
+	}
+}
+
+// OwnedOnly makes [Reconcile] only delete or update stale records for the owned addresses,
+// e.g. those published earlier by this client, so that records added by others are left alone.
+func OwnedOnly(owned []netip.Addr) reconcileOption {
+	return func(c *reconcileConfig) {
+		c.owned = map[netip.Addr]bool{}
+		for _, a := range owned {
+			c.owned[a.Unmap()] = true
+		}
+	}
+}
+
+// Reconcile changes the existing records for a domain to the desired addresses using applier,
+// so that each provider doesn't need its own comparison logic.
+//
+// By default every existing record which isn't desired is replaced:
+// stale records are updated in place if applier implements [Updater], the rest are deleted,
+// and then records are created for the remaining desired addresses.
+// The strategy can be changed with [CreateBeforeDelete], [AppendOnly], and [OwnedOnly].
+//
+// Reconcile stops at the first error, leaving the changes made so far.
+func Reconcile(ctx context.Context, existing []Record, desired []netip.Addr, applier Applier, options ...reconcileOption) error {
+	var config reconcileConfig
+	for _, opt := range options {
+		opt(&config)
+	}
+	want := map[netip.Addr]bool{}
+	for _, a := range desired {
+		want[a.Unmap()] = true
+	}
+	have := map[netip.Addr]bool{}
+	var stale []Record
+	for _, r := range existing {
+		a := r.Addr.Unmap()
+		have[a] = true
+		if want[a] || config.appendOnly || (config.owned != nil && !config.owned[a]) {
+			continue
+		}
+		stale = append(stale, r)
+	}
+	var create []netip.Addr
+	for _, a := range sortAddrs(desired) {
+		a = a.Unmap()
+		if have[a] {
+			continue
+		}
+		have[a] = true
+		create = append(create, a)
+	}
+
+	if u, ok := applier.(Updater); ok {
+		var remaining []netip.Addr
+		for _, a := range create {
+			i := sameFamily(stale, a)
+			if i < 0 {
+				remaining = append(remaining, a)
+				continue
+			}
+			r := stale[i]
+			stale = append(stale[:i], stale[i+1:]...)
+			if err := u.Update(ctx, r, a); err != nil {
+				return fmt.Errorf("unable to update record %s to %s: %w", r.Addr, a, err)
+			}
+		}
+		create = remaining
+	}
+
+	createAll := func() error {
+		for _, a := range create {
+			if err := applier.Create(ctx, a); err != nil {
+				return fmt.Errorf("unable to create record %s: %w", a, err)
+			}
+		}
+		return nil
+	}
+	if config.createFirst {
+		if err := createAll(); err != nil {
+			return err
+		}
+	}
+	for _, r := range stale {
+		if err := applier.Delete(ctx, r); err != nil {
+			return fmt.Errorf("unable to delete record %s: %w", r.Addr, err)
+		}
+	}
+	if !config.createFirst {
+		return createAll()
+	}
+	return nil
+}
+
+// addrRecords returns records for addrs, for providers which identify records only by address.
+func addrRecords(addrs []netip.Addr) []Record {
+	records := make([]Record, len(addrs))
+	for i, a := range addrs {
+		records[i] = Record{Addr: a}
+	}
+	return records
+}
+
+// sameFamily returns the index of the first record in records with the address family of a, or -1.
+func sameFamily(records []Record, a netip.Addr) int {
+	for i, r := range records {
+		if r.Addr.Unmap().Is4() == a.Is4() {
+			return i
+		}
+	}
+	return -1
+}
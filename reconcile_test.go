@@ -0,0 +1,87 @@
+package ddns_test
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// recordingApplier records the changes made by ddns.Reconcile.
+type recordingApplier struct {
+	ops []string
+}
+
+func (a *recordingApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.ops = append(a.ops, "create "+addr.String())
+	return nil
+}
+
+func (a *recordingApplier) Delete(ctx context.Context, r ddns.Record) error {
+	a.ops = append(a.ops, "delete "+r.Addr.String())
+	return nil
+}
+
+type updatingApplier struct {
+	recordingApplier
+}
+
+func (a *updatingApplier) Update(ctx context.Context, r ddns.Record, addr netip.Addr) error {
+	a.ops = append(a.ops, fmt.Sprintf("update %s %s", r.Addr, addr))
+	return nil
+}
+
+func TestReconcile(t *testing.T) {
+	mine := netip.MustParseAddr("192.0.2.1")
+	other := netip.MustParseAddr("192.0.2.10")
+	keep := netip.MustParseAddr("2001:db8::1")
+	existing := []ddns.Record{{ID: "1", Addr: mine}, {ID: "2", Addr: other}, {ID: "3", Addr: keep}}
+	desired := []netip.Addr{netip.MustParseAddr("192.0.2.2"), keep}
+
+	tt := []struct {
+		name    string
+		updates bool
+		opts    string
+		want    []string
+	}{
+		{name: "replace", want: []string{"delete 192.0.2.1", "delete 192.0.2.10", "create 192.0.2.2"}},
+		{name: "create before delete", opts: "create-first", want: []string{"create 192.0.2.2", "delete 192.0.2.1", "delete 192.0.2.10"}},
+		{name: "append only", opts: "append", want: []string{"create 192.0.2.2"}},
+		{name: "owned only", opts: "owned", want: []string{"delete 192.0.2.1", "create 192.0.2.2"}},
+		{name: "update in place", updates: true, want: []string{"update 192.0.2.1 192.0.2.2", "delete 192.0.2.10"}},
+		{name: "owned update in place", updates: true, opts: "owned", want: []string{"update 192.0.2.1 192.0.2.2"}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var rec *recordingApplier
+			var applier ddns.Applier
+			if tc.updates {
+				u := &updatingApplier{}
+				rec, applier = &u.recordingApplier, u
+			} else {
+				rec = &recordingApplier{}
+				applier = rec
+			}
+			var err error
+			switch tc.opts {
+			case "create-first":
+				err = ddns.Reconcile(context.Background(), existing, desired, applier, ddns.CreateBeforeDelete())
+			case "append":
+				err = ddns.Reconcile(context.Background(), existing, desired, applier, ddns.AppendOnly())
+			case "owned":
+				err = ddns.Reconcile(context.Background(), existing, desired, applier, ddns.OwnedOnly([]netip.Addr{mine}))
+			default:
+				err = ddns.Reconcile(context.Background(), existing, desired, applier)
+			}
+			if err != nil {
+				t.Fatalf("Reconcile returned an error: %s", err)
+			}
+			if !reflect.DeepEqual(rec.ops, tc.want) {
+				t.Errorf("Expected %q; got %q", tc.want, rec.ops)
+			}
+		})
+	}
+}
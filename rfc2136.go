@@ -0,0 +1,598 @@
+package ddns
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsClassNONE is used in the update section of an RFC 2136 message to delete a single record from an RRset.
+const dnsClassNONE dnsmessage.Class = 254
+
+const dnsTypeTSIG dnsmessage.Type = 250
+
+const dnsRCodeNotAuth dnsmessage.RCode = 9
+
+// NewRFC2136 is used by [ddns.New] to create a new Provider which sends dynamic DNS updates (RFC 2136) to server.
+//
+// server is the address of the primary name server for the zone, e.g. "ns1.example.com:53".
+// If the port is omitted then port 53 is used.
+// The zone to update is discovered by querying server for the SOA record of the domain.
+//
+// Additional options may be specified: [TSIG], [UpdatePTR].
+func NewRFC2136(server string, options ...rfc2136Option) func() (Provider, error) {
+	return func() (Provider, error) {
+		return newRFC2136Provider(server, options...)
+	}
+}
+
+type rfc2136Option func(*rfc2136Provider) error
+
+// TSIG configures an RFC 2136 provider to sign messages with a shared secret key.
+//
+// algorithm must be one of "hmac-sha1", "hmac-sha256", or "hmac-sha512".
+// secret is the base64 encoded key, as found in the BIND key file generated by tsig-keygen.
+func TSIG(keyName, algorithm, secret string) rfc2136Option {
+	return func(p *rfc2136Provider) error {
+		var h func() hash.Hash
+		switch strings.ToLower(strings.TrimSuffix(algorithm, ".")) {
+		case "hmac-sha1":
+			h = sha1.New
+		case "hmac-sha256":
+			h = sha256.New
+		case "hmac-sha512":
+			h = sha512.New
+		default:
+			return fmt.Errorf("unsupported TSIG algorithm \"%s\"", algorithm)
+		}
+		s, err := base64.StdEncoding.DecodeString(secret)
+		if err != nil {
+			return fmt.Errorf("error decoding TSIG secret: %w", err)
+		}
+		p.key = &tsigKey{
+			name:      fqdn(keyName),
+			algorithm: fqdn(algorithm),
+			secret:    s,
+			hash:      h,
+		}
+		return nil
+	}
+}
+
+// UpdatePTR configures an RFC 2136 provider to also manage the reverse DNS (PTR) records for each address,
+// pointing them back at the domain.
+//
+// The in-addr.arpa and ip6.arpa zones must be served by the same name server as the forward zone.
+// Link-local addresses are skipped.
+func UpdatePTR() rfc2136Option {
+	return func(p *rfc2136Provider) error {
+		p.ptr = true
+		return nil
+	}
+}
+
+func newRFC2136Provider(server string, options ...rfc2136Option) (*rfc2136Provider, error) {
+	if server == "" {
+		return nil, errors.New("server cannot be empty")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	p := &rfc2136Provider{
+		server: server,
+		logger: discard,
+		ttl:    60,
+	}
+	for i, opt := range options {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("rfc2136 option %d returned an error: %w", i, err)
+		}
+	}
+	return p, nil
+}
+
+// rfc2136Provider implements ddns.Provider by sending dynamic updates directly to a name server.
+type rfc2136Provider struct {
+	mu     sync.Mutex // guards key during credential rotation
+	server string
+	logger *log.Logger
+	ttl    uint32
+	key    *tsigKey
+	ptr    bool
+	dial   func(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+func (p *rfc2136Provider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, TXT: true, ListRecords: true, Batch: true, TTL: true}
+}
+
+func (p *rfc2136Provider) SetTTL(ttl time.Duration) {
+	p.ttl = uint32(ttlSeconds(ttl))
+}
+
+func (p *rfc2136Provider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+// SetHTTPClient makes connections to the name server with the dialer of httpclient's transport, if any.
+func (p *rfc2136Provider) SetHTTPClient(httpclient *http.Client) {
+	p.dial = dialer(httpclient)
+}
+
+// RotateCredentials replaces the TSIG secret with token, a base64 encoded key.
+// The key name and algorithm are unchanged.
+func (p *rfc2136Provider) RotateCredentials(ctx context.Context, token string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.key == nil {
+		return errors.New("provider is not configured with a TSIG key")
+	}
+	s, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("error decoding TSIG secret: %w", err)
+	}
+	key := *p.key
+	key.secret = s
+	p.key = &key
+	return nil
+}
+
+func (p *rfc2136Provider) tsigKey() *tsigKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.key
+}
+
+func (p *rfc2136Provider) SetDNSRecords(ctx context.Context, domain string, addrs []netip.Addr) error {
+	name, err := dnsmessage.NewName(fqdn(domain))
+	if err != nil {
+		return fmt.Errorf("invalid domain name \"%s\": %w", domain, err)
+	}
+	zone, err := p.findZone(ctx, name)
+	if err != nil {
+		return fmt.Errorf("unable to find zone for %s: %w", domain, err)
+	}
+	p.logger.Printf("found zone %s\n", zone)
+
+	existing, err := p.lookup(ctx, name)
+	if err != nil {
+		return fmt.Errorf("error looking up existing records for %s: %w", domain, err)
+	}
+	p.logger.Printf("found %d existing records: %+v\n", len(existing), existing)
+
+	applier := &rfc2136Applier{name: name, ttl: p.ttl, logger: p.logger}
+	if err := Reconcile(ctx, addrRecords(existing), addrs, applier); err != nil {
+		return err
+	}
+	if len(applier.update) == 0 {
+		p.logger.Printf("records for %s are up to date\n", domain)
+		return nil
+	}
+	if err := p.update(ctx, zone, applier.update); err != nil {
+		return fmt.Errorf("error updating records for %s: %w", domain, err)
+	}
+	p.logger.Printf("successfully updated records for %s\n", domain)
+
+	if !p.ptr {
+		return nil
+	}
+	var errs []error
+	for _, a := range applier.removed {
+		if err := p.setPTR(ctx, a, name, false); err != nil {
+			errs = append(errs, fmt.Errorf("error deleting PTR record for %s: %w", a, err))
+		}
+	}
+	for _, a := range applier.added {
+		if err := p.setPTR(ctx, a, name, true); err != nil {
+			errs = append(errs, fmt.Errorf("error creating PTR record for %s: %w", a, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *rfc2136Provider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	name, err := dnsmessage.NewName(fqdn(domain))
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain name \"%s\": %w", domain, err)
+	}
+	return p.lookup(ctx, name)
+}
+
+func (p *rfc2136Provider) GetTXTRecords(ctx context.Context, name string) ([]string, error) {
+	n, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid name \"%s\": %w", name, err)
+	}
+	resp, err := p.query(ctx, n, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, r := range resp.Answers {
+		if txt, ok := r.Body.(*dnsmessage.TXTResource); ok {
+			values = append(values, strings.Join(txt.TXT, ""))
+		}
+	}
+	return values, nil
+}
+
+// SetTXTRecords replaces the TXT records at name with values.
+func (p *rfc2136Provider) SetTXTRecords(ctx context.Context, name string, values []string) error {
+	n, err := dnsmessage.NewName(fqdn(name))
+	if err != nil {
+		return fmt.Errorf("invalid name \"%s\": %w", name, err)
+	}
+	zone, err := p.findZone(ctx, n)
+	if err != nil {
+		return fmt.Errorf("unable to find zone for %s: %w", name, err)
+	}
+	update := []dnsmessage.Resource{rrsetResource(n, dnsmessage.TypeTXT)}
+	for _, v := range values {
+		update = append(update, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: n, Class: dnsmessage.ClassINET, TTL: p.ttl},
+			Body:   &dnsmessage.TXTResource{TXT: splitTXT(v)},
+		})
+	}
+	p.logger.Printf("setting TXT records for %s...\n", name)
+	return p.update(ctx, zone, update)
+}
+
+// splitTXT splits v into the 255 byte character-strings which make up a TXT record.
+func splitTXT(v string) []string {
+	var s []string
+	for len(v) > 255 {
+		s = append(s, v[:255])
+		v = v[255:]
+	}
+	return append(s, v)
+}
+
+// setPTR creates (or deletes) the reverse record for addr pointing to target.
+// Creating a PTR record replaces any existing PTR records for that address.
+func (p *rfc2136Provider) setPTR(ctx context.Context, addr netip.Addr, target dnsmessage.Name, create bool) error {
+	if addr.IsLinkLocalUnicast() {
+		p.logger.Printf("skipping PTR record for link-local address %s\n", addr)
+		return nil
+	}
+	name, err := dnsmessage.NewName(reverseName(addr))
+	if err != nil {
+		return fmt.Errorf("invalid reverse name for %s: %w", addr, err)
+	}
+	zone, err := p.findZone(ctx, name)
+	if err != nil {
+		return fmt.Errorf("unable to find reverse zone: %w", err)
+	}
+	var update []dnsmessage.Resource
+	if create {
+		p.logger.Printf("setting PTR record %s to %s...\n", name, target)
+		update = append(update,
+			rrsetResource(name, dnsmessage.TypePTR),
+			ptrResource(name, dnsmessage.ClassINET, p.ttl, target),
+		)
+	} else {
+		p.logger.Printf("deleting PTR record %s...\n", name)
+		update = append(update, ptrResource(name, dnsClassNONE, 0, target))
+	}
+	return p.update(ctx, zone, update)
+}
+
+// findZone returns the name of the zone containing name by asking for its SOA record.
+// An authoritative server answers with the SOA of the enclosing zone in the authority section,
+// or in the answer section if name is the zone apex.
+func (p *rfc2136Provider) findZone(ctx context.Context, name dnsmessage.Name) (dnsmessage.Name, error) {
+	resp, err := p.query(ctx, name, dnsmessage.TypeSOA)
+	if err != nil {
+		return dnsmessage.Name{}, err
+	}
+	for _, section := range [][]dnsmessage.Resource{resp.Answers, resp.Authorities} {
+		for _, r := range section {
+			if r.Header.Type == dnsmessage.TypeSOA {
+				return r.Header.Name, nil
+			}
+		}
+	}
+	return dnsmessage.Name{}, fmt.Errorf("no SOA record found for %s; is %s authoritative for the zone?", name, p.server)
+}
+
+// lookup returns the A and AAAA records for name.
+func (p *rfc2136Provider) lookup(ctx context.Context, name dnsmessage.Name) (addrs []netip.Addr, err error) {
+	for _, t := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		resp, err := p.query(ctx, name, t)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range resp.Answers {
+			if !strings.EqualFold(r.Header.Name.String(), name.String()) {
+				continue
+			}
+			switch b := r.Body.(type) {
+			case *dnsmessage.AResource:
+				addrs = append(addrs, netip.AddrFrom4(b.A))
+			case *dnsmessage.AAAAResource:
+				addrs = append(addrs, netip.AddrFrom16(b.AAAA))
+			}
+		}
+	}
+	return addrs, nil
+}
+
+func (p *rfc2136Provider) query(ctx context.Context, name dnsmessage.Name, t dnsmessage.Type) (*dnsmessage.Message, error) {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32())},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  t,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	resp, err := p.exchange(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess && resp.RCode != dnsmessage.RCodeNameError {
+		return nil, &dnsError{op: "query", rcode: resp.RCode}
+	}
+	return resp, nil
+}
+
+// update sends an RFC 2136 update message for zone.
+// The zone section of an update reuses the question section,
+// and the update section reuses the authority section.
+func (p *rfc2136Provider) update(ctx context.Context, zone dnsmessage.Name, update []dnsmessage.Resource) error {
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{ID: uint16(rand.Uint32()), OpCode: 5},
+		Questions: []dnsmessage.Question{{
+			Name:  zone,
+			Type:  dnsmessage.TypeSOA,
+			Class: dnsmessage.ClassINET,
+		}},
+		Authorities: update,
+	}
+	resp, err := p.exchange(ctx, msg)
+	if err != nil {
+		return err
+	}
+	if resp.RCode != dnsmessage.RCodeSuccess {
+		return &dnsError{op: "update", rcode: resp.RCode}
+	}
+	return nil
+}
+
+// exchange sends msg to the server over TCP and returns the response.
+func (p *rfc2136Provider) exchange(ctx context.Context, msg *dnsmessage.Message) (*dnsmessage.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	b, err := msg.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("error packing message: %w", err)
+	}
+	if key := p.tsigKey(); key != nil {
+		if b, err = key.sign(b, time.Now()); err != nil {
+			return nil, fmt.Errorf("error signing message: %w", err)
+		}
+	}
+
+	dial := p.dial
+	if dial == nil {
+		dial = dialer(nil)
+	}
+	conn, err := dial(ctx, "tcp", p.server)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", p.server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(b)))); err != nil {
+		return nil, fmt.Errorf("error writing message: %w", err)
+	}
+	if _, err := conn.Write(b); err != nil {
+		return nil, fmt.Errorf("error writing message: %w", err)
+	}
+	var length uint16
+	if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+		return nil, fmt.Errorf("error reading response length: %w", err)
+	}
+	rb := make([]byte, length)
+	if _, err := io.ReadFull(conn, rb); err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	resp := new(dnsmessage.Message)
+	if err := resp.Unpack(rb); err != nil {
+		return nil, fmt.Errorf("error parsing response: %w", err)
+	}
+	if resp.ID != msg.ID {
+		return nil, fmt.Errorf("response ID %d does not match request ID %d", resp.ID, msg.ID)
+	}
+	return resp, nil
+}
+
+// rfc2136Applier collects the changes found by Reconcile into the prerequisite-free update section of one message,
+// so that the records are changed atomically.
+type rfc2136Applier struct {
+	name   dnsmessage.Name
+	ttl    uint32
+	logger *log.Logger
+	update []dnsmessage.Resource
+
+	added, removed []netip.Addr // for updating PTR records after the update succeeds
+}
+
+func (a *rfc2136Applier) Create(ctx context.Context, addr netip.Addr) error {
+	a.logger.Printf("creating record for %s...\n", addr)
+	a.update = append(a.update, addrResource(a.name, dnsmessage.ClassINET, a.ttl, addr))
+	a.added = append(a.added, addr)
+	return nil
+}
+
+func (a *rfc2136Applier) Delete(ctx context.Context, r Record) error {
+	a.logger.Printf("deleting DNS record for %s...\n", r.Addr)
+	a.update = append(a.update, addrResource(a.name, dnsClassNONE, 0, r.Addr))
+	a.removed = append(a.removed, r.Addr)
+	return nil
+}
+
+func addrResource(name dnsmessage.Name, class dnsmessage.Class, ttl uint32, a netip.Addr) dnsmessage.Resource {
+	a = a.Unmap()
+	t := dnsmessage.TypeAAAA
+	if a.Is4() {
+		t = dnsmessage.TypeA
+	}
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: class, TTL: ttl},
+		Body:   &dnsmessage.UnknownResource{Type: t, Data: a.AsSlice()},
+	}
+}
+
+func ptrResource(name dnsmessage.Name, class dnsmessage.Class, ttl uint32, target dnsmessage.Name) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: class, TTL: ttl},
+		Body:   &dnsmessage.UnknownResource{Type: dnsmessage.TypePTR, Data: wireName(target.String())},
+	}
+}
+
+// rrsetResource is the update section entry which deletes every record of type t at name.
+func rrsetResource(name dnsmessage.Name, t dnsmessage.Type) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: name, Class: dnsmessage.ClassANY},
+		Body:   &dnsmessage.UnknownResource{Type: t},
+	}
+}
+
+// reverseName returns the in-addr.arpa or ip6.arpa name for addr.
+func reverseName(addr netip.Addr) string {
+	addr = addr.Unmap()
+	b := addr.AsSlice()
+	var sb strings.Builder
+	if addr.Is4() {
+		for i := len(b) - 1; i >= 0; i-- {
+			fmt.Fprintf(&sb, "%d.", b[i])
+		}
+		sb.WriteString("in-addr.arpa.")
+		return sb.String()
+	}
+	const hex = "0123456789abcdef"
+	for i := len(b) - 1; i >= 0; i-- {
+		sb.WriteByte(hex[b[i]&0x0f])
+		sb.WriteByte('.')
+		sb.WriteByte(hex[b[i]>>4])
+		sb.WriteByte('.')
+	}
+	sb.WriteString("ip6.arpa.")
+	return sb.String()
+}
+
+// fqdn returns name with a trailing dot.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// wireName returns the uncompressed wire format of the fully qualified name,
+// lowercased so that it is also the canonical form used for TSIG.
+func wireName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(strings.ToLower(name), "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+type tsigKey struct {
+	name      string
+	algorithm string
+	secret    []byte
+	hash      func() hash.Hash
+}
+
+// sign appends a TSIG record (RFC 8945) to the packed message msg.
+func (k *tsigKey) sign(msg []byte, now time.Time) ([]byte, error) {
+	if len(msg) < 12 {
+		return nil, errors.New("message too short")
+	}
+	const fudge = 300
+	name := wireName(k.name)
+	alg := wireName(k.algorithm)
+	signed := uint64(now.Unix())
+	timeSigned := []byte{byte(signed >> 40), byte(signed >> 32), byte(signed >> 24), byte(signed >> 16), byte(signed >> 8), byte(signed)}
+
+	mac := hmac.New(k.hash, k.secret)
+	mac.Write(msg)
+	mac.Write(name)
+	mac.Write(binary.BigEndian.AppendUint16(nil, uint16(dnsmessage.ClassANY)))
+	mac.Write(binary.BigEndian.AppendUint32(nil, 0)) // TTL
+	mac.Write(alg)
+	mac.Write(timeSigned)
+	mac.Write(binary.BigEndian.AppendUint16(nil, fudge))
+	mac.Write(binary.BigEndian.AppendUint16(nil, 0)) // error
+	mac.Write(binary.BigEndian.AppendUint16(nil, 0)) // other len
+	sum := mac.Sum(nil)
+
+	var rdata []byte
+	rdata = append(rdata, alg...)
+	rdata = append(rdata, timeSigned...)
+	rdata = binary.BigEndian.AppendUint16(rdata, fudge)
+	rdata = binary.BigEndian.AppendUint16(rdata, uint16(len(sum)))
+	rdata = append(rdata, sum...)
+	rdata = append(rdata, msg[0], msg[1]) // original ID
+	rdata = binary.BigEndian.AppendUint16(rdata, 0)
+	rdata = binary.BigEndian.AppendUint16(rdata, 0)
+
+	out := append([]byte{}, msg...)
+	out = append(out, name...)
+	out = binary.BigEndian.AppendUint16(out, uint16(dnsTypeTSIG))
+	out = binary.BigEndian.AppendUint16(out, uint16(dnsmessage.ClassANY))
+	out = binary.BigEndian.AppendUint32(out, 0)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(rdata)))
+	out = append(out, rdata...)
+
+	// increment ARCOUNT
+	binary.BigEndian.PutUint16(out[10:12], binary.BigEndian.Uint16(out[10:12])+1)
+	return out, nil
+}
+
+type dnsError struct {
+	op    string
+	rcode dnsmessage.RCode
+}
+
+func (e *dnsError) Error() string {
+	return fmt.Sprintf("%s failed: server responded %s", e.op, e.rcode)
+}
+
+// IsAuthenticationError reports whether the server rejected the TSIG signature.
+func (e *dnsError) IsAuthenticationError() bool { return e.rcode == dnsRCodeNotAuth }
+
+// IsAuthorizationError reports whether the server refused the update.
+func (e *dnsError) IsAuthorizationError() bool { return e.rcode == dnsmessage.RCodeRefused }
+
+// IsTemporary reports whether the server failed to process the request.
+func (e *dnsError) IsTemporary() bool { return e.rcode == dnsmessage.RCodeServerFailure }
@@ -0,0 +1,126 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// tsigSecret is a base64 encoded key for testing TSIG.
+const tsigSecret = "c2VjcmV0IGtleSBmb3IgdGVzdGluZyBkZG5zIHVwZGF0ZXM="
+
+func TestRFC2136Conformance(t *testing.T) {
+	srv := ddnstest.NewDNSServer("example.com")
+	t.Cleanup(srv.Close)
+	ddnstest.TestProvider(t, ddns.NewRFC2136(srv.Addr))
+}
+
+func TestRFC2136(t *testing.T) {
+	ctx := context.Background()
+	srv := ddnstest.NewDNSServer("example.com", "in-addr.arpa")
+	defer srv.Close()
+	srv.SetAddrs("home.example.com", netip.MustParseAddr("198.51.100.7"))
+
+	c, err := ddns.New("home.example.com", ddns.NewRFC2136(srv.Addr, ddns.UpdatePTR()),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if got := srv.Addrs("home.example.com"); len(got) != 1 || got[0] != netip.MustParseAddr("203.0.113.5") {
+		t.Errorf("Expected [203.0.113.5]; got %v", got)
+	}
+	if got := srv.PTR("5.113.0.203.in-addr.arpa"); len(got) != 1 || got[0] != "home.example.com" {
+		t.Errorf("Expected %q; got %q", []string{"home.example.com"}, got)
+	}
+
+	// the update is visible to lookups, as when checking that it has propagated
+	addrs, err := ddns.LookupIP(ctx, "home.example.com", ddns.DNSServer(srv.Addr))
+	if err != nil {
+		t.Fatalf("LookupIP failed: %s", err)
+	}
+	if len(addrs) != 1 || addrs[0] != netip.MustParseAddr("203.0.113.5") {
+		t.Errorf("Expected [203.0.113.5]; got %v", addrs)
+	}
+}
+
+func TestRFC2136TSIG(t *testing.T) {
+	ctx := context.Background()
+	srv := ddnstest.NewDNSServer("example.com")
+	defer srv.Close()
+	srv.RequireTSIG("ddns-key", "hmac-sha256", tsigSecret)
+	addrs := []netip.Addr{netip.MustParseAddr("203.0.113.5")}
+
+	p, err := ddns.NewRFC2136(srv.Addr, ddns.TSIG("ddns-key", "hmac-sha256", tsigSecret))()
+	if err != nil {
+		t.Fatalf("NewRFC2136 returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(ctx, "home.example.com", addrs); err != nil {
+		t.Fatalf("SetDNSRecords with the correct key failed: %s", err)
+	}
+	if srv.Updates() != 1 {
+		t.Errorf("Expected 1 update; got %d", srv.Updates())
+	}
+
+	tests := []struct {
+		name     string
+		provider func() (ddns.Provider, error)
+	}{
+		{"unsigned", ddns.NewRFC2136(srv.Addr)},
+		{"wrong secret", ddns.NewRFC2136(srv.Addr, ddns.TSIG("ddns-key", "hmac-sha256", "d3Jvbmcgc2VjcmV0"))},
+		{"wrong key name", ddns.NewRFC2136(srv.Addr, ddns.TSIG("other-key", "hmac-sha256", tsigSecret))},
+		{"wrong algorithm", ddns.NewRFC2136(srv.Addr, ddns.TSIG("ddns-key", "hmac-sha512", tsigSecret))},
+	}
+	for _, tt := range tests {
+		p, err := tt.provider()
+		if err != nil {
+			t.Fatalf("%s: NewRFC2136 returned an error: %s", tt.name, err)
+		}
+		err = p.SetDNSRecords(ctx, "other.example.com", addrs)
+		if !ddns.IsAuthError(err) {
+			t.Errorf("%s: Expected an auth error; got %v", tt.name, err)
+		}
+	}
+	if srv.Updates() != 1 {
+		t.Errorf("Expected the rejected updates not to be applied; got %d updates", srv.Updates())
+	}
+}
+
+func TestRFC2136ServerFailure(t *testing.T) {
+	ctx := context.Background()
+	srv := ddnstest.NewDNSServer("example.com")
+	defer srv.Close()
+	p, err := ddns.NewRFC2136(srv.Addr)()
+	if err != nil {
+		t.Fatalf("NewRFC2136 returned an error: %s", err)
+	}
+	addrs := []netip.Addr{netip.MustParseAddr("203.0.113.5")}
+
+	srv.FailUpdates(dnsmessage.RCodeServerFailure)
+	if err := p.SetDNSRecords(ctx, "home.example.com", addrs); !ddns.IsTemporary(err) {
+		t.Errorf("Expected a temporary error for a failed update; got %v", err)
+	}
+	srv.FailUpdates(dnsmessage.RCodeSuccess)
+	srv.Fail(dnsmessage.RCodeServerFailure)
+	if err := p.SetDNSRecords(ctx, "home.example.com", addrs); !ddns.IsTemporary(err) {
+		t.Errorf("Expected a temporary error for a failed query; got %v", err)
+	}
+	if got := srv.Addrs("home.example.com"); len(got) != 0 {
+		t.Errorf("Expected no records; got %v", got)
+	}
+	srv.Fail(dnsmessage.RCodeSuccess)
+	if err := p.SetDNSRecords(ctx, "home.example.com", addrs); err != nil {
+		t.Errorf("SetDNSRecords failed after the server recovered: %s", err)
+	}
+
+	if err := p.SetDNSRecords(ctx, "home.example.net", addrs); err == nil {
+		t.Errorf("Expected an error for a domain outside the served zones")
+	}
+}
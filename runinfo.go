@@ -0,0 +1,48 @@
+package ddns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// RunInfo identifies an update and why it was started.
+// It is included in events, journal entries, and record change log messages,
+// so that triggered updates can be told apart from scheduled ones.
+type RunInfo struct {
+	ID      string `json:"id"`                // generated by the client if empty
+	Trigger string `json:"trigger,omitempty"` // e.g. [TriggerScheduled], [TriggerManual], or a reason of the caller's choosing
+}
+
+// Triggers set by [RunDaemon].
+const (
+	TriggerScheduled = "scheduled" // the interval elapsed, or the daemon started
+	TriggerManual    = "manual"    // the update was requested with [Agent.Trigger]
+)
+
+type runInfoKey struct{}
+
+// WithRunInfo returns a copy of ctx carrying info, for passing to RunDDNS:
+//
+//	err := client.RunDDNS(ddns.WithRunInfo(ctx, ddns.RunInfo{Trigger: "netlink"}))
+func WithRunInfo(ctx context.Context, info RunInfo) context.Context {
+	return context.WithValue(ctx, runInfoKey{}, info)
+}
+
+// RunInfoFrom returns the RunInfo carried by ctx, or the zero RunInfo if there is none.
+func RunInfoFrom(ctx context.Context) RunInfo {
+	info, _ := ctx.Value(runInfoKey{}).(RunInfo)
+	return info
+}
+
+// withRunID returns ctx with a RunInfo which has an ID, generating one if needed.
+func withRunID(ctx context.Context) (context.Context, RunInfo) {
+	info := RunInfoFrom(ctx)
+	if info.ID != "" {
+		return ctx, info
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	info.ID = hex.EncodeToString(b)
+	return WithRunInfo(ctx, info), info
+}
@@ -0,0 +1,329 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"text/template"
+)
+
+// SimpleAPI declaratively describes a simple HTTP update API,
+// such as the dyndns2 protocol used by many hosting providers.
+//
+// The URL, Body, and Headers values are [text/template] strings executed with [SimpleRequest].
+// Presets are provided for [DynDNS2], [StratoAPI], [IONOSAPI], [HurricaneElectricAPI], [TunnelBrokerAPI], [ClouDNSAPI], [FreeDNSAPI], [NjallaAPI], and [DeSECAPI];
+// they may be copied and modified for other providers.
+// APIs which need a login session, such as netcup's, can't be described by SimpleAPI; see [NewNetcup].
+type SimpleAPI struct {
+	Method      string            // defaults to GET
+	URL         string            // update URL template
+	Body        string            // optional request body template
+	ContentType string            // content type of Body
+	Headers     map[string]string // additional header templates; a User-Agent header replaces the default
+	Auth        string            // "basic" sends Username and Password, "bearer" sends Token; otherwise credentials must be in the templates
+
+	// Success lists substrings, one of which must be in the response body for an update to succeed.
+	// If empty, any 2xx response is a success.
+	Success []string
+	// AuthFailure lists substrings in the response body which mean the credentials were rejected, e.g. "badauth".
+	AuthFailure []string
+	// PerFamily sends one request for the IPv4 address and another for the IPv6 address,
+	// for APIs which only accept one address per request.
+	PerFamily bool
+}
+
+// SimpleAccount holds the credentials for a [SimpleAPI].
+// Which fields are needed depends on the API.
+type SimpleAccount struct {
+	Username string
+	Password string
+	Token    string
+}
+
+// SimpleRequest is the data available to [SimpleAPI] templates.
+type SimpleRequest struct {
+	Domain string
+	IP     string // the address for this request: IPv4 if present, otherwise IPv6; or the request's family when PerFamily is set
+	IPv4   string // the first IPv4 address, if any
+	IPv6   string // the first IPv6 address, if any
+	IPs    string // IPv4 and IPv6 separated by a comma, omitting an absent family
+	SimpleAccount
+}
+
+// DynDNS2 is the dyndns2 protocol, with the update URL of the provider left to be filled in, e.g.:
+//
+//	api := ddns.DynDNS2
+//	api.URL = "https://members.example.com/nic/update?hostname={{.Domain}}&myip={{.IP}}"
+var DynDNS2 = SimpleAPI{
+	Auth:        "basic",
+	Success:     []string{"good", "nochg"},
+	AuthFailure: []string{"badauth", "!donator", "abuse"},
+	PerFamily:   true,
+}
+
+// StratoAPI is the Strato DynDNS API.
+// Username is the domain and Password is the DynDNS password set for it.
+var StratoAPI = withURL(DynDNS2, "https://dyndns.strato.com/nic/update?hostname={{.Domain | urlquery}}&myip={{.IPs}}", false)
+
+// IONOSAPI is the IONOS Dynamic DNS API.
+// Token is the "q" parameter of the update URL returned when creating the Dynamic DNS configuration,
+// which determines the domains to update.
+// IONOS uses the source address of the request, so the client must resolve the same address it connects from,
+// e.g. with [WebResolver].
+var IONOSAPI = SimpleAPI{
+	URL: "https://ipv4.api.hosting.ionos.com/dns/v1/dyndns?q={{.Token | urlquery}}",
+}
+
+// HurricaneElectricAPI is the dynamic DNS API of Hurricane Electric's free DNS service (dns.he.net).
+// Username is the domain and Password is the key generated for the record.
+var HurricaneElectricAPI = withURL(DynDNS2, "https://dyn.dns.he.net/nic/update?hostname={{.Domain | urlquery}}&myip={{.IP}}", true)
+
+// TunnelBrokerAPI updates the IPv4 endpoint of a Hurricane Electric tunnelbroker.net IPv6 tunnel.
+// Username is the tunnelbroker account name, Password is the tunnel's update key, and Token is the tunnel ID.
+// The domain is ignored.
+//
+// Combine it with the DNS update so that both are maintained by the same client:
+//
+//	ddns.MultiProvider(
+//		ddns.NewSimpleAPI(ddns.HurricaneElectricAPI, ddns.SimpleAccount{Username: domain, Password: dnsKey}),
+//		ddns.Filter(ddns.NewSimpleAPI(ddns.TunnelBrokerAPI, ddns.SimpleAccount{Username: user, Password: updateKey, Token: tunnelID}), ddns.IPv4Addr),
+//	)
+var TunnelBrokerAPI = withURL(DynDNS2, "https://ipv4.tunnelbroker.net/nic/update?hostname={{.Token | urlquery}}&myip={{.IPv4}}", false)
+
+// ClouDNSAPI is the ClouDNS dynamic URL API.
+// Token is the "q" parameter of the record's dynamic URL.
+// ClouDNS uses the source address of the request,
+// which is sent over IPv4 or IPv6 to match each address family.
+var ClouDNSAPI = SimpleAPI{
+	URL:       "https://{{if eq .IP .IPv6}}ipv6{{else}}ipv4{{end}}.cloudns.net/api/dynamicURL/?q={{.Token | urlquery}}",
+	Success:   []string{"OK"},
+	PerFamily: true,
+}
+
+// FreeDNSAPI is the afraid.org FreeDNS version 2 dynamic update API.
+// Token is the random token of the record's update URL.
+var FreeDNSAPI = SimpleAPI{
+	URL:         "https://{{if eq .IP .IPv6}}v6.{{end}}sync.afraid.org/u/{{.Token | urlquery}}/?ip={{.IP}}",
+	Success:     []string{"Updated", "No IP change"},
+	AuthFailure: []string{"Invalid update URL"},
+	PerFamily:   true,
+}
+
+// NjallaAPI is the Njalla dynamic DNS API.
+// Token is the key of a "Dynamic" record created in the Njalla web interface for the domain.
+// Both address families are set in one request.
+var NjallaAPI = SimpleAPI{
+	URL:     "https://njal.la/update/?h={{.Domain | urlquery}}&k={{.Token | urlquery}}{{with .IPv4}}&a={{.}}{{end}}{{with .IPv6}}&aaaa={{.}}{{end}}",
+	Success: []string{`"status": 200`, `"status":200`},
+}
+
+// DeSECAPI is the deSEC (desec.io) dynamic DNS API.
+// Token is a deSEC API token; no username is needed.
+// An address family missing from the update removes the records of that family.
+var DeSECAPI = SimpleAPI{
+	URL:         "https://update.dedyn.io/?hostname={{.Domain | urlquery}}&myipv4={{.IPv4}}&myipv6={{.IPv6}}",
+	Headers:     map[string]string{"Authorization": "Token {{.Token}}"},
+	Success:     []string{"good", "nochg"},
+	AuthFailure: []string{"badauth"},
+}
+
+func withURL(api SimpleAPI, url string, perFamily bool) SimpleAPI {
+	api.URL = url
+	api.PerFamily = perFamily
+	return api
+}
+
+// NewSimpleAPI is used by [ddns.New] to create a Provider for an HTTP update API described by api.
+//
+// Simple update APIs can't delete records,
+// so updates with no addresses (e.g. when [GateOn] withdraws records) return an error.
+func NewSimpleAPI(api SimpleAPI, account SimpleAccount) func() (Provider, error) {
+	return func() (Provider, error) {
+		return newSimpleProvider(api, account)
+	}
+}
+
+func newSimpleProvider(api SimpleAPI, account SimpleAccount) (*simpleProvider, error) {
+	p := &simpleProvider{api: api, account: account, logger: discard}
+	if p.api.Method == "" {
+		p.api.Method = http.MethodGet
+	}
+	if api.URL == "" {
+		return nil, errors.New("url cannot be empty")
+	}
+	var err error
+	if p.url, err = parseSimpleTemplate("url", api.URL); err != nil {
+		return nil, err
+	}
+	if p.body, err = parseSimpleTemplate("body", api.Body); err != nil {
+		return nil, err
+	}
+	p.headers = map[string]*template.Template{}
+	for k, v := range api.Headers {
+		if p.headers[k], err = parseSimpleTemplate(k, v); err != nil {
+			return nil, err
+		}
+	}
+	switch api.Auth {
+	case "", "basic", "bearer":
+	default:
+		return nil, fmt.Errorf("unknown auth style \"%s\"", api.Auth)
+	}
+	return p, nil
+}
+
+func parseSimpleTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+type simpleProvider struct {
+	httpClient *http.Client
+	logger     *log.Logger
+	api        SimpleAPI
+	account    SimpleAccount
+	url        *template.Template
+	body       *template.Template
+	headers    map[string]*template.Template
+}
+
+func (p *simpleProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *simpleProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *simpleProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true}
+}
+
+func (p *simpleProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	if len(records) == 0 {
+		return errors.New("this provider cannot remove records")
+	}
+	data := SimpleRequest{Domain: canonicalName(domain), SimpleAccount: p.account}
+	for _, a := range records {
+		if a.Unmap().Is4() && data.IPv4 == "" {
+			data.IPv4 = a.Unmap().String()
+		}
+		if !a.Unmap().Is4() && data.IPv6 == "" {
+			data.IPv6 = a.String()
+		}
+	}
+	var ips []string
+	for _, ip := range []string{data.IPv4, data.IPv6} {
+		if ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+	data.IPs = strings.Join(ips, ",")
+	if !p.api.PerFamily {
+		data.IP = ips[0]
+		return p.send(ctx, data)
+	}
+	var errs []error
+	for _, ip := range ips {
+		data.IP = ip
+		errs = append(errs, p.send(ctx, data))
+	}
+	return errors.Join(errs...)
+}
+
+func (p *simpleProvider) send(ctx context.Context, data SimpleRequest) error {
+	execute := func(t *template.Template) (string, error) {
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return "", fmt.Errorf("error executing %s template: %w", t.Name(), err)
+		}
+		return b.String(), nil
+	}
+	u, err := execute(p.url)
+	if err != nil {
+		return err
+	}
+	body, err := execute(p.body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, p.api.Method, u, bytes.NewBufferString(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	setUserAgent(req)
+	if p.api.ContentType != "" {
+		req.Header.Set("Content-Type", p.api.ContentType)
+	}
+	for k, t := range p.headers {
+		v, err := execute(t)
+		if err != nil {
+			return err
+		}
+		req.Header.Set(k, v)
+	}
+	switch p.api.Auth {
+	case "basic":
+		req.SetBasicAuth(p.account.Username, p.account.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+p.account.Token)
+	}
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	p.logger.Printf("updating %s to %s\n", data.Domain, data.IP)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// the error includes the URL, which may contain credentials
+		return fmt.Errorf("request for %s failed: %w", data.Domain, errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	response := strings.TrimSpace(string(b))
+	return p.check(resp, response)
+}
+
+// check returns an error if the response does not indicate success.
+func (p *simpleProvider) check(resp *http.Response, body string) error {
+	for _, s := range p.api.AuthFailure {
+		if strings.Contains(body, s) {
+			return &simpleAPIError{msg: fmt.Sprintf("credentials rejected: %s", body), auth: true}
+		}
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &simpleAPIError{msg: fmt.Sprintf("credentials rejected: %s", resp.Status), auth: true}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &simpleAPIError{msg: fmt.Sprintf("unexpected response status %s: %s", resp.Status, body), status: resp.StatusCode}
+	}
+	if len(p.api.Success) == 0 {
+		return nil
+	}
+	for _, s := range p.api.Success {
+		if strings.Contains(body, s) {
+			return nil
+		}
+	}
+	return &simpleAPIError{msg: fmt.Sprintf("update failed: %s", body)}
+}
+
+type simpleAPIError struct {
+	msg    string
+	auth   bool
+	status int // the response status, if it was an error
+}
+
+func (e *simpleAPIError) Error() string               { return e.msg }
+func (e *simpleAPIError) IsAuthenticationError() bool { return e.auth }
+func (e *simpleAPIError) IsRateLimited() bool         { return e.status == http.StatusTooManyRequests }
+func (e *simpleAPIError) IsTemporary() bool           { return e.status >= 500 }
@@ -0,0 +1,159 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestSimpleAPIDynDNS2(t *testing.T) {
+	var updates []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "www.example.com" || pass != "secret" {
+			fmt.Fprint(w, "badauth")
+			return
+		}
+		updates = append(updates, r.URL.Query().Get("myip"))
+		fmt.Fprintf(w, "good %s", r.URL.Query().Get("myip"))
+	}))
+	defer srv.Close()
+
+	api := ddns.DynDNS2
+	api.URL = srv.URL + "/nic/update?hostname={{.Domain}}&myip={{.IP}}"
+	addrs := []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}
+	p, err := ddns.NewSimpleAPI(api, ddns.SimpleAccount{Username: "www.example.com", Password: "secret"})()
+	if err != nil {
+		t.Fatalf("NewSimpleAPI returned an error: %s", err)
+	}
+	if err := p.SetDNSRecords(context.Background(), "www.example.com", addrs); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	if len(updates) != 2 || updates[0] != "192.0.2.1" || updates[1] != "2001:db8::1" {
+		t.Fatalf("Expected one update per address family; got %q", updates)
+	}
+
+	p, _ = ddns.NewSimpleAPI(api, ddns.SimpleAccount{Username: "www.example.com", Password: "wrong"})()
+	err = p.SetDNSRecords(context.Background(), "www.example.com", addrs)
+	var authErr interface{ IsAuthenticationError() bool }
+	if !errors.As(err, &authErr) || !authErr.IsAuthenticationError() {
+		t.Fatalf("Expected an authentication error; got %v", err)
+	}
+}
+
+func TestSimpleAPIPresets(t *testing.T) {
+	var got []string
+	var auth, response string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Host+r.URL.RequestURI())
+		auth = r.Header.Get("Authorization")
+		if response != "" {
+			fmt.Fprint(w, response)
+			return
+		}
+		fmt.Fprint(w, `good OK Updated {"status": 200}`)
+	}))
+	defer srv.Close()
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r = r.Clone(r.Context())
+		// keep the original host in the Host header so that the test can check it
+		r.Host = r.URL.Host
+		r.URL.Scheme, r.URL.Host = "http", srv.Listener.Addr().String()
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	addrs := []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("www.example.com:secret"))
+	tests := []struct {
+		name     string
+		api      ddns.SimpleAPI
+		want     []string
+		auth     string // the expected Authorization header, if checked
+		response string // the response body, if not the default which every API accepts
+	}{
+		{"strato", ddns.StratoAPI, []string{"dyndns.strato.com/nic/update?hostname=www.example.com&myip=192.0.2.1,2001:db8::1"}, basic, ""},
+		{"he", ddns.HurricaneElectricAPI, []string{"dyn.dns.he.net/nic/update?hostname=www.example.com&myip=192.0.2.1", "dyn.dns.he.net/nic/update?hostname=www.example.com&myip=2001:db8::1"}, basic, "nochg 192.0.2.1"},
+		{"ionos", ddns.IONOSAPI, []string{"ipv4.api.hosting.ionos.com/dns/v1/dyndns?q=token"}, "", ""},
+		{"tunnelbroker", ddns.TunnelBrokerAPI, []string{"ipv4.tunnelbroker.net/nic/update?hostname=token&myip=192.0.2.1"}, "", ""},
+		{"cloudns", ddns.ClouDNSAPI, []string{"ipv4.cloudns.net/api/dynamicURL/?q=token", "ipv6.cloudns.net/api/dynamicURL/?q=token"}, "", ""},
+		{"freedns", ddns.FreeDNSAPI, []string{"sync.afraid.org/u/token/?ip=192.0.2.1", "v6.sync.afraid.org/u/token/?ip=2001:db8::1"}, "", ""},
+		{"njalla", ddns.NjallaAPI, []string{"njal.la/update/?h=www.example.com&k=token&a=192.0.2.1&aaaa=2001:db8::1"}, "", ""},
+		{"desec", ddns.DeSECAPI, []string{"update.dedyn.io/?hostname=www.example.com&myipv4=192.0.2.1&myipv6=2001:db8::1"}, "", ""},
+	}
+	for _, tt := range tests {
+		got, response = nil, tt.response
+		c, err := ddns.New("www.example.com", ddns.NewSimpleAPI(tt.api, ddns.SimpleAccount{Username: "www.example.com", Password: "secret", Token: "token"}),
+			ddns.UsingResolver(ddns.StaticIP(addrs...)),
+			ddns.UsingHTTPClient(&http.Client{Transport: transport}),
+		)
+		if err != nil {
+			t.Fatalf("%s: New returned an error: %s", tt.name, err)
+		}
+		if err := c.RunDDNS(context.Background()); err != nil {
+			t.Fatalf("%s: RunDDNS failed: %s", tt.name, err)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: Expected requests %q; got %q", tt.name, tt.want, got)
+		}
+		if tt.auth != "" && auth != tt.auth {
+			t.Errorf("%s: Expected Authorization %q; got %q", tt.name, tt.auth, auth)
+		}
+	}
+
+	response = "badauth"
+	p, err := ddns.NewSimpleAPI(ddns.HurricaneElectricAPI, ddns.SimpleAccount{Username: "www.example.com", Password: "wrong"})()
+	if err != nil {
+		t.Fatalf("NewSimpleAPI returned an error: %s", err)
+	}
+	p.(interface{ SetHTTPClient(*http.Client) }).SetHTTPClient(&http.Client{Transport: transport})
+	if err := p.SetDNSRecords(context.Background(), "www.example.com", addrs); !ddns.IsAuthError(err) {
+		t.Errorf("he: Expected an auth error for a badauth response; got %v", err)
+	}
+}
+
+func TestURLProvider(t *testing.T) {
+	type request struct{ method, uri, header, body string }
+	var got []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		got = append(got, request{r.Method, r.URL.RequestURI(), r.Header.Get("X-Host"), string(b)})
+		if r.URL.Query().Get("ip") == "192.0.2.2" {
+			fmt.Fprint(w, "error")
+			return
+		}
+		fmt.Fprint(w, "success")
+	}))
+	defer srv.Close()
+
+	p, err := ddns.NewURLProvider(srv.URL+"/update?ip={{.IP}}",
+		ddns.URLMethod(http.MethodPost, "text/plain", "{{.IPv4}} {{.IPv6}}"),
+		ddns.URLHeader("X-Host", "{{.Domain}}"),
+		ddns.ExpectResponse("success"),
+	)()
+	if err != nil {
+		t.Fatalf("NewURLProvider returned an error: %s", err)
+	}
+	addrs := []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("2001:db8::1")}
+	if err := p.SetDNSRecords(context.Background(), "www.example.com.", addrs); err != nil {
+		t.Fatalf("SetDNSRecords returned an error: %s", err)
+	}
+	want := request{http.MethodPost, "/update?ip=192.0.2.1", "www.example.com", "192.0.2.1 2001:db8::1"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("Expected request %+v; got %+v", want, got)
+	}
+	err = p.SetDNSRecords(context.Background(), "www.example.com", []netip.Addr{netip.MustParseAddr("192.0.2.2")})
+	if err == nil {
+		t.Errorf("Expected an error for a response without the expected substring")
+	}
+
+	if _, err := ddns.NewURLProvider("https://example.com/{{.Nope")(); err == nil {
+		t.Errorf("Expected an error for an invalid template")
+	}
+}
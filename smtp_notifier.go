@@ -0,0 +1,169 @@
+package ddns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTP constructs a Notifier which emails each notification from from to the to addresses
+// through the mail server at addr, e.g. "smtp.example.com:587".
+//
+// Port 465 uses implicit TLS. On other ports the connection is upgraded with STARTTLS if the server offers it,
+// and is required to be if [SMTPAuth] is given, unless the server is on localhost.
+func SMTP(addr, from string, to []string, options ...smtpOption) Notifier {
+	n := &smtpNotifier{addr: addr, from: from, to: to}
+	n.err = n.configure(options)
+	return n
+}
+
+type smtpOption func(*smtpNotifier) error
+
+// SMTPAuth authenticates to the mail server with the PLAIN mechanism.
+func SMTPAuth(username, password string) smtpOption {
+	return func(n *smtpNotifier) error {
+		n.username, n.password = username, password
+		return nil
+	}
+}
+
+// SMTPTLSConfig sets the TLS configuration for connecting to the mail server,
+// e.g. to trust a private CA.
+func SMTPTLSConfig(config *tls.Config) smtpOption {
+	return func(n *smtpNotifier) error {
+		n.tlsConfig = config
+		return nil
+	}
+}
+
+type smtpNotifier struct {
+	addr      string
+	host      string
+	from      string
+	to        []string
+	username  string
+	password  string
+	tlsConfig *tls.Config
+	err       error // configuration error, returned by Notify
+}
+
+func (n *smtpNotifier) configure(options []smtpOption) error {
+	for _, opt := range options {
+		if err := opt(n); err != nil {
+			return err
+		}
+	}
+	host, _, err := net.SplitHostPort(n.addr)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP server address \"%s\": %w", n.addr, err)
+	}
+	n.host = host
+	if _, err := mail.ParseAddress(n.from); err != nil {
+		return fmt.Errorf("invalid sender address \"%s\": %w", n.from, err)
+	}
+	if len(n.to) == 0 {
+		return errors.New("no recipients given")
+	}
+	for _, to := range n.to {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("invalid recipient address \"%s\": %w", to, err)
+		}
+	}
+	return nil
+}
+
+func (n *smtpNotifier) validate() error { return n.err }
+
+func (n *smtpNotifier) Notify(ctx context.Context, notification Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("unable to connect to SMTP server: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+	tlsConfig := &tls.Config{ServerName: n.host}
+	if n.tlsConfig != nil {
+		tlsConfig = n.tlsConfig.Clone()
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = n.host
+		}
+	}
+	implicitTLS := strings.HasSuffix(n.addr, ":465")
+	if implicitTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok && !implicitTLS {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if n.username != "" {
+		// PlainAuth refuses to send the password without TLS, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := c.Mail(n.from); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, to := range n.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+	if _, err := w.Write(n.message(notification)); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected message: %w", err)
+	}
+	return c.Quit()
+}
+
+// message formats notification as a plain text email.
+func (n *smtpNotifier) message(notification Notification) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", notification.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "Domain: %s\r\n", notification.Domain)
+	switch {
+	case notification.Changed:
+		fmt.Fprintf(&b, "Old records: %s\r\n", joinAddrs(notification.Old))
+		fmt.Fprintf(&b, "New records: %s\r\n", joinAddrs(notification.New))
+	case notification.Recovered:
+		fmt.Fprintf(&b, "Updates succeeded again after %d consecutive failures.\r\n", notification.Failures)
+	default:
+		fmt.Fprintf(&b, "Consecutive failures: %d\r\n", notification.Failures)
+		fmt.Fprintf(&b, "Error: %s\r\n", notification.Error)
+	}
+	fmt.Fprintf(&b, "Time: %s\r\n", notification.Time.Format(time.RFC3339))
+	return b.Bytes()
+}
@@ -0,0 +1,141 @@
+package ddns_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+// fakeSMTP accepts one message on a local listener, returning the commands it received and the message data.
+func fakeSMTP(t *testing.T) (addr string, result <-chan []string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var lines []string
+		r := bufio.NewReader(conn)
+		reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+		reply("220 localhost ESMTP")
+		data := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				ch <- lines
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case data && line == ".":
+				data = false
+				reply("250 queued")
+			case data:
+			case cmd == "EHLO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case cmd == "AUTH":
+				reply("235 accepted")
+			case cmd == "DATA":
+				data = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				ch <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTP(t *testing.T) {
+	addr, result := fakeSMTP(t)
+	n := ddns.SMTP(addr, "ddns@example.com", []string{"admin@example.com"}, ddns.SMTPAuth("ddns", "secret"))
+	err := n.Notify(context.Background(), ddns.Notification{
+		Time:    time.Now(),
+		Domain:  "home.example.com",
+		Changed: true,
+		Old:     []netip.Addr{netip.MustParseAddr("192.0.2.1")},
+		New:     []netip.Addr{netip.MustParseAddr("192.0.2.2")},
+	})
+	if err != nil {
+		t.Fatalf("Notify returned an error: %s", err)
+	}
+	lines := <-result
+	got := strings.Join(lines, "\n")
+	auth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00ddns\x00secret"))
+	for _, want := range []string{auth, "MAIL FROM:<ddns@example.com>", "RCPT TO:<admin@example.com>", "Subject: ddns: home.example.com changed to 192.0.2.2", "Old records: 192.0.2.1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the SMTP session; got:\n%s", want, got)
+		}
+	}
+
+	if _, err := ddns.New("home.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.WithNotifier(ddns.MultiNotifier(
+			ddns.Webhook("https://hooks.example.com/ddns"),
+			ddns.SMTP("smtp.example.com", "ddns@example.com", []string{"admin@example.com"}),
+		)),
+	); err == nil {
+		t.Errorf("Expected an error for an SMTP server address without a port")
+	}
+}
+
+func TestNotifyOnChange(t *testing.T) {
+	ctx := context.Background()
+	ip := netip.MustParseAddr("192.0.2.1")
+	r := ddns.ResolverFunc(func(context.Context) ([]netip.Addr, error) { return []netip.Addr{ip}, nil })
+	var sent []ddns.Notification
+	c, err := ddns.New("www.example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}),
+		ddns.UsingResolver(r),
+		ddns.WithNotifier(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+			sent = append(sent, n)
+			return nil
+		})),
+		ddns.NotifyOnChange(),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	c.RunDDNS(ctx)
+	c.RunDDNS(ctx)
+	ip = netip.MustParseAddr("192.0.2.2")
+	c.RunDDNS(ctx)
+	if len(sent) != 2 {
+		t.Fatalf("Expected notifications for the first record and the change; got %+v", sent)
+	}
+	if n := sent[1]; !n.Changed || len(n.Old) != 1 || n.Old[0] != netip.MustParseAddr("192.0.2.1") || len(n.New) != 1 || n.New[0] != ip {
+		t.Errorf("Unexpected change notification: %+v", n)
+	}
+}
+
+func TestAlertsOnly(t *testing.T) {
+	var sent []ddns.Notification
+	n := ddns.AlertsOnly(ddns.NotifierFunc(func(_ context.Context, n ddns.Notification) error {
+		sent = append(sent, n)
+		return nil
+	}))
+	n.Notify(context.Background(), ddns.Notification{Changed: true})
+	n.Notify(context.Background(), ddns.Notification{Failures: 1, Error: "update failed"})
+	if len(sent) != 1 || sent[0].Changed {
+		t.Errorf("Expected only the failure to be sent; got %+v", sent)
+	}
+}
@@ -0,0 +1,85 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SRV is the data of an SRV record, which points the clients of a service at a host and port,
+// e.g. for game servers and SIP.
+type SRV struct {
+	Priority uint16 // clients use the targets with the lowest priority first
+	Weight   uint16 // relative chance of choosing the target among those with the same priority
+	Port     uint16
+	Target   string // the host name of the server
+}
+
+func (s SRV) String() string {
+	return fmt.Sprintf("%d %d %d %s", s.Priority, s.Weight, s.Port, s.Target)
+}
+
+// SRVProvider is the interface for providers which can also manage SRV records.
+//
+// name is the fully qualified record name including the service and protocol labels, e.g. _minecraft._tcp.example.com.
+// The given records are the desired set for name.
+type SRVProvider interface {
+	GetSRVRecords(ctx context.Context, name string) ([]SRV, error)
+	SetSRVRecords(ctx context.Context, name string, records []SRV) error
+}
+
+var errSRVUnsupported = errors.New("provider does not support SRV records")
+
+// PublishSRV configures the client to publish SRV records for service under the domain,
+// e.g. "_minecraft._tcp" for _minecraft._tcp.example.com,
+// so that the clients of the service find the host at the domain on the port of each record.
+// A record with an empty Target points at the domain.
+//
+// The records are set after the first successful update, and again after each failed attempt until they are set.
+// The Provider must implement [SRVProvider].
+func PublishSRV(service string, records ...SRV) clientOption {
+	return func(c *client) error {
+		labels := strings.Split(service, ".")
+		if len(labels) != 2 || !strings.HasPrefix(labels[0], "_") || len(labels[0]) < 2 || !strings.HasPrefix(labels[1], "_") || len(labels[1]) < 2 {
+			return fmt.Errorf("invalid SRV service \"%s\"; expected a form such as _minecraft._tcp", service)
+		}
+		if len(records) == 0 {
+			return errors.New("at least one SRV record is required")
+		}
+		for _, r := range records {
+			if r.Port == 0 {
+				return fmt.Errorf("SRV record for %s has no port", service)
+			}
+		}
+		c.srvService = service
+		c.srv = append([]SRV(nil), records...)
+		return nil
+	}
+}
+
+// publishSRV sets the SRV records, if any, unless they have already been set.
+func (c *client) publishSRV(ctx context.Context) error {
+	if len(c.srv) == 0 || c.srvPublished || c.dryRun {
+		return nil
+	}
+	records := make([]SRV, len(c.srv))
+	for i, r := range c.srv {
+		if r.Target == "" {
+			r.Target = c.domain
+		}
+		r.Target = canonicalName(r.Target)
+		records[i] = r
+	}
+	sp, ok := c.Provider.(SRVProvider)
+	if !ok {
+		return errSRVUnsupported
+	}
+	name := c.srvService + "." + c.domain
+	if err := sp.SetSRVRecords(ctx, name, records); err != nil {
+		return fmt.Errorf("error publishing SRV records for %s: %w", name, err)
+	}
+	c.logger.Printf("published SRV records %v for %s\n", records, name)
+	c.srvPublished = true
+	return nil
+}
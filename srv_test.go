@@ -0,0 +1,80 @@
+package ddns_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestPublishSRV(t *testing.T) {
+	ctx := context.Background()
+	p := &ddnstest.Provider{}
+	c, err := ddns.New("mc.example.com", ddnstest.ProviderFunc(p),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+		ddns.PublishSRV("_minecraft._tcp", ddns.SRV{Port: 25565}, ddns.SRV{Priority: 10, Port: 25565, Target: "Backup.example.com."}),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	got, _ := p.GetSRVRecords(ctx, "_minecraft._tcp.mc.example.com")
+	expected := []ddns.SRV{{Port: 25565, Target: "mc.example.com"}, {Priority: 10, Port: 25565, Target: "backup.example.com"}}
+	if len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+		t.Errorf("Expected %v; got %v", expected, got)
+	}
+}
+
+func TestPublishSRVValidation(t *testing.T) {
+	tests := []struct {
+		service string
+		records []ddns.SRV
+	}{
+		{"minecraft._tcp", []ddns.SRV{{Port: 25565}}},
+		{"_minecraft", []ddns.SRV{{Port: 25565}}},
+		{"_sip._udp", nil},
+		{"_sip._udp", []ddns.SRV{{Target: "sip.example.com"}}},
+	}
+	for _, tt := range tests {
+		_, err := ddns.New("example.com", ddnstest.ProviderFunc(&ddnstest.Provider{}), ddns.PublishSRV(tt.service, tt.records...))
+		if err == nil {
+			t.Errorf("Expected an error for %s %v", tt.service, tt.records)
+		}
+	}
+	if _, err := ddns.New("example.com", ddnstest.ProviderFunc(&ipv4OnlyProvider{}), ddns.PublishSRV("_sip._udp", ddns.SRV{Port: 5060})); err == nil {
+		t.Errorf("Expected an error for a provider without SRV support")
+	}
+}
+
+func TestCloudflareSRV(t *testing.T) {
+	ctx := context.Background()
+	cf := newFakeCloudflare("example.com")
+	stale := cf.add("zone0", fakeRecord{Type: "SRV", Name: "_minecraft._tcp.mc.example.com", Data: json.RawMessage(`{"priority":0,"weight":5,"port":25566,"target":"old.example.com"}`)})
+	kept := cf.add("zone0", fakeRecord{Type: "SRV", Name: "_minecraft._tcp.mc.example.com", Data: json.RawMessage(`{"priority":0,"weight":5,"port":25565,"target":"mc.example.com"}`)})
+	c, err := ddns.New("mc.example.com", ddns.NewCloudflare("token"),
+		ddns.UsingResolver(ddns.StaticIP(netip.MustParseAddr("203.0.113.5"))),
+		ddns.UsingHTTPClient(cf.client(t)),
+		ddns.PublishSRV("_minecraft._tcp", ddns.SRV{Weight: 5, Port: 25565, Target: "mc.example.com"}, ddns.SRV{Priority: 10, Port: 25565, Target: "backup.example.com"}),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if _, ok := cf.Record(stale.ID); ok {
+		t.Errorf("Expected the stale SRV record to be deleted")
+	}
+	if _, ok := cf.Record(kept.ID); !ok {
+		t.Errorf("Expected the matching SRV record to be kept")
+	}
+	if n := cf.calls["POST /zones/:id/dns_records"]; n != 2 {
+		// one A record and one SRV record
+		t.Errorf("Expected 2 records created; got %d", n)
+	}
+}
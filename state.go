@@ -0,0 +1,65 @@
+package ddns
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// StateReporter is the interface for clients which can report the records of their domain,
+// e.g. for a dashboard or health endpoint of the program embedding the client.
+//
+// It is implemented by the client returned by ddns.New,
+// and its methods may be called while the client is updating.
+type StateReporter interface {
+	// CurrentRecords returns the records the Provider currently has for the domain.
+	// The Provider must implement [RecordGetter].
+	CurrentRecords(ctx context.Context) ([]netip.Addr, error)
+	// LastPublished returns the records most recently published by the client.
+	LastPublished() Published
+}
+
+// Published describes the records most recently published by a client.
+type Published struct {
+	Domain   string
+	Records  []netip.Addr // the records set for the domain
+	Resolved []netip.Addr // the resolved addresses the records were computed from
+	Time     time.Time    // the zero Time if the client has not published since it was created
+	Run      RunInfo
+}
+
+// lastPublished holds the Published state of a client.
+type lastPublished struct {
+	mu sync.Mutex
+	p  Published
+}
+
+func (c *client) CurrentRecords(ctx context.Context) ([]netip.Addr, error) {
+	rg, ok := c.Provider.(RecordGetter)
+	if !ok {
+		return nil, errGetRecordsUnsupported
+	}
+	records, err := rg.GetDNSRecords(ctx, c.domain)
+	if err != nil {
+		return nil, err
+	}
+	return sortAddrs(records), nil
+}
+
+func (c *client) LastPublished() Published {
+	c.last.mu.Lock()
+	defer c.last.mu.Unlock()
+	p := c.last.p
+	p.Domain = c.domain
+	p.Records = append([]netip.Addr(nil), p.Records...)
+	p.Resolved = append([]netip.Addr(nil), p.Resolved...)
+	return p
+}
+
+// setPublished records a successful update for LastPublished.
+func (c *client) setPublished(ctx context.Context, records, addrs []netip.Addr) {
+	c.last.mu.Lock()
+	defer c.last.mu.Unlock()
+	c.last.p = Published{Records: records, Resolved: addrs, Time: time.Now(), Run: RunInfoFrom(ctx)}
+}
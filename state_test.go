@@ -0,0 +1,49 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+	"github.com/Travis-Britz/ddns/ddnstest"
+)
+
+func TestStateReporter(t *testing.T) {
+	p := &ddnstest.Provider{}
+	ctx := context.Background()
+	p.SetDNSRecords(ctx, "example.com", []netip.Addr{netip.MustParseAddr("192.0.2.1")})
+	addrs := []netip.Addr{netip.MustParseAddr("192.0.2.2")}
+	c, err := ddns.New("example.com", ddnstest.ProviderFunc(p), ddns.UsingResolver(ddns.StaticIP(addrs...)))
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	sr := c.(ddns.StateReporter)
+	if last := sr.LastPublished(); !last.Time.IsZero() || last.Records != nil {
+		t.Errorf("Expected nothing to be published before the first run; got %+v", last)
+	}
+	current, err := sr.CurrentRecords(ctx)
+	if err != nil {
+		t.Fatalf("CurrentRecords returned an error: %s", err)
+	}
+	if expected := []netip.Addr{netip.MustParseAddr("192.0.2.1")}; !reflect.DeepEqual(current, expected) {
+		t.Errorf("Expected %q; got %q", expected, current)
+	}
+
+	if err := c.RunDDNS(ddns.WithRunInfo(ctx, ddns.RunInfo{ID: "run1"})); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	last := sr.LastPublished()
+	if last.Domain != "example.com" || !reflect.DeepEqual(last.Records, addrs) || !reflect.DeepEqual(last.Resolved, addrs) || last.Time.IsZero() || last.Run.ID != "run1" {
+		t.Errorf("Expected the published records of run1; got %+v", last)
+	}
+	if current, _ = sr.CurrentRecords(ctx); !reflect.DeepEqual(current, addrs) {
+		t.Errorf("Expected %q; got %q", addrs, current)
+	}
+
+	c, _ = ddns.New("example.com", (&recordingProvider{}).fn())
+	if _, err := c.(ddns.StateReporter).CurrentRecords(ctx); err == nil {
+		t.Errorf("Expected an error for a provider which can't list records")
+	}
+}
@@ -0,0 +1,162 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store is the interface for persisting client state between runs.
+//
+// Embedded users may implement Store with their own database.
+// This package provides [MemoryStore] and [NewFileStore].
+type Store interface {
+	// LastPublished returns the records most recently published for domain,
+	// or nil if there are none.
+	LastPublished(ctx context.Context, domain string) ([]netip.Addr, error)
+	SetLastPublished(ctx context.Context, domain string, addrs []netip.Addr) error
+	// AppendEvent records a change made by the client.
+	AppendEvent(ctx context.Context, e JournalEntry) error
+}
+
+// UsingStore configures the client to persist the records it publishes and the changes it makes to s.
+//
+// Records remembered by the store are used as the previous records for the journal
+// when the Provider cannot list records.
+func UsingStore(s Store) clientOption {
+	return func(c *client) error {
+		c.store = s
+		return nil
+	}
+}
+
+// MemoryStore is an in-memory implementation of [Store].
+//
+// It is safe for concurrent use.
+// The zero value is ready to use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	published map[string][]netip.Addr
+	events    []JournalEntry
+}
+
+func (s *MemoryStore) LastPublished(ctx context.Context, domain string) ([]netip.Addr, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]netip.Addr(nil), s.published[domain]...), nil
+}
+
+func (s *MemoryStore) SetLastPublished(ctx context.Context, domain string, addrs []netip.Addr) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.published == nil {
+		s.published = map[string][]netip.Addr{}
+	}
+	s.published[domain] = append([]netip.Addr(nil), addrs...)
+	return nil
+}
+
+func (s *MemoryStore) AppendEvent(ctx context.Context, e JournalEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e)
+	return nil
+}
+
+// Events returns the events appended to the store.
+func (s *MemoryStore) Events() []JournalEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]JournalEntry(nil), s.events...)
+}
+
+// NewFileStore returns a [Store] which keeps its state in the directory dir.
+//
+// The last published records are kept in state.json,
+// and events are appended to journal.jsonl in the same format as [WithJournal].
+func NewFileStore(dir string) Store {
+	return &fileStore{dir: dir}
+}
+
+type fileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func (s *fileStore) statePath() string   { return filepath.Join(s.dir, "state.json") }
+func (s *fileStore) journalPath() string { return filepath.Join(s.dir, "journal.jsonl") }
+
+// state reads the published records for all domains.
+func (s *fileStore) state() (map[string][]netip.Addr, error) {
+	state := map[string][]netip.Addr{}
+	b, err := os.ReadFile(s.statePath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state: %w", err)
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return nil, fmt.Errorf("error parsing \"%s\": %w", s.statePath(), err)
+	}
+	return state, nil
+}
+
+func (s *fileStore) LastPublished(ctx context.Context, domain string) ([]netip.Addr, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.state()
+	if err != nil {
+		return nil, err
+	}
+	return state[domain], nil
+}
+
+func (s *fileStore) SetLastPublished(ctx context.Context, domain string, addrs []netip.Addr) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, err := s.state()
+	if err != nil {
+		return err
+	}
+	state[domain] = addrs
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	// write to a temporary file first so that a crash never leaves a truncated state file
+	tmp := s.statePath() + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("error writing state: %w", err)
+	}
+	if err := os.Rename(tmp, s.statePath()); err != nil {
+		return fmt.Errorf("error writing state: %w", err)
+	}
+	return nil
+}
+
+func (s *fileStore) AppendEvent(ctx context.Context, e JournalEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return fmt.Errorf("error creating state directory: %w", err)
+	}
+	f, err := os.OpenFile(s.journalPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening journal: %w", err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(e); err != nil {
+		return fmt.Errorf("error writing journal: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,71 @@
+package ddns_test
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+// setOnlyProvider cannot list records, so the client must rely on its store for the previous records.
+type setOnlyProvider struct{}
+
+func (setOnlyProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	return nil
+}
+
+func TestFileStoreSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, second := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")
+	run := func(addr netip.Addr) {
+		c, err := ddns.New("example.com", func() (ddns.Provider, error) { return setOnlyProvider{}, nil },
+			ddns.UsingResolver(ddns.StaticIP(addr)),
+			ddns.UsingStore(ddns.NewFileStore(dir)),
+		)
+		if err != nil {
+			t.Fatalf("New returned an error: %s", err)
+		}
+		if err := c.RunDDNS(ctx); err != nil {
+			t.Fatalf("RunDDNS failed: %s", err)
+		}
+	}
+	run(first)
+	run(second)
+
+	got, err := ddns.NewFileStore(dir).LastPublished(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("LastPublished returned an error: %s", err)
+	}
+	if len(got) != 1 || got[0] != second {
+		t.Fatalf("Expected last published %s; got %q", second, got)
+	}
+}
+
+func TestMemoryStoreEvents(t *testing.T) {
+	ctx := context.Background()
+	store := &ddns.MemoryStore{}
+	first, second := netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2")
+	store.SetLastPublished(ctx, "example.com", []netip.Addr{first})
+	c, err := ddns.New("example.com", func() (ddns.Provider, error) { return setOnlyProvider{}, nil },
+		ddns.UsingResolver(ddns.StaticIP(second)),
+		ddns.UsingStore(store),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(ctx); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	events := store.Events()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events; got %d", len(events))
+	}
+	if events[0].Action != "delete" || events[0].Addr != first {
+		t.Errorf("Expected delete of %s; got %s of %s", first, events[0].Action, events[0].Addr)
+	}
+	if events[1].Action != "create" || events[1].Addr != second {
+		t.Errorf("Expected create of %s; got %s of %s", second, events[1].Action, events[1].Addr)
+	}
+}
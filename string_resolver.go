@@ -18,5 +18,19 @@ func (s stringResolver) Resolve(context.Context) ([]netip.Addr, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse IP: %w", err)
 	}
+	if addr.Zone() != "" {
+		return nil, fmt.Errorf("unable to use IP %q: addresses with a zone cannot be published", string(s))
+	}
 	return []netip.Addr{addr}, nil
 }
+
+// StaticIP constructs a resolver that always returns addrs.
+func StaticIP(addrs ...netip.Addr) Resolver {
+	return staticResolver(append([]netip.Addr(nil), addrs...))
+}
+
+type staticResolver []netip.Addr
+
+func (s staticResolver) Resolve(context.Context) ([]netip.Addr, error) {
+	return append([]netip.Addr(nil), s...), nil
+}
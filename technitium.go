@@ -0,0 +1,184 @@
+package ddns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewTechnitium is used by [ddns.New] to create a Provider which updates records using the HTTP API of Technitium DNS Server,
+// e.g. to maintain LAN-internal names alongside public ones with [MultiProvider].
+//
+// serverURL is the address of the web console, e.g. "http://192.168.1.2:5380".
+// token is an API token created in the console's Sessions page.
+// The zone for each domain must already exist on the server.
+func NewTechnitium(serverURL, token string) func() (Provider, error) {
+	return func() (Provider, error) {
+		u, err := url.Parse(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing server URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("server URL must be http or https; got \"%s\"", serverURL)
+		}
+		if token == "" {
+			return nil, errors.New("token cannot be empty")
+		}
+		return &technitiumProvider{server: u, token: token, logger: discard, ttl: 60}, nil
+	}
+}
+
+type technitiumProvider struct {
+	httpClient *http.Client
+	logger     *log.Logger
+	server     *url.URL
+	token      string
+	ttl        int
+}
+
+func (p *technitiumProvider) SetLogger(logger *log.Logger) {
+	p.logger = logger
+}
+
+func (p *technitiumProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func (p *technitiumProvider) Capabilities() Capabilities {
+	return Capabilities{IPv4: true, IPv6: true, ListRecords: true, TTL: true}
+}
+
+func (p *technitiumProvider) SetTTL(ttl time.Duration) {
+	p.ttl = ttlSeconds(ttl)
+}
+
+func (p *technitiumProvider) SetDNSRecords(ctx context.Context, domain string, records []netip.Addr) error {
+	existing, err := p.GetDNSRecords(ctx, domain)
+	if err != nil {
+		return err
+	}
+	return Reconcile(ctx, addrRecords(existing), records, technitiumApplier{p: p, domain: domain})
+}
+
+// technitiumApplier applies record changes for one domain through the Technitium API.
+type technitiumApplier struct {
+	p      *technitiumProvider
+	domain string
+}
+
+func (a technitiumApplier) Delete(ctx context.Context, r Record) error {
+	a.p.logger.Printf("deleting record %s for %s...\n", r.Addr, a.domain)
+	v, err := recordParams(a.domain, r.Addr)
+	if err != nil {
+		return err
+	}
+	return a.p.call(ctx, "/api/zones/records/delete", v, nil)
+}
+
+func (a technitiumApplier) Create(ctx context.Context, addr netip.Addr) error {
+	a.p.logger.Printf("adding record %s for %s...\n", addr, a.domain)
+	v, err := recordParams(a.domain, addr)
+	if err != nil {
+		return err
+	}
+	v.Set("ttl", strconv.Itoa(a.p.ttl))
+	return a.p.call(ctx, "/api/zones/records/add", v, nil)
+}
+
+func recordParams(domain string, a netip.Addr) (url.Values, error) {
+	typ, err := recordType(a)
+	if err != nil {
+		return nil, err
+	}
+	v := url.Values{}
+	v.Set("domain", canonicalName(domain))
+	v.Set("type", typ)
+	v.Set("ipAddress", a.Unmap().String())
+	return v, nil
+}
+
+func (p *technitiumProvider) GetDNSRecords(ctx context.Context, domain string) ([]netip.Addr, error) {
+	v := url.Values{}
+	v.Set("domain", canonicalName(domain))
+	var resp struct {
+		Records []struct {
+			Name  string `json:"name"`
+			Type  string `json:"type"`
+			RData struct {
+				IPAddress string `json:"ipAddress"`
+			} `json:"rData"`
+		} `json:"records"`
+	}
+	if err := p.call(ctx, "/api/zones/records/get", v, &resp); err != nil {
+		return nil, fmt.Errorf("unable to get records for %s: %w", domain, err)
+	}
+	var addrs []netip.Addr
+	for _, r := range resp.Records {
+		if (r.Type != "A" && r.Type != "AAAA") || !strings.EqualFold(r.Name, canonicalName(domain)) {
+			continue
+		}
+		a, err := netip.ParseAddr(r.RData.IPAddress)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing IP from record: %w", err)
+		}
+		addrs = append(addrs, a)
+	}
+	return addrs, nil
+}
+
+// call makes an API request and decodes the "response" object into result if it is not nil.
+func (p *technitiumProvider) call(ctx context.Context, path string, params url.Values, result any) error {
+	u := *p.server
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	params.Set("token", p.token)
+	u.RawQuery = params.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	setUserAgent(req)
+	httpClient := p.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Status       string          `json:"status"`
+		ErrorMessage string          `json:"errorMessage"`
+		Response     json.RawMessage `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("error decoding response (status %s): %w", resp.Status, err)
+	}
+	switch body.Status {
+	case "ok":
+	case "invalid-token":
+		return &technitiumError{msg: "invalid API token", auth: true}
+	default:
+		return &technitiumError{msg: fmt.Sprintf("api returned status \"%s\": %s", body.Status, body.ErrorMessage)}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body.Response, result)
+}
+
+type technitiumError struct {
+	msg  string
+	auth bool
+}
+
+func (e *technitiumError) Error() string               { return e.msg }
+func (e *technitiumError) IsAuthenticationError() bool { return e.auth }
@@ -0,0 +1,223 @@
+package ddns
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UsingTransport configures the DDNSClient to make HTTP requests with rt,
+// so that one transport policy applies to the resolvers, providers, and notifier alike.
+// It replaces the transport of the http.Client given to [UsingHTTPClient], if any.
+//
+// The default transport honors the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables;
+// a custom *http.Transport should set Proxy to [http.ProxyFromEnvironment] to keep that behavior.
+func UsingTransport(rt http.RoundTripper) clientOption {
+	return func(c *client) error {
+		if rt == nil {
+			return errors.New("transport cannot be nil")
+		}
+		c.transport = rt
+		return nil
+	}
+}
+
+// UsingDialer configures the DDNSClient to open connections with dial,
+// e.g. to tunnel through a SOCKS5 proxy over SSH.
+// dial is used for HTTP requests as well as the DNS connections made by [NewRFC2136] and [DNSResolver].
+//
+// The transport given to [UsingTransport], if any, must be an *http.Transport.
+func UsingDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) clientOption {
+	return func(c *client) error {
+		if dial == nil {
+			return errors.New("dialer cannot be nil")
+		}
+		c.dial = dial
+		return nil
+	}
+}
+
+// HTTPTimeout configures the DDNSClient to limit each HTTP request to d,
+// including connecting, redirects, and reading the response body.
+func HTTPTimeout(d time.Duration) clientOption {
+	return func(c *client) error {
+		if d <= 0 {
+			return errors.New("timeout must be positive")
+		}
+		c.httpTimeout = d
+		return nil
+	}
+}
+
+// MinTLSVersion configures the DDNSClient to require at least TLS version v, e.g. [tls.VersionTLS13],
+// for HTTPS requests and DNS-over-TLS.
+func MinTLSVersion(v uint16) clientOption {
+	return func(c *client) error {
+		if v < tls.VersionTLS10 || v > tls.VersionTLS13 {
+			return errors.New("unknown TLS version")
+		}
+		c.tlsMinVersion = v
+		return nil
+	}
+}
+
+// RootCAs configures the DDNSClient to verify HTTPS and DNS-over-TLS servers with pool instead of the system roots,
+// e.g. for a self-hosted IP echo service with a private CA.
+func RootCAs(pool *x509.CertPool) clientOption {
+	return func(c *client) error {
+		if pool == nil {
+			return errors.New("certificate pool cannot be nil")
+		}
+		c.rootCAs = pool
+		return nil
+	}
+}
+
+// configureTransport applies the transport, dialer, timeout, and TLS options to c.httpClient,
+// and to the clients given to [ResolverHTTPClient] and [ProviderHTTPClient].
+func (c *client) configureTransport() error {
+	tlsOptions := c.tlsMinVersion != 0 || c.rootCAs != nil
+	if c.transport == nil && c.dial == nil && c.httpTimeout == 0 && !tlsOptions {
+		return nil
+	}
+	var err error
+	if c.httpClient, err = c.applyTransport(c.httpClient); err != nil {
+		return err
+	}
+	if c.resolverHTTPClient != nil {
+		if c.resolverHTTPClient, err = c.applyTransport(c.resolverHTTPClient); err != nil {
+			return err
+		}
+	}
+	if c.providerHTTPClient != nil {
+		if c.providerHTTPClient, err = c.applyTransport(c.providerHTTPClient); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyTransport returns a copy of hc, or of the zero http.Client if hc is nil, with the transport options applied.
+func (c *client) applyTransport(hc *http.Client) (*http.Client, error) {
+	tlsOptions := c.tlsMinVersion != 0 || c.rootCAs != nil
+	httpclient := http.Client{}
+	if hc != nil {
+		httpclient = *hc
+	}
+	rt := c.transport
+	if rt == nil {
+		rt = httpclient.Transport
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if c.dial != nil || tlsOptions {
+		t, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, errors.New("dialer and TLS options can only be used with an *http.Transport")
+		}
+		t = t.Clone()
+		if c.dial != nil {
+			t.DialContext = c.dial
+		}
+		if tlsOptions {
+			if t.TLSClientConfig == nil {
+				t.TLSClientConfig = &tls.Config{}
+			}
+			if c.tlsMinVersion != 0 {
+				t.TLSClientConfig.MinVersion = c.tlsMinVersion
+			}
+			if c.rootCAs != nil {
+				t.TLSClientConfig.RootCAs = c.rootCAs
+			}
+		}
+		rt = t
+	}
+	if c.httpTimeout != 0 {
+		httpclient.Timeout = c.httpTimeout
+	}
+	httpclient.Transport = rt
+	return &httpclient, nil
+}
+
+// dialer returns the function used by httpclient to open connections,
+// so that DNS connections can follow the same policy.
+func dialer(httpclient *http.Client) func(ctx context.Context, network, address string) (net.Conn, error) {
+	if httpclient != nil {
+		if t, ok := httpclient.Transport.(*http.Transport); ok && t.DialContext != nil {
+			return t.DialContext
+		}
+	}
+	var d net.Dialer
+	return d.DialContext
+}
+
+// HTTPClientIPv4 returns an http.Client which only connects over IPv4,
+// so that a [WebResolver] reports the public IPv4 address of a host which also has IPv6.
+// Use [PinHTTPClient] to give it to one resolver.
+func HTTPClientIPv4() *http.Client {
+	return familyHTTPClient("tcp4")
+}
+
+// HTTPClientIPv6 returns an http.Client which only connects over IPv6,
+// so that a [WebResolver] reports the public IPv6 address of a host which also has IPv4.
+// Use [PinHTTPClient] to give it to one resolver.
+func HTTPClientIPv6() *http.Client {
+	return familyHTTPClient("tcp6")
+}
+
+func familyHTTPClient(network string) *http.Client {
+	t := cloneDefaultTransport()
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t.DialContext = func(ctx context.Context, _, address string) (net.Conn, error) {
+		return d.DialContext(ctx, network, address)
+	}
+	return &http.Client{Transport: t}
+}
+
+// PinHTTPClient returns resolver configured to use httpclient,
+// which is kept when the DDNSClient is given another client with [UsingHTTPClient] or [ResolverHTTPClient].
+// The transport options such as [UsingDialer] don't apply to httpclient.
+//
+// To publish the public address of each family as seen by web services:
+//
+//	ddns.Join(
+//		ddns.PinHTTPClient(ddns.WebResolver(), ddns.HTTPClientIPv4()),
+//		ddns.PinHTTPClient(ddns.WebResolver(ddns.DefaultWebServicesIPv6()...), ddns.HTTPClientIPv6()),
+//	)
+func PinHTTPClient(resolver Resolver, httpclient *http.Client) Resolver {
+	setHTTPClient(resolver, httpclient)
+	return pinnedResolver{resolver}
+}
+
+// pinnedResolver hides the SetHTTPClient method of a Resolver.
+type pinnedResolver struct {
+	Resolver
+}
+
+func (r pinnedResolver) validate() error              { return validateResolver(r.Resolver) }
+func (r pinnedResolver) lookupURLs() []*url.URL       { return lookupURLs(r.Resolver) }
+func (r pinnedResolver) SetLogger(logger *log.Logger) { setLogger(r.Resolver, logger) }
+
+// cloneDefaultTransport returns a copy of http.DefaultTransport,
+// or a new transport with the same settings if the program has replaced it with another http.RoundTripper.
+func cloneDefaultTransport() *http.Transport {
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
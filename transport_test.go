@@ -0,0 +1,206 @@
+package ddns_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestUsingDialer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "192.0.2.1")
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var dialed []string
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		mu.Lock()
+		dialed = append(dialed, address)
+		mu.Unlock()
+		if address == "192.0.2.53:53" {
+			return nil, errors.New("blocked")
+		}
+		var d net.Dialer
+		return d.DialContext(ctx, network, address)
+	}
+	p := &recordingProvider{}
+	c, err := ddns.New("example.com", p.fn(),
+		ddns.UsingResolver(ddns.Join(
+			ddns.WebResolver(srv.URL),
+			ddns.DNSResolver("example.net", "A", ddns.DNSServer("192.0.2.53:53")),
+		)),
+		ddns.UsingDialer(dial),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	c.RunDDNS(context.Background())
+	want := map[string]bool{srv.Listener.Addr().String(): true, "192.0.2.53:53": true}
+	for _, a := range dialed {
+		delete(want, a)
+	}
+	if len(want) > 0 {
+		t.Errorf("Expected connections to %v to use the dialer; got %q", want, dialed)
+	}
+}
+
+type countingTransport struct {
+	count int
+}
+
+func (t *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.count++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestUsingTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "192.0.2.1")
+	}))
+	defer srv.Close()
+	rt := &countingTransport{}
+	p := &recordingProvider{}
+	c, err := ddns.New("example.com", p.fn(),
+		ddns.UsingTransport(rt),
+		ddns.UsingHTTPClient(&http.Client{}),
+		ddns.UsingResolver(ddns.WebResolver(srv.URL)),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS failed: %s", err)
+	}
+	if rt.count != 1 {
+		t.Errorf("Expected 1 request through the transport; got %d", rt.count)
+	}
+
+	_, err = ddns.New("example.com", p.fn(), ddns.UsingTransport(rt), ddns.UsingDialer((&net.Dialer{}).DialContext))
+	if err == nil {
+		t.Errorf("Expected an error for a dialer with a custom RoundTripper")
+	}
+}
+
+func TestTLSOptions(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		io.WriteString(w, "192.0.2.1")
+	}))
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	p := &recordingProvider{}
+	tests := []struct {
+		name    string
+		new     func() (ddns.DDNSClient, error)
+		wantErr bool
+	}{
+		{"untrusted", func() (ddns.DDNSClient, error) {
+			return ddns.New("example.com", p.fn(), ddns.UsingResolver(ddns.WebResolver(srv.URL)))
+		}, true},
+		{"root CAs", func() (ddns.DDNSClient, error) {
+			return ddns.New("example.com", p.fn(), ddns.UsingResolver(ddns.WebResolver(srv.URL)), ddns.RootCAs(pool))
+		}, false},
+		{"min TLS version", func() (ddns.DDNSClient, error) {
+			return ddns.New("example.com", p.fn(), ddns.UsingResolver(ddns.WebResolver(srv.URL)), ddns.RootCAs(pool), ddns.MinTLSVersion(tls.VersionTLS13))
+		}, true},
+		{"timeout", func() (ddns.DDNSClient, error) {
+			return ddns.New("example.com", p.fn(), ddns.UsingResolver(ddns.WebResolver(srv.URL+"/slow")), ddns.RootCAs(pool), ddns.HTTPTimeout(10*time.Millisecond))
+		}, true},
+	}
+	for _, tt := range tests {
+		c, err := tt.new()
+		if err != nil {
+			t.Fatalf("%s: New returned an error: %s", tt.name, err)
+		}
+		err = c.RunDDNS(context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Expected error %t; got %v", tt.name, tt.wantErr, err)
+		}
+	}
+}
+
+// httpClientProvider records the http.Client it is given.
+type httpClientProvider struct {
+	recordingProvider
+	httpClient *http.Client
+}
+
+func (p *httpClientProvider) SetHTTPClient(httpclient *http.Client) {
+	p.httpClient = httpclient
+}
+
+func TestComponentHTTPClients(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "192.0.2.1")
+	}))
+	defer srv.Close()
+
+	resolverTransport, defaultTransport := &countingTransport{}, &countingTransport{}
+	p := &httpClientProvider{}
+	c, err := ddns.New("example.com", func() (ddns.Provider, error) { return p, nil },
+		ddns.UsingWebResolver(srv.URL),
+		ddns.UsingHTTPClient(&http.Client{Transport: defaultTransport}),
+		ddns.ResolverHTTPClient(&http.Client{Transport: resolverTransport}),
+		ddns.HTTPTimeout(time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	if resolverTransport.count != 1 || defaultTransport.count != 0 {
+		t.Errorf("Expected the resolver to use only its own client; got %d and %d requests", resolverTransport.count, defaultTransport.count)
+	}
+	if p.httpClient == nil || p.httpClient.Transport != defaultTransport || p.httpClient.Timeout != time.Minute {
+		t.Errorf("Expected the provider to be given the default client with the timeout applied; got %+v", p.httpClient)
+	}
+}
+
+func TestHTTPClientFamily(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, _ := net.SplitHostPort(r.RemoteAddr)
+		io.WriteString(w, host)
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	// localhost resolves to both families, but the server only listens on 127.0.0.1
+	url := "http://localhost:" + port
+
+	pinned := &countingTransport{}
+	c, err := ddns.New("example.com", (&recordingProvider{}).fn(),
+		ddns.UsingResolver(ddns.PinHTTPClient(ddns.WebResolver(url), ddns.HTTPClientIPv4())),
+		ddns.UsingHTTPClient(&http.Client{Transport: pinned}),
+	)
+	if err != nil {
+		t.Fatalf("New returned an error: %s", err)
+	}
+	if err := c.RunDDNS(context.Background()); err != nil {
+		t.Fatalf("RunDDNS returned an error: %s", err)
+	}
+	if pinned.count != 0 {
+		t.Errorf("Expected the pinned resolver not to use the client's http.Client; got %d requests", pinned.count)
+	}
+	if _, err := ddns.PinHTTPClient(ddns.WebResolver(url), ddns.HTTPClientIPv6()).Resolve(context.Background()); err == nil {
+		t.Errorf("Expected an error connecting to an IPv4 listener over IPv6")
+	}
+}
@@ -0,0 +1,66 @@
+package ddns
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithTTL configures the TTL of the records created by the Provider.
+// Shorter TTLs let changes reach resolvers sooner, at the cost of more queries to the name servers.
+//
+// [New] returns an error if the Provider cannot set the TTL,
+// or if ttl is below the minimum it accepts (see [Capabilities]) unless [ClampTTL] is given,
+// rather than failing on every update.
+// A ttl of 0 keeps the Provider's default.
+func WithTTL(ttl time.Duration) clientOption {
+	return func(c *client) error {
+		if ttl != 0 && ttl < time.Second {
+			return fmt.Errorf("TTL must be at least one second: %s", ttl)
+		}
+		c.ttl = ttl
+		return nil
+	}
+}
+
+// ClampTTL configures the client to raise a TTL given to [WithTTL] to the minimum accepted by the Provider,
+// logging a warning, instead of returning an error.
+func ClampTTL() clientOption {
+	return func(c *client) error {
+		c.clampTTL = true
+		return nil
+	}
+}
+
+// configureTTL checks the configured TTL against the Provider's minimum and passes it to the Provider.
+func (c *client) configureTTL() error {
+	if c.ttl == 0 {
+		return nil
+	}
+	caps := ProviderCapabilities(c.Provider)
+	if !caps.TTL {
+		return errors.New("provider does not support setting the TTL")
+	}
+	if c.ttl < caps.MinTTL {
+		if !c.clampTTL {
+			return fmt.Errorf("TTL %s is below the provider's minimum of %s", c.ttl, caps.MinTTL)
+		}
+		c.warnf("warning: raising TTL %s to the provider's minimum of %s\n", c.ttl, caps.MinTTL)
+		c.ttl = caps.MinTTL
+	}
+	setTTL(c.Provider, c.ttl)
+	c.logger.Printf("records are created with a TTL of %s; changes may take that long to reach resolvers which cached the old records\n", c.ttl)
+	return nil
+}
+
+// setTTL configures the TTL of new records for v if it implements a SetTTL method.
+func setTTL(v any, ttl time.Duration) {
+	if s, ok := v.(interface{ SetTTL(time.Duration) }); ok {
+		s.SetTTL(ttl)
+	}
+}
+
+// ttlSeconds returns ttl in whole seconds, rounded up.
+func ttlSeconds(ttl time.Duration) int {
+	return int((ttl + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,70 @@
+package ddns
+
+import (
+	"errors"
+	"fmt"
+)
+
+// NewURLProvider is used by [ddns.New] to create a Provider which updates records by requesting a URL,
+// for the many router-style dynamic DNS services that aren't worth a [SimpleAPI] preset.
+//
+// url is a [text/template] executed with [SimpleRequest], so it may reference {{.Domain}}, {{.IP}}, {{.IPv4}}, and {{.IPv6}}:
+//
+//	ddns.NewURLProvider("https://dyn.example.com/update?host={{.Domain}}&ip={{.IP}}&key=secret",
+//		ddns.ExpectResponse("success"),
+//	)
+//
+// The request is a GET by default and succeeds on any 2xx response.
+func NewURLProvider(url string, options ...urlProviderOption) func() (Provider, error) {
+	return func() (Provider, error) {
+		api := SimpleAPI{URL: url, Headers: map[string]string{}}
+		for i, opt := range options {
+			if err := opt(&api); err != nil {
+				return nil, fmt.Errorf("url provider option %d returned an error: %w", i, err)
+			}
+		}
+		return newSimpleProvider(api, SimpleAccount{})
+	}
+}
+
+type urlProviderOption func(*SimpleAPI) error
+
+// URLMethod configures a URL provider to use the given HTTP method,
+// sending the request body produced by the template body with the given content type.
+func URLMethod(method, contentType, body string) urlProviderOption {
+	return func(api *SimpleAPI) error {
+		if method == "" {
+			return errors.New("method cannot be empty")
+		}
+		api.Method, api.ContentType, api.Body = method, contentType, body
+		return nil
+	}
+}
+
+// URLHeader configures a URL provider to send a request header.
+// The value is a template like the URL.
+func URLHeader(name, value string) urlProviderOption {
+	return func(api *SimpleAPI) error {
+		if name == "" {
+			return errors.New("header name cannot be empty")
+		}
+		api.Headers[name] = value
+		return nil
+	}
+}
+
+// ExpectResponse configures a URL provider to require one of the substrings in the response body.
+func ExpectResponse(substrings ...string) urlProviderOption {
+	return func(api *SimpleAPI) error {
+		api.Success = append(api.Success, substrings...)
+		return nil
+	}
+}
+
+// PerFamily configures a URL provider to send one request for the IPv4 address and another for the IPv6 address.
+func PerFamily() urlProviderOption {
+	return func(api *SimpleAPI) error {
+		api.PerFamily = true
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package ddns
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const modulePath = "github.com/Travis-Britz/ddns"
+
+// version may be set at build time to override the module version from the build info:
+//
+//	go build -ldflags "-X github.com/Travis-Britz/ddns.version=v1.2.3" ./cmd/ddnscf
+var version string
+
+var versionOnce sync.Once
+
+// Version returns the version of this module, e.g. "v1.2.3".
+// It is set with ldflags at build time, or read from the build info of the program.
+func Version() string {
+	versionOnce.Do(func() { version = readVersion() })
+	return version
+}
+
+func readVersion() string {
+	if version != "" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath {
+		if info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+		// builds from a checkout have no module version, so identify the commit instead
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+				return "devel-" + s.Value[:12]
+			}
+		}
+		return "(devel)"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// userAgent is the default User-Agent of requests made by resolvers and providers,
+// so that service operators can identify the client.
+func userAgent() string {
+	return "Travis-Britz-ddns/" + strings.Trim(Version(), "()")
+}
+
+// setUserAgent sets the default User-Agent of req.
+func setUserAgent(req *http.Request) {
+	req.Header.Set("User-Agent", userAgent())
+}
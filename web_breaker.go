@@ -0,0 +1,109 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// breakerThreshold is the number of consecutive failures after which a service is skipped.
+	breakerThreshold = 3
+	// breakerCooldown is how long a failing service is first skipped for.
+	// It doubles with each failed probe, up to breakerMaxCooldown.
+	breakerCooldown    = 5 * time.Minute
+	breakerMaxCooldown = time.Hour
+)
+
+// WebServiceStats are the statistics of one service used by a [WebResolver].
+type WebServiceStats struct {
+	URL       string
+	Successes int
+	Failures  int
+	Latency   time.Duration // of the most recent successful lookup
+	Skipped   bool          // whether the service is skipped after failing repeatedly
+	RetryAt   time.Time     // when a skipped service will be tried again
+}
+
+// WebResolverStats returns the statistics of each service used by r,
+// or nil if r was not created by [WebResolver] or [WebResolverStrict].
+func WebResolverStats(r Resolver) []WebServiceStats {
+	wr, ok := r.(*webResolver)
+	if !ok {
+		return nil
+	}
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	now := time.Now()
+	stats := make([]WebServiceStats, len(wr.services))
+	for i, s := range wr.services {
+		stats[i] = WebServiceStats{
+			URL:       wr.serviceURLs[i].String(),
+			Successes: s.successes,
+			Failures:  s.failures,
+			Latency:   s.latency,
+			Skipped:   now.Before(s.retryAt),
+			RetryAt:   s.retryAt,
+		}
+	}
+	return stats
+}
+
+// serviceState is the circuit breaker state of one service.
+//
+// A service which fails breakerThreshold times in a row is skipped until retryAt.
+// After that a single lookup is allowed as a probe:
+// success resets the service, and failure skips it again for twice as long.
+type serviceState struct {
+	successes, failures int
+	consecutiveFailures int
+	latency             time.Duration
+	cooldown            time.Duration
+	retryAt             time.Time
+}
+
+// available returns the indexes of the services which are not being skipped, in order of preference.
+func (wr *webResolver) available(now time.Time) []int {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	var avail []int
+	for i, s := range wr.services {
+		if !now.Before(s.retryAt) {
+			avail = append(avail, i)
+		}
+	}
+	return avail
+}
+
+// report records the result of a lookup from service i.
+// Lookups canceled because enough other services answered are not counted.
+func (wr *webResolver) report(i int, latency time.Duration, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+	s := &wr.services[i]
+	if err == nil {
+		s.successes++
+		s.consecutiveFailures = 0
+		s.cooldown = 0
+		s.latency = latency
+		return
+	}
+	s.failures++
+	s.consecutiveFailures++
+	if s.consecutiveFailures < breakerThreshold {
+		return
+	}
+	switch {
+	case s.cooldown == 0:
+		s.cooldown = breakerCooldown
+	case s.cooldown < breakerMaxCooldown:
+		s.cooldown *= 2
+		if s.cooldown > breakerMaxCooldown {
+			s.cooldown = breakerMaxCooldown
+		}
+	}
+	s.retryAt = time.Now().Add(s.cooldown)
+}
@@ -1,22 +1,24 @@
 package ddns
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/netip"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 )
 
 // WebResolver constructs a resolver which uses external web services to look up a "public" IP address.
 //
 // Each serviceURL must speak HTTP and return status "200 OK",
-// with a valid IPv4 or IPv6 address as the first line of the response body.
+// with a valid IPv4 or IPv6 address as the first line of the response body,
+// or in HTML as "Current IP Address: x.x.x.x" like checkip.dyndns.org.
 // All other responses are considered an error.
 //
 // If only one serviceURL is given,
@@ -31,20 +33,131 @@ import (
 // it is possible for one service to return IPv4 and another to return IPv6,
 // causing matching to fail.
 // There are at least two ways to ensure both responses use the same protocol version:
-// supply a custom *http.Client (using [ddns.WithHTTPClient]) with a custom http.Transport which is configured to use IPv4/6,
+// give the resolver an http.Client which only connects over one of them with [PinHTTPClient] and [HTTPClientIPv4] or [HTTPClientIPv6],
 // or simply use a public IP service endpoint that prefers one or the other, e.g. https://ipv4.icanhazip.com.
 //
 // If you want both IPv4 and IPv6 DNS records set,
-// then use one of the above approaches to ensure IPv4 and IPv6 respectively for each of two web resolvers and then use [ddns.Join] to combine their results.
+// then use one of the above approaches to ensure IPv4 and IPv6 respectively for each of two web resolvers and then use [ddns.Join] to combine their results
+// (see [PinHTTPClient] for an example).
 //
 // The http.Client used to make requests can be configured in ddns.New's clientOptions with [ddns.UsingHTTPClient].
+//
+// An invalid URL is reported by [New], or by Resolve if the resolver is used on its own.
+// Plain http:// URLs are accepted; use [WebResolverStrict] to require https.
+//
+// If no serviceURL is given, the services from [DefaultWebServices] are used.
 func WebResolver(serviceURL ...string) Resolver {
-	return &webResolver{serviceURLs: serviceURL}
+	if len(serviceURL) == 0 {
+		serviceURL = DefaultWebServices()
+	}
+	wr, err := newWebResolver(serviceURL, webResolverConfig{allowHTTP: true})
+	wr.err = err
+	return wr
+}
+
+// WebResolverStrict is like [WebResolver], but validates the URLs immediately
+// and rejects plain http:// URLs unless [AllowHTTP] is given or the host is a loopback address,
+// as a service reached over plain HTTP can be impersonated to control the published records.
+func WebResolverStrict(serviceURLs []string, options ...webResolverOption) (Resolver, error) {
+	var config webResolverConfig
+	for _, opt := range options {
+		opt(&config)
+	}
+	if len(serviceURLs) == 0 {
+		serviceURLs = DefaultWebServices()
+	}
+	wr, err := newWebResolver(serviceURLs, config)
+	if err != nil {
+		return nil, err
+	}
+	return wr, nil
+}
+
+// DefaultWebServices returns IPv4 lookup services run by well known operators over https,
+// for use with [WebResolver]:
+//
+//   - https://ipv4.icanhazip.com, operated by Cloudflare
+//   - https://checkip.amazonaws.com, operated by Amazon Web Services
+//   - https://api.ipify.org, operated by ipify
+//
+// Each service only answers over IPv4, so that their answers can be compared.
+// The list may change in future versions as services come and go.
+func DefaultWebServices() []string {
+	return []string{
+		"https://ipv4.icanhazip.com",
+		"https://checkip.amazonaws.com",
+		"https://api.ipify.org",
+	}
+}
+
+// DefaultWebServicesIPv6 returns IPv6 lookup services run by well known operators over https,
+// for use with [WebResolver]:
+//
+//   - https://ipv6.icanhazip.com, operated by Cloudflare
+//   - https://api6.ipify.org, operated by ipify
+//   - https://v6.ident.me, operated by ident.me
+//
+// To publish both address families:
+//
+//	ddns.Join(ddns.WebResolver(), ddns.WebResolver(ddns.DefaultWebServicesIPv6()...))
+func DefaultWebServicesIPv6() []string {
+	return []string{
+		"https://ipv6.icanhazip.com",
+		"https://api6.ipify.org",
+		"https://v6.ident.me",
+	}
+}
+
+type webResolverOption func(*webResolverConfig)
+
+type webResolverConfig struct {
+	allowHTTP bool
+}
+
+// AllowHTTP allows [WebResolverStrict] to use plain http:// URLs,
+// e.g. for a service on the local network.
+func AllowHTTP() webResolverOption {
+	return func(c *webResolverConfig) {
+		c.allowHTTP = true
+	}
+}
+
+func newWebResolver(serviceURLs []string, config webResolverConfig) (*webResolver, error) {
+	wr := &webResolver{}
+	for _, u := range serviceURLs {
+		pu, err := url.Parse(u)
+		if err != nil {
+			return wr, fmt.Errorf("error parsing URL \"%s\": %w", u, err)
+		}
+		if pu.Scheme != "https" && pu.Scheme != "http" {
+			return wr, fmt.Errorf("IP lookup URL \"%s\" must be an http or https URL", u)
+		}
+		if plaintext(pu) && !config.allowHTTP {
+			return wr, fmt.Errorf("IP lookup URL \"%s\" must use https", u)
+		}
+		if pu.Host == "" {
+			return wr, fmt.Errorf("IP lookup URL \"%s\" has no host", u)
+		}
+		wr.serviceURLs = append(wr.serviceURLs, pu)
+	}
+	wr.services = make([]serviceState, len(wr.serviceURLs))
+	return wr, nil
 }
 
 type webResolver struct {
 	httpClient  *http.Client
-	serviceURLs []string
+	serviceURLs []*url.URL
+	err         error // configuration error, returned by Resolve
+
+	mu       sync.Mutex
+	services []serviceState // indexed like serviceURLs
+}
+
+func (wr *webResolver) validate() error        { return wr.err }
+func (wr *webResolver) lookupURLs() []*url.URL { return wr.serviceURLs }
+
+func (wr *webResolver) SetHTTPClient(httpclient *http.Client) {
+	wr.httpClient = httpclient
 }
 
 func (wr *webResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
@@ -56,24 +169,13 @@ func (wr *webResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
 	// - safer from wrong results in the event of accidental caching
 	// - safer from a single compromised service returning malicious results (assuming all supplied resolvers are https)
 	//
-	// todo: round-robin or randomize resolver selection. right now it's just using the first three.
-	// todo: having less than three services configured will increase traffic to one
+	// todo: round-robin or randomize resolver selection. right now it's just using the first three which aren't failing.
 	// todo: are there cases where one request is made over ipv4 and one over ipv6? one solution is to hit each resolver with both ipv4/6 and return both
-	if wr.serviceURLs == nil {
-		return nil, errors.New("no external IP lookup services were provided")
-	}
-
-	var URLs []*url.URL
-	for _, u := range wr.serviceURLs {
-		pu, err := url.Parse(u)
-		if err != nil {
-			return nil, fmt.Errorf("error parsing URL \"%s\": %w", u, err)
-		}
-		URLs = append(URLs, pu)
+	if wr.err != nil {
+		return nil, wr.err
 	}
-
 	var useCount, waitFor int
-	switch len(URLs) {
+	switch len(wr.serviceURLs) {
 	case 1:
 		useCount, waitFor = 1, 1
 	case 2:
@@ -81,6 +183,17 @@ func (wr *webResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
 	default:
 		useCount, waitFor = 3, 2
 	}
+	// services which keep failing are skipped, as long as enough others remain to agree
+	use := wr.available(time.Now())
+	if len(use) < waitFor {
+		use = make([]int, len(wr.serviceURLs))
+		for i := range use {
+			use[i] = i
+		}
+	}
+	if useCount > len(use) {
+		useCount = len(use)
+	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -92,15 +205,16 @@ func (wr *webResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
 
 	results := make(chan result, useCount)
 
-	resolvercount := len(URLs)
 	var wg sync.WaitGroup
 	wg.Add(useCount)
-	for i := 0; i < useCount; i++ {
-		u := URLs[i%resolvercount]
+	for _, i := range use[:useCount] {
+		i := i
 		go func() {
 			defer wg.Done()
 			r := result{}
-			r.addr, r.err = wr.lookup(ctx, u)
+			started := time.Now()
+			r.addr, r.err = wr.lookup(ctx, wr.serviceURLs[i])
+			wr.report(i, time.Since(started), r.err)
 
 			select {
 			case results <- r:
@@ -138,7 +252,9 @@ func (wr *webResolver) Resolve(ctx context.Context) ([]netip.Addr, error) {
 
 }
 
-func (wr *webResolver) lookup(ctx context.Context, url *url.URL) (netip.Addr, error) {
+func (wr *webResolver) lookup(ctx context.Context, url *url.URL) (_ netip.Addr, err error) {
+	// lookups run in their own goroutine, and parse responses from services on the internet
+	defer recoverError(&err)
 	// 15 seconds is an eternity for the size of the request we're making,
 	// but this ensures that all calls to resolve will eventually complete even if the user supplied context.TODO or context.Background
 	// using http.DefaultClient (with no timeout).
@@ -148,6 +264,7 @@ func (wr *webResolver) lookup(ctx context.Context, url *url.URL) (netip.Addr, er
 	if err != nil {
 		return netip.Addr{}, fmt.Errorf("error creating request: %w", err)
 	}
+	setUserAgent(req)
 	req.Header.Set("Cache-Control", "no-cache")
 
 	httpclient := wr.httpClient
@@ -165,11 +282,57 @@ func (wr *webResolver) lookup(ctx context.Context, url *url.URL) (netip.Addr, er
 		return netip.Addr{}, fmt.Errorf("http request returned %s", resp.Status)
 	}
 
-	scanner := bufio.NewReader(resp.Body)
-	ipstring, _ := scanner.ReadString('\n')
-	ip, err := netip.ParseAddr(strings.TrimSpace(ipstring))
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("error reading response body: %w", err)
+	}
+	ip, err := parseAddrBody(string(body))
 	if err != nil {
 		return netip.Addr{}, fmt.Errorf("error parsing IP address from response body: %w", err)
 	}
 	return ip, nil
 }
+
+// parseAddrBody returns the address from the body of an IP lookup response.
+// The address is normally the first line of the body,
+// but legacy services such as checkip.dyndns.org wrap it in HTML, e.g.:
+//
+//	<html><head><title>Current IP Check</title></head><body>Current IP Address: 203.0.113.5</body></html>
+func parseAddrBody(body string) (netip.Addr, error) {
+	line, _, _ := strings.Cut(body, "\n")
+	if ip, err := netip.ParseAddr(strings.TrimSpace(line)); err == nil && ip.Zone() == "" {
+		return ip, nil
+	}
+	const label = "ip address:"
+	if i := indexFold(body, label); i >= 0 {
+		value := strings.TrimSpace(body[i+len(label):])
+		if end := strings.IndexFunc(value, func(r rune) bool {
+			return r == '<' || unicode.IsSpace(r)
+		}); end >= 0 {
+			value = value[:end]
+		}
+		if ip, err := netip.ParseAddr(value); err == nil && ip.Zone() == "" {
+			return ip, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no IP address found in response %q", truncate(body, 64))
+}
+
+// indexFold returns the index of the first instance of the ASCII string substr in s, ignoring case, or -1.
+// Unlike searching strings.ToLower(s), the index is always within s, whose length may change when lowercased.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncate shortens s to at most n bytes for use in an error message, marking where it was cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
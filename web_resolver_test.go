@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/netip"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -156,3 +157,159 @@ func TestHitCount(t *testing.T) {
 		}
 	}
 }
+
+func TestJoinDeduplicates(t *testing.T) {
+	shared := netip.MustParseAddr("203.0.113.5")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, shared.String())
+	}))
+	defer srv.Close()
+
+	addrs, err := ddns.Join(ddns.WebResolver(srv.URL), ddns.StaticIP(netip.MustParseAddr("2001:db8::1"), shared)).Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve failed: %s", err)
+	}
+	seen := map[netip.Addr]bool{}
+	for _, a := range addrs {
+		if seen[a] {
+			t.Fatalf("Expected no duplicate addresses; got %q", addrs)
+		}
+		seen[a] = true
+	}
+	if len(addrs) != 2 || !seen[shared] || !seen[netip.MustParseAddr("2001:db8::1")] {
+		t.Fatalf("Expected [%s 2001:db8::1] in any order; got %q", shared, addrs)
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var ua string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.UserAgent()
+		io.WriteString(w, "192.168.2.1")
+	}))
+	defer srv.Close()
+	if _, err := ddns.WebResolver(srv.URL).Resolve(context.Background()); err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if expected := "Travis-Britz-ddns/"; !strings.HasPrefix(ua, expected) || ua == expected {
+		t.Fatalf("Expected User-Agent to start with %q and include a version; got %q", expected, ua)
+	}
+	if ddns.Version() == "" {
+		t.Fatalf("Expected a version; got %q", ddns.Version())
+	}
+}
+
+func TestWebResolverStrict(t *testing.T) {
+	tests := []struct {
+		url       string
+		allowHTTP bool
+		valid     bool
+	}{
+		{"https://ipv4.icanhazip.com", false, true},
+		{"http://ipv4.icanhazip.com", false, false},
+		{"http://192.168.1.1/ip", true, true},
+		{"ftp://example.com/ip", true, false},
+		{"ipv4.icanhazip.com", false, false},
+		{"https://", false, false},
+		{"http://[::1", true, false},
+	}
+	for _, tt := range tests {
+		var err error
+		if tt.allowHTTP {
+			_, err = ddns.WebResolverStrict([]string{tt.url}, ddns.AllowHTTP())
+		} else {
+			_, err = ddns.WebResolverStrict([]string{tt.url})
+		}
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("Expected %q (AllowHTTP: %t) to be valid: %t; got error %v", tt.url, tt.allowHTTP, tt.valid, err)
+		}
+	}
+	if _, err := ddns.WebResolverStrict(nil); err != nil {
+		t.Errorf("Expected the default services to be used when no URLs are given; got %v", err)
+	}
+}
+
+func TestRequireHTTPS(t *testing.T) {
+	tests := []struct {
+		resolver ddns.Resolver
+		valid    bool
+	}{
+		{ddns.WebResolver("https://ipv4.icanhazip.com"), true},
+		{ddns.WebResolver("http://127.0.0.1:8080/ip", "http://localhost/ip", "http://[::1]/ip"), true},
+		{ddns.Join(ddns.FromString("192.0.2.1"), ddns.WebResolver("https://ipv4.icanhazip.com", "http://ipv4.icanhazip.com")), false},
+		{ddns.DNSResolver("example.net", "A", ddns.DNSOverHTTPS("http://dns.example.net/dns-query")), false},
+		{ddns.DNSResolver("example.net", "A", ddns.DNSOverHTTPS("https://dns.example.net/dns-query")), true},
+	}
+	for i, tt := range tests {
+		_, err := ddns.New("example.com", (&recordingProvider{}).fn(), ddns.UsingResolver(tt.resolver), ddns.RequireHTTPS())
+		if valid := err == nil; valid != tt.valid {
+			t.Errorf("Resolver %d: expected to be accepted: %t; got error %v", i, tt.valid, err)
+		}
+	}
+}
+
+func TestLookupHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><head><title>Current IP Check</title></head><body>Current IP Address: 203.0.113.5</body></html>\r\n")
+	}))
+	defer srv.Close()
+	res, err := ddns.WebResolver(srv.URL).Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Request failed: %s", err)
+	}
+	if expected, got := netip.MustParseAddr("203.0.113.5"), res[0]; expected != got {
+		t.Fatalf("Expected %q; got %q", expected, got)
+	}
+}
+
+func TestDefaultWebServices(t *testing.T) {
+	for _, services := range [][]string{ddns.DefaultWebServices(), ddns.DefaultWebServicesIPv6()} {
+		if len(services) < 3 {
+			t.Errorf("Expected at least three services for consensus; got %q", services)
+		}
+		if _, err := ddns.WebResolverStrict(services); err != nil {
+			t.Errorf("Expected the default services to be valid https URLs; got %s", err)
+		}
+	}
+}
+
+func TestWebResolverSkipsFailingService(t *testing.T) {
+	var mu sync.Mutex
+	var badRequests int
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		badRequests++
+		mu.Unlock()
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer bad.Close()
+	urls := []string{bad.URL}
+	for i := 0; i < 3; i++ {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// answer after the failing service, so that its failure is counted before Resolve returns
+			time.Sleep(10 * time.Millisecond)
+			io.WriteString(w, "192.168.2.1")
+		}))
+		defer srv.Close()
+		urls = append(urls, srv.URL)
+	}
+	wr := ddns.WebResolver(urls...)
+	for i := 0; i < 6; i++ {
+		if _, err := wr.Resolve(context.Background()); err != nil {
+			t.Fatalf("Resolve failed: %s", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if badRequests > 3 {
+		t.Errorf("Expected the failing service to be skipped after 3 failures; got %d requests", badRequests)
+	}
+	stats := ddns.WebResolverStats(wr)
+	if len(stats) != 4 || !stats[0].Skipped || stats[0].Failures != badRequests {
+		t.Errorf("Expected stats to show the failing service as skipped after %d failures; got %+v", badRequests, stats)
+	}
+	if stats[1].Successes == 0 {
+		t.Errorf("Expected stats to count successful lookups; got %+v", stats[1])
+	}
+}
@@ -0,0 +1,55 @@
+package ddns
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// ZoneFromDomain returns the DNS zone which contains domain.
+//
+// If zones is not empty, the result is the longest zone name in zones which is domain or a parent of domain,
+// and an error is returned if there is none.
+// Otherwise the zone is derived from the public suffix list,
+// e.g. "example.co.uk" for "host.example.co.uk".
+// Prefer passing the zones actually hosted by the provider,
+// since delegated subdomains can't be derived from the public suffix list.
+//
+// Names are compared case-insensitively and the result has no trailing dot.
+func ZoneFromDomain(domain string, zones []string) (string, error) {
+	domain = canonicalName(domain)
+	if domain == "" {
+		return "", errors.New("domain cannot be empty")
+	}
+	if len(zones) == 0 {
+		// the public suffix list has no rules for wildcard labels, and they can't be zones anyway
+		name := strings.TrimPrefix(domain, "*.")
+		zone, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil {
+			return "", fmt.Errorf("unable to derive zone for \"%s\": %w", domain, err)
+		}
+		return zone, nil
+	}
+	var match string
+	for _, z := range zones {
+		name := canonicalName(z)
+		if name == "" {
+			continue
+		}
+		// match on a label boundary so that zone example.com does not contain notexample.com
+		if (domain == name || strings.HasSuffix(domain, "."+name)) && len(name) > len(match) {
+			match = name
+		}
+	}
+	if match == "" {
+		return "", fmt.Errorf("unable to find a zone matching \"%s\"", domain)
+	}
+	return match, nil
+}
+
+// canonicalName returns name in lower case without a trailing dot.
+func canonicalName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,44 @@
+package ddns_test
+
+import (
+	"testing"
+
+	"github.com/Travis-Britz/ddns"
+)
+
+func TestZoneFromDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		zones  []string
+		want   string
+	}{
+		{"host.example.co.uk", nil, "example.co.uk"},
+		{"a.b.example.com", nil, "example.com"},
+		{"*.home.example.co.uk", nil, "example.co.uk"},
+		{"Example.COM.", nil, "example.com"},
+		{"host.example.co.uk", []string{"co.uk", "example.co.uk"}, "example.co.uk"},
+		{"host.home.example.com", []string{"example.com", "home.example.com"}, "home.example.com"},
+		{"example.com", []string{"example.com"}, "example.com"},
+	}
+	for _, tt := range tests {
+		got, err := ddns.ZoneFromDomain(tt.domain, tt.zones)
+		if err != nil {
+			t.Errorf("ZoneFromDomain(%q, %q) returned an error: %s", tt.domain, tt.zones, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expected %q for %q; got %q", tt.want, tt.domain, got)
+		}
+	}
+}
+
+func TestZoneFromDomainNoMatch(t *testing.T) {
+	for _, domain := range []string{"notexample.com", "example.org"} {
+		if z, err := ddns.ZoneFromDomain(domain, []string{"example.com"}); err == nil {
+			t.Errorf("Expected an error for %q; got %q", domain, z)
+		}
+	}
+	if z, err := ddns.ZoneFromDomain("co.uk", nil); err == nil {
+		t.Errorf("Expected an error for a public suffix; got %q", z)
+	}
+}